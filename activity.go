@@ -0,0 +1,244 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+const dayLayout = "2006-01-02"
+
+// heatmapLevels shade a day by how many notes were edited on it, relative
+// to the busiest day. They stay readable without colors.
+var heatmapLevels = []string{"·", "░", "▒", "▓", "█"}
+
+// vaultActivity counts, per day, the notes edited in a vault. A note counts
+// on its modification day and, when the vault is in a git repository, on
+// every day a commit touched it.
+type vaultActivity struct {
+	days    map[string]int
+	notes   int
+	words   int
+	git     bool
+	written map[string]int
+	goal    int
+}
+
+type activityMsg struct {
+	vault    string
+	activity vaultActivity
+}
+
+func loadActivity(vault string) vaultActivity {
+	edits := make(map[string]map[string]struct{})
+	add := func(day string, rel string) {
+		if edits[day] == nil {
+			edits[day] = make(map[string]struct{})
+		}
+		edits[day][rel] = struct{}{}
+	}
+
+	a := vaultActivity{days: make(map[string]int), written: loadWordCounts(vault)}
+	_ = walkVault(vault, func(p string, d fs.DirEntry) error {
+		if !isIndexedNote(p) {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return nil
+		}
+		rel := relOrBase(vault, p)
+		add(info.ModTime().Format(dayLayout), rel)
+		a.notes++
+		if content, err := os.ReadFile(p); err == nil {
+			a.words += len(strings.Fields(string(content)))
+		}
+		return nil
+	})
+
+	if out, err := runGit(vault, "log", "--relative", "--date=short", "--format=@%ad", "--name-only", "--", "."); err == nil {
+		a.git = true
+		day := ""
+		scanner := bufio.NewScanner(bytes.NewReader(out))
+		for scanner.Scan() {
+			line := strings.TrimSpace(scanner.Text())
+			switch {
+			case strings.HasPrefix(line, "@"):
+				day = line[1:]
+			case line != "" && day != "" && isIndexedNote(line):
+				add(day, filepath.ToSlash(line))
+			}
+		}
+	}
+
+	for day, notes := range edits {
+		a.days[day] = len(notes)
+	}
+	return a
+}
+
+func (m Model) showActivity() (tea.Model, tea.Cmd) {
+	vault, goal := m.vault, m.cfg.Editor.DailyGoal
+	m.state = stateActivity
+	m.activity = nil
+	m.status = infoStatus("Collecting writing activity...")
+	return m, func() tea.Msg {
+		a := loadActivity(vault)
+		a.goal = goal
+		return activityMsg{vault: vault, activity: a}
+	}
+}
+
+func (m Model) applyActivity(msg activityMsg) Model {
+	if msg.vault != m.vault || m.state != stateActivity {
+		return m
+	}
+	m.activity = &msg.activity
+	m.status = statusLine{}
+	return m
+}
+
+func (m Model) handleActivityKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "ctrl+c":
+		return m, tea.Quit
+	case "esc", "enter", "q":
+		m.state = stateFileList
+		m.activity = nil
+		m.status = statusLine{}
+		m = m.refreshFileList()
+	}
+	return m, nil
+}
+
+func (m Model) activityView(contentW int) string {
+	if m.activity == nil {
+		return ""
+	}
+	weeks := minInt(53, maxInt(4, (contentW-4)/2))
+	return heatmap(m.activity.days, time.Now(), weeks) + "\n\n" + activitySummary(*m.activity, time.Now())
+}
+
+// heatmap draws weeks columns of days, Monday on top, ending with the week
+// of today.
+func heatmap(days map[string]int, today time.Time, weeks int) string {
+	today = time.Date(today.Year(), today.Month(), today.Day(), 0, 0, 0, 0, today.Location())
+	offset := (int(today.Weekday()) + 6) % 7
+	start := today.AddDate(0, 0, -offset-7*(weeks-1))
+
+	peak := 0
+	for w := 0; w < weeks; w++ {
+		for d := 0; d < 7; d++ {
+			peak = maxInt(peak, days[start.AddDate(0, 0, 7*w+d).Format(dayLayout)])
+		}
+	}
+
+	// Month names go above the first week of each month, when they fit.
+	months := []rune(strings.Repeat(" ", 4+2*weeks+3))
+	for w := 0; w < weeks; w++ {
+		monday := start.AddDate(0, 0, 7*w)
+		at := 4 + 2*w
+		if monday.Day() <= 7 && months[at-1] == ' ' {
+			copy(months[at:], []rune(monday.Format("Jan")))
+		}
+	}
+
+	labels := []string{"Mon", "", "Wed", "", "Fri", "", "Sun"}
+	rows := []string{strings.TrimRight(string(months), " ")}
+	cell := lipgloss.NewStyle().Foreground(colorSuccess)
+	for d := 0; d < 7; d++ {
+		var b strings.Builder
+		b.WriteString(fmt.Sprintf("%-4s", tr(labels[d])))
+		for w := 0; w < weeks; w++ {
+			day := start.AddDate(0, 0, 7*w+d)
+			if day.After(today) {
+				break
+			}
+			level := 0
+			if n := days[day.Format(dayLayout)]; n > 0 {
+				level = (n*(len(heatmapLevels)-1) + peak - 1) / peak
+			}
+			if level == 0 {
+				b.WriteString(heatmapLevels[0] + " ")
+			} else {
+				b.WriteString(cell.Render(heatmapLevels[level]) + " ")
+			}
+		}
+		rows = append(rows, strings.TrimRight(b.String(), " "))
+	}
+	legend := "    " + tr("Less") + " " + strings.Join(heatmapLevels, " ") + " " + tr("More")
+	return strings.Join(append(rows, "", legend), "\n")
+}
+
+func activitySummary(a vaultActivity, today time.Time) string {
+	var active []string
+	for day, n := range a.days {
+		if n > 0 {
+			active = append(active, day)
+		}
+	}
+	sort.Strings(active)
+
+	longest, run := 0, 0
+	var prev time.Time
+	busiest, busiestN := "", 0
+	for _, day := range active {
+		t, err := time.Parse(dayLayout, day)
+		if err != nil {
+			continue
+		}
+		if !prev.IsZero() && t.Sub(prev) == 24*time.Hour {
+			run++
+		} else {
+			run = 1
+		}
+		longest = maxInt(longest, run)
+		prev = t
+		if a.days[day] > busiestN {
+			busiest, busiestN = day, a.days[day]
+		}
+	}
+
+	current := 0
+	day := today
+	if a.days[day.Format(dayLayout)] == 0 {
+		day = day.AddDate(0, 0, -1)
+	}
+	for a.days[day.Format(dayLayout)] > 0 {
+		current++
+		day = day.AddDate(0, 0, -1)
+	}
+
+	yearAgo := today.AddDate(-1, 0, 0).Format(dayLayout)
+	lastYear := 0
+	for _, d := range active {
+		if d > yearAgo {
+			lastYear++
+		}
+	}
+
+	source := tr("file modification times")
+	if a.git {
+		source = tr("git history and file modification times")
+	}
+	lines := []string{
+		tr("%s, %s", trn("%d note", "%d notes", a.notes), trn("%d word", "%d words", a.words)),
+		trn("%d active day in the last year", "%d active days in the last year", lastYear),
+		tr("Current streak: %s | Longest: %s", trn("%d day", "%d days", current), trn("%d day", "%d days", longest)),
+	}
+	if busiest != "" {
+		lines = append(lines, tr("Busiest day: %s (%s)", busiest, trn("%d note", "%d notes", busiestN)))
+	}
+	lines = append(lines, writingSummary(a.written, a.goal, today)...)
+	lines = append(lines, hintStyle.Render(tr("Based on %s", source)))
+	return strings.Join(lines, "\n")
+}
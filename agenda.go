@@ -0,0 +1,310 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// The agenda (Alt+A in the file list, or gono agenda) lists what is
+// planned for today or this week (Monday to Sunday): open tasks with a date
+// ("- [ ] call Ann 2024-06-03", also "due:" or "📅" before the date), notes
+// whose due: field falls in the range, reminders and expiry dates, and the
+// headings of the daily notes. Open tasks and due notes from before the
+// range are listed first as overdue. The report is Markdown, so it can be
+// exported as a note; tasks are listed with ☐ rather than a checkbox so an
+// exported agenda does not show up in the next one.
+
+var (
+	openTaskRe = regexp.MustCompile(`^\s*[-*+] \[ \]\s+(.*)$`)
+	taskDateRe = regexp.MustCompile(`(?:(?:due:|📅)\s*)?\b(\d{4}-\d{2}-\d{2})\b`)
+)
+
+type agendaEntry struct {
+	day  time.Time
+	text string
+	link string
+	// open entries (tasks and due notes) stay on the agenda after their day.
+	open bool
+}
+
+type agendaView struct {
+	week  bool
+	title string
+	text  string
+	lines []string
+	top   int
+}
+
+func startOfDay(t time.Time) time.Time {
+	y, mo, d := t.Date()
+	return time.Date(y, mo, d, 0, 0, 0, 0, t.Location())
+}
+
+// agendaRange returns the first day and the day after the last one.
+func agendaRange(now time.Time, week bool) (time.Time, time.Time) {
+	from := startOfDay(now)
+	if !week {
+		return from, from.AddDate(0, 0, 1)
+	}
+	offset := (int(from.Weekday()) + 6) % 7
+	from = from.AddDate(0, 0, -offset)
+	return from, from.AddDate(0, 0, 7)
+}
+
+// collectAgenda gathers the entries of vault up to the end of the range;
+// entries before from are overdue.
+func collectAgenda(vault string, to time.Time) []agendaEntry {
+	var entries []agendaEntry
+	_ = walkVault(vault, func(p string, d fs.DirEntry) error {
+		if !isIndexedNote(p) {
+			return nil
+		}
+		link := noteLink(vault, p)
+		content, err := os.ReadFile(p)
+		if err != nil {
+			return nil
+		}
+		fields, _ := parseFrontmatter(string(content))
+		if due, ok := parseReminderTime(fields["due"]); ok && due.Before(to) && !strings.EqualFold(fields["status"], "done") {
+			title, _ := splitNoteTitle(p, string(content))
+			entries = append(entries, agendaEntry{day: startOfDay(due), text: tr("Due: %s", title), link: link, open: true})
+		}
+		if at, ok := parseReminderTime(fields["remind"]); ok && at.Before(to) {
+			entries = append(entries, agendaEntry{day: startOfDay(at), text: tr("Reminder%s", clockSuffix(at)), link: link})
+		}
+		if at, ok := parseReminderTime(fields["expires"]); ok && at.Before(to) {
+			entries = append(entries, agendaEntry{day: startOfDay(at), text: tr("Expires%s", clockSuffix(at)), link: link})
+		}
+		for _, at := range bodyReminders(string(content)) {
+			if at.Before(to) {
+				entries = append(entries, agendaEntry{day: startOfDay(at), text: tr("Reminder%s", clockSuffix(at)), link: link})
+			}
+		}
+		for _, line := range strings.Split(string(content), "\n") {
+			task := openTaskRe.FindStringSubmatch(strings.TrimRight(line, "\r"))
+			if task == nil {
+				continue
+			}
+			date := taskDateRe.FindStringSubmatch(task[1])
+			if date == nil {
+				continue
+			}
+			day, err := time.ParseInLocation("2006-01-02", date[1], time.Local)
+			if err != nil || !day.Before(to) {
+				continue
+			}
+			text := strings.TrimSpace(strings.Replace(task[1], date[0], "", 1))
+			entries = append(entries, agendaEntry{day: day, text: "☐ " + text, link: link, open: true})
+		}
+		return nil
+	})
+	sort.SliceStable(entries, func(i, j int) bool {
+		if !entries[i].day.Equal(entries[j].day) {
+			return entries[i].day.Before(entries[j].day)
+		}
+		return entries[i].link < entries[j].link
+	})
+	return entries
+}
+
+func clockSuffix(t time.Time) string {
+	if t.Hour() == 0 && t.Minute() == 0 {
+		return ""
+	}
+	return " " + t.Format("15:04")
+}
+
+// dailyHeadings returns the section headings of the daily note of day,
+// leaving out the top-level date heading.
+func dailyHeadings(vault string, cfg startupConfig, day time.Time) []string {
+	data, err := os.ReadFile(cfg.dailyNotePath(vault, day))
+	if err != nil {
+		return nil
+	}
+	var out []string
+	inFence := false
+	for _, line := range strings.Split(string(data), "\n") {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "```") {
+			inFence = !inFence
+		}
+		if inFence || !strings.HasPrefix(trimmed, "##") {
+			continue
+		}
+		if heading := strings.TrimSpace(strings.TrimLeft(trimmed, "#")); heading != "" {
+			out = append(out, heading)
+		}
+	}
+	return out
+}
+
+// agendaMarkdown renders the agenda of vault for today or this week.
+func agendaMarkdown(vault string, cfg appConfig, now time.Time, week bool) (string, string) {
+	from, to := agendaRange(now, week)
+	entries := collectAgenda(vault, to)
+	title := tr("Agenda for %s", from.Format("Monday, 2006-01-02"))
+	if week {
+		title = tr("Agenda for %s to %s", from.Format("2006-01-02"), to.AddDate(0, 0, -1).Format("2006-01-02"))
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "# %s\n", title)
+	item := func(e agendaEntry, withDate bool) {
+		text := e.text
+		if withDate {
+			text += " (" + e.day.Format("2006-01-02") + ")"
+		}
+		fmt.Fprintf(&b, "- %s — %s\n", text, e.link)
+	}
+	var overdue []agendaEntry
+	for _, e := range entries {
+		if e.day.Before(from) && e.open {
+			overdue = append(overdue, e)
+		}
+	}
+	if len(overdue) > 0 {
+		fmt.Fprintf(&b, "\n## %s\n\n", tr("Overdue"))
+		for _, e := range overdue {
+			item(e, true)
+		}
+	}
+	for day := from; day.Before(to); day = day.AddDate(0, 0, 1) {
+		fmt.Fprintf(&b, "\n## %s\n\n", day.Format("Monday, 2006-01-02"))
+		empty := true
+		for _, heading := range dailyHeadings(vault, cfg.Startup, day) {
+			fmt.Fprintf(&b, "- %s\n", tr("Daily note: %s", heading))
+			empty = false
+		}
+		for _, e := range entries {
+			if e.day.Equal(day) {
+				item(e, false)
+				empty = false
+			}
+		}
+		if empty {
+			fmt.Fprintf(&b, "%s\n", tr("Nothing planned."))
+		}
+	}
+	return title, b.String()
+}
+
+func agendaCommand(args []string, stdout io.Writer) error {
+	flags := flag.NewFlagSet("agenda", flag.ContinueOnError)
+	flags.SetOutput(io.Discard)
+	week := flags.Bool("week", false, "")
+	out := flags.String("o", "", "")
+	if err := flags.Parse(args); err != nil || flags.NArg() != 1 {
+		return errors.New("usage: gono agenda [-week] [-o FILE.md] VAULT")
+	}
+	vault, err := filepath.Abs(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+	if info, err := os.Stat(vault); err != nil || !info.IsDir() {
+		return fmt.Errorf("vault not found: %s", vault)
+	}
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+	_, text := agendaMarkdown(vault, cfg, time.Now(), *week)
+	if *out == "" {
+		_, err = io.WriteString(stdout, text)
+		return err
+	}
+	if err := os.WriteFile(*out, []byte(text), 0644); err != nil {
+		return err
+	}
+	fmt.Fprintf(stdout, "Wrote %s\n", *out)
+	return nil
+}
+
+func (m Model) showAgenda(week bool) (tea.Model, tea.Cmd) {
+	title, text := agendaMarkdown(m.vault, m.cfg, time.Now(), week)
+	m.agenda = &agendaView{week: week, title: title, text: text, lines: strings.Split(strings.TrimSuffix(text, "\n"), "\n")}
+	m.state = stateAgenda
+	m.status = statusLine{}
+	return m, nil
+}
+
+func (m Model) handleAgendaKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	a := m.agenda
+	page := maxInt(1, m.list.Height())
+	last := maxInt(0, len(a.lines)-page)
+	switch msg.String() {
+	case "ctrl+c":
+		return m, tea.Quit
+	case "esc", "q":
+		m.agenda = nil
+		m.state = stateFileList
+		m = m.refreshFileList()
+		return m, nil
+	case "w", "tab":
+		return m.showAgenda(!a.week)
+	case "e":
+		return m.exportAgenda()
+	case "up", "k":
+		a.top = maxInt(0, a.top-1)
+	case "down", "j":
+		a.top = minInt(last, a.top+1)
+	case "pgup":
+		a.top = maxInt(0, a.top-page)
+	case "pgdown", " ":
+		a.top = minInt(last, a.top+page)
+	}
+	return m, nil
+}
+
+// exportAgenda saves the agenda shown as a note in the current folder.
+func (m Model) exportAgenda() (tea.Model, tea.Cmd) {
+	name := "agenda-" + time.Now().Format("2006-01-02")
+	if m.agenda.week {
+		from, _ := agendaRange(time.Now(), true)
+		name = "agenda-week-" + from.Format("2006-01-02")
+	}
+	target := uniquePath(filepath.Join(m.current, name+".md"))
+	if err := writeNewFile(target, []byte(m.agenda.text), 0644); err != nil {
+		m.status = errorStatus(err)
+		return m, nil
+	}
+	m = m.reindex(target)
+	m.status = okStatus("Agenda saved as %s", relOrBase(m.vault, target))
+	m = m.logChange(journalCreate, target, false)
+	return m, nil
+}
+
+func (m Model) agendaView(contentW int, height int) string {
+	a := m.agenda
+	end := minInt(len(a.lines), a.top+height)
+	lines := make([]string, 0, end-a.top)
+	for _, l := range a.lines[a.top:end] {
+		style := lipgloss.NewStyle().MaxWidth(contentW)
+		switch {
+		case strings.HasPrefix(l, "## "):
+			style = titleStyle.MaxWidth(contentW)
+			l = strings.TrimPrefix(l, "## ")
+		case strings.HasPrefix(l, "# "):
+			continue
+		}
+		lines = append(lines, style.Render(l))
+	}
+	return strings.Join(lines, "\n")
+}
+
+func agendaHints(width int) string {
+	if width < 60 {
+		return tr("↑/↓ scroll | W today/week\nE save as note | Esc back")
+	}
+	return tr("↑/↓: scroll | W: today/this week | E: save as note | Esc: back")
+}
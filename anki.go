@@ -0,0 +1,191 @@
+package main
+
+import (
+	"crypto/sha1"
+	"encoding/base64"
+	"errors"
+	"flag"
+	"fmt"
+	"html"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Flashcards are written in notes as
+//
+//	Q: question
+//	A: answer, possibly over several lines
+//
+// ending at a blank line or the next Q:, or as lines using Anki's cloze
+// syntax, {{c1::hidden text}}. export-anki writes them as a tab-separated
+// file with Anki's import headers (Anki 2.1.55 or newer).
+
+var (
+	ankiQuestionRe = regexp.MustCompile(`^\s*(?:[-*]\s+)?Q:\s*(.*)$`)
+	ankiAnswerRe   = regexp.MustCompile(`^\s*(?:[-*]\s+)?A:\s*(.*)$`)
+	ankiClozeRe    = regexp.MustCompile(`\{\{c\d+::`)
+)
+
+type flashcard struct {
+	cloze bool
+	front string
+	back  string
+	rel   string
+	tags  []string
+}
+
+// parseFlashcards returns the cards in one note.
+func parseFlashcards(content string) []flashcard {
+	var cards []flashcard
+	var current *flashcard
+	inAnswer := false
+	flush := func() {
+		if current != nil && strings.TrimSpace(current.front) != "" && strings.TrimSpace(current.back) != "" {
+			current.front = strings.TrimSpace(current.front)
+			current.back = strings.TrimSpace(current.back)
+			cards = append(cards, *current)
+		}
+		current = nil
+		inAnswer = false
+	}
+	inFence := false
+	for _, line := range strings.Split(strings.ReplaceAll(content, "\r\n", "\n"), "\n") {
+		if strings.HasPrefix(strings.TrimSpace(line), "```") {
+			flush()
+			inFence = !inFence
+			continue
+		}
+		if inFence {
+			continue
+		}
+		switch {
+		case ankiQuestionRe.MatchString(line):
+			flush()
+			current = &flashcard{front: ankiQuestionRe.FindStringSubmatch(line)[1]}
+		case current != nil && ankiAnswerRe.MatchString(line):
+			inAnswer = true
+			current.back = ankiAnswerRe.FindStringSubmatch(line)[1]
+		case strings.TrimSpace(line) == "":
+			flush()
+		case current != nil && inAnswer:
+			current.back += "\n" + line
+		case current != nil:
+			current.front += "\n" + line
+		case ankiClozeRe.MatchString(line):
+			cards = append(cards, flashcard{cloze: true, front: strings.TrimSpace(line)})
+		}
+	}
+	flush()
+	return cards
+}
+
+func exportAnkiCommand(args []string, stdout io.Writer) error {
+	flags := flag.NewFlagSet("export-anki", flag.ContinueOnError)
+	flags.SetOutput(io.Discard)
+	deck := flags.String("deck", "", "")
+	tag := flags.String("tag", "", "")
+	if err := flags.Parse(args); err != nil || flags.NArg() != 2 {
+		return errors.New("usage: gono export-anki [-deck NAME] [-tag TAG] VAULT FILE.tsv")
+	}
+	vault, err := filepath.Abs(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+	if info, err := os.Stat(vault); err != nil || !info.IsDir() {
+		return fmt.Errorf("vault not found: %s", vault)
+	}
+	if *deck == "" {
+		*deck = "GoNo::" + filepath.Base(vault)
+	}
+	onlyTag := strings.ToLower(strings.TrimPrefix(strings.TrimSpace(*tag), "#"))
+
+	var cards []flashcard
+	notes := 0
+	err = walkVault(vault, func(p string, d fs.DirEntry) error {
+		if !isIndexedNote(p) {
+			return nil
+		}
+		content, err := os.ReadFile(p)
+		if err != nil {
+			return nil
+		}
+		tagSet := noteTags(string(content))
+		if onlyTag != "" {
+			if _, ok := tagSet[onlyTag]; !ok {
+				return nil
+			}
+		}
+		found := parseFlashcards(string(content))
+		if len(found) == 0 {
+			return nil
+		}
+		notes++
+		tags := make([]string, 0, len(tagSet))
+		for t := range tagSet {
+			tags = append(tags, strings.ReplaceAll(t, " ", "_"))
+		}
+		sort.Strings(tags)
+		rel := relOrBase(vault, p)
+		for _, c := range found {
+			c.rel = rel
+			c.tags = tags
+			cards = append(cards, c)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if len(cards) == 0 {
+		return errors.New("no flashcards found (Q:/A: blocks or {{c1::cloze}} lines)")
+	}
+
+	var b strings.Builder
+	b.WriteString("#separator:tab\n#html:true\n#guid column:1\n#notetype column:2\n#deck column:3\n#tags column:6\n")
+	for _, c := range cards {
+		notetype, back := "Basic", c.back
+		if c.cloze {
+			notetype, back = "Cloze", ""
+		}
+		source := "<small>" + html.EscapeString(c.rel) + "</small>"
+		if back != "" {
+			back = ankiField(back) + "<br><br>" + source
+		} else {
+			back = source
+		}
+		b.WriteString(strings.Join([]string{
+			ankiGUID(c.rel, c.front),
+			notetype,
+			*deck,
+			ankiField(c.front),
+			back,
+			strings.Join(c.tags, " "),
+		}, "\t"))
+		b.WriteString("\n")
+	}
+	if err := os.WriteFile(flags.Arg(1), []byte(b.String()), 0644); err != nil {
+		return err
+	}
+	fmt.Fprintf(stdout, "Exported %s from %s to %s\n",
+		pluralize(len(cards), "card", "cards"), pluralize(notes, "note", "notes"), flags.Arg(1))
+	return nil
+}
+
+// ankiField escapes text for an HTML field of a tab-separated line.
+func ankiField(s string) string {
+	s = html.EscapeString(s)
+	s = strings.ReplaceAll(s, "\t", " ")
+	return strings.ReplaceAll(s, "\n", "<br>")
+}
+
+// ankiGUID keeps a card's identity stable across exports, so importing again
+// updates cards instead of duplicating them.
+func ankiGUID(rel string, front string) string {
+	sum := sha1.Sum([]byte(rel + "\x00" + front))
+	return "gono-" + base64.RawURLEncoding.EncodeToString(sum[:12])
+}
@@ -0,0 +1,136 @@
+package main
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// GoNo keeps its own files in the platform's directories for them instead
+// of the home directory: the config, the translation catalogs and the theme
+// files in the config directory, the vault registry in the data directory and
+// the search indexes in the cache directory.
+//
+//	           Linux (XDG)                    macOS                               Windows
+//	config     $XDG_CONFIG_HOME/gono          ~/Library/Application Support/gono  %AppData%\gono
+//	data       $XDG_DATA_HOME/gono            ~/Library/Application Support/gono  %LocalAppData%\gono
+//	cache      $XDG_CACHE_HOME/gono           ~/Library/Caches/gono               %LocalAppData%\gono\cache
+//
+// Files left in the old places (~/.gono_config.json, ~/.gono_vaults.json,
+// ~/.gono_locales, .gono/index.gob in each vault) are moved over the first
+// time they are needed. The keyfile and the capture socket stay in the home
+// directory, since they are referred to by those paths elsewhere.
+
+const appName = "gono"
+
+// appConfigDir returns the directory of the config file of the active
+// profile.
+func appConfigDir() string {
+	return profileDir(baseConfigDir())
+}
+
+// appDataDir returns the directory of the vault registry of the active
+// profile.
+func appDataDir() string {
+	return profileDir(baseDataDir())
+}
+
+func baseConfigDir() string {
+	if dir, err := os.UserConfigDir(); err == nil {
+		return filepath.Join(dir, appName)
+	}
+	return filepath.Join(homeDir(), ".config", appName)
+}
+
+// baseDataDir follows the conventions for the data directory, which Go has
+// no standard function for.
+func baseDataDir() string {
+	switch runtime.GOOS {
+	case "windows":
+		if dir := os.Getenv("LocalAppData"); dir != "" {
+			return filepath.Join(dir, appName)
+		}
+	case "darwin", "ios":
+		return baseConfigDir()
+	case "plan9":
+		return filepath.Join(homeDir(), "lib", appName)
+	default:
+		if dir := os.Getenv("XDG_DATA_HOME"); filepath.IsAbs(dir) {
+			return filepath.Join(dir, appName)
+		}
+	}
+	return filepath.Join(homeDir(), ".local", "share", appName)
+}
+
+// appCacheDir returns the directory of files GoNo can rebuild.
+func appCacheDir() string {
+	if runtime.GOOS == "windows" {
+		return filepath.Join(baseDataDir(), "cache")
+	}
+	if dir, err := os.UserCacheDir(); err == nil {
+		return filepath.Join(dir, appName)
+	}
+	return filepath.Join(homeDir(), ".cache", appName)
+}
+
+// vaultCacheKey names the cache files of a vault after its path.
+func vaultCacheKey(vault string) string {
+	abs, err := filepath.Abs(vault)
+	if err != nil {
+		abs = vault
+	}
+	if runtime.GOOS == "windows" || runtime.GOOS == "darwin" {
+		abs = strings.ToLower(abs)
+	}
+	sum := sha1.Sum([]byte(filepath.Clean(abs)))
+	return strings.ToLower(filepath.Base(abs)) + "-" + hex.EncodeToString(sum[:6])
+}
+
+// migrateAppFile moves a file or directory from its old place to its new
+// one, unless the new one exists already. A move across file systems falls
+// back to copying a file; a directory that cannot be renamed is left where
+// it is.
+func migrateAppFile(old string, path string) error {
+	if _, err := os.Lstat(path); err == nil {
+		return nil
+	}
+	info, err := os.Stat(old)
+	if err != nil {
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	if err := os.Rename(old, path); err == nil || info.IsDir() {
+		return err
+	}
+	data, err := os.ReadFile(old)
+	if err != nil {
+		return err
+	}
+	if err := writeFileAtomic(path, data, info.Mode().Perm()); err != nil {
+		return err
+	}
+	return os.Remove(old)
+}
+
+// migrateAppFiles moves the files GoNo kept in the home directory to their
+// new places. A file that cannot be moved stays where it was and is no
+// longer read, so the first error is returned to be reported.
+func migrateAppFiles() error {
+	home := homeDir()
+	var first error
+	for _, move := range [][2]string{
+		{filepath.Join(home, ".gono_config.json"), configPath()},
+		{filepath.Join(home, ".gono_vaults.json"), vaultRegistryPath()},
+		{filepath.Join(home, ".gono_locales"), localesDir()},
+	} {
+		if err := migrateAppFile(move[0], move[1]); err != nil && first == nil {
+			first = err
+		}
+	}
+	return first
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"regexp"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// Enter in the editor starts the new line with the indentation and the
+// blockquote markers of the current one, so quotes (> , > > ) and indented
+// or fenced code go on without retyping them. Enter on a quote line with
+// nothing after its markers ends the quote instead. editor.auto_indent off
+// makes Enter insert a plain line break.
+
+// linePrefixRe matches the indentation and blockquote markers a line
+// starts with.
+var linePrefixRe = regexp.MustCompile(`^(?:[ \t]*>)*[ \t]*`)
+
+// editorNewline breaks the line at the cursor, continuing its prefix.
+func (m Model) editorNewline() (tea.Model, tea.Cmd) {
+	if m.readOnly {
+		return m, nil
+	}
+	if !m.cfg.Editor.AutoIndent {
+		m.textarea.InsertString("\n")
+		return m, nil
+	}
+	lines := strings.Split(m.textarea.Value(), "\n")
+	row := m.textarea.Line()
+	if row >= len(lines) {
+		m.textarea.InsertString("\n")
+		return m, nil
+	}
+	line := lines[row]
+	prefix := linePrefixRe.FindString(line)
+	col := editorColumn(m.textarea)
+	if col < len(prefix) {
+		m.textarea.InsertString("\n")
+		return m, nil
+	}
+	if prefix == line {
+		// A line that is only a prefix is left empty: a quote ends there, and
+		// indentation moves on to the new line without trailing blanks.
+		lines[row] = ""
+		at := cursorPos{row: row}
+		if !strings.Contains(prefix, ">") {
+			lines = append(lines[:row+1], append([]string{prefix}, lines[row+1:]...)...)
+			at = cursorPos{row: row + 1, col: len(prefix)}
+		}
+		m.textarea.SetValue(strings.Join(lines, "\n"))
+		setEditorCursor(&m.textarea, at)
+		return m, nil
+	}
+	m.textarea.InsertString("\n" + prefix)
+	return m, nil
+}
@@ -0,0 +1,136 @@
+package main
+
+import (
+	"strings"
+	"unicode"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// With editor.auto_pair on, the editor closes Markdown markup as it is
+// typed: [ adds ], ` adds ` and _ adds _ (unless it follows a letter, as in
+// snake_case), and a second * right after a first one makes **|** for bold,
+// so that a single * still starts a list item or italics. Typing the closing
+// character where it already is steps over it, and Backspace between an
+// empty pair removes both. With a selection (Ctrl+Space and moving the
+// cursor), the key wraps it instead: * makes it bold, _ italic, ` code, and
+// [ a link, [text](), with the cursor between the parentheses.
+
+// pairCloser maps the characters that are closed as they are typed to their
+// closing character.
+var pairCloser = map[rune]rune{'[': ']', '`': '`', '_': '_', '*': '*'}
+
+// wrapMarkup is the markup put around a selection by each key.
+var wrapMarkup = map[rune][2]string{
+	'*': {"**", "**"},
+	'_': {"_", "_"},
+	'`': {"`", "`"},
+	'[': {"[", "]("},
+}
+
+// autoPair handles a key in the editor that closes or wraps markup.
+func (m Model) autoPair(msg tea.KeyMsg) (Model, bool) {
+	if msg.Type == tea.KeyBackspace {
+		return m.deletePair()
+	}
+	if msg.Type != tea.KeyRunes || msg.Paste || msg.Alt || len(msg.Runes) != 1 {
+		return m, false
+	}
+	r := msg.Runes[0]
+	if _, ok := wrapMarkup[r]; ok {
+		if start, end, ok := m.selection(); ok {
+			return m.wrapSelection(start, end, r), true
+		}
+	}
+	prev, next := m.charsAroundCursor()
+	col := editorColumn(m.textarea)
+	if (r == ']' || pairCloser[r] == r) && next == r {
+		m.textarea.SetCursor(col + 1)
+		return m, true
+	}
+	closer, ok := pairCloser[r]
+	if !ok {
+		return m, false
+	}
+	switch r {
+	case '[':
+		if isWordRune(next) {
+			return m, false
+		}
+	case '`':
+		// Three backticks start a code block, which is not closed here.
+		if prev == '`' {
+			return m, false
+		}
+	case '_':
+		if isWordRune(prev) || isWordRune(next) {
+			return m, false
+		}
+	case '*':
+		if prev != '*' || isWordRune(next) {
+			return m, false
+		}
+		m.textarea.InsertString("***")
+		m.textarea.SetCursor(col + 1)
+		return m, true
+	}
+	m.textarea.InsertString(string(r) + string(closer))
+	m.textarea.SetCursor(col + 1)
+	return m, true
+}
+
+// wrapSelection puts the markup of r around the runes from start to end and
+// leaves the cursor after it, or inside the () of a link.
+func (m Model) wrapSelection(start int, end int, r rune) Model {
+	markup := wrapMarkup[r]
+	value := []rune(m.textarea.Value())
+	before := string(value[:start]) + markup[0] + string(value[start:end]) + markup[1]
+	after := string(value[end:])
+	if r == '[' {
+		after = ")" + after
+	}
+	m.textarea.SetValue(before + after)
+	lines := splitRuneLines(before)
+	setEditorCursor(&m.textarea, cursorPos{row: len(lines) - 1, col: len(lines[len(lines)-1])})
+	m.mark = nil
+	return m
+}
+
+// deletePair removes the closing character along with the opening one
+// when Backspace is pressed between them. The opening character is left to
+// the textarea.
+func (m Model) deletePair() (Model, bool) {
+	if _, _, ok := m.selection(); ok {
+		return m, false
+	}
+	prev, next := m.charsAroundCursor()
+	if closer, ok := pairCloser[prev]; !ok || closer != next {
+		return m, false
+	}
+	m.textarea, _ = m.textarea.Update(tea.KeyMsg{Type: tea.KeyDelete})
+	return m, false
+}
+
+// charsAroundCursor returns the characters before and after the cursor on
+// its line, 0 at either end.
+func (m Model) charsAroundCursor() (rune, rune) {
+	lines := strings.Split(m.textarea.Value(), "\n")
+	row := m.textarea.Line()
+	if row >= len(lines) {
+		return 0, 0
+	}
+	line := []rune(lines[row])
+	col := minInt(editorColumn(m.textarea), len(line))
+	var prev, next rune
+	if col > 0 {
+		prev = line[col-1]
+	}
+	if col < len(line) {
+		next = line[col]
+	}
+	return prev, next
+}
+
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r)
+}
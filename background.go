@@ -0,0 +1,132 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/muesli/termenv"
+)
+
+// Every color of a theme comes in a light and a dark variant. Which one is
+// drawn follows the terminal's background: with background set to "auto",
+// GoNo asks the terminal for its background color once at startup (an OSC
+// 11 query, with COLORFGBG as fallback), and terminals that report changes
+// of their color scheme (DEC mode 2031) switch GoNo along with them while
+// it runs. "light" and "dark" skip the question, for terminals that do not
+// answer it or answer it wrong.
+
+const (
+	backgroundAuto  = "auto"
+	backgroundLight = "light"
+	backgroundDark  = "dark"
+)
+
+// Switching color scheme reports on and off; a report is CSI ? 997 ; 1 n
+// for dark and CSI ? 997 ; 2 n for light.
+const (
+	colorSchemeReportsOn  = "\x1b[?2031h"
+	colorSchemeReportsOff = "\x1b[?2031l"
+)
+
+var (
+	backgroundChoices = []string{backgroundAuto, backgroundLight, backgroundDark}
+
+	// terminalDark is the terminal's background as detected at startup or
+	// last reported.
+	terminalDark     bool
+	backgroundProbed bool
+)
+
+func validBackground(value string) string {
+	for _, choice := range backgroundChoices {
+		if value == choice {
+			return value
+		}
+	}
+	return backgroundAuto
+}
+
+func nextBackground(current string) string {
+	for i, choice := range backgroundChoices {
+		if choice == current {
+			return backgroundChoices[(i+1)%len(backgroundChoices)]
+		}
+	}
+	return backgroundChoices[0]
+}
+
+func backgroundLabel(value string) string {
+	switch value {
+	case backgroundLight:
+		return tr("Light")
+	case backgroundDark:
+		return tr("Dark")
+	}
+	if terminalDark {
+		return tr("Detect (dark now)")
+	}
+	return tr("Detect (light now)")
+}
+
+// applyBackground picks the light or dark colors of the theme. The
+// terminal is only asked the first time the setting is auto; later calls
+// use that answer or the last report of a change.
+func applyBackground(setting string) {
+	switch setting {
+	case backgroundLight:
+		lipgloss.SetHasDarkBackground(false)
+	case backgroundDark:
+		lipgloss.SetHasDarkBackground(true)
+	default:
+		if !backgroundProbed {
+			terminalDark = termenv.HasDarkBackground()
+			backgroundProbed = true
+		}
+		lipgloss.SetHasDarkBackground(terminalDark)
+	}
+}
+
+// colorSchemeReport reads a color scheme report of the terminal. Bubble Tea
+// passes CSI sequences it does not know on as messages of an unexported
+// type, which show the bytes after CSI when printed.
+func colorSchemeReport(msg any) (dark bool, ok bool) {
+	s, isStringer := msg.(fmt.Stringer)
+	if !isStringer {
+		return false, false
+	}
+	inner, found := strings.CutPrefix(s.String(), "?CSI[")
+	if !found {
+		return false, false
+	}
+	var seq strings.Builder
+	for _, field := range strings.Fields(strings.TrimSuffix(inner, "]?")) {
+		b, err := strconv.Atoi(field)
+		if err != nil {
+			return false, false
+		}
+		seq.WriteByte(byte(b))
+	}
+	switch seq.String() {
+	case "?997;1n":
+		return true, true
+	case "?997;2n":
+		return false, true
+	}
+	return false, false
+}
+
+// followBackground switches to the colors for the background the terminal
+// reported, unless the background is set in the config.
+func (m Model) followBackground(dark bool) Model {
+	terminalDark = dark
+	backgroundProbed = true
+	if m.cfg.Background == backgroundAuto {
+		applyBackground(m.cfg.Background)
+	}
+	if m.state == stateSettings {
+		m = m.refreshSettingsList()
+	}
+	return m
+}
@@ -0,0 +1,203 @@
+package main
+
+import (
+	"os"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/textarea"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/mattn/go-runewidth"
+)
+
+// A second note can be shown beside the one being edited, for writing a
+// summary while reading the source. Alt+N in the editor asks for the note
+// (by ID or name, as Ctrl+G) and splits the editor in two halves; Alt+N
+// again closes it. Alt+D moves the focus to the note beside, which then
+// scrolls on its own with the arrow keys, and back. Enter on the focused
+// note beside swaps the two notes, so that one is edited instead. The note
+// beside is read from disk and re-read when it changes, so saving the
+// edited note shows the change there when both are the same note.
+
+const besideMinWidth = 60
+
+type besideNote struct {
+	path    string
+	mod     time.Time
+	lines   []string
+	top     int
+	focused bool
+}
+
+// load re-reads the note when it changed on disk since it was last read.
+func (b *besideNote) load() {
+	info, err := os.Stat(b.path)
+	if err != nil {
+		b.mod = time.Time{}
+		b.lines = []string{err.Error()}
+		return
+	}
+	if b.lines != nil && b.mod.Equal(info.ModTime()) {
+		return
+	}
+	b.mod = info.ModTime()
+	b.lines = readBesideLines(b.path)
+	b.top = minInt(b.top, maxInt(0, len(b.lines)-1))
+}
+
+func readBesideLines(path string) []string {
+	if isEncryptedNote(path) {
+		return []string{tr("Encrypted note: open it to read")}
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return []string{err.Error()}
+	}
+	text, _, ok := decodeText(content)
+	if !ok {
+		return []string{tr("Binary file")}
+	}
+	return strings.Split(normalizeLineEndings(text), "\n")
+}
+
+// besideWidths returns the widths of the editor and the note beside it, or
+// false when no note is beside the editor or the window is too narrow.
+func (m Model) besideWidths(contentW int) (int, int, bool) {
+	if m.beside == nil || m.readOnly || contentW < besideMinWidth {
+		return 0, contentW, false
+	}
+	editW := (contentW - 1) / 2
+	return editW, contentW - editW - 1, true
+}
+
+func (m Model) beginBeside() (tea.Model, tea.Cmd) {
+	if m.beside != nil {
+		m.beside = nil
+		m.textarea.Focus()
+		m.status = infoStatus("Closed the note beside")
+		return m, nil
+	}
+	m = m.enterPrompt(stateBesideNote, tr("Note ID or name to show beside"))
+	return m, textinput.Blink
+}
+
+func (m Model) openBeside(query string) (tea.Model, tea.Cmd) {
+	m, path, ok := m.resolveNote(query)
+	if !ok {
+		return m, nil
+	}
+	m.input.Blur()
+	m.state = stateEditor
+	m.lastList = stateFileList
+	m.beside = &besideNote{path: path}
+	m.beside.load()
+	m.status = infoStatus("%s beside: Alt+D switches focus, Alt+N closes it", relOrBase(m.vault, path))
+	contentW, _ := m.contentDims()
+	if _, _, ok := m.besideWidths(contentW); !ok {
+		m.status = infoStatus("%s beside, shown when the window is at least %d columns wide", relOrBase(m.vault, path), besideMinWidth)
+	}
+	return m, nil
+}
+
+// switchBesideFocus moves the keys between the editor and the note beside.
+func (m Model) switchBesideFocus() (tea.Model, tea.Cmd) {
+	if m.beside == nil {
+		m.status = infoStatus("No note beside: Alt+N opens one")
+		return m, nil
+	}
+	m.beside.focused = !m.beside.focused
+	if m.beside.focused {
+		m.textarea.Blur()
+		return m, nil
+	}
+	m.textarea.Focus()
+	return m, textarea.Blink
+}
+
+// handleBesideKey scrolls the focused note beside. Keys that would edit are
+// ignored; handled is false for the keys the editor screen still handles.
+func (m Model) handleBesideKey(msg tea.KeyMsg) (Model, tea.Cmd, bool) {
+	b := m.beside
+	step := maxInt(1, m.textarea.Height()-2)
+	last := maxInt(0, len(b.lines)-1)
+	switch msg.String() {
+	case "ctrl+c", "ctrl+s", "alt+,", "alt+.", "alt+l":
+		return m, nil, false
+	case "alt+d", "esc":
+		next, cmd := m.switchBesideFocus()
+		return next.(Model), cmd, true
+	case "alt+n":
+		next, cmd := m.beginBeside()
+		return next.(Model), cmd, true
+	case "enter":
+		next, cmd := m.swapBeside()
+		return next.(Model), cmd, true
+	case "up", "k":
+		b.top = maxInt(0, b.top-1)
+	case "down", "j":
+		b.top = minInt(last, b.top+1)
+	case "pgup", "b":
+		b.top = maxInt(0, b.top-step)
+	case "pgdown", "f", " ":
+		b.top = minInt(last, b.top+step)
+	case "home", "g":
+		b.top = 0
+	case "end", "G":
+		b.top = last
+	}
+	return m, nil, true
+}
+
+// swapBeside edits the note beside and shows the edited one beside it.
+func (m Model) swapBeside() (tea.Model, tea.Cmd) {
+	if m.dirty() {
+		m.status = warnStatus("Save %s before swapping the notes", relOrBase(m.vault, m.editing))
+		return m, nil
+	}
+	other, editing := m.beside.path, m.editing
+	next, cmd := m.openFile(other)
+	nm, ok := next.(Model)
+	if !ok || nm.state != stateEditor || nm.editing != other {
+		return next, cmd
+	}
+	nm.beside = &besideNote{path: editing}
+	nm.beside.load()
+	nm.status = infoStatus("Editing %s, %s beside", relOrBase(nm.vault, other), relOrBase(nm.vault, editing))
+	return nm, cmd
+}
+
+// besideView draws the note beside: its name, then its lines from top.
+func (m Model) besideView(width int, height int) string {
+	b := m.beside
+	b.load()
+	nameStyle := hintStyle
+	if b.focused {
+		nameStyle = titleStyle
+	}
+	out := []string{nameStyle.Render(runewidth.Truncate(" "+relOrBase(m.vault, b.path), width, "…"))}
+	textW := maxInt(8, width-2)
+	for i := b.top; i < len(b.lines) && len(out) < height; i++ {
+		line := maskSecrets([]rune(strings.ReplaceAll(b.lines[i], "\t", "    ")))
+		segments, indent := m.segments(string(line), textW)
+		for r, seg := range segments {
+			if len(out) >= height {
+				break
+			}
+			row := " " + string(line[seg.start:seg.end])
+			if r > 0 {
+				row = " " + strings.Repeat(" ", indent) + string(line[seg.start:seg.end])
+			}
+			out = append(out, runewidth.Truncate(row, width, "…"))
+		}
+	}
+	return lipgloss.NewStyle().Width(width).Render(strings.Join(out, "\n"))
+}
+
+func (m Model) besideHints() string {
+	if m.beside != nil && m.beside.focused {
+		return tr("↑/↓ PgUp/PgDn: scroll note beside | Enter: edit it instead | Alt+D/Esc: back to editor | Alt+N: close")
+	}
+	return tr("Alt+D: focus note beside | Alt+N: close note beside")
+}
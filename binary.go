@@ -0,0 +1,134 @@
+package main
+
+import (
+	"bytes"
+	"encoding/hex"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// Files that decodeText cannot read as text are not loaded into the editor:
+// the textarea would mangle them and a save would write the damage back.
+// They open in a read-only viewer instead, with the file's size, date and
+// type and a hex dump of its start; O hands the file to its opener (see
+// openers.go) or the desktop's default application.
+
+const (
+	binarySniffSize = 8000
+	binaryDumpSize  = 4096
+)
+
+type binaryView struct {
+	path  string
+	size  int64
+	mod   time.Time
+	kind  string
+	lines []string
+	top   int
+}
+
+// isBinaryContent reports whether content looks like anything but text:
+// a null byte near the start, or bytes that are not valid UTF-8.
+func isBinaryContent(content []byte) bool {
+	if bytes.IndexByte(content[:minInt(len(content), binarySniffSize)], 0) >= 0 {
+		return true
+	}
+	return !utf8.Valid(trimPartialRune(content))
+}
+
+// trimPartialRune drops a rune cut off at the end of a partial read.
+func trimPartialRune(b []byte) []byte {
+	for i := len(b) - 1; i >= 0 && i >= len(b)-utf8.UTFMax; i-- {
+		if utf8.RuneStart(b[i]) {
+			if !utf8.FullRune(b[i:]) {
+				return b[:i]
+			}
+			break
+		}
+	}
+	return b
+}
+
+func (m Model) openBinary(path string, content []byte) (tea.Model, tea.Cmd) {
+	b := &binaryView{path: path, size: int64(len(content)), kind: http.DetectContentType(content)}
+	if info, err := os.Stat(path); err == nil {
+		b.mod = info.ModTime()
+	}
+	dump := hex.Dump(content[:minInt(len(content), binaryDumpSize)])
+	b.lines = strings.Split(strings.TrimRight(dump, "\n"), "\n")
+	if len(content) > binaryDumpSize {
+		b.lines = append(b.lines, tr("... %s more", formatSize(int64(len(content)-binaryDumpSize))))
+	}
+	m.binary = b
+	m.state = stateBinary
+	m.status = infoStatus("Binary file: not opened in the editor. O opens it externally")
+	return m, nil
+}
+
+func (m Model) handleBinaryKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	b := m.binary
+	page := maxInt(1, m.list.Height())
+	last := maxInt(0, len(b.lines)-page)
+	switch msg.String() {
+	case "ctrl+c":
+		return m, tea.Quit
+	case "esc", "q":
+		m.binary = nil
+		m.state = stateFileList
+		m = m.refreshFileList()
+		return m, nil
+	case "o":
+		command, ok := m.cfg.openerFor(b.path)
+		if !ok {
+			command = openerDefault
+		}
+		next, cmd := m.openExternally(b.path, command)
+		return next, cmd
+	case "up", "k":
+		b.top = maxInt(0, b.top-1)
+	case "down", "j":
+		b.top = minInt(last, b.top+1)
+	case "pgup":
+		b.top = maxInt(0, b.top-page)
+	case "pgdown", " ":
+		b.top = minInt(last, b.top+page)
+	case "home", "g":
+		b.top = 0
+	case "end", "G":
+		b.top = last
+	}
+	return m, nil
+}
+
+func (m Model) binarySubtitle() string {
+	b := m.binary
+	parts := []string{formatSize(b.size), b.kind}
+	if !b.mod.IsZero() {
+		parts = append(parts, tr("modified %s", b.mod.Format("2006-01-02 15:04")))
+	}
+	return strings.Join(parts, " | ")
+}
+
+func (m Model) binaryDumpView(contentW int, height int) string {
+	b := m.binary
+	end := minInt(len(b.lines), b.top+height)
+	style := lipgloss.NewStyle().MaxWidth(contentW)
+	lines := make([]string, 0, end-b.top)
+	for _, l := range b.lines[b.top:end] {
+		lines = append(lines, style.Render(l))
+	}
+	return strings.Join(lines, "\n")
+}
+
+func binaryHints(width int) string {
+	if width < 60 {
+		return tr("↑/↓ scroll | O open externally\nEsc back")
+	}
+	return tr("↑/↓: scroll | O: open externally | Esc: back")
+}
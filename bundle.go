@@ -0,0 +1,361 @@
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/x/term"
+)
+
+// A .gono bundle is one note plus the local files it links to, packed in a
+// zip archive. The file starts with bundleMagic and a mode line; encrypted
+// bundles hold the zip sealed with AES-256-GCM under a PBKDF2 key.
+const (
+	bundleMagic        = "GONO-BUNDLE 1\n"
+	bundlePlain        = "zip\n"
+	bundleEncrypted    = "aes-gcm\n"
+	bundleManifestName = "manifest.json"
+	bundleSaltSize     = 16
+	bundleIterations   = 600000
+	bundleMaxSize      = 256 << 20
+)
+
+type bundleManifest struct {
+	Version     int       `json:"version"`
+	Note        string    `json:"note"`
+	Attachments []string  `json:"attachments"`
+	Exported    time.Time `json:"exported"`
+}
+
+func exportNoteCommand(args []string, stdout io.Writer) error {
+	flags := flag.NewFlagSet("export-note", flag.ContinueOnError)
+	flags.SetOutput(io.Discard)
+	encrypt := flags.Bool("encrypt", false, "")
+	if err := flags.Parse(args); err != nil || flags.NArg() < 1 || flags.NArg() > 2 {
+		return errors.New("usage: gono export-note [-encrypt] NOTE [FILE.gono]")
+	}
+	note, err := filepath.Abs(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+	dest := strings.TrimSuffix(filepath.Base(note), filepath.Ext(note)) + ".gono"
+	if flags.NArg() == 2 {
+		dest = flags.Arg(1)
+	}
+
+	archive, manifest, err := packNote(note)
+	if err != nil {
+		return err
+	}
+	data := append([]byte(bundleMagic+bundlePlain), archive...)
+	if *encrypt {
+		password, err := readPassword(true)
+		if err != nil {
+			return err
+		}
+		sealed, err := sealBundle(archive, password)
+		if err != nil {
+			return err
+		}
+		data = append([]byte(bundleMagic+bundleEncrypted), sealed...)
+	}
+	if err := os.WriteFile(dest, data, 0600); err != nil {
+		return err
+	}
+	fmt.Fprintf(stdout, "Exported %s with %s to %s\n", manifest.Note,
+		pluralize(len(manifest.Attachments), "attachment", "attachments"), dest)
+	return nil
+}
+
+func importNoteCommand(args []string, stdout io.Writer) error {
+	flags := flag.NewFlagSet("import-note", flag.ContinueOnError)
+	flags.SetOutput(io.Discard)
+	overwrite := flags.Bool("overwrite", false, "")
+	if err := flags.Parse(args); err != nil || flags.NArg() != 2 {
+		return errors.New("usage: gono import-note [-overwrite] FILE.gono DIR")
+	}
+	data, err := os.ReadFile(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+	if !bytes.HasPrefix(data, []byte(bundleMagic)) {
+		return errors.New("not a GoNo bundle")
+	}
+	data = data[len(bundleMagic):]
+	var archive []byte
+	switch {
+	case bytes.HasPrefix(data, []byte(bundlePlain)):
+		archive = data[len(bundlePlain):]
+	case bytes.HasPrefix(data, []byte(bundleEncrypted)):
+		password, err := readPassword(false)
+		if err != nil {
+			return err
+		}
+		archive, err = openBundle(data[len(bundleEncrypted):], password)
+		if err != nil {
+			return err
+		}
+	default:
+		return errors.New("unsupported bundle format")
+	}
+
+	dir, err := filepath.Abs(flags.Arg(1))
+	if err != nil {
+		return err
+	}
+	manifest, err := unpackNote(archive, dir, *overwrite)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(stdout, "Imported %s with %s into %s\n", manifest.Note,
+		pluralize(len(manifest.Attachments), "attachment", "attachments"), dir)
+	return nil
+}
+
+// packNote zips the note and the local files its Markdown links point to.
+// Files outside the note's directory are stored under attachments/ and the
+// links in the packed copy are rewritten to match.
+func packNote(note string) ([]byte, bundleManifest, error) {
+	content, err := os.ReadFile(note)
+	if err != nil {
+		return nil, bundleManifest{}, err
+	}
+	dir := filepath.Dir(note)
+	manifest := bundleManifest{Version: 1, Note: filepath.Base(note), Exported: time.Now().UTC()}
+	files := make(map[string]string)
+
+	text := markdownLinkRe.ReplaceAllStringFunc(string(content), func(m string) string {
+		parts := markdownLinkRe.FindStringSubmatch(m)
+		target := parts[3]
+		if strings.Contains(target, "://") || strings.HasPrefix(target, "mailto:") || strings.HasPrefix(target, "#") {
+			return m
+		}
+		if i := strings.Index(target, "#"); i >= 0 {
+			target = target[:i]
+		}
+		if unescaped, err := url.PathUnescape(target); err == nil {
+			target = unescaped
+		}
+		abs := filepath.Join(dir, filepath.FromSlash(target))
+		if info, err := os.Stat(abs); err != nil || info.IsDir() || abs == note {
+			return m
+		}
+		name := filepath.ToSlash(filepath.Clean(filepath.FromSlash(target)))
+		if !pathWithin(dir, abs) {
+			name = "attachments/" + filepath.Base(abs)
+		}
+		if existing, ok := files[name]; ok && existing != abs {
+			name = "attachments/" + fmt.Sprintf("%d-", len(files)) + filepath.Base(abs)
+		}
+		files[name] = abs
+		if name == filepath.ToSlash(filepath.Clean(filepath.FromSlash(target))) {
+			return m
+		}
+		return strings.Replace(m, "("+parts[3], "("+name, 1)
+	})
+
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	write := func(name string, data []byte) error {
+		w, err := zw.Create(name)
+		if err != nil {
+			return err
+		}
+		_, err = w.Write(data)
+		return err
+	}
+	if err := write("note/"+manifest.Note, []byte(text)); err != nil {
+		return nil, manifest, err
+	}
+	for name, abs := range files {
+		data, err := os.ReadFile(abs)
+		if err != nil {
+			return nil, manifest, err
+		}
+		if err := write("files/"+name, data); err != nil {
+			return nil, manifest, err
+		}
+		manifest.Attachments = append(manifest.Attachments, name)
+	}
+	meta, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return nil, manifest, err
+	}
+	if err := write(bundleManifestName, meta); err != nil {
+		return nil, manifest, err
+	}
+	if err := zw.Close(); err != nil {
+		return nil, manifest, err
+	}
+	return buf.Bytes(), manifest, nil
+}
+
+// unpackNote writes the bundle's note and attachments below dir, refusing
+// entries that would land outside it.
+func unpackNote(archive []byte, dir string, overwrite bool) (bundleManifest, error) {
+	var manifest bundleManifest
+	zr, err := zip.NewReader(bytes.NewReader(archive), int64(len(archive)))
+	if err != nil {
+		return manifest, err
+	}
+	type entry struct {
+		target string
+		file   *zip.File
+	}
+	var entries []entry
+	for _, f := range zr.File {
+		var rel string
+		switch {
+		case f.Name == bundleManifestName:
+			rc, err := f.Open()
+			if err != nil {
+				return manifest, err
+			}
+			err = json.NewDecoder(io.LimitReader(rc, 1<<20)).Decode(&manifest)
+			rc.Close()
+			if err != nil {
+				return manifest, err
+			}
+			continue
+		case strings.HasPrefix(f.Name, "note/"):
+			rel = strings.TrimPrefix(f.Name, "note/")
+		case strings.HasPrefix(f.Name, "files/"):
+			rel = strings.TrimPrefix(f.Name, "files/")
+		default:
+			continue
+		}
+		clean := path.Clean(rel)
+		target := filepath.Join(dir, filepath.FromSlash(clean))
+		if rel == "" || path.IsAbs(clean) || !pathWithin(dir, target) || target == dir {
+			return manifest, fmt.Errorf("unsafe path in bundle: %s", f.Name)
+		}
+		if _, err := os.Stat(target); err == nil && !overwrite {
+			return manifest, fmt.Errorf("file already exists: %s (use -overwrite)", target)
+		}
+		entries = append(entries, entry{target: target, file: f})
+	}
+	if manifest.Note == "" {
+		return manifest, errors.New("bundle has no manifest")
+	}
+	for _, e := range entries {
+		rc, err := e.file.Open()
+		if err != nil {
+			return manifest, err
+		}
+		data, err := io.ReadAll(io.LimitReader(rc, bundleMaxSize))
+		rc.Close()
+		if err != nil {
+			return manifest, err
+		}
+		if err := os.MkdirAll(filepath.Dir(e.target), 0755); err != nil {
+			return manifest, err
+		}
+		if err := os.WriteFile(e.target, data, 0644); err != nil {
+			return manifest, err
+		}
+	}
+	return manifest, nil
+}
+
+func bundleKey(password []byte, salt []byte) ([]byte, error) {
+	return pbkdf2.Key(sha256.New, string(password), salt, bundleIterations, 32)
+}
+
+func sealBundle(plain []byte, password []byte) ([]byte, error) {
+	salt := make([]byte, bundleSaltSize)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+	key, err := bundleKey(password, salt)
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	out := append(salt, nonce...)
+	return gcm.Seal(out, nonce, plain, []byte(bundleMagic)), nil
+}
+
+func openBundle(sealed []byte, password []byte) ([]byte, error) {
+	if len(sealed) < bundleSaltSize+12 {
+		return nil, errors.New("bundle is truncated")
+	}
+	key, err := bundleKey(password, sealed[:bundleSaltSize])
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	rest := sealed[bundleSaltSize:]
+	nonce, body := rest[:gcm.NonceSize()], rest[gcm.NonceSize():]
+	plain, err := gcm.Open(nil, nonce, body, []byte(bundleMagic))
+	if err != nil {
+		return nil, errors.New("wrong password or damaged bundle")
+	}
+	return plain, nil
+}
+
+// readPassword takes the password from GONO_PASSWORD or asks on the
+// terminal without echo, twice when confirm is set.
+func readPassword(confirm bool) ([]byte, error) {
+	if env := os.Getenv("GONO_PASSWORD"); env != "" {
+		return []byte(env), nil
+	}
+	fd := os.Stdin.Fd()
+	if !term.IsTerminal(fd) {
+		return nil, errors.New("no terminal to ask for a password; set GONO_PASSWORD")
+	}
+	fmt.Fprint(os.Stderr, "Password: ")
+	password, err := term.ReadPassword(fd)
+	fmt.Fprintln(os.Stderr)
+	if err != nil {
+		return nil, err
+	}
+	if len(password) == 0 {
+		return nil, errors.New("empty password")
+	}
+	if confirm {
+		fmt.Fprint(os.Stderr, "Repeat password: ")
+		again, err := term.ReadPassword(fd)
+		fmt.Fprintln(os.Stderr)
+		if err != nil {
+			return nil, err
+		}
+		if !bytes.Equal(password, again) {
+			return nil, errors.New("passwords do not match")
+		}
+	}
+	return password, nil
+}
@@ -0,0 +1,271 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/charmbracelet/bubbles/textarea"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+const captureMaxBytes = 1 << 20
+
+// captureTarget is the inbox note quick captures are appended to.
+type captureTarget struct {
+	vault string
+	inbox string
+}
+
+func (t captureTarget) path() string {
+	return filepath.Join(t.vault, t.inbox)
+}
+
+func captureSocketPath() string {
+	return filepath.Join(homeDir(), ".gono_capture.sock")
+}
+
+// resolveCaptureTarget picks the vault from the flag, the config or the
+// first registered vault, in that order.
+func resolveCaptureTarget(vault string, inbox string) (captureTarget, error) {
+	cfg, err := loadConfig()
+	if err != nil {
+		return captureTarget{}, err
+	}
+	if vault == "" {
+		vault = expandHome(cfg.Capture.Vault)
+	}
+	if vault == "" {
+		vaults, err := loadVaultRegistry()
+		if err != nil {
+			return captureTarget{}, err
+		}
+		if len(vaults) == 0 {
+			return captureTarget{}, errors.New("no vault given and no vault registered")
+		}
+		vault = vaults[0]
+	}
+	if inbox == "" {
+		inbox = cfg.Capture.Inbox
+	}
+	abs, err := filepath.Abs(vault)
+	if err != nil {
+		return captureTarget{}, err
+	}
+	if info, err := os.Stat(abs); err != nil || !info.IsDir() {
+		return captureTarget{}, fmt.Errorf("vault not found: %s", abs)
+	}
+	if !insideVault(abs, filepath.Join(abs, inbox)) {
+		return captureTarget{}, errors.New("inbox must be inside the vault")
+	}
+	return captureTarget{vault: abs, inbox: inbox}, nil
+}
+
+// appendCapture adds text to the inbox under a timestamp heading, creating
+// the note when needed.
+func appendCapture(target captureTarget, text string, now time.Time) error {
+	text = strings.TrimSpace(strings.ReplaceAll(text, "\r\n", "\n"))
+	if text == "" {
+		return errors.New("nothing to capture")
+	}
+	path := target.path()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	entry := fmt.Sprintf("\n## %s\n\n%s\n", now.Format("2006-01-02 15:04"), text)
+	_, err = file.WriteString(entry)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+	if _, statErr := os.Stat(indexPath(target.vault)); statErr == nil {
+		if index := loadIndex(target.vault); index.indexFile(target.vault, path) {
+			_ = index.save(target.vault)
+		}
+	}
+	return nil
+}
+
+// captureCommand appends the text given as arguments or on stdin to the
+// inbox. Without either it opens a small capture window, meant to be bound
+// to an OS shortcut that starts a terminal running "gono capture".
+func captureCommand(args []string, stdin io.Reader, stdout io.Writer) error {
+	flags := flag.NewFlagSet("capture", flag.ContinueOnError)
+	flags.SetOutput(io.Discard)
+	vault := flags.String("vault", "", "")
+	inbox := flags.String("inbox", "", "")
+	if err := flags.Parse(args); err != nil {
+		return errors.New("usage: gono capture [-vault DIR] [-inbox NOTE] [TEXT...]")
+	}
+	target, err := resolveCaptureTarget(*vault, *inbox)
+	if err != nil {
+		return err
+	}
+
+	text := strings.Join(flags.Args(), " ")
+	if text == "" && !isTerminal(stdin) {
+		data, err := io.ReadAll(io.LimitReader(stdin, captureMaxBytes))
+		if err != nil {
+			return err
+		}
+		text = string(data)
+	}
+	if text == "" {
+		return runCaptureWindow(target)
+	}
+	if err := appendCapture(target, text, time.Now()); err != nil {
+		return err
+	}
+	fmt.Fprintf(stdout, "Captured to %s\n", target.path())
+	return nil
+}
+
+func isTerminal(r io.Reader) bool {
+	file, ok := r.(*os.File)
+	if !ok {
+		return false
+	}
+	info, err := file.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// captureDaemonCommand listens on a local socket and appends everything
+// written to a connection as one capture, so other programs can capture
+// without starting GoNo (for example "echo idea | nc -U ~/.gono_capture.sock").
+func captureDaemonCommand(args []string, stdout io.Writer) error {
+	flags := flag.NewFlagSet("capture-daemon", flag.ContinueOnError)
+	flags.SetOutput(io.Discard)
+	vault := flags.String("vault", "", "")
+	inbox := flags.String("inbox", "", "")
+	if err := flags.Parse(args); err != nil || flags.NArg() > 0 {
+		return errors.New("usage: gono capture-daemon [-vault DIR] [-inbox NOTE]")
+	}
+	target, err := resolveCaptureTarget(*vault, *inbox)
+	if err != nil {
+		return err
+	}
+
+	socket := captureSocketPath()
+	if conn, err := net.Dial("unix", socket); err == nil {
+		_ = conn.Close()
+		return fmt.Errorf("capture daemon already running on %s", socket)
+	}
+	_ = os.Remove(socket)
+	listener, err := net.Listen("unix", socket)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(socket)
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-signals
+		_ = listener.Close()
+	}()
+
+	fmt.Fprintf(stdout, "Capturing to %s via %s\n", target.path(), socket)
+	var mu sync.Mutex
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			if errors.Is(err, net.ErrClosed) {
+				return nil
+			}
+			return err
+		}
+		go func() {
+			defer conn.Close()
+			data, err := io.ReadAll(io.LimitReader(bufio.NewReader(conn), captureMaxBytes))
+			if err == nil {
+				mu.Lock()
+				err = appendCapture(target, string(data), time.Now())
+				mu.Unlock()
+			}
+			if err != nil {
+				fmt.Fprintln(conn, "error:", err)
+				return
+			}
+			fmt.Fprintln(conn, "ok")
+		}()
+	}
+}
+
+// captureModel is the minimal capture window: a text area that is appended
+// to the inbox on Ctrl+S.
+type captureModel struct {
+	target   captureTarget
+	textarea textarea.Model
+	status   statusLine
+	width    int
+}
+
+func runCaptureWindow(target captureTarget) error {
+	cfg, _ := loadConfig()
+	setLanguage(cfg.Language)
+	applyBackground(cfg.Background)
+	applyTheme(cfg.Theme)
+	applyLayout(cfg.Layout)
+	ta := textarea.New()
+	ta.ShowLineNumbers = false
+	ta.Placeholder = tr("Type a note...")
+	ta.MaxHeight = 0
+	ta.Focus()
+	_, err := tea.NewProgram(captureModel{target: target, textarea: ta, width: 80}).Run()
+	return err
+}
+
+func (m captureModel) Init() tea.Cmd {
+	return textarea.Blink
+}
+
+func (m captureModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width = msg.Width
+		m.textarea.SetWidth(maxInt(20, msg.Width-2))
+		m.textarea.SetHeight(maxInt(3, msg.Height-6))
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "ctrl+c", "esc":
+			return m, tea.Quit
+		case "ctrl+s":
+			if err := appendCapture(m.target, m.textarea.Value(), time.Now()); err != nil {
+				m.status = errorStatus(err)
+				return m, nil
+			}
+			return m, tea.Quit
+		}
+	}
+	var cmd tea.Cmd
+	m.textarea, cmd = m.textarea.Update(msg)
+	return m, cmd
+}
+
+func (m captureModel) View() string {
+	contentW, _ := contentSize(maxInt(m.width, 24), 24)
+	return renderScreen(
+		contentW,
+		tr("Quick capture"),
+		tr("Appends to %s", m.target.inbox),
+		m.textarea.View(),
+		tr("Ctrl+S: capture and close | Esc: discard"),
+		m.status,
+	)
+}
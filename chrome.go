@@ -0,0 +1,127 @@
+package main
+
+import (
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/mattn/go-runewidth"
+)
+
+// The window chrome around every screen comes from the "layout" section of
+// the config: a border (or none), the screen title drawn into the top
+// border, padding inside the panel, and a maximum width beyond which the
+// panel is centered in the terminal.
+
+const (
+	borderNone    = "none"
+	borderRounded = "rounded"
+	borderNormal  = "normal"
+	borderDouble  = "double"
+	borderThick   = "thick"
+)
+
+var (
+	borderChoices   = []string{borderNone, borderRounded, borderNormal, borderDouble, borderThick}
+	paddingChoices  = []int{0, 1, 2, 4}
+	maxWidthChoices = []int{0, 80, 100, 120, 160}
+
+	// chrome is the layout applied last by applyLayout.
+	chrome layoutConfig
+)
+
+func borderFor(name string) (lipgloss.Border, bool) {
+	switch name {
+	case borderRounded:
+		return lipgloss.RoundedBorder(), true
+	case borderNormal:
+		return lipgloss.NormalBorder(), true
+	case borderDouble:
+		return lipgloss.DoubleBorder(), true
+	case borderThick:
+		return lipgloss.ThickBorder(), true
+	}
+	return lipgloss.Border{}, false
+}
+
+// applyLayout rebuilds the panel style. It runs after applyTheme, since the
+// border takes the theme's color and the plain theme draws no border.
+func applyLayout(layout layoutConfig) {
+	chrome = layout
+	style := lipgloss.NewStyle().Padding(maxInt(0, layout.PaddingY), maxInt(0, layout.PaddingX))
+	if compactMode {
+		style = style.Padding(0)
+	}
+	if border, ok := borderFor(layout.Border); ok && !plainMode {
+		style = style.Border(border).BorderForeground(colorBorder)
+	}
+	panelStyle = style
+}
+
+// titleInBorder reports whether screen titles are drawn into the top border
+// instead of on a line of their own.
+func titleInBorder() bool {
+	return chrome.Title && panelStyle.GetBorderTop()
+}
+
+// framePanel wraps the content of a screen in the panel.
+func framePanel(title string, content string) string {
+	if !titleInBorder() || strings.TrimSpace(title) == "" {
+		return appStyle.Render(panelStyle.Render(content))
+	}
+	body := panelStyle.BorderTop(false).Render(content)
+	width := lipgloss.Width(body)
+	border := panelStyle.GetBorderStyle()
+	line := lipgloss.NewStyle().Foreground(colorBorder)
+	label := runewidth.Truncate(" "+title+" ", maxInt(0, width-3), "…")
+	fill := maxInt(0, width-3-runewidth.StringWidth(label))
+	top := line.Render(border.TopLeft+border.Top) + titleStyle.Render(label) +
+		line.Render(strings.Repeat(border.Top, fill)+border.TopRight)
+	return appStyle.Render(top + "\n" + body)
+}
+
+// frameBar lines the status bar up with the content inside the panel.
+func frameBar(bar string) string {
+	left := panelStyle.GetBorderLeftSize() + panelStyle.GetPaddingLeft()
+	right := panelStyle.GetBorderRightSize() + panelStyle.GetPaddingRight()
+	return appStyle.Render(lipgloss.NewStyle().Padding(0, right, 0, left).Render(bar))
+}
+
+// centerScreen centers the panel when the window is wider than the
+// configured maximum width.
+func centerScreen(screen string, windowW int) string {
+	if chrome.MaxWidth <= 0 || windowW <= chrome.MaxWidth {
+		return screen
+	}
+	return lipgloss.PlaceHorizontal(windowW, lipgloss.Center, screen)
+}
+
+func maxWidthLabel(width int) string {
+	if width <= 0 {
+		return tr("Whole window")
+	}
+	return tr("%d columns, centered", width)
+}
+
+func borderLabel(name string) string {
+	switch name {
+	case borderRounded:
+		return tr("Rounded")
+	case borderNormal:
+		return tr("Square")
+	case borderDouble:
+		return tr("Double")
+	case borderThick:
+		return tr("Thick")
+	default:
+		return tr("None")
+	}
+}
+
+func nextBorder(current string) string {
+	for i, name := range borderChoices {
+		if name == current {
+			return borderChoices[(i+1)%len(borderChoices)]
+		}
+	}
+	return borderChoices[0]
+}
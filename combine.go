@@ -0,0 +1,383 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"html"
+	"io"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// export-dir merges the notes of a directory into one document, in the
+// order the file list shows them (.order, then "order:" frontmatter when
+// enabled, then by name). Every note starts with a level-one heading taken
+// from its title and its own headings are moved down one level. The output
+// format follows the file extension: .md, .html, .pdf (rendered from the
+// HTML with a headless Chromium or wkhtmltopdf) or .docx (see docx.go).
+// Mermaid blocks become diagrams in the HTML and, with Chromium, in the PDF.
+
+var (
+	mdHeadingRe  = regexp.MustCompile(`^(#{1,6})\s+(.*?)\s*#*\s*$`)
+	mdListItemRe = regexp.MustCompile(`^\s*(?:[-*+]|(\d+)[.)])\s+(.*)$`)
+	mdCodeSpanRe = regexp.MustCompile("`([^`]+)`")
+	mdStrongRe   = regexp.MustCompile(`\*\*([^*]+)\*\*|__([^_]+)__`)
+	mdEmRe       = regexp.MustCompile(`\*([^*\s][^*]*)\*|\b_([^_]+)_\b`)
+)
+
+type combinedNote struct {
+	path  string
+	title string
+	body  string
+}
+
+func exportDirCommand(args []string, stdout io.Writer) error {
+	flags := flag.NewFlagSet("export-dir", flag.ContinueOnError)
+	flags.SetOutput(io.Discard)
+	recursive := flags.Bool("r", false, "")
+	title := flags.String("title", "", "")
+	if err := flags.Parse(args); err != nil || flags.NArg() != 2 {
+		return errors.New("usage: gono export-dir [-r] [-title TITLE] DIR FILE.md|FILE.html|FILE.pdf|FILE.docx")
+	}
+	dir, err := filepath.Abs(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+	if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+		return fmt.Errorf("directory not found: %s", dir)
+	}
+	out, err := filepath.Abs(flags.Arg(1))
+	if err != nil {
+		return err
+	}
+	format := strings.TrimPrefix(strings.ToLower(filepath.Ext(out)), ".")
+	if format == "markdown" {
+		format = "md"
+	}
+	if format != "md" && format != "html" && format != "pdf" && format != "docx" {
+		return fmt.Errorf("unsupported output format %q: use .md, .html, .pdf or .docx", filepath.Ext(out))
+	}
+	if *title == "" {
+		*title = filepath.Base(dir)
+	}
+	cfg, _ := loadConfig()
+
+	notes, err := collectCombined(dir, *recursive, cfg.List.FrontmatterOrder)
+	if err != nil {
+		return err
+	}
+	if len(notes) == 0 {
+		return fmt.Errorf("no notes in %s", dir)
+	}
+	markdown := combineMarkdown(notes, filepath.Dir(out))
+
+	switch format {
+	case "md":
+		err = os.WriteFile(out, []byte(markdown), 0644)
+	case "html":
+		err = os.WriteFile(out, []byte(htmlDocument(*title, markdown)), 0644)
+	case "pdf":
+		err = writePDF(out, htmlDocument(*title, markdown))
+	case "docx":
+		_, err = writeDocx(out, *title, markdown, true)
+	}
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(stdout, "Combined %s into %s\n", pluralize(len(notes), "note", "notes"), flags.Arg(1))
+	return nil
+}
+
+// collectCombined reads the notes of dir in list order. Subdirectories are
+// included after the notes, each in the same order, when recursive is set.
+func collectCombined(dir string, recursive bool, useFrontmatter bool) ([]combinedNote, error) {
+	files, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	entries := make([]item, 0, len(files))
+	for _, f := range files {
+		if strings.HasPrefix(f.Name(), ".") {
+			continue
+		}
+		p := filepath.Join(dir, f.Name())
+		if f.IsDir() || isExportedNote(p) {
+			entries = append(entries, item{title: f.Name(), path: p, isDir: f.IsDir()})
+		}
+	}
+	sortEntries(dir, entries, useFrontmatter)
+
+	var notes, nested []combinedNote
+	for _, e := range entries {
+		if e.isDir {
+			if !recursive {
+				continue
+			}
+			sub, err := collectCombined(e.path, true, useFrontmatter)
+			if err != nil {
+				return nil, err
+			}
+			nested = append(nested, sub...)
+			continue
+		}
+		n, err := readCombinedNote(e.path)
+		if err != nil {
+			return nil, err
+		}
+		notes = append(notes, n)
+	}
+	return append(notes, nested...), nil
+}
+
+func readCombinedNote(path string) (combinedNote, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return combinedNote{}, err
+	}
+	text := string(content)
+	if isOrgFile(path) {
+		text = orgToMarkdown(text)
+	}
+	title, body := splitNoteTitle(path, text)
+	return combinedNote{path: path, title: title, body: body}, nil
+}
+
+// splitNoteTitle returns the note's title (frontmatter title, a leading
+// level-one heading, or the file name) and its body without frontmatter and
+// without that heading.
+func splitNoteTitle(path string, text string) (string, string) {
+	text = strings.ReplaceAll(strings.TrimPrefix(text, "\ufeff"), "\r\n", "\n")
+	fields, ok := parseFrontmatter(text)
+	if ok {
+		lines := strings.SplitAfter(text, "\n")
+		for i := 1; i < len(lines); i++ {
+			if t := strings.TrimSpace(lines[i]); t == "---" || t == "..." {
+				text = strings.Join(lines[i+1:], "")
+				break
+			}
+		}
+	}
+	text = strings.TrimLeft(text, "\n")
+	title := fields["title"]
+	if first, rest, _ := strings.Cut(text, "\n"); strings.HasPrefix(first, "# ") {
+		if title == "" {
+			title = strings.TrimSpace(first[2:])
+		}
+		text = rest
+	}
+	if title == "" {
+		title = strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	}
+	return title, strings.TrimSpace(text)
+}
+
+// combineMarkdown joins the notes, demoting their headings and pointing
+// relative links at the same files from outDir.
+func combineMarkdown(notes []combinedNote, outDir string) string {
+	var b strings.Builder
+	for i, n := range notes {
+		if i > 0 {
+			b.WriteString("\n\n")
+		}
+		b.WriteString("# " + n.title + "\n\n")
+		b.WriteString(relinkMarkdown(demoteHeadings(n.body), filepath.Dir(n.path), outDir))
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+func demoteHeadings(text string) string {
+	lines := strings.Split(text, "\n")
+	inFence := false
+	for i, line := range lines {
+		if strings.HasPrefix(strings.TrimSpace(line), "```") {
+			inFence = !inFence
+			continue
+		}
+		if !inFence && strings.HasPrefix(line, "#") && mdHeadingRe.MatchString(line) && !strings.HasPrefix(line, "######") {
+			lines[i] = "#" + line
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+func relinkMarkdown(text string, noteDir string, outDir string) string {
+	if samePath(noteDir, outDir) {
+		return text
+	}
+	return markdownLinkRe.ReplaceAllStringFunc(text, func(m string) string {
+		parts := markdownLinkRe.FindStringSubmatch(m)
+		target := parts[3]
+		if strings.Contains(target, "://") || strings.HasPrefix(target, "mailto:") || strings.HasPrefix(target, "#") || filepath.IsAbs(target) {
+			return m
+		}
+		anchor := ""
+		if i := strings.Index(target, "#"); i >= 0 {
+			target, anchor = target[:i], target[i:]
+		}
+		if unescaped, err := url.PathUnescape(target); err == nil {
+			target = unescaped
+		}
+		rel, err := filepath.Rel(outDir, filepath.Join(noteDir, filepath.FromSlash(target)))
+		if err != nil {
+			return m
+		}
+		return parts[1] + "[" + parts[2] + "](" + filepath.ToSlash(rel) + anchor + ")"
+	})
+}
+
+// markdownToHTML converts the Markdown GoNo notes commonly use: headings,
+// paragraphs, lists, quotes, rules, fenced code, and inline code, emphasis,
+// links and images. Anything else is kept as escaped text. Headings get the
+// anchors of a table of contents (see toc.go).
+func markdownToHTML(text string) string {
+	var b strings.Builder
+	var para []string
+	list := ""
+	flushPara := func() {
+		if len(para) > 0 {
+			b.WriteString("<p>" + strings.Join(para, "<br>\n") + "</p>\n")
+			para = nil
+		}
+	}
+	closeList := func() {
+		if list != "" {
+			b.WriteString("</" + list + ">\n")
+			list = ""
+		}
+	}
+	anchors := make(map[string]int)
+	lines := strings.Split(text, "\n")
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case strings.HasPrefix(trimmed, "```"):
+			flushPara()
+			closeList()
+			startTag, endTag := "<pre><code>", "</code></pre>\n"
+			if strings.EqualFold(strings.TrimSpace(strings.TrimLeft(trimmed, "`")), "mermaid") {
+				startTag, endTag = "<pre class=\"mermaid\">", "</pre>\n"
+			}
+			b.WriteString(startTag)
+			for i++; i < len(lines) && !strings.HasPrefix(strings.TrimSpace(lines[i]), "```"); i++ {
+				b.WriteString(html.EscapeString(lines[i]) + "\n")
+			}
+			b.WriteString(endTag)
+		case trimmed == "" || trimmed == tocStart || trimmed == tocEnd:
+			flushPara()
+			closeList()
+		case mdHeadingRe.MatchString(line):
+			flushPara()
+			closeList()
+			parts := mdHeadingRe.FindStringSubmatch(line)
+			level := len(parts[1])
+			id := html.EscapeString(headingAnchor(parts[2], anchors))
+			fmt.Fprintf(&b, "<h%d id=\"%s\">%s</h%d>\n", level, id, inlineHTML(parts[2]), level)
+		case trimmed == "---" || trimmed == "***" || trimmed == "___":
+			flushPara()
+			closeList()
+			b.WriteString("<hr>\n")
+		case strings.HasPrefix(trimmed, ">"):
+			flushPara()
+			closeList()
+			b.WriteString("<blockquote>" + inlineHTML(strings.TrimSpace(strings.TrimPrefix(trimmed, ">"))) + "</blockquote>\n")
+		case mdListItemRe.MatchString(line):
+			flushPara()
+			parts := mdListItemRe.FindStringSubmatch(line)
+			kind := "ul"
+			if parts[1] != "" {
+				kind = "ol"
+			}
+			if list != kind {
+				closeList()
+				b.WriteString("<" + kind + ">\n")
+				list = kind
+			}
+			b.WriteString("<li>" + inlineHTML(parts[2]) + "</li>\n")
+		default:
+			closeList()
+			para = append(para, inlineHTML(trimmed))
+		}
+	}
+	flushPara()
+	closeList()
+	return b.String()
+}
+
+func inlineHTML(s string) string {
+	var codes []string
+	s = mdCodeSpanRe.ReplaceAllStringFunc(s, func(m string) string {
+		codes = append(codes, "<code>"+html.EscapeString(m[1:len(m)-1])+"</code>")
+		return fmt.Sprintf("\x00%d\x00", len(codes)-1)
+	})
+	var links []string
+	s = markdownLinkRe.ReplaceAllStringFunc(s, func(m string) string {
+		parts := markdownLinkRe.FindStringSubmatch(m)
+		target := html.EscapeString(parts[3])
+		if parts[1] == "!" {
+			links = append(links, `<img src="`+target+`" alt="`+html.EscapeString(parts[2])+`">`)
+		} else {
+			links = append(links, `<a href="`+target+`">`+html.EscapeString(parts[2])+`</a>`)
+		}
+		return fmt.Sprintf("\x01%d\x01", len(links)-1)
+	})
+	s = html.EscapeString(s)
+	s = mdStrongRe.ReplaceAllString(s, "<strong>$1$2</strong>")
+	s = mdEmRe.ReplaceAllString(s, "<em>$1$2</em>")
+	for i, c := range codes {
+		s = strings.Replace(s, fmt.Sprintf("\x00%d\x00", i), c, 1)
+	}
+	for i, l := range links {
+		s = strings.Replace(s, fmt.Sprintf("\x01%d\x01", i), l, 1)
+	}
+	return s
+}
+
+// mermaidScript renders the mermaid blocks of an HTML export in the browser.
+// Without network access the diagram source stays visible instead.
+const mermaidScript = "<script type=\"module\">import mermaid from \"https://cdn.jsdelivr.net/npm/mermaid@11/dist/mermaid.esm.min.mjs\";" +
+	"mermaid.initialize({startOnLoad: true});</script>\n"
+
+func htmlDocument(title string, markdown string) string {
+	body := markdownToHTML(markdown)
+	if strings.Contains(body, "<pre class=\"mermaid\">") {
+		body += mermaidScript
+	}
+	return "<!DOCTYPE html>\n<html>\n<head>\n<meta charset=\"utf-8\">\n<title>" + html.EscapeString(title) + "</title>\n" +
+		"<style>body{font-family:sans-serif;max-width:48em;margin:2em auto;line-height:1.5}" +
+		"pre{background:#f4f4f4;padding:.5em;overflow-x:auto}blockquote{color:#555;border-left:3px solid #ccc;margin-left:0;padding-left:1em}" +
+		"h1{page-break-before:always}h1:first-of-type{page-break-before:auto}</style>\n</head>\n<body>\n" +
+		body + "</body>\n</html>\n"
+}
+
+// writePDF prints the HTML document with the first PDF renderer found.
+func writePDF(out string, document string) error {
+	tmp, err := os.CreateTemp(filepath.Dir(out), ".gono-export-*.html")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.WriteString(document); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	for _, browser := range []string{"chromium", "chromium-browser", "google-chrome", "google-chrome-stable", "microsoft-edge"} {
+		if _, err := exec.LookPath(browser); err == nil {
+			return exec.Command(browser, "--headless", "--disable-gpu", "--no-pdf-header-footer", "--virtual-time-budget=5000",
+				"--print-to-pdf="+out, "file://"+filepath.ToSlash(tmp.Name())).Run()
+		}
+	}
+	if _, err := exec.LookPath("wkhtmltopdf"); err == nil {
+		return exec.Command("wkhtmltopdf", "--quiet", "--enable-local-file-access", tmp.Name(), out).Run()
+	}
+	return fmt.Errorf("PDF export needs chromium, google-chrome or wkhtmltopdf: %w", exec.ErrNotFound)
+}
@@ -0,0 +1,295 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const migrationVersion = 1
+
+// registryBundle is the portable export of the vault registry, the app
+// config and each vault's settings. Paths under the home directory are
+// stored as "~/..." so they can be remapped on another machine.
+type registryBundle struct {
+	Version int                   `json:"version"`
+	Config  *appConfig            `json:"config,omitempty"`
+	Vaults  []registryBundleVault `json:"vaults"`
+}
+
+type registryBundleVault struct {
+	Path     string          `json:"path"`
+	Settings json.RawMessage `json:"settings,omitempty"`
+}
+
+// runCommand handles non-interactive subcommands. It returns the process
+// exit code.
+func runCommand(args []string, stdout io.Writer, stderr io.Writer) int {
+	var err error
+	switch args[0] {
+	case "export-registry":
+		err = exportRegistryCommand(args[1:], stdout)
+	case "import-registry":
+		err = importRegistryCommand(args[1:], stdout)
+	case "export-obsidian":
+		err = exportObsidianCommand(args[1:], stdout)
+	case "export-note":
+		err = exportNoteCommand(args[1:], stdout)
+	case "import-note":
+		err = importNoteCommand(args[1:], stdout)
+	case "import":
+		err = importCommand(args[1:], stdout)
+	case "import-templates":
+		err = importTemplatesCommand(args[1:], os.Stdin, stdout)
+	case "export-anki":
+		err = exportAnkiCommand(args[1:], stdout)
+	case "export-dir":
+		err = exportDirCommand(args[1:], stdout)
+	case "export-docx":
+		err = exportDocxCommand(args[1:], stdout)
+	case "export-meta":
+		err = exportMetaCommand(args[1:], stdout)
+	case "sync":
+		err = syncCommand(args[1:], stdout)
+	case "relay":
+		err = relayCommand(args[1:], stdout)
+	case "keys":
+		err = keysCommand(args[1:], stdout)
+	case "move-vaults":
+		err = moveVaultsCommand(args[1:], stdout)
+	case "emergency-export":
+		err = emergencyExportCommand(args[1:], stdout)
+	case "keywords":
+		err = keywordsCommand(args[1:], stdout)
+	case "stale":
+		err = staleCommand(args[1:], stdout)
+	case "links":
+		err = linksCommand(args[1:], stdout)
+	case "journal":
+		err = journalCommand(args[1:], stdout)
+	case "line-endings":
+		err = lineEndingsCommand(args[1:], stdout)
+	case "query":
+		err = queryCommand(args[1:], stdout)
+	case "agenda":
+		err = agendaCommand(args[1:], stdout)
+	case "tidy":
+		err = tidyCommand(args[1:], stdout)
+	case "password":
+		err = passwordCommand(args[1:], stdout)
+	case "capture":
+		err = captureCommand(args[1:], os.Stdin, stdout)
+	case "capture-daemon":
+		err = captureDaemonCommand(args[1:], stdout)
+	case "capture-mail":
+		err = captureMailCommand(args[1:], stdout)
+	case "profiles":
+		err = profilesCommand(args[1:], stdout)
+	case "help", "-h", "--help":
+		printUsage(stdout)
+		return 0
+	default:
+		fmt.Fprintln(stderr, "Error: unknown command:", args[0])
+		printUsage(stderr)
+		return 2
+	}
+	if err != nil {
+		fmt.Fprintln(stderr, "Error:", err)
+		return 1
+	}
+	return 0
+}
+
+func printUsage(w io.Writer) {
+	fmt.Fprintln(w, "Usage:")
+	fmt.Fprintln(w, "  gono                                   start the UI")
+	fmt.Fprintln(w, "  gono --profile NAME [COMMAND]          use the vaults and config of profile NAME")
+	fmt.Fprintln(w, "  gono profiles                          list the profiles")
+	fmt.Fprintln(w, "  gono export-registry FILE              export vaults, config and vault settings")
+	fmt.Fprintln(w, "  gono import-registry [-create] [-overwrite] FILE")
+	fmt.Fprintln(w, "                                         import an export made on another machine")
+	fmt.Fprintln(w, "  gono export-obsidian VAULT DEST        copy a vault to DEST in Obsidian format")
+	fmt.Fprintln(w, "  gono export-note [-encrypt] NOTE [FILE.gono]")
+	fmt.Fprintln(w, "                                         pack a note and its attachments")
+	fmt.Fprintln(w, "  gono import-note [-overwrite] FILE.gono DIR")
+	fmt.Fprintln(w, "                                         unpack a note bundle into DIR")
+	fmt.Fprintln(w, "  gono import [-link] [-into DIR] SRC VAULT")
+	fmt.Fprintln(w, "                                         copy or link a folder of Markdown files in")
+	fmt.Fprintln(w, "  gono import-templates [-yes] [-overwrite] URL|REPO|PATH VAULT")
+	fmt.Fprintln(w, "                                         add shared templates and themes after a preview")
+	fmt.Fprintln(w, "  gono export-anki [-deck NAME] [-tag TAG] VAULT FILE.tsv")
+	fmt.Fprintln(w, "                                         export Q:/A: and cloze flashcards for Anki")
+	fmt.Fprintln(w, "  gono export-dir [-r] [-title TITLE] DIR FILE.md|FILE.html|FILE.pdf|FILE.docx")
+	fmt.Fprintln(w, "                                         combine the notes of DIR into one document")
+	fmt.Fprintln(w, "  gono export-docx [-r] [-title TITLE] [-no-pandoc] NOTE|DIR [FILE.docx]")
+	fmt.Fprintln(w, "                                         export a note or folder as a Word document")
+	fmt.Fprintln(w, "  gono export-meta [-format json|csv] [-table notes|links|tags] VAULT [FILE]")
+	fmt.Fprintln(w, "                                         dump notes, tags and links for analysis")
+	fmt.Fprintln(w, "  gono sync [-relay URL] [-group NAME] VAULT")
+	fmt.Fprintln(w, "                                         exchange encrypted changes through a relay")
+	fmt.Fprintln(w, "  gono relay [-addr HOST:PORT] DIR       run a sync relay storing blobs in DIR")
+	fmt.Fprintln(w, "  gono keys gen [-o FILE] | list|rotate VAULT | add|remove VAULT KEY [NAME]")
+	fmt.Fprintln(w, "                                         manage keyfiles and note recipients")
+	fmt.Fprintln(w, "  gono keys passphrase [-hint TEXT] VAULT | hint [-clear] VAULT [TEXT]")
+	fmt.Fprintln(w, "                                         set a recovery passphrase and its hint")
+	fmt.Fprintln(w, "  gono move-vaults [-yes] [VAULT...]     move vaults from the home directory into vault_dir")
+	fmt.Fprintln(w, "  gono emergency-export VAULT DEST       decrypt all encrypted notes to DEST")
+	fmt.Fprintln(w, "  gono query VAULT QUERY                 list notes whose frontmatter matches QUERY")
+	fmt.Fprintln(w, "  gono agenda [-week] [-o FILE.md] VAULT print today's or this week's agenda")
+	fmt.Fprintln(w, "  gono keywords [-n N] VAULT [NOTE]      list frequent terms and suggest tags")
+	fmt.Fprintln(w, "  gono stale [-months N] VAULT           list notes not opened or modified for N months")
+	fmt.Fprintln(w, "  gono links [-top N] [-recent N] VAULT  list the most linked notes and the newest links")
+	fmt.Fprintln(w, "  gono journal [-days N | -day DAY] VAULT")
+	fmt.Fprintln(w, "                                         list notes created, saved or deleted in GoNo")
+	fmt.Fprintln(w, "  gono line-endings [-to lf|crlf] VAULT|NOTE")
+	fmt.Fprintln(w, "                                         list CRLF notes or convert line endings")
+	fmt.Fprintln(w, "  gono tidy [-similarity 0.8] [-min-words N] VAULT")
+	fmt.Fprintln(w, "                                         list orphan notes and duplicate content")
+	fmt.Fprintln(w, "  gono password [-length N] [-no-symbols]")
+	fmt.Fprintln(w, "                                         print a random password")
+	fmt.Fprintln(w, "  gono capture [-vault DIR] [-inbox NOTE] [TEXT...]")
+	fmt.Fprintln(w, "                                         append TEXT or stdin to the inbox note,")
+	fmt.Fprintln(w, "                                         or open a capture window")
+	fmt.Fprintln(w, "  gono capture-daemon [-vault DIR] [-inbox NOTE]")
+	fmt.Fprintln(w, "                                         accept captures on ~/.gono_capture.sock")
+	fmt.Fprintln(w, "  gono capture-mail [-vault DIR] [-inbox NOTE] [-once]")
+	fmt.Fprintln(w, "                                         capture mails from a maildir or IMAP mailbox")
+}
+
+func exportRegistryCommand(args []string, stdout io.Writer) error {
+	if len(args) != 1 {
+		return errors.New("usage: gono export-registry FILE")
+	}
+	vaults, err := loadVaultRegistry()
+	if err != nil {
+		return err
+	}
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+
+	bundle := registryBundle{Version: migrationVersion, Config: &cfg}
+	for _, v := range vaults {
+		entry := registryBundleVault{Path: homeRelative(v)}
+		if data, readErr := os.ReadFile(vaultSettingsPath(v)); readErr == nil && json.Valid(data) {
+			entry.Settings = data
+		}
+		bundle.Vaults = append(bundle.Vaults, entry)
+	}
+
+	data, err := json.MarshalIndent(bundle, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(args[0], data, 0644); err != nil {
+		return err
+	}
+	fmt.Fprintf(stdout, "Exported %s to %s\n", pluralize(len(bundle.Vaults), "vault", "vaults"), args[0])
+	return nil
+}
+
+func importRegistryCommand(args []string, stdout io.Writer) error {
+	flags := flag.NewFlagSet("import-registry", flag.ContinueOnError)
+	create := flags.Bool("create", false, "create vault directories that do not exist yet")
+	overwrite := flags.Bool("overwrite", false, "replace existing config and vault settings")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 1 {
+		return errors.New("usage: gono import-registry [-create] [-overwrite] FILE")
+	}
+
+	data, err := os.ReadFile(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+	var bundle registryBundle
+	if err := json.Unmarshal(data, &bundle); err != nil {
+		return err
+	}
+	if bundle.Version != migrationVersion {
+		return fmt.Errorf("unsupported export version %d", bundle.Version)
+	}
+
+	if bundle.Config != nil {
+		if _, statErr := os.Stat(configPath()); os.IsNotExist(statErr) || *overwrite {
+			if err := saveConfig(*bundle.Config); err != nil {
+				return err
+			}
+			fmt.Fprintln(stdout, "Config imported")
+		} else {
+			fmt.Fprintln(stdout, "Config kept (use -overwrite to replace it)")
+		}
+	}
+
+	imported := 0
+	for _, entry := range bundle.Vaults {
+		path := expandHome(entry.Path)
+		info, statErr := os.Stat(path)
+		switch {
+		case statErr == nil && !info.IsDir():
+			fmt.Fprintln(stdout, "Skipped (not a directory):", path)
+			continue
+		case os.IsNotExist(statErr) && *create:
+			if err := os.MkdirAll(path, 0755); err != nil {
+				return err
+			}
+		case statErr != nil:
+			fmt.Fprintln(stdout, "Skipped (missing, use -create):", path)
+			continue
+		}
+
+		if err := registerVault(path); err != nil {
+			return err
+		}
+		if len(entry.Settings) > 0 {
+			if err := importVaultSettings(path, entry.Settings, *overwrite); err != nil {
+				return err
+			}
+		}
+		imported++
+		fmt.Fprintln(stdout, "Registered:", path)
+	}
+	fmt.Fprintf(stdout, "Imported %s\n", pluralize(imported, "vault", "vaults"))
+	return nil
+}
+
+func importVaultSettings(vault string, settings json.RawMessage, overwrite bool) error {
+	target := vaultSettingsPath(vault)
+	if _, err := os.Stat(target); err == nil && !overwrite {
+		return nil
+	}
+	if err := os.MkdirAll(appDir(vault), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(target, settings, 0644)
+}
+
+// homeRelative rewrites paths inside the home directory as "~/rel".
+func homeRelative(path string) string {
+	home := homeDir()
+	rel, err := filepath.Rel(home, path)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(os.PathSeparator)) {
+		return path
+	}
+	if rel == "." {
+		return "~"
+	}
+	return "~/" + filepath.ToSlash(rel)
+}
+
+func expandHome(path string) string {
+	if path == "~" {
+		return homeDir()
+	}
+	if strings.HasPrefix(path, "~/") {
+		return filepath.Join(homeDir(), filepath.FromSlash(path[2:]))
+	}
+	return path
+}
@@ -0,0 +1,179 @@
+package main
+
+import (
+	"strings"
+
+	"github.com/charmbracelet/bubbles/list"
+	"github.com/mattn/go-runewidth"
+)
+
+// In a narrow window, such as a split tmux pane, the full layout spends
+// most of its lines on key hints. Below compactWidth columns (or always,
+// with layout.compact set to "always") the compact layout takes over: the
+// hints of every screen collapse into one "?: help" line, screen titles lose
+// their label ("Vault: notes" becomes "notes"), lists leave out their title
+// and the descriptions under their entries, and the panel drops its
+// padding. ? (F1 while typing) shows the hints of the screen until the next
+// key.
+
+const compactWidth = 50
+
+const (
+	compactAuto   = "auto"
+	compactAlways = "always"
+	compactNever  = "never"
+)
+
+var (
+	compactChoices = []string{compactAuto, compactAlways, compactNever}
+
+	// compactMode is set while the compact layout is in use.
+	compactMode bool
+	// collapsedHints replaces the hints of every screen while the compact
+	// layout is in use and help is not open.
+	collapsedHints string
+)
+
+func validCompact(value string) string {
+	for _, choice := range compactChoices {
+		if value == choice {
+			return value
+		}
+	}
+	return compactAuto
+}
+
+func nextCompact(current string) string {
+	for i, choice := range compactChoices {
+		if choice == current {
+			return compactChoices[(i+1)%len(compactChoices)]
+		}
+	}
+	return compactChoices[0]
+}
+
+func compactLabel(value string) string {
+	switch value {
+	case compactAlways:
+		return tr("Always")
+	case compactNever:
+		return tr("Never")
+	default:
+		return tr("Below %d columns", compactWidth)
+	}
+}
+
+// wantsCompact reports whether a window windowW columns wide uses the
+// compact layout. Before the first size is known, it does not.
+func wantsCompact(setting string, windowW int) bool {
+	switch setting {
+	case compactAlways:
+		return true
+	case compactNever:
+		return false
+	}
+	return windowW > 0 && windowW < compactWidth
+}
+
+// updateCompact switches the compact layout on or off to suit the window,
+// restyling the panel and the list when it changes.
+func (m Model) updateCompact() Model {
+	on := wantsCompact(m.cfg.Layout.Compact, m.windowW)
+	if !on {
+		m.help = false
+	}
+	if on == compactMode {
+		return m
+	}
+	compactMode = on
+	applyLayout(m.cfg.Layout)
+	styleComponents(&m.list, &m.input, &m.textarea)
+	m.list.SetShowTitle(!on)
+	return m
+}
+
+// typing reports whether keys go into a text field on the current screen,
+// so that ? is typed rather than opening help.
+func (m Model) typing() bool {
+	switch m.state {
+	case stateEditor, stateSearch, stateVaultCreate, stateVaultOpenPath, stateFileCreate, stateTitleCreate, stateExtractNote, statePipeCommand, stateGotoNote, stateLinkNote, stateBesideNote, stateRandomTag, stateTagToggle, stateDirCreate, stateTemplatePrompt, stateKeyAdd, stateSmartFolderName, stateImportPath, stateProfileCreate:
+		return true
+	case stateConfirmDelete:
+		return m.pending != nil && m.pending.confirmName != ""
+	}
+	return m.list.FilterState() == list.Filtering
+}
+
+// isHelpKey reports whether key opens help in the compact layout. F1 keeps
+// opening the details of an error while one is shown.
+func (m Model) isHelpKey(key string) bool {
+	if !compactMode {
+		return false
+	}
+	switch key {
+	case "f1":
+		return m.status.kind != statusError
+	case "?":
+		return !m.typing()
+	}
+	return false
+}
+
+// helpHint is the line the hints collapse into, or "" when they are shown
+// in full.
+func (m Model) helpHint() string {
+	if !compactMode || m.help {
+		return ""
+	}
+	if m.typing() {
+		return tr("F1: help")
+	}
+	return tr("?: help")
+}
+
+// fitHints returns the hints to draw for a screen: the help line, or in the
+// compact layout the hints wrapped between entries, so that none is cut off.
+func fitHints(hints string, width int) string {
+	if !compactMode || strings.TrimSpace(hints) == "" {
+		return hints
+	}
+	if collapsedHints != "" {
+		return collapsedHints
+	}
+	var lines []string
+	for _, line := range strings.Split(hints, "\n") {
+		current := ""
+		for _, entry := range strings.Split(line, " | ") {
+			switch {
+			case current == "":
+				current = entry
+			case runewidth.StringWidth(current+" | "+entry) <= width:
+				current += " | " + entry
+			default:
+				lines = append(lines, current)
+				current = entry
+			}
+		}
+		lines = append(lines, current)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// hintLines is the number of lines the hints of a screen take.
+func hintLines(hints string, width int) int {
+	return wrappedLineCount(fitHints(hints, width), width)
+}
+
+// shortTitle drops the label before the first ": " of a screen title and
+// keeps the last part of a path that does not fit.
+func shortTitle(title string, width int) string {
+	if _, rest, ok := strings.Cut(title, ": "); ok && strings.TrimSpace(rest) != "" {
+		title = rest
+	}
+	if runewidth.StringWidth(title) > width {
+		if i := strings.LastIndex(title, "/"); i >= 0 && i < len(title)-1 {
+			title = "…/" + title[i+1:]
+		}
+	}
+	return runewidth.Truncate(title, width, "…")
+}
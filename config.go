@@ -0,0 +1,407 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+type appConfig struct {
+	Language            string           `json:"language"`
+	Theme               string           `json:"theme"`
+	Background          string           `json:"background"`
+	Editor              editorConfig     `json:"editor"`
+	List                listConfig       `json:"list"`
+	SaveAllOnExit       bool             `json:"save_all_on_exit"`
+	FollowExternalLinks bool             `json:"follow_external_links"`
+	ZettelIDs           bool             `json:"zettel_ids"`
+	StaleMonths         int              `json:"stale_months"`
+	StatusValues        []string         `json:"status_values"`
+	Confirm             confirmConfig    `json:"confirm"`
+	Capture             captureConfig    `json:"capture"`
+	Secrets             secretsConfig    `json:"secrets"`
+	Dates               dateConfig       `json:"dates"`
+	Startup             startupConfig    `json:"startup"`
+	Reminders           reminderConfig   `json:"reminders"`
+	Encryption          encryptionConfig `json:"encryption"`
+	StatusBar           statusBarConfig  `json:"status_bar"`
+	Layout              layoutConfig     `json:"layout"`
+	Kanban              kanbanConfig     `json:"kanban"`
+	People              peopleConfig     `json:"people"`
+	Titles              titlesConfig     `json:"titles"`
+	Trash               trashConfig      `json:"trash"`
+	Signing             signingConfig    `json:"signing"`
+	// VaultDir is where new vaults are created: an absolute path, or one
+	// relative to the home directory.
+	VaultDir string `json:"vault_dir"`
+	// Openers maps file extensions to the programs Enter opens them with.
+	Openers map[string]string `json:"openers"`
+}
+
+// titlesConfig replaces the titles and hint lines of the vault and file
+// lists with templates (see expandTitle); empty ones keep the built-in text.
+type titlesConfig struct {
+	VaultList  string `json:"vault_list"`
+	VaultHints string `json:"vault_hints"`
+	FileList   string `json:"file_list"`
+	FileHints  string `json:"file_hints"`
+}
+
+// peopleConfig is the folder, relative to the vault, holding person notes.
+type peopleConfig struct {
+	Dir string `json:"dir"`
+}
+
+// kanbanConfig is the frontmatter field the board groups notes by and the
+// columns it always shows, in order.
+type kanbanConfig struct {
+	Field   string   `json:"field"`
+	Columns []string `json:"columns"`
+}
+
+// layoutConfig is the window chrome: border "none", "rounded", "normal",
+// "double" or "thick"; the screen title in the top border; padding inside
+// the panel; and a maximum panel width (0 = the whole window), beyond which
+// the panel is centered. Split is the share of the width, in percent, of
+// the list beside the editor on wide terminals (0 = no split); Presets are
+// named layouts, Preset the one applied last.
+type layoutConfig struct {
+	Border   string                  `json:"border"`
+	Title    bool                    `json:"title_in_border"`
+	PaddingX int                     `json:"padding_x"`
+	PaddingY int                     `json:"padding_y"`
+	MaxWidth int                     `json:"max_width"`
+	Split    int                     `json:"split"`
+	Preset   string                  `json:"preset"`
+	Compact  string                  `json:"compact"`
+	Presets  map[string]layoutPreset `json:"presets"`
+}
+
+// statusBarConfig lists the status bar segments in the order they are
+// drawn (an empty list hides the bar) and optional colors per segment,
+// e.g. {"git": "#5FAF5F"} or ANSI numbers such as "208".
+type statusBarConfig struct {
+	Segments []string          `json:"segments"`
+	Colors   map[string]string `json:"colors"`
+}
+
+// encryptionConfig lists the keyfiles tried when an encrypted note is
+// opened; without any, ~/.gono_key is used. GONO_KEYFILE adds one more.
+type encryptionConfig struct {
+	Keyfiles []string `json:"keyfiles"`
+}
+
+// reminderConfig controls how due reminders are announced when a vault is
+// opened; the status line always shows them.
+type reminderConfig struct {
+	Notify bool `json:"notify"`
+}
+
+type secretsConfig struct {
+	ClipboardClearSeconds int `json:"clipboard_clear_seconds"`
+	PasswordLength        int `json:"password_length"`
+}
+
+// captureConfig is where "gono capture" appends when no -vault/-inbox flags
+// are given. An empty vault means the first registered vault.
+type captureConfig struct {
+	Vault string            `json:"vault"`
+	Inbox string            `json:"inbox"`
+	Mail  mailCaptureConfig `json:"mail"`
+}
+
+// mailCaptureConfig is the mailbox "gono capture-mail" reads: a local
+// maildir, or an IMAP server (host:port, TLS) whose password is taken from
+// GONO_MAIL_PASSWORD. With an address set only mails sent to it are taken.
+type mailCaptureConfig struct {
+	Maildir  string `json:"maildir"`
+	Server   string `json:"server"`
+	User     string `json:"user"`
+	Mailbox  string `json:"mailbox"`
+	Address  string `json:"address"`
+	Interval int    `json:"interval_seconds"`
+}
+
+// confirmConfig sets how destructive actions are confirmed: "ask" (Y/N),
+// "type-name" (type the file or vault name) or "off". ShowDiff opens the
+// unsaved changes prompt with the changes against the file on disk shown;
+// ReviewSave shows them on Ctrl+S and saves only once they are confirmed.
+type confirmConfig struct {
+	DeleteFile   string `json:"delete_file"`
+	DeleteDir    string `json:"delete_dir"`
+	DeleteVault  string `json:"delete_vault"`
+	SaveConflict string `json:"save_conflict"`
+	ShowDiff     bool   `json:"show_diff"`
+	ReviewSave   bool   `json:"review_save"`
+}
+
+type listConfig struct {
+	PageSize         int    `json:"page_size"`
+	FrontmatterOrder bool   `json:"frontmatter_order"`
+	Icons            string `json:"icons"`
+	ShowHidden       bool   `json:"show_hidden"`
+	Sort             string `json:"sort"`
+	LargeNoteKB      int    `json:"large_note_kb"`
+}
+
+type editorConfig struct {
+	TabWidth    int  `json:"tab_width"`
+	ExpandTabs  bool `json:"expand_tabs"`
+	SoftWrap    bool `json:"soft_wrap"`
+	LineNumbers bool `json:"line_numbers"`
+	LineGuide   int  `json:"line_guide"`
+	AutoSave    bool `json:"autosave"`
+	// SaveEncoding is "keep" to save notes in the encoding they were read
+	// in, or "utf-8" to convert them.
+	SaveEncoding string `json:"save_encoding"`
+	// LineEndings is "lf" or "crlf", for notes without line breaks yet.
+	LineEndings string `json:"line_endings"`
+	// LinkPreview is how many lines of a linked note are shown while the
+	// cursor is on the link; 0 turns the preview off.
+	LinkPreview int `json:"link_preview"`
+	// Fsync flushes every save to the disk before reporting it saved.
+	Fsync bool `json:"fsync"`
+	// DailyGoal is the number of words to write per day; 0 hides it.
+	DailyGoal int `json:"daily_goal"`
+	// AutoPair closes Markdown markup as it is typed and wraps selections.
+	AutoPair bool `json:"auto_pair"`
+	// AutoIndent continues indentation and blockquote markers on Enter.
+	AutoIndent bool `json:"auto_indent"`
+}
+
+var (
+	tabWidthChoices    = []int{2, 4, 8}
+	lineGuideChoices   = []int{0, 72, 80, 100, 120}
+	staleMonthsChoices = []int{3, 6, 12, 24}
+)
+
+func defaultConfig() appConfig {
+	return appConfig{
+		Theme:        themeDefault,
+		Background:   backgroundAuto,
+		StaleMonths:  defaultStaleMonths,
+		StatusValues: append([]string(nil), defaultStatusValues...),
+		Trash:        trashConfig{Enabled: true, RetentionDays: defaultTrashRetention},
+		Signing:      signingConfig{Notes: signOff},
+		VaultDir:     defaultVaultDir,
+		Editor: editorConfig{
+			TabWidth:     4,
+			ExpandTabs:   true,
+			SoftWrap:     true,
+			LineNumbers:  true,
+			SaveEncoding: saveEncodingKeep,
+			LineEndings:  lineEndingLF,
+			LinkPreview:  defaultLinkPreview,
+			AutoPair:     true,
+			AutoIndent:   true,
+		},
+		List: listConfig{
+			PageSize:    500,
+			Icons:       iconsUnicode,
+			Sort:        sortByName,
+			LargeNoteKB: 1024,
+		},
+		Secrets: secretsConfig{
+			ClipboardClearSeconds: 30,
+			PasswordLength:        20,
+		},
+		Capture: captureConfig{
+			Inbox: "inbox.md",
+		},
+		Kanban: kanbanConfig{
+			Field:   "status",
+			Columns: []string{"todo", "doing", "done"},
+		},
+		People: peopleConfig{
+			Dir: "people",
+		},
+		Layout: layoutConfig{
+			Border:   borderNone,
+			PaddingX: 1,
+			Presets:  defaultLayoutPresets(),
+			Compact:  compactAuto,
+		},
+		StatusBar: statusBarConfig{
+			Segments: append([]string(nil), barSegments...),
+		},
+		Startup: startupConfig{
+			View:     startupVaults,
+			DailyDir: "daily",
+		},
+		Dates: dateConfig{
+			Date:      "2006-01-02",
+			Time:      "15:04",
+			Timestamp: time.RFC3339,
+		},
+		Confirm: confirmConfig{
+			DeleteFile:   confirmAsk,
+			DeleteDir:    confirmAsk,
+			DeleteVault:  confirmAsk,
+			SaveConflict: confirmAsk,
+		},
+	}
+}
+
+func configPath() string {
+	return filepath.Join(appConfigDir(), "config.json")
+}
+
+// loadConfig reads the config file on top of the defaults, so keys missing
+// from the file keep their default values.
+func loadConfig() (appConfig, error) {
+	cfg := defaultConfig()
+	data, err := os.ReadFile(configPath())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return cfg, nil
+		}
+		return cfg, err
+	}
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return defaultConfig(), err
+	}
+	return cfg.normalized(), nil
+}
+
+func saveConfig(cfg appConfig) error {
+	data, err := json.MarshalIndent(cfg.normalized(), "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(appConfigDir(), 0755); err != nil {
+		return err
+	}
+	return writeFileAtomic(configPath(), data, 0644)
+}
+
+func (c appConfig) normalized() appConfig {
+	switch c.Theme {
+	case themeDefault, themeHighContrast, themePlain:
+	default:
+		if !isCustomTheme(c.Theme) {
+			c.Theme = themeDefault
+		}
+	}
+	c.Background = validBackground(c.Background)
+	if strings.TrimSpace(c.VaultDir) == "" {
+		c.VaultDir = defaultVaultDir
+	}
+	if c.Editor.TabWidth < 1 || c.Editor.TabWidth > 16 {
+		c.Editor.TabWidth = 4
+	}
+	c.Editor.SaveEncoding = validSaveEncoding(c.Editor.SaveEncoding)
+	c.Editor.LineEndings = validLineEnding(c.Editor.LineEndings)
+	if c.Editor.LineGuide < 0 {
+		c.Editor.LineGuide = 0
+	}
+	if c.Editor.LinkPreview < 0 {
+		c.Editor.LinkPreview = 0
+	}
+	if c.Editor.DailyGoal < 0 {
+		c.Editor.DailyGoal = 0
+	}
+	c.Trash.RetentionDays = maxInt(0, c.Trash.RetentionDays)
+	c.Trash.MaxSizeMB = maxInt(0, c.Trash.MaxSizeMB)
+	c.Signing.Notes = validSignNotes(c.Signing.Notes)
+	c.Signing.Key = strings.TrimSpace(c.Signing.Key)
+	c.StatusValues = normalizedStatusValues(c.StatusValues)
+	if c.StaleMonths < 1 {
+		c.StaleMonths = defaultStaleMonths
+	}
+	c.Confirm.DeleteFile = validConfirmLevel(c.Confirm.DeleteFile, deleteConfirmChoices)
+	c.Confirm.DeleteDir = validConfirmLevel(c.Confirm.DeleteDir, deleteConfirmChoices)
+	c.Confirm.DeleteVault = validConfirmLevel(c.Confirm.DeleteVault, deleteConfirmChoices)
+	c.Confirm.SaveConflict = validConfirmLevel(c.Confirm.SaveConflict, saveConfirmChoices)
+	if strings.TrimSpace(c.Capture.Inbox) == "" {
+		c.Capture.Inbox = "inbox.md"
+	}
+	if c.Secrets.PasswordLength < 8 {
+		c.Secrets.PasswordLength = 20
+	}
+	if c.Secrets.ClipboardClearSeconds < 0 {
+		c.Secrets.ClipboardClearSeconds = 0
+	}
+	c.Startup.View = validStartupView(c.Startup.View)
+	if strings.TrimSpace(c.Startup.DailyDir) == "" {
+		c.Startup.DailyDir = "daily"
+	}
+	if c.Layout.Presets == nil {
+		c.Layout.Presets = defaultLayoutPresets()
+	}
+	c.Layout.Split = maxInt(0, minInt(splitMaxPercent, c.Layout.Split))
+	c.Layout.Compact = validCompact(c.Layout.Compact)
+	if strings.TrimSpace(c.People.Dir) == "" {
+		c.People.Dir = "people"
+	}
+	defaults := defaultConfig().Dates
+	if strings.TrimSpace(c.Dates.Date) == "" {
+		c.Dates.Date = defaults.Date
+	}
+	if strings.TrimSpace(c.Dates.Time) == "" {
+		c.Dates.Time = defaults.Time
+	}
+	if strings.TrimSpace(c.Dates.Timestamp) == "" {
+		c.Dates.Timestamp = defaults.Timestamp
+	}
+	if c.List.PageSize < 1 {
+		c.List.PageSize = 500
+	}
+	c.List.Icons = validIcons(c.List.Icons)
+	c.List.Sort = validFileSort(c.List.Sort)
+	c.List.LargeNoteKB = maxInt(0, c.List.LargeNoteKB)
+	c.Openers = normalizedOpeners(c.Openers)
+	return c
+}
+
+// expandTabs replaces tabs with spaces up to the next tab stop. The textarea
+// cannot hold literal tabs, so buffers are always edited with spaces.
+func expandTabs(s string, width int) string {
+	if !strings.Contains(s, "\t") {
+		return s
+	}
+	var b strings.Builder
+	col := 0
+	for _, r := range s {
+		switch r {
+		case '\t':
+			n := width - col%width
+			b.WriteString(strings.Repeat(" ", n))
+			col += n
+		case '\n':
+			b.WriteRune(r)
+			col = 0
+		default:
+			b.WriteRune(r)
+			col++
+		}
+	}
+	return b.String()
+}
+
+// unexpandIndent turns leading runs of width spaces back into tabs.
+func unexpandIndent(s string, width int) string {
+	indent := strings.Repeat(" ", width)
+	lines := strings.Split(s, "\n")
+	for i, line := range lines {
+		tabs := 0
+		for strings.HasPrefix(line, indent) {
+			line = line[width:]
+			tabs++
+		}
+		if tabs > 0 {
+			lines[i] = strings.Repeat("\t", tabs) + line
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+func nextChoice(choices []int, current int) int {
+	for i, c := range choices {
+		if c == current {
+			return choices[(i+1)%len(choices)]
+		}
+	}
+	return choices[0]
+}
@@ -0,0 +1,228 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// Confirmation levels for destructive actions.
+const (
+	confirmAsk      = "ask"
+	confirmTypeName = "type-name"
+	confirmOff      = "off"
+)
+
+var (
+	deleteConfirmChoices = []string{confirmAsk, confirmTypeName, confirmOff}
+	saveConfirmChoices   = []string{confirmAsk, confirmOff}
+)
+
+var errSaveConflict = errors.New("file changed on disk since it was opened")
+
+func validConfirmLevel(level string, choices []string) string {
+	for _, c := range choices {
+		if c == level {
+			return level
+		}
+	}
+	return confirmAsk
+}
+
+func nextConfirmLevel(choices []string, current string) string {
+	for i, c := range choices {
+		if c == current {
+			return choices[(i+1)%len(choices)]
+		}
+	}
+	return choices[0]
+}
+
+func confirmLabel(level string) string {
+	switch level {
+	case confirmTypeName:
+		return tr("Type the name")
+	case confirmOff:
+		return tr("Off")
+	default:
+		return tr("Ask Y/N")
+	}
+}
+
+func (m Model) deleteLevel(target deleteTarget) string {
+	switch {
+	case target.purge:
+		// Emptying the trash cannot be undone, so it is always confirmed.
+		if m.cfg.Confirm.DeleteDir == confirmOff {
+			return confirmAsk
+		}
+		return m.cfg.Confirm.DeleteDir
+	case target.isVault:
+		return m.cfg.Confirm.DeleteVault
+	case target.isDir:
+		return m.cfg.Confirm.DeleteDir
+	default:
+		return m.cfg.Confirm.DeleteFile
+	}
+}
+
+// beginDelete asks for confirmation as configured for the kind of target,
+// or deletes right away when confirmation is off. The contents of a
+// directory are counted in the background while the question is shown.
+func (m Model) beginDelete(target deleteTarget) (tea.Model, tea.Cmd) {
+	target.trash = m.usesTrash(target)
+	root := m.vault
+	if target.isVault {
+		root = target.path
+	}
+	if dir, ok := guardedDir(root, target.path); ok && target.isDir && !target.link && !target.purge {
+		target.guard = dir
+	}
+	m.lastList = m.state
+	m.state = stateConfirmDelete
+	m.pending = &target
+	level := m.deleteLevel(target)
+	if target.guard != "" {
+		level = confirmTypeName
+	}
+	if level == confirmOff {
+		return m.confirmDelete()
+	}
+	var summarize tea.Cmd
+	if target.isDir && !target.link {
+		summarize = deleteSummaryCmd(target.path)
+	}
+	if level == confirmTypeName {
+		m.pending.confirmName = filepath.Base(target.path)
+		if target.guard != "" && !target.isVault {
+			m.pending.confirmName = filepath.ToSlash(relOrBase(m.vault, target.path))
+		}
+		m.input.SetValue("")
+		m.input.Placeholder = tr("Type %s to confirm", m.pending.confirmName)
+		m.input.Focus()
+		return m, tea.Batch(textinput.Blink, summarize)
+	}
+	return m, summarize
+}
+
+// typedNameMatches reports whether the name typed for a type-name
+// confirmation matches; targets without one always match.
+func (m Model) typedNameMatches() bool {
+	if m.pending == nil || m.pending.confirmName == "" {
+		return true
+	}
+	return strings.TrimSpace(m.input.Value()) == m.pending.confirmName
+}
+
+// changedOnDisk reports whether the open file was modified by another
+// program since it was loaded or last saved. A file that was removed in the
+// meantime is not a conflict; saving simply recreates it.
+func (m Model) changedOnDisk() bool {
+	info, err := os.Stat(m.editing)
+	if err != nil {
+		return false
+	}
+	return !info.ModTime().Equal(m.diskMod)
+}
+
+// saveFromEditor saves the buffer for Ctrl+S, asking first when that would
+// overwrite changes made to the file outside the editor.
+func (m Model) saveFromEditor() (tea.Model, tea.Cmd) {
+	saved, err := m.saveBuffer()
+	if errors.Is(err, errSaveConflict) {
+		m.state = stateConfirmOverwrite
+		return m, nil
+	}
+	if err != nil {
+		m.status = errorStatus(err)
+		return m, nil
+	}
+	m = saved
+	m.status = okStatus("Saved: %s", relOrBase(m.vault, m.editing))
+	return m, nil
+}
+
+// beginReviewSave shows the changes Ctrl+S is about to write, for
+// confirm.review_save. Dates are resolved first, so the diff is exactly
+// what gets written.
+func (m Model) beginReviewSave() Model {
+	m = m.resolveBufferDates()
+	m.quickDiff = m.bufferDiff()
+	m.state = stateReviewSave
+	return m
+}
+
+// handleReviewSaveKey saves the reviewed changes or goes back to editing
+// without saving.
+func (m Model) handleReviewSaveKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "ctrl+c":
+		m.quickDiff = nil
+		return m.quitEditor()
+	case "y", "s", "enter", "ctrl+s":
+		m.state = stateEditor
+		m.quickDiff = nil
+		return m.saveFromEditor()
+	case "n", "esc":
+		m.state = stateEditor
+		m.quickDiff = nil
+		m.status = infoStatus("Not saved: %s", relOrBase(m.vault, m.editing))
+	default:
+		if m.quickDiff != nil {
+			m.quickDiff.scroll(msg, maxInt(1, m.list.Height()-1))
+		}
+	}
+	return m, nil
+}
+
+func reviewSaveHints(width int) string {
+	if width < 58 {
+		return tr("Y/Enter: save\nN/Esc: keep editing")
+	}
+	return tr("Y/Enter: save | ↑/↓: scroll | N/Esc: keep editing")
+}
+
+// handleOverwriteKey resolves the prompt shown when Ctrl+S would overwrite
+// changes made to the file outside the editor.
+func (m Model) handleOverwriteKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "ctrl+c":
+		return m.quitEditor()
+	case "y", "enter":
+		saved, err := m.writeBuffer()
+		m.state = stateEditor
+		if err != nil {
+			m.status = errorStatus(err)
+			return m, nil
+		}
+		m = saved
+		m.status = okStatus("Saved: %s", relOrBase(m.vault, m.editing))
+	case "n", "esc":
+		m.state = stateEditor
+		m.status = infoStatus("Not saved: %s changed on disk", relOrBase(m.vault, m.editing))
+	}
+	return m, nil
+}
+
+func overwriteHints(width int) string {
+	if width < 58 {
+		return tr("Y/Enter: overwrite\nN/Esc: keep editing")
+	}
+	return tr("Y/Enter: overwrite with my version | N/Esc: keep editing")
+}
+
+func typeNameHints(width int, trash bool) string {
+	switch {
+	case trash && width < 58:
+		return tr("Enter: move to trash\nEsc: cancel")
+	case trash:
+		return tr("Type the name, then Enter: move to trash | Esc: cancel")
+	case width < 58:
+		return tr("Enter: delete\nEsc: cancel")
+	}
+	return tr("Type the name, then Enter: delete permanently | Esc: cancel")
+}
@@ -0,0 +1,38 @@
+package main
+
+import (
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// dateConfig holds the Go time layouts inserted by Alt+1 (date), Alt+2
+// (time) and Alt+3 (timestamp).
+type dateConfig struct {
+	Date      string `json:"date"`
+	Time      string `json:"time"`
+	Timestamp string `json:"timestamp"`
+}
+
+// vaultDates returns the date formats for vault: its own config where set,
+// the app config otherwise.
+func vaultDates(vault string, global dateConfig) dateConfig {
+	dates := global
+	vs := loadVaultSettings(vault)
+	if strings.TrimSpace(vs.Dates.Date) != "" {
+		dates.Date = vs.Dates.Date
+	}
+	if strings.TrimSpace(vs.Dates.Time) != "" {
+		dates.Time = vs.Dates.Time
+	}
+	if strings.TrimSpace(vs.Dates.Timestamp) != "" {
+		dates.Timestamp = vs.Dates.Timestamp
+	}
+	return dates
+}
+
+func (m Model) insertDate(layout string) (tea.Model, tea.Cmd) {
+	m.textarea.InsertString(time.Now().Format(layout))
+	return m, nil
+}
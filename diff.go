@@ -0,0 +1,210 @@
+package main
+
+import (
+	"os"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/mattn/go-runewidth"
+)
+
+// A line diff between the note on disk and the editor buffer, shown in the
+// unsaved changes prompt so it is clear what saving or discarding means.
+// Unchanged lines around each change are kept as context; the rest are
+// left out.
+
+const (
+	diffContext = 2
+	// diffMaxCells bounds the table of the longest common subsequence; a
+	// larger changed region is shown as removed and added as a whole.
+	diffMaxCells = 4 << 20
+)
+
+type diffLine struct {
+	op   byte // ' ', '-' or '+'
+	text string
+	// line is the number of the line in the new text, or in the old one
+	// for removed lines.
+	line int
+}
+
+// diffLines returns the edit script turning a into b.
+func diffLines(a []string, b []string) []diffLine {
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+	out := make([]diffLine, 0, len(b)+len(a)-prefix-suffix)
+	for i := 0; i < prefix; i++ {
+		out = append(out, diffLine{op: ' ', text: a[i], line: i + 1})
+	}
+	midA := a[prefix : len(a)-suffix]
+	midB := b[prefix : len(b)-suffix]
+	out = append(out, diffMiddle(midA, midB, prefix)...)
+	for i := 0; i < suffix; i++ {
+		out = append(out, diffLine{op: ' ', text: b[len(b)-suffix+i], line: len(b) - suffix + i + 1})
+	}
+	return out
+}
+
+func diffMiddle(a []string, b []string, offset int) []diffLine {
+	var out []diffLine
+	if len(a) == 0 || len(b) == 0 || (len(a)+1)*(len(b)+1) > diffMaxCells {
+		for i, s := range a {
+			out = append(out, diffLine{op: '-', text: s, line: offset + i + 1})
+		}
+		for i, s := range b {
+			out = append(out, diffLine{op: '+', text: s, line: offset + i + 1})
+		}
+		return out
+	}
+	// lcs[i][j] is the length of the longest common subsequence of a[i:]
+	// and b[j:].
+	w := len(b) + 1
+	lcs := make([]int32, (len(a)+1)*w)
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i*w+j] = lcs[(i+1)*w+j+1] + 1
+			} else {
+				lcs[i*w+j] = max(lcs[(i+1)*w+j], lcs[i*w+j+1])
+			}
+		}
+	}
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && j < len(b) && a[i] == b[j]:
+			out = append(out, diffLine{op: ' ', text: b[j], line: offset + j + 1})
+			i++
+			j++
+		case i < len(a) && (j == len(b) || lcs[(i+1)*w+j] >= lcs[i*w+j+1]):
+			out = append(out, diffLine{op: '-', text: a[i], line: offset + i + 1})
+			i++
+		default:
+			out = append(out, diffLine{op: '+', text: b[j], line: offset + j + 1})
+			j++
+		}
+	}
+	return out
+}
+
+// diffHunks keeps the changed lines with diffContext lines around them and
+// starts every hunk with the line number it begins at.
+func diffHunks(lines []diffLine) []diffLine {
+	keep := make([]bool, len(lines))
+	for i, l := range lines {
+		if l.op == ' ' {
+			continue
+		}
+		for k := maxInt(0, i-diffContext); k <= minInt(len(lines)-1, i+diffContext); k++ {
+			keep[k] = true
+		}
+	}
+	var out []diffLine
+	for i, l := range lines {
+		if !keep[i] {
+			continue
+		}
+		if i == 0 || !keep[i-1] {
+			out = append(out, diffLine{op: '@', line: l.line})
+		}
+		out = append(out, l)
+	}
+	return out
+}
+
+func diffStats(lines []diffLine) (int, int) {
+	added, removed := 0, 0
+	for _, l := range lines {
+		switch l.op {
+		case '+':
+			added++
+		case '-':
+			removed++
+		}
+	}
+	return added, removed
+}
+
+// diffView is the diff shown in a prompt, scrolled by top.
+type diffView struct {
+	lines   []diffLine
+	added   int
+	removed int
+	top     int
+}
+
+// bufferDiff compares the file on disk with the editor buffer.
+func (m Model) bufferDiff() *diffView {
+	var disk []byte
+	var err error
+	if isEncryptedNote(m.editing) {
+		disk, err = readEncryptedNote(m.editing, m.cfg.Encryption)
+	} else {
+		disk, err = os.ReadFile(m.editing)
+	}
+	old := ""
+	if err == nil {
+		text, _, _ := decodeText(disk)
+		old = expandTabs(text, m.cfg.Editor.TabWidth)
+	}
+	all := diffLines(splitDiffLines(old), splitDiffLines(m.textarea.Value()))
+	added, removed := diffStats(all)
+	return &diffView{lines: diffHunks(all), added: added, removed: removed}
+}
+
+func splitDiffLines(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(strings.ReplaceAll(s, "\r\n", "\n"), "\n"), "\n")
+}
+
+func (d *diffView) summary() string {
+	if d.added == 0 && d.removed == 0 {
+		return tr("Only the line ending at the end of the note differs")
+	}
+	return tr("%s, %s", trn("%d line added", "%d lines added", d.added), trn("%d line removed", "%d lines removed", d.removed))
+}
+
+// scroll handles the keys that move through the diff.
+func (d *diffView) scroll(msg tea.KeyMsg, page int) {
+	last := maxInt(0, len(d.lines)-page)
+	switch msg.String() {
+	case "up", "k":
+		d.top = maxInt(0, d.top-1)
+	case "down", "j":
+		d.top = minInt(last, d.top+1)
+	case "pgup":
+		d.top = maxInt(0, d.top-page)
+	case "pgdown", " ":
+		d.top = minInt(last, d.top+page)
+	}
+}
+
+func (d *diffView) render(width int, height int) string {
+	removed := lipgloss.NewStyle().Foreground(colorError)
+	added := lipgloss.NewStyle().Foreground(colorSuccess)
+	end := minInt(len(d.lines), d.top+height)
+	out := make([]string, 0, end-d.top)
+	for _, l := range d.lines[d.top:end] {
+		text := strings.ReplaceAll(l.text, "\t", "    ")
+		switch l.op {
+		case '@':
+			out = append(out, hintStyle.Render(runewidth.Truncate(tr("@@ line %d", l.line), width, "…")))
+		case '-':
+			out = append(out, removed.Render(runewidth.Truncate("- "+text, width, "…")))
+		case '+':
+			out = append(out, added.Render(runewidth.Truncate("+ "+text, width, "…")))
+		default:
+			out = append(out, runewidth.Truncate("  "+text, width, "…"))
+		}
+	}
+	return strings.Join(out, "\n")
+}
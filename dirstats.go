@@ -0,0 +1,254 @@
+package main
+
+import (
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// dirStats summarizes everything below a directory. Dot directories and
+// symlinks are skipped, matching what the file list and index show.
+type dirStats struct {
+	notes int
+	dirs  int
+	size  int64
+}
+
+// dirStatsCache holds computed stats per directory for the open vault.
+// pending marks directories whose stats are being computed in the
+// background, so each is walked at most once.
+type dirStatsCache struct {
+	stats   map[string]dirStats
+	pending map[string]struct{}
+}
+
+type dirStatsMsg struct {
+	vault string
+	stats map[string]dirStats
+}
+
+func newDirStatsCache() *dirStatsCache {
+	return &dirStatsCache{
+		stats:   make(map[string]dirStats),
+		pending: make(map[string]struct{}),
+	}
+}
+
+// invalidate drops the stats of p and every directory above it, since all
+// of them include p in their totals.
+func (c *dirStatsCache) invalidate(vault string, p string) {
+	for dir := p; pathWithin(vault, dir); dir = filepath.Dir(dir) {
+		delete(c.stats, dir)
+		if dir == vault || filepath.Dir(dir) == dir {
+			return
+		}
+	}
+}
+
+func computeDirStats(dir string) dirStats {
+	var st dirStats
+	_ = filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil || p == dir {
+			return nil
+		}
+		if strings.HasPrefix(d.Name(), ".") {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if d.Type()&fs.ModeSymlink != 0 {
+			return nil
+		}
+		if d.IsDir() {
+			st.dirs++
+			return nil
+		}
+		if isIndexedNote(p) {
+			st.notes++
+		}
+		if info, err := d.Info(); err == nil {
+			st.size += info.Size()
+		}
+		return nil
+	})
+	return st
+}
+
+func (s dirStats) describe() string {
+	return tr("%s, %s, %s",
+		trn("%d note", "%d notes", s.notes),
+		trn("%d folder", "%d folders", s.dirs),
+		formatSize(s.size))
+}
+
+func formatSize(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return tr("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for v := n / unit; v >= unit; v /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %cB", float64(n)/float64(div), "KMGTPE"[exp])
+}
+
+// deleteSummary is everything a delete would remove below a directory,
+// hidden files included.
+type deleteSummary struct {
+	files int
+	notes int
+	dirs  int
+	size  int64
+}
+
+type deleteSummaryMsg struct {
+	path    string
+	summary deleteSummary
+}
+
+// summarizeDelete counts what lies below dir without following symlinks,
+// which a delete removes but does not descend into.
+func summarizeDelete(dir string) deleteSummary {
+	var s deleteSummary
+	_ = filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil || p == dir {
+			return nil
+		}
+		if d.IsDir() {
+			s.dirs++
+			return nil
+		}
+		s.files++
+		if isIndexedNote(p) {
+			s.notes++
+		}
+		if info, err := d.Info(); err == nil && d.Type()&fs.ModeSymlink == 0 {
+			s.size += info.Size()
+		}
+		return nil
+	})
+	return s
+}
+
+func deleteSummaryCmd(path string) tea.Cmd {
+	return func() tea.Msg {
+		return deleteSummaryMsg{path: path, summary: summarizeDelete(path)}
+	}
+}
+
+func (s deleteSummary) describe() string {
+	if s.files == 0 && s.dirs == 0 {
+		return tr("The directory is empty.")
+	}
+	return tr("Removes %s (%s) and %s, %s in total.",
+		trn("%d file", "%d files", s.files),
+		trn("%d note", "%d notes", s.notes),
+		trn("%d subdirectory", "%d subdirectories", s.dirs),
+		formatSize(s.size))
+}
+
+// applyDeleteSummary fills in the summary of the directory awaiting
+// confirmation, unless the prompt has moved on in the meantime.
+func (m Model) applyDeleteSummary(msg deleteSummaryMsg) Model {
+	if m.state != stateConfirmDelete || m.pending == nil || m.pending.path != msg.path {
+		return m
+	}
+	summary := msg.summary
+	m.pending.summary = &summary
+	return m
+}
+
+// details is the line under the name of the target of a delete.
+func (t deleteTarget) details() string {
+	if !t.isDir || t.link {
+		return ""
+	}
+	lines := ""
+	if t.guard != "" {
+		lines = tr("%s is protected in the vault settings.", t.guard) + "\n"
+	}
+	switch {
+	case t.summary == nil:
+		lines += tr("Counting contents…")
+	case t.trash:
+		lines += t.summary.describe() + " " + tr("It goes to the trash of the vault.")
+	default:
+		lines += t.summary.describe()
+	}
+	return lines
+}
+
+// dirStatsCmd starts computing stats for the directories on the current
+// list page that have none cached yet.
+func (m Model) dirStatsCmd() tea.Cmd {
+	if m.state != stateFileList || m.dirStats == nil {
+		return nil
+	}
+	visible := m.list.VisibleItems()
+	start, end := m.list.Paginator.GetSliceBounds(len(visible))
+	var dirs []string
+	for _, li := range visible[start:end] {
+		it := li.(item)
+		if !it.isDir || it.mode != "" || it.link {
+			continue
+		}
+		if _, ok := m.dirStats.stats[it.path]; ok {
+			continue
+		}
+		if _, ok := m.dirStats.pending[it.path]; ok {
+			continue
+		}
+		m.dirStats.pending[it.path] = struct{}{}
+		dirs = append(dirs, it.path)
+	}
+	if len(dirs) == 0 {
+		return nil
+	}
+	vault := m.vault
+	return func() tea.Msg {
+		stats := make(map[string]dirStats, len(dirs))
+		for _, dir := range dirs {
+			stats[dir] = computeDirStats(dir)
+		}
+		return dirStatsMsg{vault: vault, stats: stats}
+	}
+}
+
+func (m Model) applyDirStats(msg dirStatsMsg) Model {
+	if msg.vault != m.vault || m.dirStats == nil {
+		return m
+	}
+	for dir, st := range msg.stats {
+		delete(m.dirStats.pending, dir)
+		m.dirStats.stats[dir] = st
+	}
+	if m.state != stateFileList {
+		return m
+	}
+	for i, li := range m.list.Items() {
+		it := li.(item)
+		if st, ok := msg.stats[it.path]; ok && it.isDir && it.mode == "" && !it.link {
+			it.desc = st.describe()
+			m.list.SetItem(i, it)
+		}
+	}
+	return m
+}
+
+func (m Model) invalidateDirStats(p string) Model {
+	if m.dirStats != nil && m.vault != "" {
+		delete(m.dirStats.stats, p)
+		m.dirStats.invalidate(m.vault, filepath.Dir(p))
+	}
+	if m.fileStats != nil {
+		delete(m.fileStats.info, p)
+		delete(m.fileStats.missing, p)
+	}
+	return m
+}
@@ -0,0 +1,448 @@
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"html"
+	"image"
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+	"io"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// Word documents are written by pandoc when it is installed, since it
+// handles every Markdown construct. Without it (or with -no-pandoc) GoNo
+// writes the .docx itself from the Markdown subset the HTML export covers:
+// headings, paragraphs, lists, quotes, rules, fenced code, inline emphasis,
+// code and links, and PNG, JPEG or GIF images. gono export-docx takes a
+// single note or a folder; export-dir writes .docx files the same way.
+
+const (
+	// docxMaxImageWidth is 6 inches in EMU, the width of an A4 page less
+	// its margins.
+	docxMaxImageWidth = 6 * 914400
+	docxEMUPerPixel   = 9525
+)
+
+var docxInlineRe = regexp.MustCompile("`[^`]+`|" + markdownLinkRe.String())
+
+func exportDocxCommand(args []string, stdout io.Writer) error {
+	flags := flag.NewFlagSet("export-docx", flag.ContinueOnError)
+	flags.SetOutput(io.Discard)
+	recursive := flags.Bool("r", false, "")
+	title := flags.String("title", "", "")
+	noPandoc := flags.Bool("no-pandoc", false, "")
+	if err := flags.Parse(args); err != nil || flags.NArg() < 1 || flags.NArg() > 2 {
+		return errors.New("usage: gono export-docx [-r] [-title TITLE] [-no-pandoc] NOTE|DIR [FILE.docx]")
+	}
+	src, err := filepath.Abs(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+	info, err := os.Stat(src)
+	if err != nil {
+		return err
+	}
+	out := strings.TrimSuffix(src, filepath.Ext(src)) + ".docx"
+	if info.IsDir() {
+		out = filepath.Join(filepath.Dir(src), filepath.Base(src)+".docx")
+	}
+	if flags.NArg() == 2 {
+		if out, err = filepath.Abs(flags.Arg(1)); err != nil {
+			return err
+		}
+	}
+	if !strings.EqualFold(filepath.Ext(out), ".docx") {
+		return fmt.Errorf("output must be a .docx file: %s", out)
+	}
+
+	var notes []combinedNote
+	if info.IsDir() {
+		cfg, _ := loadConfig()
+		if notes, err = collectCombined(src, *recursive, cfg.List.FrontmatterOrder); err != nil {
+			return err
+		}
+		if len(notes) == 0 {
+			return fmt.Errorf("no notes in %s", src)
+		}
+	} else {
+		if !isExportedNote(src) {
+			return fmt.Errorf("not a note: %s", src)
+		}
+		n, err := readCombinedNote(src)
+		if err != nil {
+			return err
+		}
+		notes = []combinedNote{n}
+	}
+	if *title == "" {
+		*title = notes[0].title
+		if info.IsDir() {
+			*title = filepath.Base(src)
+		}
+	}
+	pandoc, err := writeDocx(out, *title, combineMarkdown(notes, filepath.Dir(out)), !*noPandoc)
+	if err != nil {
+		return err
+	}
+	via := ""
+	if pandoc {
+		via = " with pandoc"
+	}
+	fmt.Fprintf(stdout, "Exported %s to %s%s\n", pluralize(len(notes), "note", "notes"), out, via)
+	return nil
+}
+
+// writeDocx writes markdown, whose relative links point from the folder of
+// out, as a Word document titled title (in its properties; pandoc leaves
+// the title out). It reports whether pandoc wrote it.
+func writeDocx(out string, title string, markdown string, usePandoc bool) (bool, error) {
+	if usePandoc {
+		if _, err := exec.LookPath("pandoc"); err == nil {
+			cmd := exec.Command("pandoc", "-f", "markdown", "-t", "docx", "-o", out, "--resource-path="+filepath.Dir(out))
+			cmd.Stdin = strings.NewReader(markdown)
+			if output, err := cmd.CombinedOutput(); err != nil {
+				return true, fmt.Errorf("pandoc: %s", strings.TrimSpace(string(output)))
+			}
+			return true, nil
+		}
+	}
+	data, err := buildDocx(title, markdown, filepath.Dir(out))
+	if err != nil {
+		return false, err
+	}
+	return false, os.WriteFile(out, data, 0644)
+}
+
+type docxImage struct {
+	name string
+	data []byte
+}
+
+// docxWriter collects the body of word/document.xml and the hyperlinks and
+// images it refers to.
+type docxWriter struct {
+	dir      string
+	body     strings.Builder
+	rels     []string
+	images   []docxImage
+	headings int
+}
+
+// rel adds a relationship of word/document.xml and returns its ID. rId1 is
+// the style sheet.
+func (w *docxWriter) rel(kind string, target string, external bool) string {
+	id := fmt.Sprintf("rId%d", len(w.rels)+2)
+	mode := ""
+	if external {
+		mode = ` TargetMode="External"`
+	}
+	w.rels = append(w.rels, fmt.Sprintf(`<Relationship Id="%s" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/%s" Target="%s"%s/>`,
+		id, kind, html.EscapeString(target), mode))
+	return id
+}
+
+func docxText(s string, props string) string {
+	if s == "" {
+		return ""
+	}
+	if props != "" {
+		props = "<w:rPr>" + props + "</w:rPr>"
+	}
+	// XML 1.0 has no place for most control characters; tabs become tab
+	// elements.
+	s = strings.Map(func(r rune) rune {
+		if r < 0x20 && r != '\t' {
+			return -1
+		}
+		return r
+	}, s)
+	text := strings.ReplaceAll(html.EscapeString(s), "\t", `</w:t><w:tab/><w:t xml:space="preserve">`)
+	return `<w:r>` + props + `<w:t xml:space="preserve">` + text + `</w:t></w:r>`
+}
+
+func (w *docxWriter) paragraph(props string, runs string) {
+	if props != "" {
+		props = "<w:pPr>" + props + "</w:pPr>"
+	}
+	w.body.WriteString("<w:p>" + props + runs + "</w:p>\n")
+}
+
+// runs converts inline Markdown: code spans, links and images, then
+// strong and emphasized text.
+func (w *docxWriter) runs(s string, props string) string {
+	var b strings.Builder
+	last := 0
+	for _, loc := range docxInlineRe.FindAllStringSubmatchIndex(s, -1) {
+		b.WriteString(emphasisRuns(s[last:loc[0]], props))
+		last = loc[1]
+		token := s[loc[0]:loc[1]]
+		if strings.HasPrefix(token, "`") {
+			b.WriteString(docxText(token[1:len(token)-1], props+`<w:rStyle w:val="CodeChar"/>`))
+			continue
+		}
+		embed, label, target := s[loc[2]:loc[3]] == "!", s[loc[4]:loc[5]], s[loc[6]:loc[7]]
+		if embed {
+			if img, ok := w.image(target, label); ok {
+				b.WriteString(img)
+				continue
+			}
+			b.WriteString(docxText(label, props+"<w:i/>"))
+			continue
+		}
+		if label == "" {
+			label = target
+		}
+		id := w.rel("hyperlink", target, true)
+		b.WriteString(`<w:hyperlink r:id="` + id + `">` + emphasisRuns(label, props+`<w:rStyle w:val="Hyperlink"/>`) + `</w:hyperlink>`)
+	}
+	b.WriteString(emphasisRuns(s[last:], props))
+	return b.String()
+}
+
+func emphasisRuns(s string, props string) string {
+	var b strings.Builder
+	last := 0
+	for _, loc := range mdStrongRe.FindAllStringSubmatchIndex(s, -1) {
+		b.WriteString(italicRuns(s[last:loc[0]], props))
+		inner := ""
+		if loc[2] >= 0 {
+			inner = s[loc[2]:loc[3]]
+		} else {
+			inner = s[loc[4]:loc[5]]
+		}
+		b.WriteString(docxText(inner, props+"<w:b/>"))
+		last = loc[1]
+	}
+	b.WriteString(italicRuns(s[last:], props))
+	return b.String()
+}
+
+func italicRuns(s string, props string) string {
+	var b strings.Builder
+	last := 0
+	for _, loc := range mdEmRe.FindAllStringSubmatchIndex(s, -1) {
+		b.WriteString(docxText(s[last:loc[0]], props))
+		inner := ""
+		if loc[2] >= 0 {
+			inner = s[loc[2]:loc[3]]
+		} else {
+			inner = s[loc[4]:loc[5]]
+		}
+		b.WriteString(docxText(inner, props+"<w:i/>"))
+		last = loc[1]
+	}
+	b.WriteString(docxText(s[last:], props))
+	return b.String()
+}
+
+// image embeds a local PNG, JPEG or GIF, scaled down to the page width.
+func (w *docxWriter) image(target string, alt string) (string, bool) {
+	if strings.Contains(target, "://") {
+		return "", false
+	}
+	if unescaped, err := url.PathUnescape(target); err == nil {
+		target = unescaped
+	}
+	path := filepath.FromSlash(target)
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(w.dir, path)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", false
+	}
+	cfg, format, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil || cfg.Width == 0 || cfg.Height == 0 {
+		return "", false
+	}
+	cx, cy := int64(cfg.Width)*docxEMUPerPixel, int64(cfg.Height)*docxEMUPerPixel
+	if cx > docxMaxImageWidth {
+		cy = cy * docxMaxImageWidth / cx
+		cx = docxMaxImageWidth
+	}
+	n := len(w.images) + 1
+	name := fmt.Sprintf("image%d.%s", n, format)
+	w.images = append(w.images, docxImage{name: name, data: data})
+	id := w.rel("image", "media/"+name, false)
+	alt = html.EscapeString(alt)
+	return fmt.Sprintf(`<w:r><w:drawing><wp:inline distT="0" distB="0" distL="0" distR="0"><wp:extent cx="%d" cy="%d"/>`+
+		`<wp:docPr id="%d" name="Picture %d" descr="%s"/><a:graphic><a:graphicData uri="http://schemas.openxmlformats.org/drawingml/2006/picture">`+
+		`<pic:pic><pic:nvPicPr><pic:cNvPr id="%d" name="%s"/><pic:cNvPicPr/></pic:nvPicPr>`+
+		`<pic:blipFill><a:blip r:embed="%s"/><a:stretch><a:fillRect/></a:stretch></pic:blipFill>`+
+		`<pic:spPr><a:xfrm><a:off x="0" y="0"/><a:ext cx="%d" cy="%d"/></a:xfrm><a:prstGeom prst="rect"><a:avLst/></a:prstGeom></pic:spPr>`+
+		`</pic:pic></a:graphicData></a:graphic></wp:inline></w:drawing></w:r>`,
+		cx, cy, n, n, alt, n, name, id, cx, cy), true
+}
+
+// convert walks the Markdown lines the way markdownToHTML does.
+func (w *docxWriter) convert(text string) {
+	var para []string
+	flushPara := func() {
+		if len(para) > 0 {
+			w.paragraph("", strings.Join(para, "<w:r><w:br/></w:r>"))
+			para = nil
+		}
+	}
+	lines := strings.Split(text, "\n")
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case strings.HasPrefix(trimmed, "```"):
+			flushPara()
+			for i++; i < len(lines) && !strings.HasPrefix(strings.TrimSpace(lines[i]), "```"); i++ {
+				w.paragraph(`<w:pStyle w:val="Code"/>`, docxText(strings.ReplaceAll(lines[i], "\t", "    "), ""))
+			}
+		case trimmed == "" || trimmed == tocStart || trimmed == tocEnd:
+			flushPara()
+		case mdHeadingRe.MatchString(line):
+			flushPara()
+			parts := mdHeadingRe.FindStringSubmatch(line)
+			props := fmt.Sprintf(`<w:pStyle w:val="Heading%d"/>`, len(parts[1]))
+			if len(parts[1]) == 1 {
+				if w.headings > 0 {
+					props += "<w:pageBreakBefore/>"
+				}
+				w.headings++
+			}
+			w.paragraph(props, w.runs(parts[2], ""))
+		case trimmed == "---" || trimmed == "***" || trimmed == "___":
+			flushPara()
+			w.paragraph(`<w:pBdr><w:bottom w:val="single" w:sz="6" w:space="1" w:color="auto"/></w:pBdr>`, "")
+		case strings.HasPrefix(trimmed, ">"):
+			flushPara()
+			w.paragraph(`<w:pStyle w:val="Quote"/>`, w.runs(strings.TrimSpace(strings.TrimPrefix(trimmed, ">")), ""))
+		case mdListItemRe.MatchString(line):
+			flushPara()
+			parts := mdListItemRe.FindStringSubmatch(line)
+			marker := "•"
+			if parts[1] != "" {
+				marker = parts[1] + "."
+			}
+			depth := (len(line) - len(strings.TrimLeft(line, " \t"))) / 2
+			indent := 360 * (depth + 1)
+			w.paragraph(fmt.Sprintf(`<w:ind w:left="%d" w:hanging="360"/>`, indent+360),
+				docxText(marker+"\t", "")+w.runs(parts[2], ""))
+		default:
+			para = append(para, w.runs(trimmed, ""))
+		}
+	}
+	flushPara()
+}
+
+// buildDocx packs the Markdown as a minimal Office Open XML document.
+func buildDocx(title string, markdown string, dir string) ([]byte, error) {
+	w := &docxWriter{dir: dir}
+	w.convert(markdown)
+
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	parts := []struct {
+		name string
+		data string
+	}{
+		{"[Content_Types].xml", docxContentTypes},
+		{"_rels/.rels", docxPackageRels},
+		{"docProps/core.xml", docxCoreProps(title, time.Now())},
+		{"word/styles.xml", docxStyles},
+		{"word/_rels/document.xml.rels", docxXMLHeader + `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+			`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/styles" Target="styles.xml"/>` +
+			strings.Join(w.rels, "") + `</Relationships>`},
+		{"word/document.xml", docxXMLHeader + docxDocumentStart + w.body.String() + docxDocumentEnd},
+	}
+	for _, p := range parts {
+		f, err := zw.Create(p.name)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := io.WriteString(f, p.data); err != nil {
+			return nil, err
+		}
+	}
+	for _, img := range w.images {
+		f, err := zw.Create("word/media/" + img.name)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := f.Write(img.data); err != nil {
+			return nil, err
+		}
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+const docxXMLHeader = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>` + "\n"
+
+const docxContentTypes = docxXMLHeader + `<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">` +
+	`<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>` +
+	`<Default Extension="xml" ContentType="application/xml"/>` +
+	`<Default Extension="png" ContentType="image/png"/>` +
+	`<Default Extension="jpeg" ContentType="image/jpeg"/>` +
+	`<Default Extension="gif" ContentType="image/gif"/>` +
+	`<Override PartName="/word/document.xml" ContentType="application/vnd.openxmlformats-officedocument.wordprocessingml.document.main+xml"/>` +
+	`<Override PartName="/word/styles.xml" ContentType="application/vnd.openxmlformats-officedocument.wordprocessingml.styles+xml"/>` +
+	`<Override PartName="/docProps/core.xml" ContentType="application/vnd.openxmlformats-package.core-properties+xml"/>` +
+	`</Types>`
+
+const docxPackageRels = docxXMLHeader + `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+	`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="word/document.xml"/>` +
+	`<Relationship Id="rId2" Type="http://schemas.openxmlformats.org/package/2006/relationships/metadata/core-properties" Target="docProps/core.xml"/>` +
+	`</Relationships>`
+
+func docxCoreProps(title string, now time.Time) string {
+	return docxXMLHeader + `<cp:coreProperties xmlns:cp="http://schemas.openxmlformats.org/package/2006/metadata/core-properties" ` +
+		`xmlns:dc="http://purl.org/dc/elements/1.1/" xmlns:dcterms="http://purl.org/dc/terms/" xmlns:xsi="http://www.w3.org/2001/XMLSchema-instance">` +
+		`<dc:title>` + html.EscapeString(title) + `</dc:title>` +
+		`<dcterms:created xsi:type="dcterms:W3CDTF">` + now.UTC().Format(time.RFC3339) + `</dcterms:created>` +
+		`</cp:coreProperties>`
+}
+
+const docxDocumentStart = `<w:document xmlns:w="http://schemas.openxmlformats.org/wordprocessingml/2006/main" ` +
+	`xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships" ` +
+	`xmlns:wp="http://schemas.openxmlformats.org/drawingml/2006/wordprocessingDrawing" ` +
+	`xmlns:a="http://schemas.openxmlformats.org/drawingml/2006/main" ` +
+	`xmlns:pic="http://schemas.openxmlformats.org/drawingml/2006/picture"><w:body>` + "\n"
+
+const docxDocumentEnd = `<w:sectPr><w:pgSz w:w="11906" w:h="16838"/>` +
+	`<w:pgMar w:top="1440" w:right="1440" w:bottom="1440" w:left="1440" w:header="708" w:footer="708" w:gutter="0"/></w:sectPr>` +
+	`</w:body></w:document>`
+
+var docxStyles = docxXMLHeader + `<w:styles xmlns:w="http://schemas.openxmlformats.org/wordprocessingml/2006/main">` +
+	`<w:docDefaults><w:rPrDefault><w:rPr><w:rFonts w:ascii="Calibri" w:hAnsi="Calibri" w:eastAsia="Calibri" w:cs="Calibri"/>` +
+	`<w:sz w:val="22"/><w:lang w:val="en-US"/></w:rPr></w:rPrDefault>` +
+	`<w:pPrDefault><w:pPr><w:spacing w:after="120" w:line="276" w:lineRule="auto"/></w:pPr></w:pPrDefault></w:docDefaults>` +
+	`<w:style w:type="paragraph" w:default="1" w:styleId="Normal"><w:name w:val="Normal"/><w:qFormat/></w:style>` +
+	docxHeadingStyle(1, 32) + docxHeadingStyle(2, 28) + docxHeadingStyle(3, 26) +
+	docxHeadingStyle(4, 24) + docxHeadingStyle(5, 22) + docxHeadingStyle(6, 22) +
+	`<w:style w:type="paragraph" w:styleId="Quote"><w:name w:val="Quote"/><w:basedOn w:val="Normal"/><w:qFormat/>` +
+	`<w:pPr><w:pBdr><w:left w:val="single" w:sz="12" w:space="8" w:color="CCCCCC"/></w:pBdr><w:ind w:left="360"/></w:pPr>` +
+	`<w:rPr><w:color w:val="555555"/></w:rPr></w:style>` +
+	`<w:style w:type="paragraph" w:styleId="Code"><w:name w:val="Code"/><w:basedOn w:val="Normal"/>` +
+	`<w:pPr><w:spacing w:after="0" w:line="240" w:lineRule="auto"/><w:shd w:val="clear" w:color="auto" w:fill="F4F4F4"/></w:pPr>` +
+	`<w:rPr><w:rFonts w:ascii="Consolas" w:hAnsi="Consolas" w:cs="Consolas"/><w:sz w:val="20"/></w:rPr></w:style>` +
+	`<w:style w:type="character" w:styleId="CodeChar"><w:name w:val="Code Char"/>` +
+	`<w:rPr><w:rFonts w:ascii="Consolas" w:hAnsi="Consolas" w:cs="Consolas"/><w:shd w:val="clear" w:color="auto" w:fill="F4F4F4"/></w:rPr></w:style>` +
+	`<w:style w:type="character" w:styleId="Hyperlink"><w:name w:val="Hyperlink"/>` +
+	`<w:rPr><w:color w:val="0563C1"/><w:u w:val="single"/></w:rPr></w:style>` +
+	`</w:styles>`
+
+func docxHeadingStyle(level int, size int) string {
+	return fmt.Sprintf(`<w:style w:type="paragraph" w:styleId="Heading%d"><w:name w:val="heading %d"/><w:basedOn w:val="Normal"/>`+
+		`<w:next w:val="Normal"/><w:qFormat/><w:pPr><w:keepNext/><w:spacing w:before="240" w:after="120"/><w:outlineLvl w:val="%d"/></w:pPr>`+
+		`<w:rPr><w:b/><w:sz w:val="%d"/></w:rPr></w:style>`, level, level, level-1, size)
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"os"
+	"time"
+
+	"github.com/charmbracelet/bubbles/textarea"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+type cursorPos struct {
+	row int
+	col int
+}
+
+// setBuffer loads content into the editor for path. The cursor of the note
+// being replaced is remembered, and the new note's cursor is restored when it
+// was open before.
+func (m Model) setBuffer(path string, content string, readOnly bool) Model {
+	if m.editing != "" {
+		m.cursors[m.editing] = editorCursor(m.textarea)
+		if m.editing != path {
+			m.prevNote = m.editing
+		}
+	}
+	m.editing = path
+	m.readOnly = readOnly
+	m.encoding = ""
+	m.lineEnd = detectLineEnding(content, m.cfg.Editor.LineEndings)
+	m.mark = nil
+	m.blame = nil
+	m.find = nil
+	m.signature = nil
+	m.textarea.SetValue(normalizeLineEndings(content))
+	m.saved = m.textarea.Value()
+	m.diskMod = time.Time{}
+	if info, err := os.Stat(path); err == nil {
+		m.diskMod = info.ModTime()
+	}
+	m.wrapTop = 0
+	if pos, ok := m.cursors[path]; ok {
+		setEditorCursor(&m.textarea, pos)
+	} else if saved, ok := savedPositionFor(m.vault, path); ok {
+		setEditorCursor(&m.textarea, cursorPos{row: saved.Row, col: saved.Col})
+		m.wrapTop = minInt(saved.Top, saved.Row)
+	} else {
+		setEditorCursor(&m.textarea, cursorPos{})
+	}
+	return m
+}
+
+// switchToPrevious flips between the current and the previously edited
+// note, asking first when the current buffer has unsaved changes.
+func (m Model) switchToPrevious() (tea.Model, tea.Cmd) {
+	if m.state != stateEditor && m.editing != "" {
+		return m.openFile(m.editing)
+	}
+	if m.prevNote == "" {
+		m.status = infoStatus("No previous note")
+		return m, nil
+	}
+	if m.dirty() {
+		m.switchTo = m.prevNote
+		m.state = stateConfirmUnsaved
+		return m, nil
+	}
+	return m.openFile(m.prevNote)
+}
+
+func editorCursor(ta textarea.Model) cursorPos {
+	return cursorPos{row: ta.Line(), col: editorColumn(ta)}
+}
+
+// setEditorCursor moves the cursor to pos, clamped to the buffer, and
+// scrolls the view to it.
+func setEditorCursor(ta *textarea.Model, pos cursorPos) {
+	for ta.Line() > pos.row {
+		ta.CursorUp()
+	}
+	for ta.Line() < pos.row && ta.Line() < ta.LineCount()-1 {
+		ta.CursorDown()
+	}
+	ta.SetCursor(pos.col)
+	if ta.Focused() {
+		*ta, _ = ta.Update(nil)
+	}
+}
+
+func editorColumn(ta textarea.Model) int {
+	li := ta.LineInfo()
+	return li.StartColumn + li.ColumnOffset
+}
+
+// autosave answers the unsaved changes prompt with "save" as soon as it is
+// shown, so leaving the editor, switching notes or quitting saves the
+// buffer (editor.autosave). A failed save leaves the editor open with the
+// error.
+func (m Model) autosave(cmd tea.Cmd) (Model, tea.Cmd) {
+	next, saveCmd := m.handleUnsavedKey(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'s'}})
+	nm, ok := next.(Model)
+	if !ok {
+		return m, cmd
+	}
+	return nm, tea.Batch(cmd, saveCmd)
+}
+
+// saveOnBlur saves the buffer when the terminal loses focus, for terminals
+// that report it.
+func (m Model) saveOnBlur() Model {
+	if !m.cfg.Editor.AutoSave || m.state != stateEditor || !m.dirty() {
+		return m
+	}
+	saved, err := m.saveBuffer()
+	if err != nil {
+		m.status = errorStatus(err)
+		return m
+	}
+	saved.status = okStatus("Saved: %s", relOrBase(saved.vault, saved.editing))
+	return saved
+}
@@ -0,0 +1,155 @@
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"unicode/utf16"
+)
+
+// Notes from older tools are not always UTF-8. Files starting with a UTF-16
+// byte order mark, and files that are not valid UTF-8 but read as
+// Windows-1252 text, are converted when they are opened. They are saved in
+// the same encoding again, or as UTF-8 when editor.save_encoding is
+// "utf-8".
+
+const (
+	encodingUTF8    = "utf-8"
+	encodingUTF16LE = "utf-16le"
+	encodingUTF16BE = "utf-16be"
+	encodingCP1252  = "windows-1252"
+
+	saveEncodingKeep = "keep"
+)
+
+var saveEncodingChoices = []string{saveEncodingKeep, encodingUTF8}
+
+// cp1252High maps the bytes 0x80-0x9F of Windows-1252; zero marks the five
+// undefined ones. The other bytes are the same as in Latin-1.
+var cp1252High = [32]rune{
+	'€', 0, '‚', 'ƒ', '„', '…', '†', '‡', 'ˆ', '‰', 'Š', '‹', 'Œ', 0, 'Ž', 0,
+	0, '‘', '’', '“', '”', '•', '–', '—', '˜', '™', 'š', '›', 'œ', 0, 'ž', 'Ÿ',
+}
+
+// decodeText converts content to UTF-8 text and reports the encoding it was
+// in. ok is false when content is neither UTF-8 nor one of the legacy
+// encodings, which usually means it is binary.
+func decodeText(content []byte) (text string, encoding string, ok bool) {
+	switch {
+	case bytes.HasPrefix(content, []byte{0xFF, 0xFE}):
+		return decodeUTF16(content[2:], binary.LittleEndian), encodingUTF16LE, len(content)%2 == 0
+	case bytes.HasPrefix(content, []byte{0xFE, 0xFF}):
+		return decodeUTF16(content[2:], binary.BigEndian), encodingUTF16BE, len(content)%2 == 0
+	case !isBinaryContent(content):
+		return string(content), encodingUTF8, true
+	}
+	if bytes.IndexByte(content, 0) >= 0 {
+		return "", "", false
+	}
+	runes := make([]rune, 0, len(content))
+	for _, b := range content {
+		r := rune(b)
+		switch {
+		case b >= 0x80 && b <= 0x9F:
+			if r = cp1252High[b-0x80]; r == 0 {
+				return "", "", false
+			}
+		case b < 0x20 && b != '\t' && b != '\n' && b != '\r' && b != '\f':
+			return "", "", false
+		}
+		runes = append(runes, r)
+	}
+	return string(runes), encodingCP1252, true
+}
+
+func decodeUTF16(content []byte, order binary.ByteOrder) string {
+	units := make([]uint16, len(content)/2)
+	for i := range units {
+		units[i] = order.Uint16(content[2*i:])
+	}
+	return string(utf16.Decode(units))
+}
+
+// encodeText converts text to encoding, with the byte order mark for
+// UTF-16. Text that Windows-1252 cannot hold is an error rather than
+// silently replaced.
+func encodeText(text string, encoding string) ([]byte, error) {
+	switch encoding {
+	case encodingUTF16LE, encodingUTF16BE:
+		var order binary.AppendByteOrder = binary.LittleEndian
+		out := []byte{0xFF, 0xFE}
+		if encoding == encodingUTF16BE {
+			order, out = binary.BigEndian, []byte{0xFE, 0xFF}
+		}
+		for _, unit := range utf16.Encode([]rune(text)) {
+			out = order.AppendUint16(out, unit)
+		}
+		return out, nil
+	case encodingCP1252:
+		out := make([]byte, 0, len(text))
+		for _, r := range text {
+			b, ok := cp1252Byte(r)
+			if !ok {
+				return nil, fmt.Errorf("%q cannot be saved as Windows-1252; set editor.save_encoding to utf-8", r)
+			}
+			out = append(out, b)
+		}
+		return out, nil
+	}
+	return []byte(text), nil
+}
+
+func cp1252Byte(r rune) (byte, bool) {
+	if r < 0x80 || (r >= 0xA0 && r <= 0xFF) {
+		return byte(r), true
+	}
+	for i, c := range cp1252High {
+		if c == r && c != 0 {
+			return byte(0x80 + i), true
+		}
+	}
+	return 0, false
+}
+
+// saveEncoding is the encoding the buffer is written in.
+func (m Model) saveEncoding() string {
+	if m.encoding == "" || m.cfg.Editor.SaveEncoding == encodingUTF8 {
+		return encodingUTF8
+	}
+	return m.encoding
+}
+
+func encodingLabel(encoding string) string {
+	switch encoding {
+	case encodingUTF16LE:
+		return "UTF-16 LE"
+	case encodingUTF16BE:
+		return "UTF-16 BE"
+	case encodingCP1252:
+		return "Windows-1252"
+	}
+	return "UTF-8"
+}
+
+func saveEncodingLabel(value string) string {
+	if value == encodingUTF8 {
+		return "UTF-8"
+	}
+	return tr("Original encoding")
+}
+
+func validSaveEncoding(value string) string {
+	if value == encodingUTF8 {
+		return value
+	}
+	return saveEncodingKeep
+}
+
+func nextSaveEncoding(current string) string {
+	for i, c := range saveEncodingChoices {
+		if c == current {
+			return saveEncodingChoices[(i+1)%len(saveEncodingChoices)]
+		}
+	}
+	return saveEncodingChoices[0]
+}
@@ -0,0 +1,179 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+	"os/exec"
+	"strings"
+	"syscall"
+
+	"github.com/atotto/clipboard"
+	"github.com/charmbracelet/bubbles/textarea"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// The status line shows one truncated line. F1 opens the last error in
+// full, together with the failed operation and a hint on what to do.
+
+type errorReport struct {
+	from   viewState
+	screen string
+	op     string
+	text   string
+	hint   string
+	status statusLine
+}
+
+func (m Model) showErrorDetail() (tea.Model, tea.Cmd) {
+	report := &errorReport{
+		from:   m.state,
+		screen: m.screenLabel(),
+		op:     errorOperation(m.status.err),
+		text:   m.status.text,
+		hint:   errorHint(m.status.err),
+		status: m.status,
+	}
+	if m.status.err != nil {
+		report.text = m.status.err.Error()
+	}
+	m.errReport = report
+	m.textarea.Blur()
+	m.input.Blur()
+	m.state = stateErrorDetail
+	m.status = statusLine{}
+	return m, nil
+}
+
+func (m Model) handleErrorDetailKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "ctrl+c":
+		if m.errReport.from == stateEditor && m.dirty() {
+			m.status = m.errReport.status
+			m.errReport = nil
+			return m.quitEditor()
+		}
+		return m, tea.Quit
+	case "c", "y":
+		if err := clipboard.WriteAll(m.errReport.String()); err != nil {
+			m.status = errorStatus(err)
+			return m, nil
+		}
+		m.status = okStatus("Error details copied to clipboard")
+		return m, nil
+	case "esc", "enter", "f1", "q":
+		m.state = m.errReport.from
+		m.status = m.errReport.status
+		m.errReport = nil
+		switch m.state {
+		case stateEditor:
+			m.textarea.Focus()
+			return m, textarea.Blink
+		case stateVaultCreate, stateVaultOpenPath, stateFileCreate, stateTitleCreate, stateExtractNote, statePipeCommand, stateGotoNote,
+			stateLinkNote, stateRandomTag, stateDirCreate, stateTemplatePrompt, stateSearch:
+			m.input.Focus()
+		}
+	}
+	return m, nil
+}
+
+func (r *errorReport) String() string {
+	lines := []string{
+		"Screen: " + r.screen,
+	}
+	if r.op != "" {
+		lines = append(lines, "Operation: "+r.op)
+	}
+	lines = append(lines, "Error: "+r.text)
+	if r.hint != "" {
+		lines = append(lines, "Suggestion: "+r.hint)
+	}
+	return strings.Join(lines, "\n")
+}
+
+func (m Model) errorDetailView(contentW int) string {
+	r := m.errReport
+	wrap := lipgloss.NewStyle().Width(contentW)
+	var parts []string
+	parts = append(parts, tr("Screen: %s", r.screen))
+	if r.op != "" {
+		parts = append(parts, tr("Operation: %s", r.op))
+	}
+	parts = append(parts, "", statusErrStyle.Render(r.text))
+	if r.hint != "" {
+		parts = append(parts, "", tr("Suggestion: %s", tr(r.hint)))
+	}
+	return wrap.Render(strings.Join(parts, "\n"))
+}
+
+func errorDetailHints(width int) string {
+	if width < 50 {
+		return tr("C: copy details\nEsc: back")
+	}
+	return tr("C: copy details | Esc/Enter: back")
+}
+
+// screenLabel names the current screen for error reports.
+func (m Model) screenLabel() string {
+	switch m.state {
+	case stateVaultSelect, stateVaultCreate, stateVaultOpenPath, stateVaultScaffold:
+		return tr("Vaults")
+	case stateEditor, stateConfirmUnsaved, stateConfirmOverwrite, stateReviewSave, stateExtractNote, statePipeCommand, stateDiagram, stateLinkNote, stateGitLog, stateNoteURLs, stateKeywords:
+		return tr("Editor: %s", relOrBase(m.vault, m.editing))
+	case stateSearch, stateSearchResults:
+		return tr("Search")
+	case stateSettings:
+		return tr("Settings")
+	case stateTemplateSelect, stateTemplatePrompt:
+		return tr("Templates")
+	default:
+		return tr("Files: %s", relOrDot(m.vault, m.current))
+	}
+}
+
+// errorOperation describes the file system or process call behind err.
+func errorOperation(err error) string {
+	var pathErr *fs.PathError
+	var linkErr *os.LinkError
+	var execErr *exec.Error
+	switch {
+	case errors.As(err, &pathErr):
+		return pathErr.Op + " " + pathErr.Path
+	case errors.As(err, &linkErr):
+		return linkErr.Op + " " + linkErr.Old + " -> " + linkErr.New
+	case errors.As(err, &execErr):
+		return "run " + execErr.Name
+	case errors.Is(err, errNotGitRepo):
+		return "git"
+	}
+	return ""
+}
+
+// errorHint suggests a fix for common failures; the text is translated when
+// shown.
+func errorHint(err error) string {
+	var syntaxErr *json.SyntaxError
+	switch {
+	case err == nil:
+		return ""
+	case errors.Is(err, errSaveConflict):
+		return "Another program changed the file. Save again to overwrite it, or reopen the note to see the other version."
+	case errors.Is(err, errNotGitRepo):
+		return "Run \"git init\" in the vault (or a parent folder) and commit the note."
+	case errors.Is(err, fs.ErrPermission):
+		return "Check the permissions of the file and its folder, or whether another program has locked it."
+	case errors.Is(err, fs.ErrNotExist):
+		return "The file or folder was moved, renamed or deleted outside GoNo. Go back to the list to refresh it."
+	case errors.Is(err, fs.ErrExist):
+		return "Choose another name, or delete the existing file first."
+	case errors.Is(err, syscall.ENOSPC):
+		return "The disk is full. Free up some space and try again."
+	case errors.Is(err, exec.ErrNotFound):
+		return "The program is not installed or not on PATH."
+	case errors.As(err, &syntaxErr):
+		return "A JSON file (config, vault list or translation) is malformed. Fix or remove it."
+	}
+	return ""
+}
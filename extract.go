@@ -0,0 +1,110 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// runeOffset converts a cursor position into an offset in the buffer's runes.
+func runeOffset(lines [][]rune, pos cursorPos) int {
+	offset := 0
+	for i := 0; i < pos.row && i < len(lines); i++ {
+		offset += len(lines[i]) + 1
+	}
+	if pos.row < len(lines) {
+		offset += minInt(pos.col, len(lines[pos.row]))
+	}
+	return offset
+}
+
+func splitRuneLines(s string) [][]rune {
+	parts := strings.Split(s, "\n")
+	lines := make([][]rune, len(parts))
+	for i, p := range parts {
+		lines[i] = []rune(p)
+	}
+	return lines
+}
+
+// selection returns the rune range between the mark and the cursor.
+func (m Model) selection() (int, int, bool) {
+	if m.mark == nil {
+		return 0, 0, false
+	}
+	lines := splitRuneLines(m.textarea.Value())
+	start := runeOffset(lines, *m.mark)
+	end := runeOffset(lines, editorCursor(m.textarea))
+	if start > end {
+		start, end = end, start
+	}
+	return start, end, start < end
+}
+
+// beginExtract asks for the title of the note the selection is moved to.
+func (m Model) beginExtract() (tea.Model, tea.Cmd) {
+	start, end, ok := m.selection()
+	if !ok {
+		m.status = infoStatus("Nothing selected: set a mark with Ctrl+Space and move the cursor")
+		return m, nil
+	}
+	selected := string([]rune(m.textarea.Value())[start:end])
+	m = m.enterPrompt(stateExtractNote, tr("Title of the new note"))
+	for _, line := range strings.Split(selected, "\n") {
+		if title := strings.TrimSpace(strings.TrimLeft(line, "# ")); title != "" {
+			m.input.SetValue(title)
+			m.input.CursorEnd()
+			break
+		}
+	}
+	return m, textinput.Blink
+}
+
+// extractNote writes the selection to a new note next to the open one and
+// replaces it with a [[link]] to that note.
+func (m Model) extractNote(title string) (tea.Model, tea.Cmd) {
+	start, end, ok := m.selection()
+	if !ok {
+		m.state = stateEditor
+		return m, nil
+	}
+	path := uniquePath(filepath.Join(filepath.Dir(m.editing), m.noteName(slugify(title))))
+	if !insideVault(m.vault, path) {
+		m.status = failStatus("path escapes vault")
+		return m, nil
+	}
+	value := []rune(m.textarea.Value())
+	selected := strings.Trim(string(value[start:end]), "\n")
+	content := "# " + title + "\n\n" + selected + "\n"
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
+	if err != nil {
+		m.status = errorStatus(err)
+		return m, nil
+	}
+	_, err = file.WriteString(m.diskText(content))
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		m.status = errorStatus(err)
+		return m, nil
+	}
+	m = m.reindex(path)
+
+	link := noteLink(m.vault, path)
+	replaced := string(value[:start]) + link + string(value[end:])
+	m.textarea.SetValue(replaced)
+	lines := splitRuneLines(string(value[:start]) + link)
+	setEditorCursor(&m.textarea, cursorPos{row: len(lines) - 1, col: len(lines[len(lines)-1])})
+
+	m.mark = nil
+	m.input.Blur()
+	m.state = stateEditor
+	m.lastList = stateFileList
+	m.status = okStatus("Extracted to %s", relOrBase(m.vault, path))
+	m = m.logChange(journalCreate, path, false)
+	return m, nil
+}
@@ -0,0 +1,143 @@
+package main
+
+import (
+	"os"
+	"sort"
+	"time"
+)
+
+// Files in the file list show their size next to the time they were
+// modified. list.sort orders them by "name" (the default, which follows
+// .order and the order: frontmatter field), by "size", largest first, or by
+// "modified", newest first; folders stay on top either way. Notes larger
+// than list.large_note_kb are marked as large, since they are often pasted
+// logs or embedded data rather than writing.
+
+const (
+	sortByName     = "name"
+	sortBySize     = "size"
+	sortByModified = "modified"
+)
+
+var (
+	fileSortChoices  = []string{sortByName, sortBySize, sortByModified}
+	largeNoteChoices = []int{0, 256, 512, 1024, 4096}
+)
+
+func validFileSort(name string) string {
+	for _, choice := range fileSortChoices {
+		if name == choice {
+			return name
+		}
+	}
+	return sortByName
+}
+
+func nextFileSort(current string) string {
+	for i, name := range fileSortChoices {
+		if name == current {
+			return fileSortChoices[(i+1)%len(fileSortChoices)]
+		}
+	}
+	return fileSortChoices[0]
+}
+
+func fileSortLabel(name string) string {
+	switch name {
+	case sortBySize:
+		return tr("Size, largest first")
+	case sortByModified:
+		return tr("Modified, newest first")
+	default:
+		return tr("Name")
+	}
+}
+
+func largeNoteLabel(kb int) string {
+	if kb <= 0 {
+		return tr("Off")
+	}
+	return formatSize(int64(kb) << 10)
+}
+
+// fileStatCache keeps the file infos of the listed directory, so paging,
+// filtering and re-sorting do not stat every file again. Entries are
+// dropped when GoNo changes the file, and the whole cache when another
+// directory is listed.
+type fileStatCache struct {
+	dir  string
+	info map[string]os.FileInfo
+	// missing marks files that could not be read.
+	missing map[string]struct{}
+}
+
+func newFileStatCache(dir string) *fileStatCache {
+	return &fileStatCache{
+		dir:     dir,
+		info:    make(map[string]os.FileInfo),
+		missing: make(map[string]struct{}),
+	}
+}
+
+// lstat returns the info of path, without following symlinks, from the
+// cache or the file system.
+func (c *fileStatCache) lstat(path string) (os.FileInfo, bool) {
+	if info, ok := c.info[path]; ok {
+		return info, true
+	}
+	if _, ok := c.missing[path]; ok {
+		return nil, false
+	}
+	info, err := os.Lstat(path)
+	if err != nil {
+		c.missing[path] = struct{}{}
+		return nil, false
+	}
+	c.info[path] = info
+	return info, true
+}
+
+// sortFiles reorders the files among entries by size or modification time.
+// Folders keep their place before the files; files that cannot be read go
+// last.
+func sortFiles(entries []item, by string, stats *fileStatCache) {
+	if by != sortBySize && by != sortByModified {
+		return
+	}
+	sizes := make(map[string]int64, len(entries))
+	times := make(map[string]time.Time, len(entries))
+	for _, e := range entries {
+		if e.isDir {
+			continue
+		}
+		if info, ok := stats.lstat(e.path); ok {
+			sizes[e.path] = info.Size()
+			times[e.path] = info.ModTime()
+		}
+	}
+	sort.SliceStable(entries, func(i, j int) bool {
+		a, b := entries[i], entries[j]
+		if a.isDir || b.isDir {
+			return a.isDir && !b.isDir
+		}
+		_, aKnown := times[a.path]
+		_, bKnown := times[b.path]
+		if aKnown != bKnown {
+			return aKnown
+		}
+		if by == sortBySize {
+			return sizes[a.path] > sizes[b.path]
+		}
+		return times[a.path].After(times[b.path])
+	})
+}
+
+// fileDetails is the description of a file in the list.
+func (m Model) fileDetails(path string, info os.FileInfo) string {
+	modified := info.ModTime().Format("02 Jan 15:04")
+	limit := int64(m.cfg.List.LargeNoteKB) << 10
+	if limit > 0 && info.Size() > limit && isIndexedNote(path) {
+		return tr("Modified: %s, %s (large note)", modified, formatSize(info.Size()))
+	}
+	return tr("Modified: %s, %s", modified, formatSize(info.Size()))
+}
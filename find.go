@@ -0,0 +1,224 @@
+package main
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/charmbracelet/bubbles/textarea"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// Ctrl+F in the editor opens a find bar under the note. Typing jumps to the
+// first match after the cursor; Enter/↓ and ↑ step through the matches,
+// PgUp/PgDn jump to the first and the last one, and the status line says
+// which match the cursor is on ("Match 3 of 17"). All matches on screen are
+// highlighted, the current one stronger. The search ignores case unless the
+// query has capitals. Esc closes the bar and leaves the cursor on the match.
+
+// findMatch is one match in the buffer: runes [start, end) of line.
+type findMatch struct {
+	line  int
+	start int
+	end   int
+}
+
+type editorFind struct {
+	origin  cursorPos
+	query   string
+	matches []findMatch
+	current int
+}
+
+func (m Model) beginFind() (tea.Model, tea.Cmd) {
+	m.find = &editorFind{origin: editorCursor(m.textarea), current: -1}
+	m.input.SetValue("")
+	m.input.Placeholder = tr("Find in note")
+	m.input.ShowSuggestions = false
+	m.input.SetSuggestions(nil)
+	m.input.Focus()
+	m.textarea.Blur()
+	m.peek = nil
+	m.status = statusLine{}
+	return m, textinput.Blink
+}
+
+func (m Model) closeFind() (tea.Model, tea.Cmd) {
+	m.find = nil
+	m.input.Blur()
+	m.status = statusLine{}
+	m.textarea.Focus()
+	return m, textarea.Blink
+}
+
+// findMatches returns the matches of query in lines, in order. Secret
+// values are searched as the bullets they are shown as.
+func findMatches(lines []string, query string) []findMatch {
+	needle := []rune(query)
+	if len(needle) == 0 {
+		return nil
+	}
+	fold := strings.ToLower(query) == query
+	if fold {
+		needle = foldRunes(needle)
+	}
+	var matches []findMatch
+	for i, line := range lines {
+		hay := maskSecrets([]rune(line))
+		if fold {
+			hay = foldRunes(hay)
+		}
+		for at := 0; at+len(needle) <= len(hay); {
+			if runesEqual(hay[at:at+len(needle)], needle) {
+				matches = append(matches, findMatch{line: i, start: at, end: at + len(needle)})
+				at += len(needle)
+				continue
+			}
+			at++
+		}
+	}
+	return matches
+}
+
+func foldRunes(runes []rune) []rune {
+	out := make([]rune, len(runes))
+	for i, r := range runes {
+		out[i] = unicode.ToLower(r)
+	}
+	return out
+}
+
+func runesEqual(a []rune, b []rune) bool {
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// handleFindKey handles the keys of the find bar; handled is false for the
+// keys the editor screen still handles.
+func (m Model) handleFindKey(msg tea.KeyMsg) (Model, tea.Cmd, bool) {
+	f := m.find
+	switch msg.String() {
+	case "ctrl+c", "ctrl+s":
+		return m, nil, false
+	case "esc":
+		next, cmd := m.closeFind()
+		return next.(Model), cmd, true
+	case "enter", "down", "ctrl+n":
+		return m.gotoMatch(f.current + 1), nil, true
+	case "up", "ctrl+p":
+		return m.gotoMatch(f.current - 1), nil, true
+	case "pgup", "ctrl+home":
+		return m.gotoMatch(0), nil, true
+	case "pgdown", "ctrl+end":
+		return m.gotoMatch(len(f.matches) - 1), nil, true
+	}
+	var cmd tea.Cmd
+	m.input, cmd = m.input.Update(msg)
+	if query := m.input.Value(); query != f.query {
+		f.query = query
+		f.matches = findMatches(strings.Split(m.textarea.Value(), "\n"), query)
+		if query == "" {
+			f.current = -1
+			setEditorCursor(&m.textarea, f.origin)
+			m.status = statusLine{}
+			return m, cmd, true
+		}
+		first := sort.Search(len(f.matches), func(i int) bool {
+			match := f.matches[i]
+			return match.line > f.origin.row || (match.line == f.origin.row && match.start >= f.origin.col)
+		})
+		m = m.gotoMatch(first % maxInt(1, len(f.matches)))
+	}
+	return m, cmd, true
+}
+
+// gotoMatch moves the cursor to match i, wrapping around at either end.
+func (m Model) gotoMatch(i int) Model {
+	f := m.find
+	n := len(f.matches)
+	if n == 0 {
+		if f.query != "" {
+			m.status = warnStatus("No matches for %q", f.query)
+		}
+		return m
+	}
+	wrapped := ""
+	switch {
+	case i >= n:
+		i, wrapped = 0, tr(" (from the top)")
+	case i < 0:
+		i, wrapped = n-1, tr(" (from the bottom)")
+	}
+	f.current = i
+	match := f.matches[i]
+	setEditorCursor(&m.textarea, cursorPos{row: match.line, col: match.start})
+	m.status = infoStatus("Match %d of %d", i+1, n)
+	m.status.text += wrapped
+	return m
+}
+
+// lineMatches returns the matches on line, with the index of the first one.
+func (f *editorFind) lineMatches(line int) ([]findMatch, int) {
+	first := sort.Search(len(f.matches), func(i int) bool { return f.matches[i].line >= line })
+	last := first
+	for last < len(f.matches) && f.matches[last].line == line {
+		last++
+	}
+	return f.matches[first:last], first
+}
+
+// findRow draws one row of the editor, runes [start, start+len(text)) of
+// line, with the cursor at cursor (-1 for none) and matches highlighted.
+func (m Model) findRow(text []rune, start int, line int, cursor int, textStyle lipgloss.Style) string {
+	const (
+		plain = iota
+		matched
+		current
+		cursorAt
+	)
+	kinds := make([]int, len(text)+1)
+	if m.find != nil {
+		matches, first := m.find.lineMatches(line)
+		for j, match := range matches {
+			kind := matched
+			if first+j == m.find.current {
+				kind = current
+			}
+			for k := maxInt(match.start, start); k < minInt(match.end, start+len(text)); k++ {
+				kinds[k-start] = kind
+			}
+		}
+	}
+	if cursor >= 0 {
+		kinds[minInt(cursor, len(text))] = cursorAt
+	}
+	styles := []lipgloss.Style{
+		textStyle,
+		lipgloss.NewStyle().Underline(true).Foreground(colorWarning).Inherit(textStyle),
+		lipgloss.NewStyle().Reverse(true).Bold(true).Foreground(colorWarning),
+		lipgloss.NewStyle().Reverse(true),
+	}
+	var b strings.Builder
+	for k := 0; k < len(text); {
+		end := k + 1
+		for end < len(text) && kinds[end] == kinds[k] {
+			end++
+		}
+		b.WriteString(styles[kinds[k]].Render(string(text[k:end])))
+		k = end
+	}
+	if kinds[len(text)] == cursorAt {
+		b.WriteString(styles[cursorAt].Render(" "))
+	}
+	return b.String()
+}
+
+func findHints() string {
+	return tr("Enter/↓: next match | ↑: previous | PgUp/PgDn: first/last match | Esc: close find")
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"bufio"
+	"io"
+	"os"
+	"strings"
+)
+
+const frontmatterHeadLimit = 4096
+
+// parseFrontmatter extracts a leading "---" delimited block of simple
+// "key: value" lines. Block lists ("- item" lines under a key) are folded
+// into the "[a, b]" inline form. Keys are lower-cased.
+func parseFrontmatter(content string) (map[string]string, bool) {
+	content = strings.TrimPrefix(content, "\ufeff")
+	if !strings.HasPrefix(content, "---\n") && !strings.HasPrefix(content, "---\r\n") {
+		return nil, false
+	}
+	fields := make(map[string]string)
+	lastKey := ""
+	var listItems []string
+	flush := func() {
+		if lastKey != "" && listItems != nil {
+			fields[lastKey] = "[" + strings.Join(listItems, ", ") + "]"
+		}
+		listItems = nil
+	}
+
+	lines := strings.Split(strings.ReplaceAll(content, "\r\n", "\n"), "\n")
+	for _, line := range lines[1:] {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "---" || trimmed == "..." {
+			flush()
+			return fields, true
+		}
+		if strings.HasPrefix(trimmed, "- ") && lastKey != "" && fields[lastKey] == "" {
+			listItems = append(listItems, unquote(strings.TrimSpace(trimmed[2:])))
+			continue
+		}
+		key, value, ok := strings.Cut(line, ":")
+		if !ok || strings.HasPrefix(line, " ") || strings.HasPrefix(line, "#") {
+			continue
+		}
+		flush()
+		lastKey = strings.ToLower(strings.TrimSpace(key))
+		fields[lastKey] = unquote(strings.TrimSpace(value))
+	}
+	return nil, false
+}
+
+// readFrontmatter parses the frontmatter of a file, reading only its head.
+func readFrontmatter(path string) map[string]string {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil
+	}
+	defer file.Close()
+	head, _ := io.ReadAll(io.LimitReader(bufio.NewReader(file), frontmatterHeadLimit))
+	fields, _ := parseFrontmatter(string(head))
+	return fields
+}
+
+// frontmatterList splits an inline "[a, b]" or comma separated value.
+func frontmatterList(value string) []string {
+	value = strings.TrimSpace(value)
+	value = strings.TrimSuffix(strings.TrimPrefix(value, "["), "]")
+	var out []string
+	for _, part := range strings.Split(value, ",") {
+		part = unquote(strings.TrimSpace(part))
+		if part != "" {
+			out = append(out, part)
+		}
+	}
+	return out
+}
+
+func unquote(s string) string {
+	if len(s) >= 2 && (s[0] == '"' || s[0] == '\'') && s[len(s)-1] == s[0] {
+		return s[1 : len(s)-1]
+	}
+	return s
+}
+
+// setFrontmatterField sets key to value in the frontmatter of content,
+// replacing the existing line or adding one at the end of the block. Content
+// without frontmatter gets a new block. Other lines are left untouched.
+func setFrontmatterField(content string, key string, value string) string {
+	newline := "\n"
+	if strings.Contains(content, "\r\n") {
+		newline = "\r\n"
+	}
+	bom := ""
+	if strings.HasPrefix(content, "\ufeff") {
+		bom, content = "\ufeff", strings.TrimPrefix(content, "\ufeff")
+	}
+	field := key + ": " + value
+	if _, ok := parseFrontmatter(content); !ok {
+		return bom + "---" + newline + field + newline + "---" + newline + content
+	}
+	lines := strings.SplitAfter(content, "\n")
+	for i := 1; i < len(lines); i++ {
+		line := strings.TrimRight(lines[i], "\r\n")
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "---" || trimmed == "..." {
+			lines = append(lines[:i], append([]string{field + newline}, lines[i:]...)...)
+			break
+		}
+		name, _, ok := strings.Cut(line, ":")
+		if ok && !strings.HasPrefix(line, " ") && strings.EqualFold(strings.TrimSpace(name), key) {
+			lines[i] = field + newline
+			// Drop a block list that belonged to the old value.
+			for i+1 < len(lines) && strings.HasPrefix(strings.TrimSpace(lines[i+1]), "- ") {
+				lines = append(lines[:i+1], lines[i+2:]...)
+			}
+			break
+		}
+	}
+	return bom + strings.Join(lines, "")
+}
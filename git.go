@@ -0,0 +1,182 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/list"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/mattn/go-runewidth"
+)
+
+const (
+	blameAuthorWidth = 10
+	blameGutterWidth = len("2006-01-02") + 1 + blameAuthorWidth + 1
+)
+
+var errNotGitRepo = errors.New("not inside a git repository")
+
+type blameMsg struct {
+	path  string
+	lines []string
+	err   error
+}
+
+type gitLogMsg struct {
+	path    string
+	entries []gitLogEntry
+	err     error
+}
+
+type gitLogEntry struct {
+	hash    string
+	date    string
+	author  string
+	subject string
+}
+
+func runGit(dir string, args ...string) ([]byte, error) {
+	cmd := exec.Command("git", append([]string{"-C", dir}, args...)...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			if strings.Contains(msg, "not a git repository") {
+				return nil, errNotGitRepo
+			}
+			return nil, errors.New(msg)
+		}
+		return nil, err
+	}
+	return out, nil
+}
+
+// gitBlame returns a "date author" label for every line of the committed
+// version of path. Uncommitted lines get an empty label.
+func gitBlame(path string) ([]string, error) {
+	out, err := runGit(filepath.Dir(path), "blame", "--line-porcelain", "--", filepath.Base(path))
+	if err != nil {
+		return nil, err
+	}
+	var labels []string
+	author, date := "", ""
+	scanner := bufio.NewScanner(bytes.NewReader(out))
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case strings.HasPrefix(line, "\t"):
+			if author == "Not Committed Yet" {
+				labels = append(labels, "")
+			} else {
+				labels = append(labels, date+" "+runewidth.Truncate(author, blameAuthorWidth, ""))
+			}
+		case strings.HasPrefix(line, "author "):
+			author = strings.TrimPrefix(line, "author ")
+		case strings.HasPrefix(line, "author-time "):
+			if sec, err := strconv.ParseInt(strings.TrimPrefix(line, "author-time "), 10, 64); err == nil {
+				date = time.Unix(sec, 0).Format("2006-01-02")
+			}
+		}
+	}
+	return labels, scanner.Err()
+}
+
+func gitLog(path string) ([]gitLogEntry, error) {
+	out, err := runGit(filepath.Dir(path), "log", "--follow", "--date=short",
+		"--format=%h%x09%ad%x09%an%x09%s", "--", filepath.Base(path))
+	if err != nil {
+		return nil, err
+	}
+	var entries []gitLogEntry
+	for _, line := range strings.Split(strings.TrimSpace(string(out)), "\n") {
+		parts := strings.SplitN(line, "\t", 4)
+		if len(parts) == 4 {
+			entries = append(entries, gitLogEntry{hash: parts[0], date: parts[1], author: parts[2], subject: parts[3]})
+		}
+	}
+	return entries, nil
+}
+
+// toggleBlame shows or hides the blame gutter for the open note.
+func (m Model) toggleBlame() (tea.Model, tea.Cmd) {
+	if m.blame != nil {
+		m.blame = nil
+		return m, nil
+	}
+	path := m.editing
+	m.status = infoStatus("Loading git blame...")
+	return m, func() tea.Msg {
+		lines, err := gitBlame(path)
+		return blameMsg{path: path, lines: lines, err: err}
+	}
+}
+
+func (m Model) applyBlame(msg blameMsg) Model {
+	if msg.path != m.editing {
+		return m
+	}
+	if msg.err != nil {
+		m.status = errorStatus(msg.err)
+		return m
+	}
+	if msg.lines == nil {
+		msg.lines = []string{}
+	}
+	m.blame = msg.lines
+	m.status = infoStatus("Git blame shown for the last saved version")
+	return m
+}
+
+func (m Model) blameLabel(line int) string {
+	label := ""
+	if line < len(m.blame) {
+		label = m.blame[line]
+	}
+	return runewidth.FillRight(label, blameGutterWidth)
+}
+
+func (m Model) showGitLog() (tea.Model, tea.Cmd) {
+	path := m.editing
+	m.status = infoStatus("Loading git log...")
+	return m, func() tea.Msg {
+		entries, err := gitLog(path)
+		return gitLogMsg{path: path, entries: entries, err: err}
+	}
+}
+
+func (m Model) applyGitLog(msg gitLogMsg) Model {
+	if msg.path != m.editing || m.state != stateEditor {
+		return m
+	}
+	if msg.err != nil {
+		m.status = errorStatus(msg.err)
+		return m
+	}
+	if len(msg.entries) == 0 {
+		m.status = infoStatus("No commits for %s", relOrBase(m.vault, m.editing))
+		return m
+	}
+	items := make([]list.Item, 0, len(msg.entries))
+	for _, e := range msg.entries {
+		items = append(items, item{
+			title: e.hash + "  " + e.subject,
+			desc:  e.date + "  " + e.author,
+			mode:  "commit",
+		})
+	}
+	m.textarea.Blur()
+	m.state = stateGitLog
+	m.status = statusLine{}
+	m.list.SetItems(items)
+	m.list.Title = tr("History of %s", relOrBase(m.vault, m.editing))
+	m.list.Select(0)
+	return m
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"path"
+	"path/filepath"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// Files and folders whose names start with a dot (.git, .gono, .obsidian,
+// .history and the like) are left out of the file list and the two-pane
+// browser; "." in the file list shows them (list.show_hidden). Internal
+// folders, GoNo's own and those of version control and other editors, are
+// protected even when shown: they and everything in them cannot be deleted
+// or moved from GoNo, and nothing is copied into them.
+//
+// A vault can also list folders of its own in protected_dirs of
+// .gono/settings.json, such as templates, assets or archive. Those can be
+// deleted, but only after typing their path, whatever the confirm settings
+// say, and the same goes for a folder that contains one.
+
+var internalDirs = map[string]bool{
+	appDirName:  true,
+	".git":      true,
+	".hg":       true,
+	".svn":      true,
+	".history":  true,
+	".obsidian": true,
+	".trash":    true,
+}
+
+func isHiddenName(name string) bool {
+	return strings.HasPrefix(name, ".")
+}
+
+// protectedDir returns the internal folder that p is, or lies in.
+func protectedDir(vault string, p string) (string, bool) {
+	rel, err := filepath.Rel(vault, p)
+	if err != nil || rel == "." || !pathWithin(vault, p) {
+		return "", false
+	}
+	for _, part := range strings.Split(filepath.ToSlash(rel), "/") {
+		if internalDirs[part] {
+			return part, true
+		}
+	}
+	return "", false
+}
+
+// vaultProtectedDirs returns the folders protected by the vault settings,
+// relative to the vault in slash form.
+func vaultProtectedDirs(vault string) []string {
+	var dirs []string
+	for _, dir := range loadVaultSettings(vault).ProtectedDirs {
+		dir = path.Clean(strings.Trim(filepath.ToSlash(strings.TrimSpace(dir)), "/"))
+		if dir != "." && dir != ".." && !strings.HasPrefix(dir, "../") {
+			dirs = append(dirs, dir)
+		}
+	}
+	return dirs
+}
+
+// guardedDir returns the protected folder of the vault settings that
+// deleting p would remove: p itself or one inside it.
+func guardedDir(vault string, p string) (string, bool) {
+	rel, err := filepath.Rel(vault, p)
+	if err != nil || !pathWithin(vault, p) {
+		return "", false
+	}
+	rel = filepath.ToSlash(rel)
+	for _, dir := range vaultProtectedDirs(vault) {
+		if rel == "." || dir == rel || strings.HasPrefix(dir, rel+"/") {
+			return dir, true
+		}
+	}
+	return "", false
+}
+
+func (m Model) toggleHidden() (tea.Model, tea.Cmd) {
+	m.cfg.List.ShowHidden = !m.cfg.List.ShowHidden
+	m = m.refreshFileList()
+	if m.cfg.List.ShowHidden {
+		m.status = infoStatus("Showing hidden files; internal folders stay protected")
+	} else {
+		m.status = infoStatus("Hidden files are not shown")
+	}
+	if err := saveConfig(m.cfg); err != nil {
+		m.status = errorStatus(err)
+	}
+	return m, nil
+}
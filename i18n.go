@@ -0,0 +1,109 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// UI strings are written in English in the source and looked up in the
+// active catalog by their English text (gettext style). A catalog maps the
+// English format string to its translation; anything missing falls back to
+// English, so partial translations are fine.
+//
+// Built-in catalogs register themselves in catalogs from an init function
+// in a messages_<lang>.go file. Users can add or override catalogs with
+// JSON files in the locales directory (see localesDir).
+
+var (
+	catalogs      = map[string]map[string]string{"en": {}}
+	activeCatalog map[string]string
+	activeLang    = "en"
+)
+
+func localesDir() string {
+	return filepath.Join(baseConfigDir(), "locales")
+}
+
+// setLanguage activates the catalog for lang ("de", "pt_BR", ...). An empty
+// lang is taken from LC_ALL, LC_MESSAGES or LANG. It returns the language
+// that ended up active.
+func setLanguage(lang string) string {
+	if strings.TrimSpace(lang) == "" {
+		lang = envLanguage()
+	}
+	lang = normalizeLanguage(lang)
+
+	for _, candidate := range []string{lang, strings.SplitN(lang, "_", 2)[0]} {
+		if catalog, ok := lookupCatalog(candidate); ok {
+			activeCatalog = catalog
+			activeLang = candidate
+			return candidate
+		}
+	}
+	activeCatalog = nil
+	activeLang = "en"
+	return activeLang
+}
+
+func lookupCatalog(lang string) (map[string]string, bool) {
+	catalog, builtin := catalogs[lang]
+	merged := make(map[string]string, len(catalog))
+	for k, v := range catalog {
+		merged[k] = v
+	}
+	data, err := os.ReadFile(filepath.Join(localesDir(), lang+".json"))
+	if err == nil {
+		var user map[string]string
+		if json.Unmarshal(data, &user) == nil {
+			for k, v := range user {
+				merged[k] = v
+			}
+			return merged, true
+		}
+	}
+	return merged, builtin
+}
+
+func envLanguage() string {
+	for _, key := range []string{"LC_ALL", "LC_MESSAGES", "LANG"} {
+		if v := strings.TrimSpace(os.Getenv(key)); v != "" {
+			return v
+		}
+	}
+	return "en"
+}
+
+// normalizeLanguage turns values like "de_DE.UTF-8" or "pt-BR" into "de_DE"
+// and "pt_BR". The POSIX "C" locale maps to English.
+func normalizeLanguage(lang string) string {
+	lang, _, _ = strings.Cut(lang, ".")
+	lang, _, _ = strings.Cut(lang, "@")
+	lang = strings.ReplaceAll(lang, "-", "_")
+	if lang == "" || lang == "C" || lang == "POSIX" {
+		return "en"
+	}
+	return lang
+}
+
+// tr translates an English format string and applies args with fmt.Sprintf.
+func tr(format string, args ...any) string {
+	if translated, ok := activeCatalog[format]; ok && translated != "" {
+		format = translated
+	}
+	if len(args) == 0 {
+		return format
+	}
+	return fmt.Sprintf(format, args...)
+}
+
+// trn picks the singular or plural English form for n and translates it.
+// Both forms should contain %d.
+func trn(singular string, plural string, n int) string {
+	if n == 1 {
+		return tr(singular, n)
+	}
+	return tr(plural, n)
+}
@@ -0,0 +1,174 @@
+package main
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/list"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// Entries in the file list start with an icon for their type: folders,
+// notes, images, encrypted notes and notes with open tasks, each in its own
+// color. list.icons picks the set: "unicode" (the default), "nerd" for
+// terminals with a Nerd Font, "ascii" for short text badges on terminals
+// without either, or "off".
+
+const (
+	iconsUnicode = "unicode"
+	iconsNerd    = "nerd"
+	iconsASCII   = "ascii"
+	iconsOff     = "off"
+	// iconTaskScan is how much of a note is searched for open tasks.
+	iconTaskScan = 64 * 1024
+)
+
+var iconChoices = []string{iconsUnicode, iconsNerd, iconsASCII, iconsOff}
+
+// listIcons is the icon set the list delegate draws.
+var listIcons = iconsUnicode
+
+var colorImage = lipgloss.AdaptiveColor{Light: "#6D28D9", Dark: "#C4B5FD"}
+
+type entryKind int
+
+const (
+	kindOther entryKind = iota
+	kindDir
+	kindNote
+	kindImage
+	kindEncrypted
+	kindTasks
+)
+
+// entryIcons holds the icons of each set in entryKind order.
+var entryIcons = map[string][]string{
+	iconsUnicode: {"·", "▸", "≡", "▣", "◈", "☐"},
+	iconsNerd:    {"\uf15b", "\uf07b", "\uf48a", "\uf1c5", "\uf023", "\uf0ae"},
+	iconsASCII:   {"   ", "DIR", "MD ", "IMG", "ENC", "TSK"},
+}
+
+var imageExts = map[string]bool{".png": true, ".jpg": true, ".jpeg": true, ".gif": true, ".webp": true, ".svg": true, ".bmp": true}
+
+func validIcons(name string) string {
+	for _, choice := range iconChoices {
+		if name == choice {
+			return name
+		}
+	}
+	return iconsUnicode
+}
+
+func nextIcons(current string) string {
+	for i, name := range iconChoices {
+		if name == current {
+			return iconChoices[(i+1)%len(iconChoices)]
+		}
+	}
+	return iconChoices[0]
+}
+
+func iconsLabel(name string) string {
+	switch name {
+	case iconsNerd:
+		return tr("Nerd Font icons")
+	case iconsASCII:
+		return tr("Text badges (ASCII)")
+	case iconsOff:
+		return tr("Off")
+	default:
+		return tr("Unicode symbols")
+	}
+}
+
+func (i item) kind() entryKind {
+	switch {
+	case i.isDir:
+		return kindDir
+	case isEncryptedNote(i.path):
+		return kindEncrypted
+	case i.tasks:
+		return kindTasks
+	case isIndexedNote(i.path):
+		return kindNote
+	case imageExts[strings.ToLower(filepath.Ext(i.path))]:
+		return kindImage
+	}
+	return kindOther
+}
+
+func kindColor(k entryKind) lipgloss.TerminalColor {
+	switch k {
+	case kindDir:
+		return colorPrimary
+	case kindImage:
+		return colorImage
+	case kindEncrypted:
+		return colorWarning
+	case kindTasks:
+		return colorSuccess
+	}
+	return colorMuted
+}
+
+// hasOpenTasks reports whether the start of the note has an unchecked task.
+func hasOpenTasks(path string) bool {
+	if !isIndexedNote(path) || isEncryptedNote(path) {
+		return false
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		return false
+	}
+	defer file.Close()
+	buf := make([]byte, iconTaskScan)
+	n, _ := io.ReadFull(file, buf)
+	for _, line := range strings.Split(string(buf[:n]), "\n") {
+		if openTaskRe.MatchString(strings.TrimRight(line, "\r")) {
+			return true
+		}
+	}
+	return false
+}
+
+// entryDelegate draws list items like the default delegate, with the icon
+// of the entry's type before the title of files and folders and the status
+// badge of notes after it.
+type entryDelegate struct {
+	list.DefaultDelegate
+}
+
+type iconItem struct {
+	item
+	title string
+}
+
+func (i iconItem) Title() string {
+	return i.title
+}
+
+func (d entryDelegate) Render(w io.Writer, m list.Model, index int, li list.Item) {
+	it, ok := li.(item)
+	icons := entryIcons[listIcons]
+	if !ok || (icons == nil && it.status == "") || it.path == "" || it.mode != "" || m.FilterState() != list.Unfiltered {
+		d.DefaultDelegate.Render(w, m, index, li)
+		return
+	}
+	// The delegate styles the whole title, and the reset after the icon
+	// would end that style early, so the name is styled here as well.
+	style := d.Styles.NormalTitle
+	if index == m.Index() {
+		style = d.Styles.SelectedTitle
+	}
+	title := lipgloss.NewStyle().Foreground(style.GetForeground()).Bold(style.GetBold()).Render(it.title)
+	if icons != nil {
+		k := it.kind()
+		title = lipgloss.NewStyle().Foreground(kindColor(k)).Render(icons[k]) + " " + title
+	}
+	if it.status != "" {
+		title += " " + statusBadge(it.status)
+	}
+	d.DefaultDelegate.Render(w, m, index, iconItem{item: it, title: title})
+}
@@ -0,0 +1,423 @@
+package main
+
+import (
+	"crypto/sha256"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// gono import (or Alt+I in the file list) brings a folder of Markdown files
+// from outside the vault into it. Every .md and .markdown file under the
+// source is copied, or symlinked with -link, into a folder named after the
+// source, keeping the tree but normalizing names the way new notes are
+// named ("Meeting Notes/Q3 Plan.md" becomes meeting-notes/q3-plan.md). A
+// file whose content is already in the vault, or earlier in the import, is
+// not copied again. Relative links and [[wiki-links]] that the new names
+// would break are rewritten, and the local files they point to are copied
+// along; links that were broken in the source, or point outside it, are
+// reported. Symlinked files are left as they are, so their links are not
+// rewritten.
+
+var importWikiRe = regexp.MustCompile(`\[\[([^\]|#]+)(#[^\]|]*)?(\|[^\]]*)?\]\]`)
+
+type importResult struct {
+	dest        string
+	notes       int
+	attachments int
+	duplicates  int
+	rewritten   int
+	broken      []string
+}
+
+type markdownImport struct {
+	src   string
+	dest  string
+	vault string
+	link  bool
+	job   *job
+
+	// notes maps each source note to the vault file it became, or to the
+	// note it duplicates; sources keeps them in walk order.
+	sources []string
+	notes   map[string]string
+	copied  map[string]bool
+	byKey   map[string]string
+	files   map[string]string
+	claimed map[string]bool
+	result  importResult
+}
+
+// importMarkdown imports the notes under src into dest, a folder of vault.
+func importMarkdown(src string, dest string, vault string, link bool, j *job) (importResult, error) {
+	src, err := filepath.Abs(src)
+	if err != nil {
+		return importResult{}, err
+	}
+	if info, err := os.Stat(src); err != nil || !info.IsDir() {
+		return importResult{}, fmt.Errorf("not a folder: %s", src)
+	}
+	if pathWithin(vault, src) || pathWithin(src, vault) {
+		return importResult{}, errors.New("the folder to import must be outside the vault")
+	}
+	imp := &markdownImport{
+		src:     src,
+		dest:    dest,
+		vault:   vault,
+		link:    link,
+		job:     j,
+		notes:   make(map[string]string),
+		copied:  make(map[string]bool),
+		byKey:   make(map[string]string),
+		files:   make(map[string]string),
+		claimed: make(map[string]bool),
+		result:  importResult{dest: dest},
+	}
+	if err := imp.plan(); err != nil {
+		return imp.result, err
+	}
+	return imp.result, imp.run()
+}
+
+func isImportedNote(path string) bool {
+	ext := strings.ToLower(filepath.Ext(path))
+	return ext == ".md" || ext == ".markdown"
+}
+
+// importName normalizes every part of rel; notes always end in .md.
+func importName(rel string, note bool) string {
+	parts := strings.Split(filepath.ToSlash(rel), "/")
+	for i, part := range parts[:len(parts)-1] {
+		parts[i] = slugify(part)
+	}
+	name := parts[len(parts)-1]
+	ext := strings.ToLower(filepath.Ext(name))
+	if note {
+		ext = ".md"
+	}
+	parts[len(parts)-1] = slugify(strings.TrimSuffix(name, filepath.Ext(name))) + ext
+	return filepath.Join(parts...)
+}
+
+// claim returns a free path for target, also avoiding the paths handed out
+// earlier in the import.
+func (imp *markdownImport) claim(target string) string {
+	ext := filepath.Ext(target)
+	stem := strings.TrimSuffix(target, ext)
+	candidate := target
+	for i := 2; ; i++ {
+		if _, err := os.Lstat(candidate); os.IsNotExist(err) && !imp.claimed[candidate] {
+			imp.claimed[candidate] = true
+			return candidate
+		}
+		candidate = fmt.Sprintf("%s-%d%s", stem, i, ext)
+	}
+}
+
+// plan decides where each source note goes. Notes with the same content as
+// a vault note, or as a note earlier in the source, are mapped to that note.
+func (imp *markdownImport) plan() error {
+	var sources []string
+	sizes := make(map[int64]bool)
+	err := walkVault(imp.src, func(p string, d fs.DirEntry) error {
+		if !isImportedNote(p) || strings.HasPrefix(d.Name(), ".") {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return nil
+		}
+		sources = append(sources, p)
+		sizes[info.Size()] = true
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if len(sources) == 0 {
+		return fmt.Errorf("no Markdown files in %s", imp.src)
+	}
+
+	// Only vault notes of the same size as a source note can be duplicates.
+	known := make(map[[32]byte]string)
+	_ = walkVault(imp.vault, func(p string, d fs.DirEntry) error {
+		if !isIndexedNote(p) || isEncryptedNote(p) {
+			return nil
+		}
+		if info, err := d.Info(); err != nil || !sizes[info.Size()] {
+			return nil
+		}
+		if content, err := os.ReadFile(p); err == nil {
+			known[sha256.Sum256(content)] = p
+		}
+		return nil
+	})
+
+	imp.sources = sources
+	for _, p := range sources {
+		content, err := os.ReadFile(p)
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(imp.src, p)
+		if err != nil {
+			return err
+		}
+		sum := sha256.Sum256(content)
+		target, dup := known[sum]
+		if dup {
+			imp.result.duplicates++
+		} else {
+			target = imp.claim(filepath.Join(imp.dest, importName(rel, true)))
+			known[sum] = target
+			imp.copied[p] = true
+		}
+		imp.notes[p] = target
+		key := strings.ToLower(filepath.ToSlash(strings.TrimSuffix(rel, filepath.Ext(rel))))
+		imp.byKey[key] = target
+		if base := filepath.Base(key); imp.byKey[base] == "" {
+			imp.byKey[base] = target
+		}
+	}
+	imp.job.total.Store(int64(len(imp.copied)))
+	return nil
+}
+
+func (imp *markdownImport) run() error {
+	for _, p := range imp.sources {
+		target := imp.notes[p]
+		if !imp.copied[p] {
+			continue
+		}
+		if imp.job.cancelled() {
+			return nil
+		}
+		if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+			return err
+		}
+		if imp.link {
+			if err := os.Symlink(p, target); err != nil {
+				return err
+			}
+		} else {
+			content, err := os.ReadFile(p)
+			if err != nil {
+				return err
+			}
+			text, err := imp.rewriteLinks(p, target, string(content))
+			if err != nil {
+				return err
+			}
+			if err := writeNewFile(target, []byte(text), 0644); err != nil {
+				return err
+			}
+		}
+		imp.result.notes++
+		imp.job.step()
+	}
+	return nil
+}
+
+// rewriteLinks points the links of the note copied from source to target
+// at the new names, copying the local files they link to.
+func (imp *markdownImport) rewriteLinks(source string, target string, text string) (string, error) {
+	var failed error
+	text = markdownLinkRe.ReplaceAllStringFunc(text, func(m string) string {
+		parts := markdownLinkRe.FindStringSubmatch(m)
+		link := parts[3]
+		if strings.Contains(link, "://") || strings.HasPrefix(link, "mailto:") || strings.HasPrefix(link, "#") || strings.HasPrefix(link, "/") {
+			return m
+		}
+		path, anchor, _ := strings.Cut(link, "#")
+		if unescaped, err := url.PathUnescape(path); err == nil {
+			path = unescaped
+		}
+		abs := filepath.Join(filepath.Dir(source), filepath.FromSlash(path))
+		moved, ok := imp.notes[abs]
+		if !ok {
+			info, err := os.Stat(abs)
+			if err != nil || info.IsDir() || !pathWithin(imp.src, abs) {
+				imp.result.broken = append(imp.result.broken, relOrBase(imp.src, source)+": "+link)
+				return m
+			}
+			if moved, ok = imp.files[abs]; !ok {
+				rel, _ := filepath.Rel(imp.src, abs)
+				moved = imp.claim(filepath.Join(imp.dest, importName(rel, false)))
+				if err := copyFile(abs, moved); err != nil {
+					failed = err
+					return m
+				}
+				imp.files[abs] = moved
+				imp.result.attachments++
+			}
+		}
+		rel, err := filepath.Rel(filepath.Dir(target), moved)
+		if err != nil {
+			return m
+		}
+		rel = filepath.ToSlash(rel)
+		if rel == filepath.ToSlash(filepath.Clean(filepath.FromSlash(path))) {
+			return m
+		}
+		if anchor != "" {
+			rel += "#" + anchor
+		}
+		imp.result.rewritten++
+		return strings.Replace(m, "("+link, "("+strings.ReplaceAll(rel, " ", "%20"), 1)
+	})
+	text = importWikiRe.ReplaceAllStringFunc(text, func(m string) string {
+		parts := importWikiRe.FindStringSubmatch(m)
+		key := strings.ToLower(strings.TrimSpace(parts[1]))
+		key = strings.TrimSuffix(strings.TrimSuffix(key, ".md"), ".markdown")
+		moved, ok := imp.byKey[key]
+		if !ok {
+			return m
+		}
+		rel, err := filepath.Rel(imp.vault, moved)
+		if err != nil {
+			return m
+		}
+		rel = filepath.ToSlash(strings.TrimSuffix(rel, filepath.Ext(rel)))
+		if key == strings.ToLower(rel) || key == strings.ToLower(filepath.Base(rel)) {
+			return m
+		}
+		imp.result.rewritten++
+		return "[[" + rel + parts[2] + parts[3] + "]]"
+	})
+	return text, failed
+}
+
+// describe summarizes the import in one line.
+func (r importResult) describe(vault string) string {
+	text := tr("Imported %s into %s", trn("%d note", "%d notes", r.notes), relOrDot(vault, r.dest))
+	if r.attachments > 0 {
+		text += tr(" with %s", trn("%d attachment", "%d attachments", r.attachments))
+	}
+	if r.duplicates > 0 {
+		text += "; " + trn("%d duplicate skipped", "%d duplicates skipped", r.duplicates)
+	}
+	if r.rewritten > 0 {
+		text += "; " + trn("%d link rewritten", "%d links rewritten", r.rewritten)
+	}
+	return text
+}
+
+func importCommand(args []string, stdout io.Writer) error {
+	flags := flag.NewFlagSet("import", flag.ContinueOnError)
+	flags.SetOutput(io.Discard)
+	link := flags.Bool("link", false, "")
+	into := flags.String("into", "", "")
+	if err := flags.Parse(args); err != nil || flags.NArg() != 2 {
+		return errors.New("usage: gono import [-link] [-into DIR] SRC VAULT")
+	}
+	vault, err := filepath.Abs(flags.Arg(1))
+	if err != nil {
+		return err
+	}
+	if info, err := os.Stat(vault); err != nil || !info.IsDir() {
+		return fmt.Errorf("vault not found: %s", vault)
+	}
+	src, err := filepath.Abs(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+	dir := *into
+	if dir == "" {
+		dir = slugify(filepath.Base(src))
+	}
+	dest := filepath.Join(vault, filepath.FromSlash(dir))
+	if !pathWithin(vault, dest) {
+		return fmt.Errorf("%s is outside the vault", dir)
+	}
+	res, err := importMarkdown(src, dest, vault, *link, newJob("import"))
+	if err != nil {
+		return err
+	}
+	fmt.Fprintln(stdout, res.describe(vault))
+	if len(res.broken) > 0 {
+		fmt.Fprintln(stdout, "Links left as they were (broken, or to files outside the folder):")
+		for _, b := range res.broken {
+			fmt.Fprintf(stdout, "  %s\n", b)
+		}
+	}
+	return nil
+}
+
+type importDoneMsg struct {
+	job    *job
+	vault  string
+	result importResult
+	err    error
+}
+
+func (m Model) beginImport() (tea.Model, tea.Cmd) {
+	m = m.enterPrompt(stateImportPath, tr("Folder of Markdown files to import"))
+	return m, textinput.Blink
+}
+
+// startImport imports the folder typed in the prompt into a new folder of
+// the current one.
+func (m Model) startImport(value string, link bool) (tea.Model, tea.Cmd) {
+	src := strings.Trim(strings.TrimSpace(value), "\"'")
+	if src == "" {
+		m.status = infoStatus("Folder path cannot be empty")
+		return m, nil
+	}
+	if m.job != nil {
+		m.status = infoStatus("Wait for %s to finish", m.job.label)
+		return m, nil
+	}
+	src, err := filepath.Abs(src)
+	if err != nil {
+		m.status = errorStatus(err)
+		return m, nil
+	}
+	m.input.Blur()
+	m.state = stateFileList
+	m.job = newJob(tr("Importing notes"))
+	vault, dest, j := m.vault, filepath.Join(m.current, slugify(filepath.Base(src))), m.job
+	return m, j.run(func() tea.Msg {
+		res, err := importMarkdown(src, dest, vault, link, j)
+		return importDoneMsg{job: j, vault: vault, result: res, err: err}
+	})
+}
+
+func (m Model) finishImport(msg importDoneMsg) (Model, tea.Cmd) {
+	m = m.finishJob(msg.job)
+	switch {
+	case msg.err != nil:
+		m.status = errorStatus(msg.err)
+	case msg.job.cancelled():
+		m.status = warnStatus("Import cancelled after %s", trn("%d note", "%d notes", msg.result.notes))
+	case len(msg.result.broken) > 0:
+		m.status = warnStatus("%s; %s", msg.result.describe(msg.vault),
+			trn("%d link left as it was", "%d links left as they were", len(msg.result.broken)))
+	default:
+		m.status = okStatus("%s", msg.result.describe(msg.vault))
+	}
+	if msg.vault != m.vault || msg.result.notes == 0 {
+		return m, nil
+	}
+	m = m.invalidateDirStats(msg.result.dest)
+	if m.state == stateFileList {
+		m = m.refreshFileList()
+	}
+	return m.startIndexing()
+}
+
+func importHints(width int) string {
+	if width < 60 {
+		return tr("Enter copy | Alt+Enter link\nEsc cancel")
+	}
+	return tr("Enter: copy in | Alt+Enter: link in (symlinks) | Esc: cancel")
+}
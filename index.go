@@ -0,0 +1,365 @@
+package main
+
+import (
+	"encoding/gob"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+const (
+	appDirName       = ".gono"
+	indexFileName    = "index.gob"
+	indexVersion     = 2
+	maxSearchResults = 50
+)
+
+// noteIndex is a per-vault inverted index persisted under .gono/. Documents
+// are keyed by their slash-separated path relative to the vault root.
+type noteIndex struct {
+	Version int
+	Docs    map[string]indexedDoc
+	Terms   map[string]map[string]int
+}
+
+type indexedDoc struct {
+	ModTime int64
+	Size    int64
+	Terms   []string
+	// Links maps the link keys of the note (see linkKeys) to the time, in
+	// Unix nanoseconds, the link was first indexed.
+	Links map[string]int64
+	// Title is the note's title and file name, Headings its heading lines,
+	// both lower-cased, so hits can be ranked without reading the note.
+	// Entries from before they were stored have an empty Title and are
+	// reindexed.
+	Title    string
+	Headings string
+}
+
+type searchHit struct {
+	rel     string
+	score   int
+	title   int // query words found in the title or file name
+	heading int // query words found in a heading
+}
+
+func appDir(vault string) string {
+	return filepath.Join(vault, appDirName)
+}
+
+// indexPath is the vault's index in the cache directory.
+func indexPath(vault string) string {
+	return filepath.Join(appCacheDir(), "index", vaultCacheKey(vault)+".gob")
+}
+
+func newNoteIndex() *noteIndex {
+	return &noteIndex{
+		Version: indexVersion,
+		Docs:    make(map[string]indexedDoc),
+		Terms:   make(map[string]map[string]int),
+	}
+}
+
+func isIndexedNote(path string) bool {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".md", ".markdown", ".txt", ".org":
+		return true
+	}
+	return false
+}
+
+// loadIndex reads the persisted index for a vault. A missing, unreadable or
+// outdated index yields an empty one that will be rebuilt by sync.
+func loadIndex(vault string) *noteIndex {
+	_ = migrateAppFile(filepath.Join(appDir(vault), indexFileName), indexPath(vault))
+	file, err := os.Open(indexPath(vault))
+	if err != nil {
+		return newNoteIndex()
+	}
+	defer file.Close()
+
+	var ix noteIndex
+	if err := gob.NewDecoder(file).Decode(&ix); err != nil || ix.Version != indexVersion {
+		return newNoteIndex()
+	}
+	if ix.Docs == nil {
+		ix.Docs = make(map[string]indexedDoc)
+	}
+	if ix.Terms == nil {
+		ix.Terms = make(map[string]map[string]int)
+	}
+	return &ix
+}
+
+func (ix *noteIndex) save(vault string) error {
+	dir := filepath.Dir(indexPath(vault))
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(dir, indexFileName+".*.tmp")
+	if err != nil {
+		return err
+	}
+	if err := gob.NewEncoder(tmp).Encode(ix); err != nil {
+		_ = tmp.Close()
+		_ = os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		_ = os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), indexPath(vault))
+}
+
+// sync walks the vault and reindexes only notes whose size or modification
+// time changed since the last run. It reports whether anything changed.
+// When j is cancelled the walk stops and notes not reached yet keep their
+// old entries.
+func (ix *noteIndex) sync(vault string, j *job) bool {
+	changed := false
+	seen := make(map[string]struct{})
+	_ = walkVault(vault, func(p string, d fs.DirEntry) error {
+		if j.cancelled() {
+			return filepath.SkipAll
+		}
+		if !isIndexedNote(p) {
+			return nil
+		}
+		j.step()
+		rel, relErr := filepath.Rel(vault, p)
+		if relErr != nil {
+			return nil
+		}
+		rel = filepath.ToSlash(rel)
+		seen[rel] = struct{}{}
+		info, infoErr := d.Info()
+		if infoErr != nil {
+			return nil
+		}
+		if doc, ok := ix.Docs[rel]; ok && doc.ModTime == info.ModTime().UnixNano() && doc.Size == info.Size() && doc.Title != "" {
+			return nil
+		}
+		if ix.indexFile(vault, p) {
+			changed = true
+		}
+		return nil
+	})
+	if j.cancelled() {
+		return changed
+	}
+	for rel := range ix.Docs {
+		if _, ok := seen[rel]; !ok {
+			ix.remove(rel)
+			changed = true
+		}
+	}
+	return changed
+}
+
+// indexFile (re)indexes a single note; notes that no longer exist are
+// dropped from the index.
+func (ix *noteIndex) indexFile(vault string, path string) bool {
+	rel, err := filepath.Rel(vault, path)
+	if err != nil {
+		return false
+	}
+	rel = filepath.ToSlash(rel)
+	info, err := os.Stat(path)
+	if err != nil {
+		if _, ok := ix.Docs[rel]; ok {
+			ix.remove(rel)
+			return true
+		}
+		return false
+	}
+	if info.IsDir() {
+		return ix.removePrefix(rel + "/")
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return false
+	}
+
+	links := make(map[string]int64)
+	for _, key := range linkKeys(vault, path, string(content)) {
+		if since, ok := ix.Docs[rel].Links[key]; ok {
+			links[key] = since
+		} else {
+			links[key] = info.ModTime().UnixNano()
+		}
+	}
+	ix.remove(rel)
+	freq := make(map[string]int)
+	for _, term := range tokenize(filepath.Base(path) + "\n" + string(content)) {
+		freq[term]++
+	}
+	terms := make([]string, 0, len(freq))
+	for term, n := range freq {
+		postings, ok := ix.Terms[term]
+		if !ok {
+			postings = make(map[string]int)
+			ix.Terms[term] = postings
+		}
+		postings[rel] = n
+		terms = append(terms, term)
+	}
+	title, _ := splitNoteTitle(path, string(content))
+	ix.Docs[rel] = indexedDoc{
+		ModTime:  info.ModTime().UnixNano(),
+		Size:     info.Size(),
+		Terms:    terms,
+		Links:    links,
+		Title:    strings.ToLower(title + " " + filepath.Base(path)),
+		Headings: strings.ToLower(strings.Join(noteHeadings(string(content)), "\n")),
+	}
+	return true
+}
+
+func (ix *noteIndex) remove(rel string) {
+	doc, ok := ix.Docs[rel]
+	if !ok {
+		return
+	}
+	for _, term := range doc.Terms {
+		postings := ix.Terms[term]
+		delete(postings, rel)
+		if len(postings) == 0 {
+			delete(ix.Terms, term)
+		}
+	}
+	delete(ix.Docs, rel)
+}
+
+func (ix *noteIndex) removePrefix(prefix string) bool {
+	removed := false
+	for rel := range ix.Docs {
+		if strings.HasPrefix(rel, prefix) {
+			ix.remove(rel)
+			removed = true
+		}
+	}
+	return removed
+}
+
+// search returns notes containing every query term, ranked by term
+// frequency with a boost for matches in the file name.
+func (ix *noteIndex) search(query string) []searchHit {
+	terms := tokenize(query)
+	return ix.topHits(ix.match(terms, true), terms)
+}
+
+// match scores the notes containing every term. With prefixLast, a last
+// term that is no word of the index matches the words it begins.
+func (ix *noteIndex) match(terms []string, prefixLast bool) map[string]int {
+	if len(terms) == 0 {
+		return nil
+	}
+
+	scores := make(map[string]int)
+	for i, term := range terms {
+		postings := ix.Terms[term]
+		if prefixLast && i == len(terms)-1 && len(postings) == 0 {
+			postings = ix.prefixPostings(term)
+		}
+		next := make(map[string]int)
+		for rel, n := range postings {
+			if i > 0 {
+				if _, ok := scores[rel]; !ok {
+					continue
+				}
+			}
+			score := scores[rel] + n
+			if strings.Contains(strings.ToLower(filepath.Base(rel)), term) {
+				score += 10
+			}
+			next[rel] = score
+		}
+		scores = next
+		if len(scores) == 0 {
+			return nil
+		}
+	}
+	return scores
+}
+
+// topHits ranks scored notes, best first, up to maxSearchResults: notes
+// with more of terms in their title or file name first, then in a heading,
+// then by score.
+func (ix *noteIndex) topHits(scores map[string]int, terms []string) []searchHit {
+	hits := make([]searchHit, 0, len(scores))
+	for rel, score := range scores {
+		hit := searchHit{rel: rel, score: score}
+		doc := ix.Docs[rel]
+		for _, term := range terms {
+			if strings.Contains(doc.Title, term) {
+				hit.title++
+			} else if strings.Contains(doc.Headings, term) {
+				hit.heading++
+			}
+		}
+		hits = append(hits, hit)
+	}
+	sort.Slice(hits, func(i, j int) bool {
+		a, b := hits[i], hits[j]
+		if a.title != b.title {
+			return a.title > b.title
+		}
+		if a.heading != b.heading {
+			return a.heading > b.heading
+		}
+		if a.score != b.score {
+			return a.score > b.score
+		}
+		return a.rel < b.rel
+	})
+	if len(hits) > maxSearchResults {
+		hits = hits[:maxSearchResults]
+	}
+	return hits
+}
+
+func (ix *noteIndex) prefixPostings(prefix string) map[string]int {
+	out := make(map[string]int)
+	for term, postings := range ix.Terms {
+		if !strings.HasPrefix(term, prefix) {
+			continue
+		}
+		for rel, n := range postings {
+			out[rel] += n
+		}
+	}
+	return out
+}
+
+func tokenize(s string) []string {
+	fields := strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	out := fields[:0]
+	for _, f := range fields {
+		if len([]rune(f)) < 2 {
+			continue
+		}
+		out = append(out, f)
+	}
+	return out
+}
+
+// matchingLine returns the first line of content containing any query term.
+func matchingLine(content string, query string) string {
+	terms := tokenize(query)
+	for _, line := range strings.Split(content, "\n") {
+		lower := strings.ToLower(line)
+		for _, term := range terms {
+			if strings.Contains(lower, term) {
+				return strings.TrimSpace(line)
+			}
+		}
+	}
+	return ""
+}
@@ -0,0 +1,301 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/charmbracelet/bubbles/list"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// Every note or folder GoNo creates, saves, moves, copies or deletes is
+// recorded with the time in .gono/journal.jsonl, one JSON object per line.
+// The journal only grows; it answers "what did I change yesterday" (Alt+J
+// in the file list shows the last journalDays days, gono journal any
+// range) and keeps a record for vaults that need one. Changes made by other
+// programs or arriving through sync are not in it.
+
+const (
+	journalFileName = "journal.jsonl"
+	journalDays     = 7
+
+	journalCreate = "create"
+	journalSave   = "save"
+	journalDelete = "delete"
+	journalMove   = "move"
+	journalCopy   = "copy"
+)
+
+type journalEntry struct {
+	Time time.Time `json:"time"`
+	Op   string    `json:"op"`
+	Path string    `json:"path"`
+	To   string    `json:"to,omitempty"`
+	Dir  bool      `json:"dir,omitempty"`
+}
+
+type journalMsg struct {
+	vault   string
+	entries []journalEntry
+	err     error
+}
+
+func journalPath(vault string) string {
+	return filepath.Join(appDir(vault), journalFileName)
+}
+
+// appendJournal records op on path (and its destination to, for moves and
+// copies), with paths relative to vault.
+func appendJournal(vault string, op string, path string, to string, isDir bool) error {
+	if vault == "" || !insideVault(vault, path) {
+		return nil
+	}
+	entry := journalEntry{Time: time.Now().Truncate(time.Second), Op: op, Path: filepath.ToSlash(relOrBase(vault, path)), Dir: isDir}
+	if to != "" {
+		entry.To = filepath.ToSlash(relOrBase(vault, to))
+	}
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(appDir(vault), 0755); err != nil {
+		return err
+	}
+	file, err := os.OpenFile(journalPath(vault), os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	_, err = file.Write(append(line, '\n'))
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// readJournal returns the entries of vault from since on, newest first.
+// Lines that do not parse are skipped.
+func readJournal(vault string, since time.Time) ([]journalEntry, error) {
+	file, err := os.Open(journalPath(vault))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	var entries []journalEntry
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var e journalEntry
+		if json.Unmarshal(scanner.Bytes(), &e) != nil || e.Time.Before(since) {
+			continue
+		}
+		entries = append(entries, e)
+	}
+	for i, j := 0, len(entries)-1; i < j; i, j = i+1, j-1 {
+		entries[i], entries[j] = entries[j], entries[i]
+	}
+	return entries, scanner.Err()
+}
+
+// logChange records op on path in the vault's journal. A failure is shown
+// but does not undo the change.
+func (m Model) logChange(op string, path string, isDir bool) Model {
+	return m.logMove(op, path, "", isDir)
+}
+
+func (m Model) logMove(op string, from string, to string, isDir bool) Model {
+	if err := appendJournal(m.vault, op, from, to, isDir); err != nil {
+		m.status = warnStatus("Journal not updated: %v", err)
+	}
+	return m
+}
+
+func journalVerb(e journalEntry) string {
+	switch e.Op {
+	case journalCreate:
+		return tr("created")
+	case journalSave:
+		return tr("saved")
+	case journalDelete:
+		return tr("deleted")
+	case journalMove:
+		if filepath.Dir(e.Path) == filepath.Dir(e.To) {
+			return tr("renamed")
+		}
+		return tr("moved")
+	case journalCopy:
+		return tr("copied")
+	}
+	return e.Op
+}
+
+// journalTarget is the path an entry is about: where a moved or copied
+// entry ended up.
+func journalTarget(e journalEntry) string {
+	if e.To != "" {
+		return e.To
+	}
+	return e.Path
+}
+
+func journalSubject(e journalEntry) string {
+	name := e.Path
+	if e.Dir {
+		name += "/"
+	}
+	if e.To != "" {
+		name += " → " + e.To
+	}
+	return name
+}
+
+// journalDay names the day of t relative to now.
+func journalDay(t time.Time, now time.Time) string {
+	day := startOfDay(t)
+	switch today := startOfDay(now); {
+	case day.Equal(today):
+		return tr("Today")
+	case day.Equal(today.AddDate(0, 0, -1)):
+		return tr("Yesterday")
+	}
+	return day.Format(dayLayout)
+}
+
+func journalCommand(args []string, stdout io.Writer) error {
+	flags := flag.NewFlagSet("journal", flag.ContinueOnError)
+	flags.SetOutput(io.Discard)
+	days := flags.Int("days", journalDays, "")
+	day := flags.String("day", "", "")
+	usage := errors.New("usage: gono journal [-days N | -day today|yesterday|YYYY-MM-DD] VAULT")
+	if err := flags.Parse(args); err != nil || flags.NArg() != 1 || *days < 1 {
+		return usage
+	}
+	vault, err := filepath.Abs(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+	if info, err := os.Stat(vault); err != nil || !info.IsDir() {
+		return fmt.Errorf("vault not found: %s", vault)
+	}
+	now := time.Now()
+	from, to := startOfDay(now).AddDate(0, 0, 1-*days), time.Time{}
+	switch *day {
+	case "":
+	case "today":
+		from = startOfDay(now)
+	case "yesterday":
+		from = startOfDay(now).AddDate(0, 0, -1)
+		to = startOfDay(now)
+	default:
+		from, err = time.ParseInLocation(dayLayout, *day, time.Local)
+		if err != nil {
+			return usage
+		}
+		to = from.AddDate(0, 0, 1)
+	}
+	entries, err := readJournal(vault, from)
+	if err != nil {
+		return err
+	}
+	shown, heading := 0, ""
+	for _, e := range entries {
+		if !to.IsZero() && !e.Time.Before(to) {
+			continue
+		}
+		if d := e.Time.Local().Format(dayLayout); d != heading {
+			heading = d
+			fmt.Fprintln(stdout, d)
+		}
+		fmt.Fprintf(stdout, "  %s  %-8s %s\n", e.Time.Local().Format("15:04"), journalVerb(e), journalSubject(e))
+		shown++
+	}
+	if shown == 0 {
+		fmt.Fprintln(stdout, "No changes recorded")
+	}
+	return nil
+}
+
+func (m Model) showJournal() (tea.Model, tea.Cmd) {
+	vault := m.vault
+	since := startOfDay(time.Now()).AddDate(0, 0, 1-journalDays)
+	return m, func() tea.Msg {
+		entries, err := readJournal(vault, since)
+		return journalMsg{vault: vault, entries: entries, err: err}
+	}
+}
+
+func (m Model) applyJournal(msg journalMsg) (tea.Model, tea.Cmd) {
+	if msg.vault != m.vault || m.state != stateFileList {
+		return m, nil
+	}
+	if msg.err != nil {
+		m.status = errorStatus(msg.err)
+		return m, nil
+	}
+	if len(msg.entries) == 0 {
+		m.status = infoStatus("No changes recorded in the last %s", trn("%d day", "%d days", journalDays))
+		return m, nil
+	}
+	now := time.Now()
+	items := make([]list.Item, 0, len(msg.entries))
+	for _, e := range msg.entries {
+		items = append(items, item{
+			title: journalSubject(e),
+			desc:  tr("%s %s, %s", journalDay(e.Time, now), e.Time.Local().Format("15:04"), journalVerb(e)),
+			path:  filepath.Join(m.vault, filepath.FromSlash(journalTarget(e))),
+			isDir: e.Dir,
+			mode:  "journal",
+		})
+	}
+	m.lastList = stateFileList
+	m.state = stateJournal
+	m.status = statusLine{}
+	m.list.SetItems(items)
+	m.list.Title = tr("Changes in the last %s", trn("%d day", "%d days", journalDays))
+	m.list.Select(0)
+	return m, nil
+}
+
+// openJournalEntry opens the note of the selected entry, or its folder.
+func (m Model) openJournalEntry() (tea.Model, tea.Cmd) {
+	selected, ok := m.list.SelectedItem().(item)
+	if !ok {
+		return m, nil
+	}
+	info, err := os.Stat(selected.path)
+	if err != nil {
+		m.status = infoStatus("%s no longer exists", relOrBase(m.vault, selected.path))
+		return m, nil
+	}
+	if info.IsDir() {
+		m.current = selected.path
+		m.state = stateFileList
+		m = m.refreshFileList()
+		return m, nil
+	}
+	m.current = filepath.Dir(selected.path)
+	m.lastList = stateFileList
+	return m.openFile(selected.path)
+}
+
+// journalTransfer records a move or copy between panes, which may be in
+// different vaults: then each vault records its side of it.
+func journalTransfer(srcVault string, dstVault string, op string, from string, to string, isDir bool) error {
+	if samePath(srcVault, dstVault) {
+		return appendJournal(srcVault, op, from, to, isDir)
+	}
+	if op == journalMove {
+		if err := appendJournal(srcVault, journalDelete, from, "", isDir); err != nil {
+			return err
+		}
+	}
+	return appendJournal(dstVault, journalCreate, to, "", isDir)
+}
@@ -0,0 +1,286 @@
+package main
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/mattn/go-runewidth"
+)
+
+// The kanban board (Alt+B in the file list) shows the notes of the current
+// folder and its subfolders that have a status field, one column per value.
+// The configured columns (kanban.columns) come first and are shown even
+// when empty; other values follow in alphabetical order. Moving a card
+// rewrites the field in the note's frontmatter.
+
+const kanbanMinColumnWidth = 16
+
+type kanbanBoard struct {
+	dir     string
+	field   string
+	columns []kanbanColumn
+	col     int
+}
+
+type kanbanColumn struct {
+	value  string
+	cards  []kanbanCard
+	cursor int
+	top    int
+}
+
+type kanbanCard struct {
+	path  string
+	title string
+}
+
+func (m Model) kanbanField() string {
+	if f := strings.ToLower(strings.TrimSpace(m.cfg.Kanban.Field)); f != "" {
+		return f
+	}
+	return "status"
+}
+
+// loadKanban collects the cards under dir, grouped by field.
+func loadKanban(vault string, dir string, field string, configured []string) *kanbanBoard {
+	board := &kanbanBoard{dir: dir, field: field}
+	index := make(map[string]int)
+	for _, value := range configured {
+		if _, ok := index[strings.ToLower(value)]; !ok {
+			index[strings.ToLower(value)] = len(board.columns)
+			board.columns = append(board.columns, kanbanColumn{value: value})
+		}
+	}
+	fixed := len(board.columns)
+	templates := filepath.Join(vault, templatesDirName)
+	_ = walkVault(dir, func(p string, d fs.DirEntry) error {
+		if !isIndexedNote(p) || pathWithin(templates, p) {
+			return nil
+		}
+		content, err := os.ReadFile(p)
+		if err != nil {
+			return nil
+		}
+		fields, _ := parseFrontmatter(string(content))
+		value := strings.TrimSpace(fields[field])
+		if value == "" {
+			return nil
+		}
+		i, ok := index[strings.ToLower(value)]
+		if !ok {
+			i = len(board.columns)
+			index[strings.ToLower(value)] = i
+			board.columns = append(board.columns, kanbanColumn{value: value})
+		}
+		title, _ := splitNoteTitle(p, string(content))
+		board.columns[i].cards = append(board.columns[i].cards, kanbanCard{path: p, title: title})
+		return nil
+	})
+	extra := board.columns[fixed:]
+	sort.Slice(extra, func(i, j int) bool { return strings.ToLower(extra[i].value) < strings.ToLower(extra[j].value) })
+	for i := range board.columns {
+		cards := board.columns[i].cards
+		sort.Slice(cards, func(a, b int) bool { return strings.ToLower(cards[a].title) < strings.ToLower(cards[b].title) })
+	}
+	return board
+}
+
+func (b *kanbanBoard) selected() (kanbanCard, bool) {
+	if b.col >= len(b.columns) {
+		return kanbanCard{}, false
+	}
+	c := b.columns[b.col]
+	if c.cursor >= len(c.cards) {
+		return kanbanCard{}, false
+	}
+	return c.cards[c.cursor], true
+}
+
+func (m Model) openKanban() (tea.Model, tea.Cmd) {
+	board := loadKanban(m.vault, m.current, m.kanbanField(), m.cfg.Kanban.Columns)
+	if len(board.columns) == 0 {
+		m.status = infoStatus("No notes with a %s: field in this folder", m.kanbanField())
+		return m, nil
+	}
+	for i, c := range board.columns {
+		if len(c.cards) > 0 {
+			board.col = i
+			break
+		}
+	}
+	m.board = board
+	m.state = stateKanban
+	m.status = statusLine{}
+	return m, nil
+}
+
+func (m Model) handleKanbanKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	b := m.board
+	col := &b.columns[b.col]
+	m.status = statusLine{}
+	switch msg.String() {
+	case "ctrl+c":
+		return m, tea.Quit
+	case "esc", "q":
+		m.board = nil
+		m.state = stateFileList
+		m = m.refreshFileList()
+		return m, nil
+	case "left", "h":
+		b.col = maxInt(0, b.col-1)
+	case "right", "l", "tab":
+		b.col = minInt(len(b.columns)-1, b.col+1)
+	case "up", "k":
+		col.cursor = maxInt(0, col.cursor-1)
+	case "down", "j":
+		col.cursor = maxInt(0, minInt(len(col.cards)-1, col.cursor+1))
+	case "home", "g":
+		col.cursor = 0
+	case "end", "G":
+		col.cursor = maxInt(0, len(col.cards)-1)
+	case "shift+left", "<", "H":
+		return m.moveCard(-1)
+	case "shift+right", ">", "L":
+		return m.moveCard(1)
+	case "r":
+		return m.reloadKanban()
+	case "enter":
+		card, ok := b.selected()
+		if !ok {
+			return m, nil
+		}
+		m.board = nil
+		m.current = filepath.Dir(card.path)
+		m.lastList = stateFileList
+		return m.openFile(card.path)
+	}
+	return m, nil
+}
+
+// moveCard moves the selected card to the neighbouring column and writes
+// the column's value into the note.
+func (m Model) moveCard(step int) (tea.Model, tea.Cmd) {
+	b := m.board
+	card, ok := b.selected()
+	target := b.col + step
+	if !ok || target < 0 || target >= len(b.columns) {
+		return m, nil
+	}
+	if m.editing == card.path && m.dirty() {
+		m.status = warnStatus("Save %s before moving it", filepath.Base(card.path))
+		return m, nil
+	}
+	content, err := os.ReadFile(card.path)
+	if err != nil {
+		m.status = errorStatus(err)
+		return m, nil
+	}
+	value := b.columns[target].value
+	updated := setFrontmatterField(string(content), b.field, value)
+	info, err := os.Stat(card.path)
+	if err != nil {
+		m.status = errorStatus(err)
+		return m, nil
+	}
+	if err := writeFileAtomic(card.path, []byte(updated), info.Mode().Perm()); err != nil {
+		m.status = errorStatus(err)
+		return m, nil
+	}
+	if m.editing == card.path {
+		m = m.setBuffer(card.path, expandTabs(updated, m.cfg.Editor.TabWidth), m.readOnly)
+	}
+	m = m.reindex(card.path)
+	m = m.logChange(journalSave, card.path, false)
+
+	from := &b.columns[b.col]
+	from.cards = append(from.cards[:from.cursor], from.cards[from.cursor+1:]...)
+	from.cursor = maxInt(0, minInt(from.cursor, len(from.cards)-1))
+	to := &b.columns[target]
+	to.cards = append(to.cards, card)
+	sort.Slice(to.cards, func(i, j int) bool { return strings.ToLower(to.cards[i].title) < strings.ToLower(to.cards[j].title) })
+	for i, c := range to.cards {
+		if c.path == card.path {
+			to.cursor = i
+		}
+	}
+	b.col = target
+	m.status = okStatus("%s: %s", card.title, value)
+	return m, nil
+}
+
+func (m Model) reloadKanban() (tea.Model, tea.Cmd) {
+	old := m.board
+	card, _ := old.selected()
+	board := loadKanban(m.vault, old.dir, old.field, m.cfg.Kanban.Columns)
+	board.col = minInt(old.col, maxInt(0, len(board.columns)-1))
+	for ci, col := range board.columns {
+		for i, c := range col.cards {
+			if c.path == card.path {
+				board.col = ci
+				board.columns[ci].cursor = i
+			}
+		}
+	}
+	m.board = board
+	m.status = infoStatus("Board reloaded")
+	return m, nil
+}
+
+// kanbanView draws as many columns as fit, scrolled to keep the selected
+// column visible.
+func (m Model) kanbanView(contentW int, height int) string {
+	b := m.board
+	if len(b.columns) == 0 {
+		return ""
+	}
+	fit := maxInt(1, (contentW+1)/(kanbanMinColumnWidth+1))
+	shown := minInt(fit, len(b.columns))
+	first := maxInt(0, minInt(b.col-shown/2, len(b.columns)-shown))
+	width := maxInt(8, (contentW-(shown-1))/shown)
+	rows := maxInt(1, height-1)
+	columns := make([]string, 0, shown*2)
+	for ci := first; ci < first+shown; ci++ {
+		c := &b.columns[ci]
+		if c.cursor < c.top {
+			c.top = c.cursor
+		}
+		if c.cursor >= c.top+rows {
+			c.top = c.cursor - rows + 1
+		}
+		header := runewidth.Truncate(c.value+" ("+strconv.Itoa(len(c.cards))+")", width, "…")
+		headerStyle := subtitleStyle
+		if ci == b.col {
+			headerStyle = titleStyle
+		}
+		lines := []string{headerStyle.Render(runewidth.FillRight(header, width))}
+		for r := c.top; r < len(c.cards) && r < c.top+rows; r++ {
+			name := runewidth.FillRight(runewidth.Truncate(" "+c.cards[r].title, width, "…"), width)
+			style := lipgloss.NewStyle()
+			if r == c.cursor {
+				style = style.Reverse(ci == b.col).Underline(ci != b.col)
+			}
+			lines = append(lines, style.Render(name))
+		}
+		for len(lines) < rows+1 {
+			lines = append(lines, strings.Repeat(" ", width))
+		}
+		if len(columns) > 0 {
+			columns = append(columns, hintStyle.Render(strings.TrimSuffix(strings.Repeat("│\n", rows+1), "\n")))
+		}
+		columns = append(columns, strings.Join(lines, "\n"))
+	}
+	return lipgloss.JoinHorizontal(lipgloss.Top, columns...)
+}
+
+func kanbanHints(width int) string {
+	if width < 72 {
+		return tr("←/→ column | ↑/↓ card | </> move card\nEnter open | R reload | Esc back")
+	}
+	return tr("←/→: column | ↑/↓: card | Shift+←/→ or </>: move card to the next column\nEnter: open note | R: reload | Esc: back")
+}
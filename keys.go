@@ -0,0 +1,527 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/atotto/clipboard"
+	"github.com/charmbracelet/bubbles/list"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// Key management for encrypted notes (see notecrypt.go): "gono keys" on
+// the command line, and the Alt+K screen in the file list, which walks
+// through creating a keyfile, adding recipients and re-encrypting notes.
+
+func keysCommand(args []string, stdout io.Writer) error {
+	usage := errors.New("usage: gono keys gen [-o FILE] | list VAULT | add VAULT KEY [NAME] | remove VAULT KEY | rotate VAULT | passphrase [-hint TEXT] VAULT | hint [-clear] VAULT [TEXT]")
+	if len(args) == 0 {
+		return usage
+	}
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+	if args[0] == "gen" {
+		flags := flag.NewFlagSet("keys gen", flag.ContinueOnError)
+		flags.SetOutput(io.Discard)
+		out := flags.String("o", keyfilePaths(cfg.Encryption)[0], "")
+		if err := flags.Parse(args[1:]); err != nil || flags.NArg() != 0 {
+			return usage
+		}
+		pub, err := generateKeyfile(expandHome(*out))
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(stdout, "Created %s\nPublic key: %s\n", *out, encodePublicKey(pub))
+		return nil
+	}
+	if args[0] == "passphrase" {
+		return passphraseCommand(args[1:], stdout, usage)
+	}
+	if args[0] == "hint" {
+		return hintCommand(args[1:], stdout, usage)
+	}
+	if len(args) < 2 {
+		return usage
+	}
+	vault, err := filepath.Abs(args[1])
+	if err != nil {
+		return err
+	}
+	if info, err := os.Stat(vault); err != nil || !info.IsDir() {
+		return fmt.Errorf("vault not found: %s", vault)
+	}
+	switch {
+	case args[0] == "list" && len(args) == 2:
+		recipients, err := loadRecipients(vault)
+		if err != nil {
+			return err
+		}
+		if len(recipients) == 0 {
+			fmt.Fprintln(stdout, "No recipients")
+		}
+		for _, r := range recipients {
+			fmt.Fprintln(stdout, strings.TrimSpace(encodePublicKey(r.key)+" "+r.name))
+		}
+		return nil
+	case args[0] == "add" && len(args) >= 3:
+		key, err := parsePublicKey(args[2])
+		if err != nil {
+			return err
+		}
+		added, err := addRecipient(vault, key, strings.Join(args[3:], " "))
+		if err != nil {
+			return err
+		}
+		if !added {
+			fmt.Fprintln(stdout, "Already a recipient")
+			return nil
+		}
+		fmt.Fprintln(stdout, "Added; run gono keys rotate to re-encrypt existing notes for it")
+		return nil
+	case args[0] == "remove" && len(args) == 3:
+		key, err := parsePublicKey(args[2])
+		if err != nil {
+			return err
+		}
+		removed, err := removeRecipient(vault, key)
+		if err != nil {
+			return err
+		}
+		if !removed {
+			return errors.New("not a recipient of this vault")
+		}
+		fmt.Fprintln(stdout, "Removed; run gono keys rotate so it can no longer open existing notes")
+		return nil
+	case args[0] == "rotate" && len(args) == 2:
+		rotated, skipped, err := rotateVault(vault, cfg.Encryption, nil)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(stdout, "Re-encrypted %s\n", pluralize(rotated, "note", "notes"))
+		for _, rel := range skipped {
+			fmt.Fprintf(stdout, "  skipped %s: no keyfile can open it\n", rel)
+		}
+		return nil
+	}
+	return usage
+}
+
+// passphraseCommand sets the passphrase that opens the vault's notes when
+// no keyfile is left.
+func passphraseCommand(args []string, stdout io.Writer, usage error) error {
+	flags := flag.NewFlagSet("keys passphrase", flag.ContinueOnError)
+	flags.SetOutput(io.Discard)
+	hint := flags.String("hint", "", "")
+	if err := flags.Parse(args); err != nil || flags.NArg() != 1 {
+		return usage
+	}
+	vault, err := filepath.Abs(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+	if info, err := os.Stat(vault); err != nil || !info.IsDir() {
+		return fmt.Errorf("vault not found: %s", vault)
+	}
+	password, err := readPassword(true)
+	if err != nil {
+		return err
+	}
+	if err := setPassphrase(vault, password, strings.TrimSpace(*hint)); err != nil {
+		return err
+	}
+	fmt.Fprintln(stdout, "Passphrase set; run gono keys rotate so it opens existing notes too")
+	return nil
+}
+
+// hintCommand prints the passphrase hint of the vault, or replaces or
+// clears it.
+func hintCommand(args []string, stdout io.Writer, usage error) error {
+	flags := flag.NewFlagSet("keys hint", flag.ContinueOnError)
+	flags.SetOutput(io.Discard)
+	clearHint := flags.Bool("clear", false, "")
+	if err := flags.Parse(args); err != nil || flags.NArg() == 0 || (*clearHint && flags.NArg() > 1) {
+		return usage
+	}
+	vault, err := filepath.Abs(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+	if info, err := os.Stat(vault); err != nil || !info.IsDir() {
+		return fmt.Errorf("vault not found: %s", vault)
+	}
+	lock, ok, err := loadPassphraseLock(vault)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return errors.New("the vault has no passphrase: set one with gono keys passphrase")
+	}
+	text := strings.TrimSpace(strings.Join(flags.Args()[1:], " "))
+	switch {
+	case *clearHint:
+		lock.Hint = ""
+	case text != "":
+		lock.Hint = text
+	case lock.Hint == "":
+		fmt.Fprintln(stdout, "No hint set")
+		return nil
+	default:
+		fmt.Fprintln(stdout, lock.Hint)
+		return nil
+	}
+	if err := savePassphraseLock(vault, lock); err != nil {
+		return err
+	}
+	if *clearHint {
+		fmt.Fprintln(stdout, "Hint removed")
+	} else {
+		fmt.Fprintln(stdout, "Hint saved")
+	}
+	return nil
+}
+
+type keysRotatedMsg struct {
+	job     *job
+	vault   string
+	rotated int
+	skipped []string
+	err     error
+}
+
+// showKeys opens the key setup screen for the current vault.
+func (m Model) showKeys() (tea.Model, tea.Cmd) {
+	m.lastList = stateFileList
+	m.state = stateKeys
+	m.status = statusLine{}
+	m = m.refreshKeysList()
+	m.list.Select(0)
+	return m, nil
+}
+
+// refreshKeysList lists the setup steps in order: this device's keyfile,
+// the vault's recipients, and re-encryption.
+func (m Model) refreshKeysList() Model {
+	var items []list.Item
+	identities, idErr := loadIdentities(m.cfg.Encryption)
+	recipients, recErr := loadRecipients(m.vault)
+	switch {
+	case idErr != nil:
+		items = append(items, item{title: tr("Keyfile cannot be read"), desc: idErr.Error(), mode: "key-none"})
+	case len(identities) == 0:
+		items = append(items, item{
+			title: tr("Create a keyfile for this device"),
+			desc:  keyfilePaths(m.cfg.Encryption)[0],
+			mode:  "key-create",
+		})
+	default:
+		pub := identities[0].PublicKey()
+		items = append(items, item{title: tr("This device (Enter: copy public key)"), desc: encodePublicKey(pub), mode: "key-copy"})
+		known := false
+		for _, r := range recipients {
+			known = known || r.key.Equal(pub)
+		}
+		if !known && recErr == nil {
+			items = append(items, item{title: tr("Add this device as a recipient"), desc: tr("Lets this device open notes encrypted in this vault"), mode: "key-self"})
+		}
+	}
+	if recErr != nil {
+		items = append(items, item{title: tr("Recipients cannot be read"), desc: recErr.Error(), mode: "key-none"})
+	}
+	for _, r := range recipients {
+		name := r.name
+		if name == "" {
+			name = tr("(unnamed)")
+		}
+		items = append(items, item{
+			title: tr("Recipient: %s (Enter: remove)", name),
+			desc:  encodePublicKey(r.key),
+			path:  encodePublicKey(r.key),
+			mode:  "key-recipient",
+		})
+	}
+	items = append(items, item{title: tr("Add a recipient..."), desc: tr("Paste a gonopub- key, optionally followed by a name"), mode: "key-add"})
+	if len(recipients) > 0 {
+		items = append(items, item{title: tr("Re-encrypt notes"), desc: tr("New file keys for the current recipients only"), mode: "key-rotate"})
+	}
+	index := m.list.Index()
+	m.list.SetItems(items)
+	m.list.Title = tr("Encryption keys")
+	m.list.Select(minInt(index, len(items)-1))
+	return m
+}
+
+func (m Model) activateKeyItem() (tea.Model, tea.Cmd) {
+	selected := m.list.SelectedItem()
+	if selected == nil {
+		return m, nil
+	}
+	it := selected.(item)
+	switch it.mode {
+	case "key-create":
+		pub, err := generateKeyfile(it.desc)
+		if err != nil {
+			m.status = errorStatus(err)
+			return m, nil
+		}
+		m.status = okStatus("Keyfile created; keep a copy of %s somewhere safe", it.desc)
+		if _, err := addRecipient(m.vault, pub, hostName()); err != nil {
+			m.status = errorStatus(err)
+		}
+	case "key-copy":
+		if err := clipboard.WriteAll(it.desc); err != nil {
+			m.status = errorStatus(err)
+			return m, nil
+		}
+		m.status = okStatus("Public key copied to clipboard")
+	case "key-self":
+		identities, err := loadIdentities(m.cfg.Encryption)
+		if err == nil && len(identities) > 0 {
+			_, err = addRecipient(m.vault, identities[0].PublicKey(), hostName())
+		}
+		if err != nil {
+			m.status = errorStatus(err)
+			return m, nil
+		}
+		m.status = okStatus("This device was added as a recipient")
+	case "key-recipient":
+		return m.beginRemoveRecipient(it.path)
+	case "key-add":
+		m = m.enterPrompt(stateKeyAdd, tr("gonopub-... [name]"))
+		return m, textinput.Blink
+	case "key-rotate":
+		if m.job != nil {
+			m.status = infoStatus("Wait for %s to finish", m.job.label)
+			return m, nil
+		}
+		m.job = newJob(tr("Re-encrypting notes"))
+		vault, cfg, j := m.vault, m.cfg.Encryption, m.job
+		return m, j.run(func() tea.Msg {
+			rotated, skipped, err := rotateVault(vault, cfg, j)
+			return keysRotatedMsg{job: j, vault: vault, rotated: rotated, skipped: skipped, err: err}
+		})
+	default:
+		return m, nil
+	}
+	return m.refreshKeysList(), nil
+}
+
+// beginRemoveRecipient asks before removing the recipient with the public
+// key encoded, refusing to remove the last one.
+func (m Model) beginRemoveRecipient(encoded string) (tea.Model, tea.Cmd) {
+	key, err := parsePublicKey(encoded)
+	if err != nil {
+		m.status = errorStatus(err)
+		return m, nil
+	}
+	recipients, err := loadRecipients(m.vault)
+	if err != nil {
+		m.status = errorStatus(err)
+		return m, nil
+	}
+	if len(recipients) <= 1 {
+		m.status = warnStatus("The last recipient cannot be removed: nobody could open new notes")
+		return m, nil
+	}
+	for _, r := range recipients {
+		if r.key.Equal(key) {
+			m.keyDrop = &recipient{key: r.key, name: r.name}
+		}
+	}
+	if m.keyDrop == nil {
+		return m.refreshKeysList(), nil
+	}
+	m.state = stateConfirmRemoveKey
+	m.status = statusLine{}
+	return m, nil
+}
+
+// removeKeyQuestion asks about the recipient in m.keyDrop, warning when it
+// is this device or the recovery passphrase.
+func (m Model) removeKeyQuestion() string {
+	r := m.keyDrop
+	name := r.name
+	if name == "" {
+		name = tr("(unnamed)")
+	}
+	if identities, err := loadIdentities(m.cfg.Encryption); err == nil {
+		for _, id := range identities {
+			if id.PublicKey().Equal(r.key) {
+				return tr("%s is this device's key. Notes encrypted from now on cannot be opened here, and re-encrypting locks this device out of the existing ones too. Remove it?", name)
+			}
+		}
+	}
+	if lock, ok, err := loadPassphraseLock(m.vault); err == nil && ok && lock.Public == encodePublicKey(r.key) {
+		return tr("%s is the recovery passphrase. Once notes are re-encrypted, it no longer opens them when every keyfile is lost. Remove it?", name)
+	}
+	return tr("Remove %s? Re-encrypt notes afterwards so it can no longer open them.", name)
+}
+
+func (m Model) handleRemoveKeyKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "ctrl+c":
+		return m, tea.Quit
+	case "y", "enter":
+		key := m.keyDrop.key
+		m.keyDrop = nil
+		m.state = stateKeys
+		if _, err := removeRecipient(m.vault, key); err != nil {
+			m.status = errorStatus(err)
+			return m.refreshKeysList(), nil
+		}
+		m.status = warnStatus("Recipient removed; re-encrypt notes so it can no longer open them")
+		return m.refreshKeysList(), nil
+	case "n", "esc":
+		m.keyDrop = nil
+		m.state = stateKeys
+		m.status = infoStatus("Recipient kept")
+	}
+	return m, nil
+}
+
+func removeKeyHints(width int) string {
+	if width < 58 {
+		return tr("Y/Enter: remove\nN/Esc: keep")
+	}
+	return tr("Y/Enter: remove the recipient | N/Esc: keep it")
+}
+
+// addKeyRecipient adds the key typed in the prompt and goes back to the
+// key list.
+func (m Model) addKeyRecipient(value string) (tea.Model, tea.Cmd) {
+	keyText, name, _ := strings.Cut(strings.TrimSpace(value), " ")
+	key, err := parsePublicKey(keyText)
+	if err != nil {
+		m.status = errorStatus(err)
+		return m, nil
+	}
+	added, err := addRecipient(m.vault, key, strings.TrimSpace(name))
+	if err != nil {
+		m.status = errorStatus(err)
+		return m, nil
+	}
+	m = m.leaveKeyPrompt()
+	if !added {
+		m.status = infoStatus("Already a recipient")
+		return m, nil
+	}
+	m.status = okStatus("Recipient added; re-encrypt notes to let it open existing ones")
+	return m.refreshKeysList(), nil
+}
+
+func (m Model) leaveKeyPrompt() Model {
+	m.input.Blur()
+	m.state = stateKeys
+	m.lastList = stateFileList
+	return m
+}
+
+func (m Model) finishRotate(msg keysRotatedMsg) Model {
+	m = m.finishJob(msg.job)
+	switch {
+	case msg.err != nil:
+		m.status = errorStatus(msg.err)
+	case msg.job.cancelled():
+		m.status = warnStatus("Re-encryption cancelled after %s", trn("%d note", "%d notes", msg.rotated))
+	case len(msg.skipped) > 0:
+		m.status = warnStatus("Re-encrypted %s; no keyfile opens %s", trn("%d note", "%d notes", msg.rotated), strings.Join(msg.skipped, ", "))
+	default:
+		m.status = okStatus("Re-encrypted %s", trn("%d note", "%d notes", msg.rotated))
+	}
+	if m.state == stateKeys && m.vault == msg.vault {
+		m = m.refreshKeysList()
+	}
+	return m
+}
+
+// toggleEncryption encrypts the selected note to the vault's recipients,
+// or turns an encrypted note back into plain text. The old file is removed.
+func (m Model) toggleEncryption() (tea.Model, tea.Cmd) {
+	selected := m.list.SelectedItem()
+	if selected == nil {
+		return m, nil
+	}
+	it := selected.(item)
+	if it.isDir || it.path == "" {
+		return m, nil
+	}
+	if m.editing == it.path && m.dirty() {
+		m.status = warnStatus("Save %s before changing its encryption", filepath.Base(it.path))
+		return m, nil
+	}
+	var target string
+	var err error
+	if isEncryptedNote(it.path) {
+		target = strings.TrimSuffix(it.path, filepath.Ext(it.path))
+		var plain []byte
+		if plain, err = readEncryptedNote(it.path, m.cfg.Encryption); err == nil {
+			err = writeNewFile(target, plain, 0644)
+		}
+	} else if isIndexedNote(it.path) {
+		target = it.path + encryptedExt
+		var plain []byte
+		if plain, err = os.ReadFile(it.path); err == nil {
+			err = m.writeNewEncrypted(target, plain)
+		}
+	} else {
+		m.status = infoStatus("Only notes can be encrypted")
+		return m, nil
+	}
+	if err == nil {
+		err = os.Remove(it.path)
+	}
+	if err != nil {
+		m.status = errorStatus(err)
+		return m, nil
+	}
+	if m.editing == it.path {
+		m.editing = target
+		if info, err := os.Stat(target); err == nil {
+			m.diskMod = info.ModTime()
+		}
+	}
+	m = m.reindex(it.path)
+	m = m.reindex(target)
+	if isEncryptedNote(target) {
+		m.status = okStatus("Encrypted %s", filepath.Base(target))
+	} else {
+		m.status = okStatus("Decrypted %s", filepath.Base(target))
+	}
+	m = m.refreshFileList()
+	return m, nil
+}
+
+func (m Model) writeNewEncrypted(path string, plain []byte) error {
+	if _, err := os.Lstat(path); err == nil {
+		return fmt.Errorf("%s already exists", filepath.Base(path))
+	}
+	return writeEncryptedNote(m.vault, path, plain)
+}
+
+// writeNewFile writes data to path, failing if something is already there.
+func writeNewFile(path string, data []byte, perm os.FileMode) error {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, perm)
+	if err != nil {
+		return err
+	}
+	_, err = file.Write(data)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+func hostName() string {
+	name, err := os.Hostname()
+	if err != nil {
+		return ""
+	}
+	return name
+}
@@ -0,0 +1,391 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/charmbracelet/bubbles/list"
+	"github.com/charmbracelet/bubbles/textarea"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// Keyword extraction for retro-tagging old notes. The terms of a note are
+// ranked by how often they occur in it and how few other notes of the vault
+// use them (tf-idf against the search index); the best ones that are not
+// tags of the note yet are suggested as tags, tags already used elsewhere in
+// the vault first. Alt+T in the editor shows them and adds a picked tag to
+// the frontmatter; "gono keywords" prints them for a note, or the vault's
+// most frequent terms and suggestions for every untagged note.
+
+const (
+	keywordCount     = 15
+	keywordTagCount  = 5
+	keywordMinLength = 3
+	stopwordsName    = "stopwords"
+)
+
+// keywordStopwords are English words too common to say anything about a
+// note. .gono/stopwords adds more, one per line.
+var keywordStopwords = toSet(strings.Fields(`
+	about above after again against all also although always among and another any are
+	around because been before being below between both but can cannot could did does
+	doing done down during each either else enough even ever every few for from further
+	get gets getting got had has have having her here hers herself him himself his how
+	however into its itself just least less let like made make makes many may maybe
+	might more most much must myself need needs never new next nor not now off often
+	once one only onto other others our ours ourselves out over own per perhaps quite
+	rather really same see seem seems she should since some something still such than
+	that the their theirs them themselves then there these they thing things this those
+	though through thus too two under until upon use used uses using very via want was
+	way well were what whatever when where whether which while who whom whose why will
+	with within without would yes yet you your yours yourself yourselves
+	http https www com org md png jpg todo done tags tag title date`))
+
+func toSet(words []string) map[string]bool {
+	set := make(map[string]bool, len(words))
+	for _, w := range words {
+		set[w] = true
+	}
+	return set
+}
+
+// vaultStopwords returns the built-in stopwords plus the vault's own.
+func vaultStopwords(vault string) map[string]bool {
+	data, err := os.ReadFile(filepath.Join(appDir(vault), stopwordsName))
+	if err != nil {
+		return keywordStopwords
+	}
+	set := make(map[string]bool, len(keywordStopwords))
+	for w := range keywordStopwords {
+		set[w] = true
+	}
+	for _, w := range tokenize(string(data)) {
+		set[w] = true
+	}
+	return set
+}
+
+// meaningfulTerm filters out stopwords, numbers and very short words.
+func meaningfulTerm(term string, stop map[string]bool) bool {
+	if stop[term] || len([]rune(term)) < keywordMinLength {
+		return false
+	}
+	return strings.IndexFunc(term, unicode.IsLetter) >= 0
+}
+
+type keyword struct {
+	term  string
+	count int // occurrences in the note, or in the vault
+	notes int // notes of the vault using the term
+	score float64
+	used  bool // already a tag somewhere in the vault
+}
+
+// keywordText is the part of a note that says what it is about: its title
+// and body, without the rest of the frontmatter, code blocks, link targets
+// and URLs.
+func keywordText(content string) string {
+	title, body := splitNoteTitle("", content)
+	var b strings.Builder
+	b.WriteString(title)
+	b.WriteByte('\n')
+	fence := false
+	for _, line := range strings.Split(body, "\n") {
+		if strings.HasPrefix(strings.TrimSpace(line), "```") {
+			fence = !fence
+			continue
+		}
+		if fence {
+			continue
+		}
+		line = markdownLinkRe.ReplaceAllString(line, "$2")
+		line = urlRe.ReplaceAllString(line, "")
+		b.WriteString(line)
+		b.WriteByte('\n')
+	}
+	return b.String()
+}
+
+// noteKeywords ranks the terms of content by tf-idf, using ix for the
+// number of notes containing each term.
+func noteKeywords(content string, ix *noteIndex, stop map[string]bool) []keyword {
+	freq := make(map[string]int)
+	for _, term := range tokenize(keywordText(content)) {
+		if meaningfulTerm(term, stop) {
+			freq[term]++
+		}
+	}
+	docs := 1
+	if ix != nil {
+		docs = max(1, len(ix.Docs))
+	}
+	out := make([]keyword, 0, len(freq))
+	for term, n := range freq {
+		notes := 0
+		if ix != nil {
+			notes = len(ix.Terms[term])
+		}
+		idf := math.Log(float64(docs+1)/float64(notes+1)) + 1
+		out = append(out, keyword{term: term, count: n, notes: notes, score: float64(n) * idf})
+	}
+	sortKeywords(out)
+	return out
+}
+
+// vaultKeywords returns the terms used most across the vault.
+func vaultKeywords(ix *noteIndex, stop map[string]bool) []keyword {
+	out := make([]keyword, 0, len(ix.Terms))
+	for term, postings := range ix.Terms {
+		if !meaningfulTerm(term, stop) {
+			continue
+		}
+		k := keyword{term: term, notes: len(postings)}
+		for _, n := range postings {
+			k.count += n
+		}
+		k.score = float64(k.count)
+		out = append(out, k)
+	}
+	sortKeywords(out)
+	return out
+}
+
+func sortKeywords(out []keyword) {
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].score != out[j].score {
+			return out[i].score > out[j].score
+		}
+		return out[i].term < out[j].term
+	})
+}
+
+// suggestTags picks up to n keywords that are not among the note's tags
+// yet: terms used twice or more, tags of other notes first.
+func suggestTags(keywords []keyword, tags map[string]struct{}, vaultTags map[string]bool, n int) []keyword {
+	var existing, fresh []keyword
+	for _, k := range keywords {
+		if _, ok := tags[k.term]; ok || k.count < 2 {
+			continue
+		}
+		k.used = vaultTags[k.term]
+		if k.used {
+			existing = append(existing, k)
+		} else {
+			fresh = append(fresh, k)
+		}
+	}
+	out := append(existing, fresh...)
+	return out[:min(n, len(out))]
+}
+
+// collectVaultTags returns every tag used in the vault and the tags of each
+// note, keyed by its slash-separated path.
+func collectVaultTags(vault string) (map[string]bool, map[string]map[string]struct{}) {
+	all := make(map[string]bool)
+	byNote := make(map[string]map[string]struct{})
+	_ = walkVault(vault, func(p string, d fs.DirEntry) error {
+		if !isIndexedNote(p) {
+			return nil
+		}
+		content, err := os.ReadFile(p)
+		if err != nil {
+			return nil
+		}
+		tags := noteTags(string(content))
+		byNote[filepath.ToSlash(relOrBase(vault, p))] = tags
+		for tag := range tags {
+			all[tag] = true
+		}
+		return nil
+	})
+	return all, byNote
+}
+
+func keywordsCommand(args []string, stdout io.Writer) error {
+	flags := flag.NewFlagSet("keywords", flag.ContinueOnError)
+	flags.SetOutput(io.Discard)
+	count := flags.Int("n", keywordCount, "")
+	usage := errors.New("usage: gono keywords [-n N] VAULT [NOTE]")
+	if err := flags.Parse(args); err != nil || flags.NArg() < 1 || flags.NArg() > 2 || *count < 1 {
+		return usage
+	}
+	vault, err := filepath.Abs(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+	if info, err := os.Stat(vault); err != nil || !info.IsDir() {
+		return fmt.Errorf("vault not found: %s", vault)
+	}
+	ix := loadIndex(vault)
+	if ix.sync(vault, newJob("")) {
+		_ = ix.save(vault)
+	}
+	stop := vaultStopwords(vault)
+	vaultTags, noteTagSets := collectVaultTags(vault)
+
+	if flags.NArg() == 2 {
+		note, err := filepath.Abs(flags.Arg(1))
+		if err != nil {
+			return err
+		}
+		content, err := os.ReadFile(note)
+		if err != nil {
+			return err
+		}
+		keywords := noteKeywords(string(content), ix, stop)
+		fmt.Fprintf(stdout, "Keywords of %s:\n", relOrBase(vault, note))
+		printKeywords(stdout, keywords[:min(*count, len(keywords))])
+		tags := suggestTags(keywords, noteTags(string(content)), vaultTags, keywordTagCount)
+		if len(tags) > 0 {
+			fmt.Fprintf(stdout, "Suggested tags: %s\n", tagList(tags))
+		}
+		return nil
+	}
+
+	keywords := vaultKeywords(ix, stop)
+	fmt.Fprintf(stdout, "Most frequent terms in %s:\n", pluralize(len(ix.Docs), "note", "notes"))
+	printKeywords(stdout, keywords[:min(*count, len(keywords))])
+	rels := make([]string, 0, len(noteTagSets))
+	for rel, tags := range noteTagSets {
+		if len(tags) == 0 {
+			rels = append(rels, rel)
+		}
+	}
+	sort.Strings(rels)
+	printed := false
+	for _, rel := range rels {
+		content, err := os.ReadFile(filepath.Join(vault, filepath.FromSlash(rel)))
+		if err != nil {
+			continue
+		}
+		tags := suggestTags(noteKeywords(string(content), ix, stop), nil, vaultTags, keywordTagCount)
+		if len(tags) == 0 {
+			continue
+		}
+		if !printed {
+			fmt.Fprintln(stdout, "\nUntagged notes:")
+			printed = true
+		}
+		fmt.Fprintf(stdout, "  %s: %s\n", rel, tagList(tags))
+	}
+	return nil
+}
+
+func printKeywords(w io.Writer, keywords []keyword) {
+	width := 0
+	for _, k := range keywords {
+		width = max(width, len([]rune(k.term)))
+	}
+	for _, k := range keywords {
+		fmt.Fprintf(w, "  %-*s %5d  in %s\n", width, k.term, k.count, pluralize(k.notes, "note", "notes"))
+	}
+}
+
+// tagList writes suggestions as #tags, marking the ones already in use.
+func tagList(tags []keyword) string {
+	parts := make([]string, 0, len(tags))
+	used := false
+	for _, k := range tags {
+		if k.used {
+			parts = append(parts, "#"+k.term+"*")
+			used = true
+		} else {
+			parts = append(parts, "#"+k.term)
+		}
+	}
+	if used {
+		parts = append(parts, "  (* already a tag in the vault)")
+	}
+	return strings.Join(parts, " ")
+}
+
+type keywordsDoneMsg struct {
+	job      *job
+	path     string
+	keywords []keyword
+	tags     []keyword
+}
+
+// showKeywords ranks the terms of the open note against the index, then
+// reads the vault's tags in the background to order the suggestions.
+func (m Model) showKeywords() (tea.Model, tea.Cmd) {
+	if m.job != nil {
+		m.status = infoStatus("Wait for %s to finish", m.job.label)
+		return m, nil
+	}
+	content := m.textarea.Value()
+	keywords := noteKeywords(content, m.index, vaultStopwords(m.vault))
+	m.job = newJob(tr("Finding keywords"))
+	vault, path, j := m.vault, m.editing, m.job
+	return m, j.run(func() tea.Msg {
+		vaultTags, _ := collectVaultTags(vault)
+		tags := suggestTags(keywords, noteTags(content), vaultTags, keywordTagCount)
+		return keywordsDoneMsg{job: j, path: path, keywords: keywords[:min(keywordCount, len(keywords))], tags: tags}
+	})
+}
+
+func (m Model) finishKeywords(msg keywordsDoneMsg) (tea.Model, tea.Cmd) {
+	m = m.finishJob(msg.job)
+	if msg.job.cancelled() || m.state != stateEditor || m.editing != msg.path {
+		return m, nil
+	}
+	if len(msg.keywords) == 0 {
+		m.status = infoStatus("No keywords found in this note")
+		return m, nil
+	}
+	items := make([]list.Item, 0, len(msg.tags)+len(msg.keywords))
+	for _, k := range msg.tags {
+		desc := tr("%s in this note, used in %s", trn("%d time", "%d times", k.count), trn("%d note", "%d notes", k.notes))
+		if k.used {
+			desc += " · " + tr("already a tag in the vault")
+		}
+		items = append(items, item{title: tr("Add tag #%s", k.term), desc: desc, path: k.term, mode: "keyword-tag"})
+	}
+	for _, k := range msg.keywords {
+		items = append(items, item{
+			title: k.term,
+			desc:  tr("%s in this note, used in %s", trn("%d time", "%d times", k.count), trn("%d note", "%d notes", k.notes)),
+			mode:  "keyword",
+		})
+	}
+	m.textarea.Blur()
+	m.state = stateKeywords
+	m.status = statusLine{}
+	m.list.SetItems(items)
+	m.list.Title = tr("Keywords of %s", relOrBase(m.vault, m.editing))
+	m.list.Select(0)
+	return m, nil
+}
+
+// addKeywordTag adds the selected suggestion to the note's frontmatter tags
+// and goes back to the note; saving is left to the user.
+func (m Model) addKeywordTag() (tea.Model, tea.Cmd) {
+	m.state = stateEditor
+	m.textarea.Focus()
+	selected, ok := m.list.SelectedItem().(item)
+	if !ok || selected.mode != "keyword-tag" || m.readOnly {
+		return m, textarea.Blink
+	}
+	value := m.textarea.Value()
+	var tags []string
+	if fields, ok := parseFrontmatter(value); ok {
+		tags = frontmatterList(fields["tags"])
+	}
+	tags = append(tags, selected.path)
+	pos := editorCursor(m.textarea)
+	updated := setFrontmatterField(value, "tags", "["+strings.Join(tags, ", ")+"]")
+	m.textarea.SetValue(updated)
+	pos.row += strings.Count(updated, "\n") - strings.Count(value, "\n")
+	setEditorCursor(&m.textarea, pos)
+	m.status = okStatus("Tagged #%s; save to keep it", selected.path)
+	return m, textarea.Blink
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// A note's "status:" frontmatter field tracks where it is in its life:
+// draft, active, done, archived by default (status_values). The file list
+// shows it as a colored badge after the name, Alt+C moves the selected note
+// to the next status, and the quick filter matches "status:done".
+
+const statusField = "status"
+
+var defaultStatusValues = []string{"draft", "active", "done", "archived"}
+
+// normalizedStatusValues lowercases values and drops empty and repeated
+// ones; no values at all means the defaults.
+func normalizedStatusValues(values []string) []string {
+	out := make([]string, 0, len(values))
+	seen := make(map[string]bool)
+	for _, v := range values {
+		v = strings.ToLower(strings.TrimSpace(v))
+		if v == "" || seen[v] {
+			continue
+		}
+		seen[v] = true
+		out = append(out, v)
+	}
+	if len(out) == 0 {
+		return append([]string(nil), defaultStatusValues...)
+	}
+	return out
+}
+
+// noteStatus returns the lowercased status of the note at path.
+func noteStatus(path string) string {
+	if !isIndexedNote(path) || isEncryptedNote(path) {
+		return ""
+	}
+	return strings.ToLower(strings.TrimSpace(unquote(readFrontmatter(path)[statusField])))
+}
+
+// nextStatus returns the status after current in values; an unknown or
+// missing status starts at the first, the last wraps around.
+func nextStatus(values []string, current string) string {
+	for i, v := range values {
+		if v == current {
+			return values[(i+1)%len(values)]
+		}
+	}
+	return values[0]
+}
+
+func statusColor(status string) lipgloss.TerminalColor {
+	switch status {
+	case "draft":
+		return colorWarning
+	case "active":
+		return colorPrimary
+	case "done":
+		return colorSuccess
+	case "archived":
+		return colorMuted
+	}
+	return colorImage
+}
+
+func statusBadge(status string) string {
+	return lipgloss.NewStyle().Foreground(statusColor(status)).Render("[" + status + "]")
+}
+
+// cycleStatus sets the selected note to its next status.
+func (m Model) cycleStatus() (tea.Model, tea.Cmd) {
+	selected, ok := m.list.SelectedItem().(item)
+	if !ok || selected.mode != "" || selected.isDir || !isIndexedNote(selected.path) {
+		m.status = infoStatus("Select a note to change its status")
+		return m, nil
+	}
+	next := ""
+	m, _, err := m.rewriteNote(selected.path, func(text string) (string, error) {
+		fields, _ := parseFrontmatter(text)
+		next = nextStatus(m.cfg.StatusValues, strings.ToLower(strings.TrimSpace(unquote(fields[statusField]))))
+		return setFrontmatterField(text, statusField, next), nil
+	})
+	if err != nil {
+		m.status = errorStatus(err)
+		return m, nil
+	}
+	index := m.list.Index()
+	selected.status = next
+	m.list.SetItem(index, selected)
+	if m.quick != nil {
+		m.quick.tags = directoryTags(m.current)
+	}
+	m.status = okStatus("%s is now %s", relOrBase(m.vault, selected.path), next)
+	return m, nil
+}
@@ -0,0 +1,183 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// The editor works with "\n" line breaks only. A note's line endings are
+// detected when it is opened (the more frequent one wins in mixed files) and
+// restored when it is saved, so notes shared with Windows tools keep their
+// CRLF endings. New notes and notes without a line break get
+// editor.line_endings. "gono line-endings" reports or converts the line
+// endings of a vault.
+
+const (
+	lineEndingLF   = "lf"
+	lineEndingCRLF = "crlf"
+)
+
+var lineEndingChoices = []string{lineEndingLF, lineEndingCRLF}
+
+// detectLineEnding returns the line ending used most in text, or fallback
+// when text has no line break.
+func detectLineEnding(text string, fallback string) string {
+	crlf := strings.Count(text, "\r\n")
+	lf := strings.Count(text, "\n") - crlf
+	switch {
+	case crlf == 0 && lf == 0:
+		return fallback
+	case crlf > lf:
+		return lineEndingCRLF
+	}
+	return lineEndingLF
+}
+
+// normalizeLineEndings turns CRLF and lone CR line breaks into "\n".
+func normalizeLineEndings(text string) string {
+	if !strings.Contains(text, "\r") {
+		return text
+	}
+	return strings.ReplaceAll(strings.ReplaceAll(text, "\r\n", "\n"), "\r", "\n")
+}
+
+// withLineEnding converts the "\n" line breaks of text to ending.
+func withLineEnding(text string, ending string) string {
+	if ending != lineEndingCRLF {
+		return text
+	}
+	return strings.ReplaceAll(text, "\n", "\r\n")
+}
+
+func validLineEnding(value string) string {
+	if strings.EqualFold(value, lineEndingCRLF) {
+		return lineEndingCRLF
+	}
+	return lineEndingLF
+}
+
+func nextLineEnding(current string) string {
+	for i, c := range lineEndingChoices {
+		if c == current {
+			return lineEndingChoices[(i+1)%len(lineEndingChoices)]
+		}
+	}
+	return lineEndingChoices[0]
+}
+
+func lineEndingLabel(ending string) string {
+	if ending == lineEndingCRLF {
+		return "CRLF"
+	}
+	return "LF"
+}
+
+// convertLineEndings rewrites path with ending and reports whether it
+// changed. Files in a legacy encoding keep it; binary files are skipped.
+func convertLineEndings(path string, ending string) (bool, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return false, err
+	}
+	text, encoding, ok := decodeText(content)
+	if !ok {
+		return false, nil
+	}
+	converted := withLineEnding(normalizeLineEndings(text), ending)
+	if converted == text {
+		return false, nil
+	}
+	data, err := encodeText(converted, encoding)
+	if err != nil {
+		return false, err
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return false, err
+	}
+	return true, writeFileAtomic(path, data, info.Mode().Perm())
+}
+
+func lineEndingsCommand(args []string, stdout io.Writer) error {
+	flags := flag.NewFlagSet("line-endings", flag.ContinueOnError)
+	flags.SetOutput(io.Discard)
+	to := flags.String("to", "", "")
+	usage := errors.New("usage: gono line-endings [-to lf|crlf] VAULT|NOTE")
+	if err := flags.Parse(args); err != nil || flags.NArg() != 1 {
+		return usage
+	}
+	if *to != "" && *to != lineEndingLF && *to != lineEndingCRLF {
+		return usage
+	}
+	root, err := filepath.Abs(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+	info, err := os.Stat(root)
+	if err != nil {
+		return err
+	}
+	var notes []string
+	if info.IsDir() {
+		_ = walkVault(root, func(p string, d fs.DirEntry) error {
+			if isIndexedNote(p) || isTableFile(p) {
+				notes = append(notes, p)
+			}
+			return nil
+		})
+	} else {
+		notes = append(notes, root)
+	}
+	changed := 0
+	for _, p := range notes {
+		name := relOrBase(root, p)
+		if !info.IsDir() {
+			name = filepath.Base(p)
+		}
+		if *to != "" {
+			ok, err := convertLineEndings(p, *to)
+			if err != nil {
+				return fmt.Errorf("%s: %w", name, err)
+			}
+			if ok {
+				changed++
+				fmt.Fprintf(stdout, "converted  %s\n", name)
+			}
+			continue
+		}
+		content, err := os.ReadFile(p)
+		if err != nil {
+			return err
+		}
+		text, _, ok := decodeText(content)
+		if !ok {
+			continue
+		}
+		crlf := strings.Count(text, "\r\n")
+		lf := strings.Count(text, "\n") - crlf
+		switch {
+		case crlf > 0 && lf > 0:
+			fmt.Fprintf(stdout, "mixed  %s  (%d CRLF, %d LF)\n", name, crlf, lf)
+		case crlf > 0:
+			fmt.Fprintf(stdout, "crlf   %s\n", name)
+		default:
+			continue
+		}
+		changed++
+	}
+	switch {
+	case *to != "":
+		fmt.Fprintf(stdout, "%s converted to %s\n", pluralize(changed, "file", "files"), lineEndingLabel(*to))
+	case changed == 0:
+		fmt.Fprintln(stdout, "All notes use LF line endings")
+	default:
+		fmt.Fprintf(stdout, "%s with CRLF line endings\n", pluralize(changed, "file", "files"))
+	}
+	return nil
+}
@@ -0,0 +1,205 @@
+package main
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/mattn/go-runewidth"
+)
+
+// With the cursor on a [[link]] the editor shows the first lines of the
+// linked note in a box below the cursor line (above it near the bottom of
+// the screen), so the note need not be opened to recall what it says. The
+// box goes away when the cursor leaves the link. editor.link_preview is the
+// number of lines shown; 0 turns the preview off.
+
+const defaultLinkPreview = 8
+
+var linkPreviewChoices = []int{0, 5, 8, 12}
+
+// linkPeek is the preview for the link under the cursor. The target is
+// resolved again only when the cursor moves onto another link.
+type linkPeek struct {
+	target string
+	path   string
+	mod    time.Time
+	lines  []string
+}
+
+// wikiLinkAt returns the target of the [[link]] around col, without an
+// #anchor or |label.
+func wikiLinkAt(line []rune, col int) string {
+	text := string(line)
+	at := len(string(line[:minInt(col, len(line))]))
+	for _, loc := range wikiLinkRe.FindAllStringSubmatchIndex(text, -1) {
+		if at >= loc[0] && at < loc[1] {
+			return strings.TrimSpace(text[loc[2]:loc[3]])
+		}
+	}
+	return ""
+}
+
+// resolveWikiLink finds the note a link target names: a vault path, with
+// or without extension, a path next to the note linking to it, or else the
+// first note in the vault with that file name.
+func resolveWikiLink(vault string, from string, target string) (string, bool) {
+	target = filepath.FromSlash(strings.TrimSuffix(target, "/"))
+	if target == "" {
+		return "", false
+	}
+	for _, base := range []string{vault, filepath.Dir(from)} {
+		candidate := filepath.Join(base, target)
+		if !insideVault(vault, candidate) {
+			continue
+		}
+		names := []string{candidate}
+		if !isIndexedNote(candidate) {
+			names = []string{candidate + ".md", candidate + ".org", candidate + ".markdown", candidate + ".txt"}
+		}
+		for _, name := range names {
+			if info, err := os.Stat(name); err == nil && !info.IsDir() {
+				return name, true
+			}
+		}
+	}
+	want := strings.ToLower(filepath.Base(target))
+	found := ""
+	_ = walkVault(vault, func(p string, d fs.DirEntry) error {
+		if found != "" || !isIndexedNote(p) {
+			return nil
+		}
+		name := strings.ToLower(d.Name())
+		if name == want || strings.TrimSuffix(name, filepath.Ext(name)) == want {
+			found = p
+			return fs.SkipAll
+		}
+		return nil
+	})
+	return found, found != ""
+}
+
+// updateLinkPeek follows the cursor onto and off links.
+func (m Model) updateLinkPeek() Model {
+	if m.cfg.Editor.LinkPreview <= 0 || m.readOnly || m.state != stateEditor || m.find != nil {
+		m.peek = nil
+		return m
+	}
+	target := wikiLinkAt(m.cursorLine(), editorColumn(m.textarea))
+	if target == "" {
+		m.peek = nil
+		return m
+	}
+	if m.peek != nil && m.peek.target == target {
+		return m
+	}
+	m.peek = &linkPeek{target: target}
+	m.peek.path, _ = resolveWikiLink(m.vault, m.editing, target)
+	return m
+}
+
+// load reads the head of the linked note, again when it changed on disk.
+func (p *linkPeek) load(n int) {
+	if p.path == "" {
+		return
+	}
+	info, err := os.Stat(p.path)
+	if err != nil {
+		p.lines = []string{err.Error()}
+		return
+	}
+	if p.lines != nil && p.mod.Equal(info.ModTime()) {
+		return
+	}
+	p.mod = info.ModTime()
+	lines := readPreview(p.path)
+	if fields, ok := parseFrontmatter(strings.Join(lines, "\n")); ok && len(fields) > 0 {
+		for i := 1; i < len(lines); i++ {
+			if strings.TrimSpace(lines[i]) == "---" {
+				lines = lines[i+1:]
+				break
+			}
+		}
+	}
+	for len(lines) > 0 && strings.TrimSpace(lines[0]) == "" {
+		lines = lines[1:]
+	}
+	lines = lines[:minInt(n, len(lines))]
+	for len(lines) > 0 && strings.TrimSpace(lines[len(lines)-1]) == "" {
+		lines = lines[:len(lines)-1]
+	}
+	p.lines = lines
+}
+
+// linkPeekBox draws the preview box, at most width cells wide.
+func (m Model) linkPeekBox(width int) []string {
+	p := m.peek
+	n := m.cfg.Editor.LinkPreview
+	p.load(n)
+	inner := maxInt(8, width-4)
+	var rows []string
+	if p.path == "" {
+		rows = append(rows, statusWarnStyle.Render(runewidth.Truncate(tr("No note named %s", p.target), inner, "…")))
+	} else {
+		rows = append(rows, titleStyle.Render(runewidth.Truncate(relOrBase(m.vault, p.path), inner, "…")))
+		for _, line := range p.lines {
+			line = string(maskSecrets([]rune(strings.ReplaceAll(line, "\t", "    "))))
+			rows = append(rows, runewidth.Truncate(line, inner, "…"))
+		}
+		if len(p.lines) == 0 {
+			rows = append(rows, hintStyle.Render(tr("Empty note")))
+		}
+	}
+	box := lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(colorMuted).
+		Padding(0, 1).
+		Width(inner + 2).
+		Render(strings.Join(rows, "\n"))
+	return strings.Split(box, "\n")
+}
+
+// cursorScreenRow is the row of the wrapped editor view the cursor is on.
+func (m Model) cursorScreenRow(contentW int) int {
+	lines := strings.Split(m.textarea.Value(), "\n")
+	width := m.wrapWidth(contentW)
+	row := 0
+	cursorLine := minInt(m.textarea.Line(), len(lines)-1)
+	for i := m.wrapTop; i < cursorLine; i++ {
+		segments, _ := m.segments(lines[i], width)
+		row += len(segments)
+	}
+	segments, _ := m.segments(lines[cursorLine], width)
+	return row + visualRow(segments, editorColumn(m.textarea))
+}
+
+// withLinkPeek lays the preview box over the rows of view below the cursor
+// row, or above it when there is no room below.
+func (m Model) withLinkPeek(view string, contentW int) string {
+	if m.peek == nil {
+		return view
+	}
+	height := maxInt(1, m.textarea.Height())
+	rows := strings.Split(view, "\n")
+	for len(rows) < height {
+		rows = append(rows, "")
+	}
+	gutter := m.wrapGutter()
+	box := m.linkPeekBox(minInt(72, m.editorWidth(contentW)-gutter))
+	cursor := m.cursorScreenRow(contentW)
+	top := cursor + 1
+	if top+len(box) > height {
+		top = cursor - len(box)
+	}
+	if top < 0 {
+		return view
+	}
+	pad := strings.Repeat(" ", gutter)
+	for i, line := range box {
+		rows[top+i] = pad + line
+	}
+	return strings.Join(rows, "\n")
+}
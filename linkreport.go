@@ -0,0 +1,174 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/list"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// The search index keeps the links of each note along with the time each
+// was first seen. Alt+H in the file list and "gono links" report from it
+// the hubs of the vault, the notes the most other notes link to, and the
+// links added most recently, which show the topics a vault is growing
+// around. Links found when a note is first indexed date from its last
+// modification.
+
+const (
+	defaultLinkHubs   = 10
+	defaultLinkRecent = 20
+)
+
+type linkHub struct {
+	rel     string
+	inbound int
+}
+
+type recentLink struct {
+	from  string
+	to    string
+	since time.Time
+}
+
+// linkReport resolves the links of the indexed notes and returns up to hubs
+// notes with the most notes linking to them, and the recent newest links.
+// Links of a note to itself are left out.
+func (ix *noteIndex) linkReport(hubs int, recent int) ([]linkHub, []recentLink) {
+	rels := make([]string, 0, len(ix.Docs))
+	for rel := range ix.Docs {
+		rels = append(rels, rel)
+	}
+	sort.Strings(rels)
+	byKey := make(map[string]string)
+	for _, rel := range rels {
+		key := strings.ToLower(strings.TrimSuffix(rel, filepath.Ext(rel)))
+		byKey[key] = rel
+		if base := filepath.Base(key); byKey[base] == "" {
+			byKey[base] = rel
+		}
+	}
+
+	inbound := make(map[string]int)
+	var links []recentLink
+	for _, from := range rels {
+		first := make(map[string]int64)
+		for key, since := range ix.Docs[from].Links {
+			to, ok := byKey[key]
+			if !ok || to == from {
+				continue
+			}
+			if at, seen := first[to]; !seen || since < at {
+				first[to] = since
+			}
+		}
+		for to, since := range first {
+			inbound[to]++
+			links = append(links, recentLink{from: from, to: to, since: time.Unix(0, since)})
+		}
+	}
+
+	var hubList []linkHub
+	for rel, n := range inbound {
+		hubList = append(hubList, linkHub{rel: rel, inbound: n})
+	}
+	sort.Slice(hubList, func(i, j int) bool {
+		if hubList[i].inbound != hubList[j].inbound {
+			return hubList[i].inbound > hubList[j].inbound
+		}
+		return hubList[i].rel < hubList[j].rel
+	})
+	sort.Slice(links, func(i, j int) bool {
+		if !links[i].since.Equal(links[j].since) {
+			return links[i].since.After(links[j].since)
+		}
+		if links[i].from != links[j].from {
+			return links[i].from < links[j].from
+		}
+		return links[i].to < links[j].to
+	})
+	return hubList[:min(hubs, len(hubList))], links[:min(recent, len(links))]
+}
+
+func linksCommand(args []string, stdout io.Writer) error {
+	flags := flag.NewFlagSet("links", flag.ContinueOnError)
+	flags.SetOutput(io.Discard)
+	top := flags.Int("top", defaultLinkHubs, "")
+	recent := flags.Int("recent", defaultLinkRecent, "")
+	usage := errors.New("usage: gono links [-top N] [-recent N] VAULT")
+	if err := flags.Parse(args); err != nil || flags.NArg() != 1 || *top < 0 || *recent < 0 {
+		return usage
+	}
+	vault, err := filepath.Abs(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+	if info, err := os.Stat(vault); err != nil || !info.IsDir() {
+		return fmt.Errorf("vault not found: %s", vault)
+	}
+	ix := loadIndex(vault)
+	if ix.sync(vault, newJob("")) {
+		_ = ix.save(vault)
+	}
+	hubs, links := ix.linkReport(*top, *recent)
+	if len(hubs) == 0 {
+		fmt.Fprintln(stdout, "No links between notes")
+		return nil
+	}
+	if *top > 0 {
+		fmt.Fprintln(stdout, "Most linked:")
+		for _, h := range hubs {
+			fmt.Fprintf(stdout, "  %s  (%s)\n", h.rel, pluralize(h.inbound, "note links here", "notes link here"))
+		}
+	}
+	if *recent > 0 {
+		fmt.Fprintln(stdout, "Recently linked:")
+		for _, l := range links {
+			fmt.Fprintf(stdout, "  %s  %s -> %s\n", l.since.Format("2006-01-02 15:04"), l.from, l.to)
+		}
+	}
+	return nil
+}
+
+func (m Model) showLinkReport() (tea.Model, tea.Cmd) {
+	if m.index == nil {
+		m.status = infoStatus("Index is still building, try again in a moment")
+		return m, nil
+	}
+	hubs, links := m.index.linkReport(defaultLinkHubs, defaultLinkRecent)
+	if len(hubs) == 0 {
+		m.status = infoStatus("No links between notes")
+		return m, nil
+	}
+	items := make([]list.Item, 0, len(hubs)+len(links))
+	for _, h := range hubs {
+		items = append(items, item{
+			title: h.rel,
+			desc:  trn("%d note links here", "%d notes link here", h.inbound),
+			path:  filepath.Join(m.vault, filepath.FromSlash(h.rel)),
+			mode:  "link-hub",
+		})
+	}
+	for _, l := range links {
+		items = append(items, item{
+			title: l.from + " → " + l.to,
+			desc:  tr("Linked %s", l.since.Format("2006-01-02 15:04")),
+			path:  filepath.Join(m.vault, filepath.FromSlash(l.to)),
+			mode:  "link-recent",
+		})
+	}
+	m.lastList = stateFileList
+	m.state = stateLinkReport
+	m.status = statusLine{}
+	m.list.SetItems(items)
+	m.list.Title = tr("Most linked notes, then the newest links")
+	m.list.Select(0)
+	return m, nil
+}
@@ -0,0 +1,151 @@
+package main
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// realPath resolves symlinks in p. For paths that do not exist yet (a file
+// about to be created) the deepest existing parent is resolved instead.
+func realPath(p string) (string, error) {
+	abs, err := filepath.Abs(p)
+	if err != nil {
+		return "", err
+	}
+	resolved, err := filepath.EvalSymlinks(abs)
+	if err == nil {
+		return resolved, nil
+	}
+	if !os.IsNotExist(err) {
+		return "", err
+	}
+	parent := filepath.Dir(abs)
+	if parent == abs {
+		return abs, nil
+	}
+	resolvedParent, err := realPath(parent)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(resolvedParent, filepath.Base(abs)), nil
+}
+
+// pathWithin reports whether p equals base or lies below it, comparing the
+// paths lexically.
+func pathWithin(base string, p string) bool {
+	rel, err := filepath.Rel(base, p)
+	if err != nil {
+		return false
+	}
+	if rel == "." {
+		return true
+	}
+	if rel == ".." {
+		return false
+	}
+	return !strings.HasPrefix(rel, ".."+string(os.PathSeparator))
+}
+
+// linkInfo describes a symlinked directory entry.
+type linkInfo struct {
+	target   string
+	isDir    bool
+	broken   bool
+	external bool
+}
+
+func inspectLink(vault string, p string) linkInfo {
+	target, _ := os.Readlink(p)
+	info := linkInfo{target: target}
+	st, err := os.Stat(p)
+	if err != nil {
+		info.broken = true
+		return info
+	}
+	info.isDir = st.IsDir()
+	info.external = !insideVault(vault, p)
+	return info
+}
+
+func (l linkInfo) describe() string {
+	switch {
+	case l.broken:
+		return tr("Broken link -> %s", l.target)
+	case l.external:
+		return tr("Link outside vault -> %s", l.target)
+	default:
+		return tr("Link -> %s", l.target)
+	}
+}
+
+// walkVault walks the vault like filepath.WalkDir but also descends into
+// symlinked directories that resolve inside the vault, and visits symlinked
+// files only when they do too. Every real directory is visited once, so
+// link cycles cannot loop forever; linked directories are walked after
+// their real siblings so notes keep their real paths.
+func walkVault(vault string, fn func(p string, d fs.DirEntry) error) error {
+	root, err := realPath(vault)
+	if err != nil {
+		return err
+	}
+	visited := make(map[string]struct{})
+	var walk func(dir string) error
+	walk = func(dir string) error {
+		real, err := realPath(dir)
+		if err != nil {
+			return nil
+		}
+		if _, ok := visited[real]; ok {
+			return nil
+		}
+		visited[real] = struct{}{}
+
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			return nil
+		}
+		var linkedDirs []string
+		for _, entry := range entries {
+			p := filepath.Join(dir, entry.Name())
+			if entry.Type()&fs.ModeSymlink != 0 {
+				target, statErr := os.Stat(p)
+				if statErr != nil {
+					continue
+				}
+				if target.IsDir() {
+					linkReal, realErr := realPath(p)
+					if realErr != nil || !pathWithin(root, linkReal) || strings.HasPrefix(entry.Name(), ".") {
+						continue
+					}
+					linkedDirs = append(linkedDirs, p)
+					continue
+				}
+				if linkReal, realErr := realPath(p); realErr != nil || !pathWithin(root, linkReal) {
+					continue
+				}
+				entry = fs.FileInfoToDirEntry(target)
+			}
+			if entry.IsDir() {
+				if strings.HasPrefix(entry.Name(), ".") {
+					continue
+				}
+				if err := walk(p); err != nil {
+					return err
+				}
+				continue
+			}
+			if err := fn(p, entry); err != nil {
+				return err
+			}
+		}
+		for _, p := range linkedDirs {
+			if err := walk(p); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return walk(vault)
+}
@@ -0,0 +1,201 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/list"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// Vault search updates as you type: every change of the query looks the
+// words up in the index again and lists the matching notes under the input,
+// each with the line that matched. Notes with the words in their title or
+// file name come first, then notes with them in a heading, then the rest,
+// each group by how often the words occur. Up/Down pick a result and Enter
+// opens it; property queries are run on Enter as before. Filters such as
+// tag:, path: and modified: narrow the results.
+//
+// The index knows the titles and headings of the notes, so the results are
+// ranked before they are cut. The search waits until typing pauses for
+// liveSearchDelay, and only the best liveSearchResults notes are read for
+// their matching line, in the background.
+
+const (
+	liveSearchDelay   = 150 * time.Millisecond
+	liveSearchResults = 20
+)
+
+// liveSearchTickMsg fires once typing paused on query.
+type liveSearchTickMsg struct {
+	query string
+}
+
+type liveSearchMsg struct {
+	query string
+	hits  []rankedHit
+	total int
+}
+
+type rankedHit struct {
+	searchHit
+	path    string
+	preview string
+}
+
+// previewHits reads the notes of hits, which come ranked from the index, for
+// the line that matches query.
+func previewHits(vault string, hits []searchHit, query string) []rankedHit {
+	out := make([]rankedHit, 0, len(hits))
+	for _, hit := range hits {
+		r := rankedHit{searchHit: hit, path: filepath.Join(vault, filepath.FromSlash(hit.rel))}
+		if content, err := os.ReadFile(r.path); err == nil {
+			r.preview = string(maskSecrets([]rune(matchingLine(string(content), query))))
+		}
+		out = append(out, r)
+	}
+	return out
+}
+
+// noteHeadings returns the Markdown (#) and Org (*) heading lines of text.
+func noteHeadings(text string) []string {
+	var out []string
+	fence := false
+	for _, line := range strings.Split(text, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "```") {
+			fence = !fence
+			continue
+		}
+		if fence {
+			continue
+		}
+		if mdHeadingRe.MatchString(line) || strings.HasPrefix(line, "* ") {
+			out = append(out, trimmed)
+		}
+	}
+	return out
+}
+
+// liveSearch lists the notes matching the query typed so far, once typing
+// pauses.
+func (m Model) liveSearch() (Model, tea.Cmd) {
+	query := strings.TrimSpace(m.input.Value())
+	if query == "" {
+		m = m.clearLiveSearch()
+		m.status = statusLine{}
+		return m, nil
+	}
+	return m, tea.Tick(liveSearchDelay, func(time.Time) tea.Msg {
+		return liveSearchTickMsg{query: query}
+	})
+}
+
+// applyLiveSearchTick looks the query up in the index and ranks the best
+// hits in the background, unless the query changed in the meantime.
+func (m Model) applyLiveSearchTick(msg liveSearchTickMsg) (Model, tea.Cmd) {
+	if m.state != stateSearch || strings.TrimSpace(m.input.Value()) != msg.query {
+		return m, nil
+	}
+	if _, ok, _ := asPropertyQuery(msg.query); ok {
+		m = m.clearLiveSearch()
+		m.status = infoStatus("Property query: Enter runs it")
+		return m, nil
+	}
+	if m.index == nil {
+		m = m.clearLiveSearch()
+		m.status = infoStatus("Index is still building, try again in a moment")
+		return m, nil
+	}
+	q, err := parseSearch(msg.query)
+	if err != nil {
+		m = m.clearLiveSearch()
+		m.status = infoStatus("Search: %v", err)
+		return m, nil
+	}
+	hits := m.index.find(m.vault, q)
+	total := len(hits)
+	if len(hits) > liveSearchResults {
+		hits = hits[:liveSearchResults]
+	}
+	vault, words := m.vault, q.words()
+	return m, func() tea.Msg {
+		return liveSearchMsg{query: msg.query, hits: previewHits(vault, hits, words), total: total}
+	}
+}
+
+func (m Model) applyLiveSearch(msg liveSearchMsg) (Model, tea.Cmd) {
+	if m.state != stateSearch || strings.TrimSpace(m.input.Value()) != msg.query {
+		return m, nil
+	}
+	if len(msg.hits) == 0 {
+		m = m.clearLiveSearch()
+		m.status = infoStatus("No notes match: %s", msg.query)
+		return m, nil
+	}
+	items := make([]list.Item, 0, len(msg.hits))
+	for _, hit := range msg.hits {
+		items = append(items, item{title: hit.rel, desc: hit.preview, path: hit.path, mode: "search-hit"})
+	}
+	m.list.SetItems(items)
+	if msg.total > len(msg.hits) {
+		m.list.Title = tr("%d notes found, best %d shown", msg.total, len(msg.hits))
+	} else {
+		m.list.Title = trn("%d note found", "%d notes found", msg.total)
+	}
+	m.list.Select(0)
+	m.status = statusLine{}
+	return m, nil
+}
+
+func (m Model) clearLiveSearch() Model {
+	m.list.SetItems(nil)
+	m.list.Title = ""
+	return m
+}
+
+// updateSearchInput passes keys to the query input, or to the result list
+// for the keys that move through it, and searches again when the query
+// changed.
+func (m Model) updateSearchInput(msg tea.Msg) (Model, tea.Cmd) {
+	if key, ok := msg.(tea.KeyMsg); ok {
+		switch key.String() {
+		case "up", "down", "pgup", "pgdown":
+			var cmd tea.Cmd
+			m.list, cmd = m.list.Update(msg)
+			return m, cmd
+		}
+	}
+	before := m.input.Value()
+	var cmd tea.Cmd
+	m.input, cmd = m.input.Update(msg)
+	if m.input.Value() != before {
+		var search tea.Cmd
+		m, search = m.liveSearch()
+		cmd = tea.Batch(cmd, search)
+	}
+	return m, cmd
+}
+
+// openSearchHit opens the selected result of the live search.
+func (m Model) openSearchHit() (tea.Model, tea.Cmd) {
+	selected, ok := m.list.SelectedItem().(item)
+	if !ok || selected.mode != "search-hit" {
+		m.status = infoStatus("No notes match: %s", strings.TrimSpace(m.input.Value()))
+		return m, nil
+	}
+	m.query = strings.TrimSpace(m.input.Value())
+	m.input.Blur()
+	m.current = filepath.Dir(selected.path)
+	m.lastList = stateFileList
+	return m.openFile(selected.path)
+}
+
+func (m Model) searchBody() string {
+	if len(m.list.Items()) == 0 {
+		return m.input.View()
+	}
+	return m.input.View() + "\n\n" + m.list.View()
+}
@@ -0,0 +1,227 @@
+package main
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Several GoNo instances may run at once. Read-modify-write cycles on shared
+// files (the vault registry) hold an exclusive lock file next to the file,
+// and every note open in an editor has an advisory lock under
+// .gono/locks/ so a second instance can warn before both edit it. Saves stay
+// last-writer-wins, guarded by the changed-on-disk check.
+
+const (
+	lockSuffix     = ".lock"
+	lockRetryDelay = 25 * time.Millisecond
+	lockTimeout    = 3 * time.Second
+	// Locks older than this are left over from a crashed instance.
+	lockStaleAfter = 30 * time.Second
+	noteLocksDir   = "locks"
+)
+
+// heldLock is the note this instance holds the advisory lock for.
+type heldLock struct {
+	vault string
+	note  string
+}
+
+var errLocked = errors.New("file is locked by another GoNo instance")
+
+// withFileLock runs fn while holding path.lock, created exclusively.
+func withFileLock(path string, fn func() error) error {
+	lock := path + lockSuffix
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	deadline := time.Now().Add(lockTimeout)
+	for {
+		f, err := os.OpenFile(lock, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
+		if err == nil {
+			fmt.Fprintf(f, "%d\n", os.Getpid())
+			f.Close()
+			break
+		}
+		if !errors.Is(err, os.ErrExist) {
+			return err
+		}
+		if info, statErr := os.Stat(lock); statErr == nil && time.Since(info.ModTime()) > lockStaleAfter {
+			_ = os.Remove(lock)
+			continue
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("%w: %s", errLocked, path)
+		}
+		time.Sleep(lockRetryDelay)
+	}
+	defer os.Remove(lock)
+	return fn()
+}
+
+// syncWrites makes writeFileAtomic flush the file and its directory to disk
+// before returning (editor.fsync).
+var syncWrites bool
+
+// writeFileAtomic writes data to a temporary file in the same directory and
+// renames it over path, so readers never see a half-written file and a crash
+// or a full disk mid-write leaves the old file intact. A symlink at path is
+// followed, so the file it points to is replaced rather than the link.
+func writeFileAtomic(path string, data []byte, perm os.FileMode) error {
+	if target, err := filepath.EvalSymlinks(path); err == nil {
+		path = target
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	name := tmp.Name()
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(name)
+		return err
+	}
+	if syncWrites {
+		if err := tmp.Sync(); err != nil {
+			tmp.Close()
+			os.Remove(name)
+			return err
+		}
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(name)
+		return err
+	}
+	if err := os.Chmod(name, perm); err != nil {
+		os.Remove(name)
+		return err
+	}
+	if err := os.Rename(name, path); err != nil {
+		os.Remove(name)
+		return err
+	}
+	if syncWrites {
+		syncDir(filepath.Dir(path))
+	}
+	return nil
+}
+
+// syncDir flushes the directory entry of a renamed file. Not every system
+// can sync a directory (Windows cannot open one for it), so errors are
+// ignored: the file itself is on disk already.
+func syncDir(dir string) {
+	d, err := os.Open(dir)
+	if err != nil {
+		return
+	}
+	_ = d.Sync()
+	d.Close()
+}
+
+// noteLock is the content of a note's advisory lock file.
+type noteLock struct {
+	PID    int       `json:"pid"`
+	Host   string    `json:"host"`
+	Path   string    `json:"path"`
+	Opened time.Time `json:"opened"`
+}
+
+func noteLockPath(vault string, note string) string {
+	sum := sha1.Sum([]byte(strings.ToLower(filepath.Clean(note))))
+	return filepath.Join(appDir(vault), noteLocksDir, hex.EncodeToString(sum[:8])+lockSuffix)
+}
+
+func currentHost() string {
+	host, err := os.Hostname()
+	if err != nil {
+		return "?"
+	}
+	return host
+}
+
+// acquireNoteLock records that this instance has note open. When another
+// live instance holds it, the lock is left alone and its owner returned.
+func acquireNoteLock(vault string, note string) (*noteLock, error) {
+	if vault == "" || note == "" {
+		return nil, nil
+	}
+	path := noteLockPath(vault, note)
+	if data, err := os.ReadFile(path); err == nil {
+		var other noteLock
+		if json.Unmarshal(data, &other) == nil && other.PID != os.Getpid() && !other.stale() {
+			return &other, nil
+		}
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, err
+	}
+	data, err := json.Marshal(noteLock{PID: os.Getpid(), Host: currentHost(), Path: note, Opened: time.Now()})
+	if err != nil {
+		return nil, err
+	}
+	return nil, writeFileAtomic(path, data, 0644)
+}
+
+// releaseNoteLock removes the lock on note if this instance holds it.
+func releaseNoteLock(vault string, note string) {
+	if vault == "" || note == "" {
+		return
+	}
+	path := noteLockPath(vault, note)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return
+	}
+	var lock noteLock
+	if json.Unmarshal(data, &lock) == nil && lock.PID == os.Getpid() && lock.Host == currentHost() {
+		_ = os.Remove(path)
+	}
+}
+
+// stale reports whether the owning instance is gone. Processes on other
+// hosts cannot be checked, so their locks only expire after a day.
+func (l noteLock) stale() bool {
+	if l.Host != currentHost() {
+		return time.Since(l.Opened) > 24*time.Hour
+	}
+	return !processAlive(l.PID)
+}
+
+// noteOpen reports whether m.editing is loaded in the editor, including
+// prompts and dialogs shown on top of it.
+func (m Model) noteOpen() bool {
+	switch m.state {
+	case stateEditor, stateExtractNote, statePipeCommand, stateDiagram, stateLinkNote, stateBesideNote, stateGitLog, stateNoteURLs, stateKeywords, stateConfirmUnsaved, stateConfirmOverwrite, stateReviewSave:
+		return true
+	case stateErrorDetail:
+		return m.errReport != nil && m.errReport.from == stateEditor
+	}
+	return false
+}
+
+// trackNoteLock moves the advisory lock to the note open now, if any, and
+// warns when another instance is editing it.
+func (m Model) trackNoteLock() Model {
+	want := ""
+	if m.noteOpen() {
+		want = m.editing
+	}
+	if want == m.locked.note && m.vault == m.locked.vault {
+		return m
+	}
+	releaseNoteLock(m.locked.vault, m.locked.note)
+	m.locked = heldLock{vault: m.vault, note: want}
+	other, err := acquireNoteLock(m.vault, want)
+	if err != nil || other == nil {
+		return m
+	}
+	m.status = warnStatus("Also open in another GoNo (pid %d on %s) since %s; the last save wins",
+		other.PID, other.Host, other.Opened.Format("15:04"))
+	return m
+}
@@ -0,0 +1,495 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/tls"
+	"encoding/base64"
+	"errors"
+	"flag"
+	"fmt"
+	"html"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// gono capture-mail turns emails into inbox captures. Mails come from a
+// local maildir or from an IMAP mailbox (over TLS); with an address set only
+// mails sent to it are taken, so a plus address like me+gono@example.com
+// can feed the inbox. The subject and text body are appended to the inbox
+// note and attachments are saved under assets/ and linked from the capture.
+// Captured mails are marked as read (moved to cur/ in a maildir).
+
+const (
+	mailMaxBytes        = 32 << 20
+	mailDefaultInterval = 300
+	mailPasswordEnv     = "GONO_MAIL_PASSWORD"
+)
+
+var (
+	htmlBreakRe = regexp.MustCompile(`(?i)<br\s*/?>|</p>|</div>|</li>|</h[1-6]>`)
+	htmlTagRe   = regexp.MustCompile(`(?s)<[^>]*>`)
+	blankRunRe  = regexp.MustCompile(`\n{3,}`)
+)
+
+type mailAttachment struct {
+	name string
+	data []byte
+}
+
+type parsedMail struct {
+	from        string
+	subject     string
+	date        time.Time
+	body        string
+	attachments []mailAttachment
+}
+
+// mailSource is a mailbox captures are read from: fetch returns the unread
+// messages for the address, and done marks one as captured.
+type mailSource interface {
+	fetch(address string) ([]string, error)
+	read(id string) ([]byte, error)
+	done(id string) error
+	close() error
+}
+
+func captureMailCommand(args []string, stdout io.Writer) error {
+	flags := flag.NewFlagSet("capture-mail", flag.ContinueOnError)
+	flags.SetOutput(io.Discard)
+	vault := flags.String("vault", "", "")
+	inbox := flags.String("inbox", "", "")
+	once := flags.Bool("once", false, "")
+	if err := flags.Parse(args); err != nil || flags.NArg() > 0 {
+		return errors.New("usage: gono capture-mail [-vault DIR] [-inbox NOTE] [-once]")
+	}
+	target, err := resolveCaptureTarget(*vault, *inbox)
+	if err != nil {
+		return err
+	}
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+	mc := cfg.Capture.Mail
+	if mc.Maildir == "" && mc.Server == "" {
+		return errors.New("no mailbox configured: set capture.mail.maildir or capture.mail.server in the config")
+	}
+	interval := time.Duration(mc.Interval) * time.Second
+	if interval <= 0 {
+		interval = mailDefaultInterval * time.Second
+	}
+	for {
+		n, err := pollMail(mc, target)
+		if err != nil {
+			if *once {
+				return err
+			}
+			fmt.Fprintln(stdout, "Error:", err)
+		} else if n > 0 || *once {
+			fmt.Fprintf(stdout, "Captured %s to %s\n", pluralize(n, "mail", "mails"), target.path())
+		}
+		if *once {
+			return nil
+		}
+		time.Sleep(interval)
+	}
+}
+
+func openMailSource(mc mailCaptureConfig) (mailSource, error) {
+	if mc.Maildir != "" {
+		return maildirSource{dir: expandHome(mc.Maildir)}, nil
+	}
+	password := os.Getenv(mailPasswordEnv)
+	if password == "" {
+		return nil, fmt.Errorf("set %s to the mailbox password", mailPasswordEnv)
+	}
+	return dialIMAP(mc.Server, mc.User, password, mc.Mailbox)
+}
+
+// pollMail captures every waiting mail and returns how many it took.
+func pollMail(mc mailCaptureConfig, target captureTarget) (int, error) {
+	source, err := openMailSource(mc)
+	if err != nil {
+		return 0, err
+	}
+	defer source.close()
+	ids, err := source.fetch(mc.Address)
+	if err != nil {
+		return 0, err
+	}
+	captured := 0
+	for _, id := range ids {
+		raw, err := source.read(id)
+		if err != nil {
+			return captured, err
+		}
+		if mc.Maildir != "" && mc.Address != "" && !mailSentTo(raw, mc.Address) {
+			continue
+		}
+		msg, err := parseMail(raw)
+		if err != nil {
+			return captured, err
+		}
+		if err := captureMail(target, msg); err != nil {
+			return captured, err
+		}
+		if err := source.done(id); err != nil {
+			return captured, err
+		}
+		captured++
+	}
+	return captured, nil
+}
+
+// mailSentTo reports whether address is among the recipients of raw.
+func mailSentTo(raw []byte, address string) bool {
+	msg, err := mail.ReadMessage(bytes.NewReader(raw))
+	if err != nil {
+		return false
+	}
+	for _, field := range []string{"To", "Cc", "Delivered-To", "X-Original-To"} {
+		list, err := msg.Header.AddressList(field)
+		if err != nil {
+			if strings.Contains(strings.ToLower(msg.Header.Get(field)), strings.ToLower(address)) {
+				return true
+			}
+			continue
+		}
+		for _, a := range list {
+			if strings.EqualFold(a.Address, address) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func parseMail(raw []byte) (parsedMail, error) {
+	msg, err := mail.ReadMessage(bytes.NewReader(raw))
+	if err != nil {
+		return parsedMail{}, err
+	}
+	dec := new(mime.WordDecoder)
+	out := parsedMail{from: msg.Header.Get("From")}
+	if subject, err := dec.DecodeHeader(msg.Header.Get("Subject")); err == nil {
+		out.subject = strings.TrimSpace(subject)
+	}
+	if from, err := mail.ParseAddress(out.from); err == nil {
+		out.from = from.Address
+		if from.Name != "" {
+			out.from = from.Name + " <" + from.Address + ">"
+		}
+	}
+	out.date, _ = msg.Header.Date()
+	var plain, htmlBody string
+	err = walkMailPart(msg.Header.Get, msg.Body, func(mediaType string, name string, data []byte) {
+		switch {
+		case name != "":
+			out.attachments = append(out.attachments, mailAttachment{name: name, data: data})
+		case mediaType == "text/plain" && plain == "":
+			plain = string(data)
+		case mediaType == "text/html" && htmlBody == "":
+			htmlBody = string(data)
+		}
+	})
+	if err != nil {
+		return out, err
+	}
+	if plain == "" && htmlBody != "" {
+		plain = htmlToText(htmlBody)
+	}
+	out.body = strings.TrimSpace(strings.ReplaceAll(plain, "\r\n", "\n"))
+	return out, nil
+}
+
+// walkMailPart decodes a MIME part and calls visit for every leaf, with the
+// file name for attachments and inline files.
+func walkMailPart(header func(string) string, body io.Reader, visit func(mediaType string, name string, data []byte)) error {
+	mediaType, params, err := mime.ParseMediaType(header("Content-Type"))
+	if err != nil {
+		mediaType, params = "text/plain", nil
+	}
+	if strings.HasPrefix(mediaType, "multipart/") {
+		reader := multipart.NewReader(body, params["boundary"])
+		for {
+			part, err := reader.NextRawPart()
+			if err == io.EOF {
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+			if err := walkMailPart(part.Header.Get, part, visit); err != nil {
+				return err
+			}
+		}
+	}
+	var decoded io.Reader = body
+	switch strings.ToLower(strings.TrimSpace(header("Content-Transfer-Encoding"))) {
+	case "base64":
+		decoded = base64.NewDecoder(base64.StdEncoding, body)
+	case "quoted-printable":
+		decoded = quotedprintable.NewReader(body)
+	}
+	data, err := io.ReadAll(io.LimitReader(decoded, mailMaxBytes))
+	if err != nil {
+		return err
+	}
+	name := params["name"]
+	if _, dispParams, err := mime.ParseMediaType(header("Content-Disposition")); err == nil && dispParams["filename"] != "" {
+		name = dispParams["filename"]
+	}
+	if name != "" {
+		if decodedName, err := new(mime.WordDecoder).DecodeHeader(name); err == nil {
+			name = decodedName
+		}
+	}
+	visit(mediaType, name, data)
+	return nil
+}
+
+func htmlToText(s string) string {
+	s = htmlBreakRe.ReplaceAllString(s, "\n")
+	s = htmlTagRe.ReplaceAllString(s, "")
+	s = html.UnescapeString(s)
+	lines := strings.Split(s, "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimSpace(line)
+	}
+	return blankRunRe.ReplaceAllString(strings.Join(lines, "\n"), "\n\n")
+}
+
+// captureMail saves the attachments and appends the mail to the inbox.
+func captureMail(target captureTarget, msg parsedMail) error {
+	var b strings.Builder
+	subject := msg.subject
+	if subject == "" {
+		subject = "(no subject)"
+	}
+	b.WriteString("**" + subject + "**\n")
+	if msg.from != "" {
+		b.WriteString("From: " + msg.from + "\n")
+	}
+	if msg.body != "" {
+		b.WriteString("\n" + msg.body + "\n")
+	}
+	noteDir := filepath.Dir(target.path())
+	for _, a := range msg.attachments {
+		name := safeAttachmentName(a.name)
+		dest := uniquePath(filepath.Join(target.vault, assetsDirName, name))
+		if err := os.MkdirAll(filepath.Dir(dest), 0755); err != nil {
+			return err
+		}
+		if err := os.WriteFile(dest, a.data, 0644); err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(noteDir, dest)
+		if err != nil {
+			rel = dest
+		}
+		link := "[" + name + "](" + strings.ReplaceAll(filepath.ToSlash(rel), " ", "%20") + ")"
+		if mediaType := mime.TypeByExtension(filepath.Ext(name)); strings.HasPrefix(mediaType, "image/") {
+			link = "!" + link
+		}
+		b.WriteString("\n" + link + "\n")
+	}
+	when := msg.date
+	if when.IsZero() {
+		when = time.Now()
+	}
+	return appendCapture(target, b.String(), when.Local())
+}
+
+// safeAttachmentName keeps the base name of an attachment and replaces
+// characters that are awkward in file names and links.
+func safeAttachmentName(name string) string {
+	name = filepath.Base(strings.ReplaceAll(name, "\\", "/"))
+	name = strings.Map(func(r rune) rune {
+		if strings.ContainsRune(`/\:*?"<>|()[]`, r) || r < ' ' {
+			return '_'
+		}
+		return r
+	}, name)
+	if name == "" || name == "." || name == ".." || strings.HasPrefix(name, ".") {
+		name = "attachment" + name
+	}
+	return name
+}
+
+// maildirSource reads the new/ folder of a maildir; captured mails move to
+// cur/ with the Seen flag, as a mail client would.
+type maildirSource struct {
+	dir string
+}
+
+func (s maildirSource) fetch(string) ([]string, error) {
+	entries, err := os.ReadDir(filepath.Join(s.dir, "new"))
+	if err != nil {
+		return nil, err
+	}
+	var ids []string
+	for _, e := range entries {
+		if !e.IsDir() && !strings.HasPrefix(e.Name(), ".") {
+			ids = append(ids, e.Name())
+		}
+	}
+	return ids, nil
+}
+
+func (s maildirSource) read(id string) ([]byte, error) {
+	return os.ReadFile(filepath.Join(s.dir, "new", id))
+}
+
+func (s maildirSource) done(id string) error {
+	if err := os.MkdirAll(filepath.Join(s.dir, "cur"), 0700); err != nil {
+		return err
+	}
+	return os.Rename(filepath.Join(s.dir, "new", id), filepath.Join(s.dir, "cur", id+":2,S"))
+}
+
+func (s maildirSource) close() error {
+	return nil
+}
+
+// imapSource is a minimal IMAP4rev1 client: enough to log in, search the
+// unread mails, fetch them and set the Seen flag.
+type imapSource struct {
+	conn net.Conn
+	r    *bufio.Reader
+	tag  int
+}
+
+func dialIMAP(server string, user string, password string, mailbox string) (*imapSource, error) {
+	if !strings.Contains(server, ":") {
+		server += ":993"
+	}
+	host, _, _ := net.SplitHostPort(server)
+	conn, err := tls.DialWithDialer(&net.Dialer{Timeout: 30 * time.Second}, "tcp", server, &tls.Config{ServerName: host})
+	if err != nil {
+		return nil, err
+	}
+	s := &imapSource{conn: conn, r: bufio.NewReader(conn)}
+	if _, err := s.r.ReadString('\n'); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	if mailbox == "" {
+		mailbox = "INBOX"
+	}
+	if _, err := s.command("LOGIN %s %s", imapQuote(user), imapQuote(password)); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	if _, err := s.command("SELECT %s", imapQuote(mailbox)); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return s, nil
+}
+
+func imapQuote(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s) + `"`
+}
+
+// imapReply is one untagged response line with the literals it carried.
+type imapReply struct {
+	line     string
+	literals [][]byte
+}
+
+var imapLiteralRe = regexp.MustCompile(`\{(\d+)\}\r\n$`)
+
+// command sends a tagged command and collects the untagged replies until
+// the tagged completion, which must be OK.
+func (s *imapSource) command(format string, args ...any) ([]imapReply, error) {
+	s.tag++
+	tag := "g" + strconv.Itoa(s.tag)
+	_ = s.conn.SetDeadline(time.Now().Add(2 * time.Minute))
+	if _, err := fmt.Fprintf(s.conn, "%s "+format+"\r\n", append([]any{tag}, args...)...); err != nil {
+		return nil, err
+	}
+	var replies []imapReply
+	for {
+		var reply imapReply
+		for {
+			line, err := s.r.ReadString('\n')
+			if err != nil {
+				return nil, err
+			}
+			m := imapLiteralRe.FindStringSubmatch(line)
+			if m == nil {
+				reply.line += strings.TrimRight(line, "\r\n")
+				break
+			}
+			size, _ := strconv.Atoi(m[1])
+			if size > mailMaxBytes {
+				return nil, errors.New("imap: message too large")
+			}
+			literal := make([]byte, size)
+			if _, err := io.ReadFull(s.r, literal); err != nil {
+				return nil, err
+			}
+			reply.line += line[:len(line)-len(m[0])]
+			reply.literals = append(reply.literals, literal)
+		}
+		if rest, ok := strings.CutPrefix(reply.line, tag+" "); ok {
+			if !strings.HasPrefix(rest, "OK") {
+				return nil, fmt.Errorf("imap: %s", rest)
+			}
+			return replies, nil
+		}
+		replies = append(replies, reply)
+	}
+}
+
+func (s *imapSource) fetch(address string) ([]string, error) {
+	query := "UNSEEN"
+	if address != "" {
+		query = "UNSEEN OR TO " + imapQuote(address) + " CC " + imapQuote(address)
+	}
+	replies, err := s.command("UID SEARCH %s", query)
+	if err != nil {
+		return nil, err
+	}
+	var ids []string
+	for _, r := range replies {
+		if rest, ok := strings.CutPrefix(r.line, "* SEARCH"); ok {
+			ids = append(ids, strings.Fields(rest)...)
+		}
+	}
+	return ids, nil
+}
+
+func (s *imapSource) read(id string) ([]byte, error) {
+	replies, err := s.command("UID FETCH %s BODY.PEEK[]", id)
+	if err != nil {
+		return nil, err
+	}
+	for _, r := range replies {
+		if len(r.literals) > 0 {
+			return r.literals[0], nil
+		}
+	}
+	return nil, fmt.Errorf("imap: mail %s not found", id)
+}
+
+func (s *imapSource) done(id string) error {
+	_, err := s.command(`UID STORE %s +FLAGS.SILENT (\Seen)`, id)
+	return err
+}
+
+func (s *imapSource) close() error {
+	_, _ = s.command("LOGOUT")
+	return s.conn.Close()
+}
@@ -9,7 +9,9 @@ import (
 	"path/filepath"
 	"runtime"
 	"sort"
+	"strconv"
 	"strings"
+	"time"
 	"unicode"
 
 	"github.com/charmbracelet/bubbles/list"
@@ -17,6 +19,7 @@ import (
 	"github.com/charmbracelet/bubbles/textinput"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
+	"github.com/mattn/go-runewidth"
 )
 
 type viewState int
@@ -27,81 +30,175 @@ const (
 	stateVaultOpenPath
 	stateFileList
 	stateFileCreate
+	stateTitleCreate
+	stateExtractNote
+	stateGotoNote
+	stateLinkNote
+	stateRandomTag
+	stateGitLog
 	stateDirCreate
 	stateEditor
 	stateConfirmDelete
+	stateTemplateSelect
+	stateTemplatePrompt
+	stateSearch
+	stateSearchResults
+	stateSettings
+	stateConfirmUnsaved
+	stateConfirmOverwrite
+	stateReviewSave
+	stateErrorDetail
+	stateActivity
+	stateReminders
+	stateTwoPane
+	stateTable
+	stateMerge
+	statePipeCommand
+	stateVaultScaffold
+	stateDiagram
+	stateNoteURLs
+	stateKeys
+	stateKeyAdd
+	stateConfirmRemoveKey
+	stateSmartFolderName
+	stateKanban
+	stateAgenda
+	stateImportPath
+	stateKeywords
+	stateStale
+	stateBinary
+	stateJournal
+	stateTagToggle
+	stateBesideNote
+	stateProfiles
+	stateProfileCreate
+	stateLinkReport
 )
 
 type Model struct {
-	state    viewState
-	list     list.Model
-	input    textinput.Model
-	textarea textarea.Model
-	windowW  int
-	windowH  int
-	vault    string
-	current  string
-	editing  string
-	lastList viewState
-	status   string
-	pending  *deleteTarget
+	state     viewState
+	list      list.Model
+	input     textinput.Model
+	textarea  textarea.Model
+	windowW   int
+	windowH   int
+	vault     string
+	current   string
+	editing   string
+	readOnly  bool
+	lastList  viewState
+	status    statusLine
+	pending   *deleteTarget
+	tmpl      *templateSession
+	index     *noteIndex
+	query     string
+	cfg       appConfig
+	saved     string
+	diskMod   time.Time
+	quitting  bool
+	switchTo  string
+	prevNote  string
+	returnTo  viewState
+	side      *sidePane
+	quickDiff *diffView
+	cursors   map[string]cursorPos
+	limit     int
+	dirStats  *dirStatsCache
+	fileStats *fileStatCache
+	mark      *cursorPos
+	wrapTop   int
+	blame     []string
+	dates     dateConfig
+	errReport *errorReport
+	activity  *vaultActivity
+	locked    heldLock
+	panes     *twoPane
+	quick     *quickFilter
+	table     *tableView
+	lastPipe  string
+	newVault  string
+	diagram   *diagramView
+	job       *job
+	bar       barInfo
+	board     *kanbanBoard
+	agenda    *agendaView
+	entries   int
+	binary    *binaryView
+	encoding  string
+	lineEnd   string
+	tagNote   string
+	tagged    []string
+	beside    *besideNote
+	peek      *linkPeek
+	find      *editorFind
+	words     *wordTally
+	merge     *mergeView
+	signature *signatureState
+	help      bool
+	offline   map[string]bool
+	toSign    []string
+	keyDrop   *recipient
 }
 
 type vaultRegistry struct {
 	Vaults []string `json:"vaults"`
+	Last   string   `json:"last,omitempty"`
 }
 
 type deleteTarget struct {
-	path    string
-	label   string
-	isDir   bool
-	isVault bool
+	path        string
+	label       string
+	isDir       bool
+	isVault     bool
+	link        bool
+	trash       bool
+	purge       bool
+	guard       string
+	confirmName string
+	summary     *deleteSummary
+}
+
+type indexReadyMsg struct {
+	vault     string
+	index     *noteIndex
+	job       *job
+	cancelled bool
+	err       error
 }
 
 var errFolderDialogCanceled = errors.New("folder dialog canceled")
 
 var (
-	colorPrimary = lipgloss.AdaptiveColor{Light: "#0F4C5C", Dark: "#7AD9F5"}
-	colorMuted   = lipgloss.AdaptiveColor{Light: "#475467", Dark: "#D4DEE8"}
-	colorBorder  = lipgloss.AdaptiveColor{Light: "#CBD5E1", Dark: "#3B4A5A"}
-	colorSuccess = lipgloss.AdaptiveColor{Light: "#1F7A3F", Dark: "#67D08B"}
-	colorWarning = lipgloss.AdaptiveColor{Light: "#B54708", Dark: "#FDBA74"}
-	colorError   = lipgloss.AdaptiveColor{Light: "#B42318", Dark: "#FF8D8D"}
+	// Colors and text styles are set by applyTheme.
+	colorPrimary lipgloss.AdaptiveColor
+	colorMuted   lipgloss.AdaptiveColor
+	colorBorder  lipgloss.AdaptiveColor
+	colorSuccess lipgloss.AdaptiveColor
+	colorWarning lipgloss.AdaptiveColor
+	colorError   lipgloss.AdaptiveColor
 
 	appStyle        = lipgloss.NewStyle()
 	panelStyle      = lipgloss.NewStyle().Padding(0, 1)
-	titleStyle      = lipgloss.NewStyle().Bold(true).Foreground(colorPrimary)
-	subtitleStyle   = lipgloss.NewStyle().Foreground(colorMuted)
-	hintStyle       = lipgloss.NewStyle().Foreground(colorMuted)
-	statusInfoStyle = lipgloss.NewStyle().Bold(true).Foreground(colorPrimary)
-	statusOkStyle   = lipgloss.NewStyle().Bold(true).Foreground(colorSuccess)
-	statusWarnStyle = lipgloss.NewStyle().Bold(true).Foreground(colorWarning)
-	statusErrStyle  = lipgloss.NewStyle().Bold(true).Foreground(colorError)
+	titleStyle      lipgloss.Style
+	subtitleStyle   lipgloss.Style
+	hintStyle       lipgloss.Style
+	statusInfoStyle lipgloss.Style
+	statusOkStyle   lipgloss.Style
+	statusWarnStyle lipgloss.Style
+	statusErrStyle  lipgloss.Style
 )
 
 func initialModel() Model {
-	items := getVaults()
-	delegate := list.NewDefaultDelegate()
-	delegate.Styles.NormalTitle = delegate.Styles.NormalTitle.Foreground(colorPrimary)
-	delegate.Styles.NormalDesc = delegate.Styles.NormalDesc.Foreground(colorMuted)
-	delegate.Styles.SelectedTitle = delegate.Styles.SelectedTitle.Bold(true).Foreground(colorSuccess)
-	delegate.Styles.SelectedDesc = delegate.Styles.SelectedDesc.Foreground(colorSuccess)
-	delegate.Styles.DimmedTitle = delegate.Styles.DimmedTitle.Foreground(colorMuted)
-	delegate.Styles.DimmedDesc = delegate.Styles.DimmedDesc.Foreground(colorMuted)
-	delegate.SetSpacing(0)
-
-	l := list.New(items, delegate, 0, 0)
-	l.Title = "Select vault (Enter), create (Ctrl+N), open by path (Ctrl+O), open in explorer (Ctrl+P)"
-	listStyles := list.DefaultStyles()
-	listStyles.Title = listStyles.Title.Bold(true).Foreground(colorPrimary)
-	listStyles.TitleBar = listStyles.TitleBar.BorderStyle(lipgloss.NormalBorder()).BorderBottom(true).BorderForeground(colorBorder)
-	listStyles.PaginationStyle = listStyles.PaginationStyle.Foreground(colorMuted)
-	listStyles.HelpStyle = listStyles.HelpStyle.Foreground(colorMuted)
-	listStyles.StatusBar = listStyles.StatusBar.Foreground(colorMuted)
-	listStyles.StatusEmpty = listStyles.StatusEmpty.Foreground(colorMuted)
-	listStyles.FilterPrompt = listStyles.FilterPrompt.Foreground(colorPrimary).Bold(true)
-	listStyles.FilterCursor = listStyles.FilterCursor.Foreground(colorPrimary)
-	l.Styles = listStyles
+	cfg, cfgErr := loadConfig()
+	setLanguage(cfg.Language)
+	applyBackground(cfg.Background)
+	applyTheme(cfg.Theme)
+	applyLayout(cfg.Layout)
+	listIcons = cfg.List.Icons
+	syncWrites = cfg.Editor.Fsync
+	items := getVaults(nil)
+
+	l := list.New(items, list.NewDefaultDelegate(), 0, 0)
 	l.SetShowHelp(false)
 	l.SetShowStatusBar(false)
 
@@ -109,89 +206,316 @@ func initialModel() Model {
 	in.Prompt = "> "
 	in.CharLimit = 200
 	in.Width = 60
-	in.PromptStyle = lipgloss.NewStyle().Bold(true).Foreground(colorPrimary)
-	in.TextStyle = lipgloss.NewStyle().Foreground(colorPrimary)
-	in.PlaceholderStyle = lipgloss.NewStyle().Foreground(colorMuted)
-	in.Cursor.Style = lipgloss.NewStyle().Foreground(colorPrimary)
 
 	ta := textarea.New()
-	ta.Prompt = "> "
-	ta.ShowLineNumbers = true
-	ta.FocusedStyle.Prompt = lipgloss.NewStyle().Bold(true).Foreground(colorPrimary)
-	ta.FocusedStyle.LineNumber = lipgloss.NewStyle().Foreground(colorMuted)
-	ta.FocusedStyle.CursorLineNumber = lipgloss.NewStyle().Bold(true).Foreground(colorPrimary)
-	ta.FocusedStyle.CursorLine = lipgloss.NewStyle().Foreground(colorPrimary)
-	ta.FocusedStyle.Placeholder = lipgloss.NewStyle().Foreground(colorMuted)
-	ta.BlurredStyle = ta.FocusedStyle
-
-	return Model{
+	ta.ShowLineNumbers = cfg.Editor.LineNumbers
+	ta.MaxHeight = 0
+	styleComponents(&l, &in, &ta)
+
+	m := Model{
 		state:    stateVaultSelect,
 		list:     l,
 		input:    in,
 		textarea: ta,
 		windowW:  80,
 		windowH:  24,
+		cfg:      cfg,
+		cursors:  make(map[string]cursorPos),
+		side:     &sidePane{},
+	}
+	if cfgErr != nil {
+		m.status = failStatus("config not loaded: %v", cfgErr)
+		return m
 	}
+	return m.applyStartup().trackNoteLock()
 }
 
 func (m Model) Init() tea.Cmd {
-	return nil
+	return tea.Batch(m.startupCmd(), checkVaultsCmd(), barInfoCmd(m.vault, m.cfg.StatusBar.Segments), barTick(), purgeTrashCmd(m.cfg.Trash))
 }
 
 func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	next, cmd := m.update(msg)
+	nm, ok := next.(Model)
+	if !ok {
+		return next, cmd
+	}
+	if nm.state == stateConfirmUnsaved && m.state != stateConfirmUnsaved && nm.cfg.Editor.AutoSave {
+		nm, cmd = nm.autosave(cmd)
+	}
+	if statsCmd := nm.dirStatsCmd(); statsCmd != nil {
+		cmd = tea.Batch(cmd, statsCmd)
+	}
+	if signCmd := nm.signCmd(); signCmd != nil {
+		nm.toSign = nil
+		cmd = tea.Batch(cmd, signCmd)
+	}
+	if nm.state == stateEditor {
+		contentW, _ := nm.contentDims()
+		nm = nm.scrollWrapped(nm.editorPaneWidth(contentW))
+	}
+	nm = nm.updateLinkPeek()
+	nm = nm.trackWords()
+	nm.rememberPosition(m)
+	if nm.state != stateConfirmUnsaved && nm.state != stateReviewSave {
+		nm.quickDiff = nil
+	} else if m.state != stateConfirmUnsaved && nm.cfg.Confirm.ShowDiff {
+		nm.quickDiff = nm.bufferDiff()
+	}
+	nm = nm.flushScratch()
+	nm = nm.trackNoteLock()
+	return nm, cmd
+}
+
+func (m Model) update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	var cmd tea.Cmd
 	var cmds []tea.Cmd
 
+	if dark, ok := colorSchemeReport(msg); ok {
+		return m.followBackground(dark), nil
+	}
+
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
+		if msg.String() == "esc" && m.job != nil && m.job.visible() && !m.job.cancelled() {
+			return m.cancelJob(), nil
+		}
+		if m.isScratchKey(msg) {
+			return m.toggleScratch()
+		}
+		if m.help || m.isHelpKey(msg.String()) {
+			m.help = !m.help
+			return m, nil
+		}
+		if m.state == stateConfirmUnsaved {
+			return m.handleUnsavedKey(msg)
+		}
+		if m.state == stateConfirmOverwrite {
+			return m.handleOverwriteKey(msg)
+		}
+		if m.state == stateReviewSave {
+			return m.handleReviewSaveKey(msg)
+		}
+		if m.state == stateConfirmRemoveKey {
+			return m.handleRemoveKeyKey(msg)
+		}
+		if m.state == stateErrorDetail {
+			return m.handleErrorDetailKey(msg)
+		}
+		if m.state == stateActivity {
+			return m.handleActivityKey(msg)
+		}
+		if m.state == stateTwoPane {
+			return m.handleTwoPaneKey(msg)
+		}
+		if m.state == stateKanban {
+			return m.handleKanbanKey(msg)
+		}
+		if m.state == stateAgenda {
+			return m.handleAgendaKey(msg)
+		}
+		if m.state == stateTable {
+			return m.handleTableKey(msg)
+		}
+		if m.state == stateMerge {
+			return m.handleMergeKey(msg)
+		}
+		if m.state == stateBinary {
+			return m.handleBinaryKey(msg)
+		}
+		if m.state == stateDiagram {
+			return m.handleDiagramKey(msg)
+		}
+		if m.state == stateEditor && m.beside != nil && m.beside.focused {
+			var handled bool
+			if m, cmd, handled = m.handleBesideKey(msg); handled {
+				return m, cmd
+			}
+		}
+		if m.state == stateEditor && m.find != nil {
+			var handled bool
+			if m, cmd, handled = m.handleFindKey(msg); handled {
+				return m, cmd
+			}
+		}
+		if m.state == stateFileList && m.quick != nil {
+			var handled bool
+			if m, handled = m.handleQuickFilterKey(msg); handled {
+				return m, nil
+			}
+		}
+		if m.state == stateEditor && !m.readOnly && m.cfg.Editor.AutoPair {
+			var handled bool
+			if m, handled = m.autoPair(msg); handled {
+				return m, nil
+			}
+		}
+		if msg.String() == "f1" && m.status.kind == statusError {
+			return m.showErrorDetail()
+		}
 		switch msg.String() {
 		case "ctrl+c":
+			if m.noteOpen() {
+				return m.quitEditor()
+			}
 			return m, tea.Quit
 		case "esc":
 			switch m.state {
+			case stateGitLog, stateNoteURLs, stateKeywords:
+				m.state = stateEditor
+				m.textarea.Focus()
+				return m, textarea.Blink
+			case stateKeyAdd:
+				return m.leaveKeyPrompt(), nil
+			case stateSmartFolderName:
+				m.input.Blur()
+				m.state = stateSearchResults
+				m.lastList = stateFileList
+				return m, nil
 			case stateEditor:
+				if m.dirty() {
+					m.quitting = false
+					m.state = stateConfirmUnsaved
+					return m, nil
+				}
 				m.state = stateFileList
 				m.textarea.Blur()
 				m = m.refreshFileList()
 				return m, nil
-			case stateVaultCreate, stateVaultOpenPath, stateFileCreate, stateDirCreate, stateConfirmDelete:
+			case stateVaultCreate, stateVaultOpenPath, stateFileCreate, stateTitleCreate, stateExtractNote, statePipeCommand, stateGotoNote, stateLinkNote, stateBesideNote, stateRandomTag, stateTagToggle, stateDirCreate, stateImportPath, stateConfirmDelete,
+				stateTemplateSelect, stateTemplatePrompt, stateSearch, stateSearchResults, stateSettings, stateReminders, stateStale, stateLinkReport, stateJournal, stateVaultScaffold, stateKeys, stateProfiles, stateProfileCreate:
+				from := m.state
 				m.state = m.lastList
 				m.input.Blur()
 				m.pending = nil
+				m.tmpl = nil
+				m.input.ShowSuggestions = false
+				switch {
+				case m.state == stateFileList:
+					m = m.refreshFileList()
+				case m.state == stateVaultSelect && (from == stateSettings || from == stateVaultScaffold || from == stateProfiles):
+					m = m.refreshVaultList()
+				case m.state == stateProfiles:
+					m.lastList = stateVaultSelect
+				}
 				return m, nil
 			}
 		case "n":
-			if m.state == stateConfirmDelete {
+			if m.state == stateConfirmDelete && m.pending != nil && m.pending.confirmName == "" {
 				m.state = m.lastList
 				m.pending = nil
 				return m, nil
 			}
 		case "y":
-			if m.state == stateConfirmDelete {
+			if m.state == stateConfirmDelete && m.pending != nil && m.pending.confirmName == "" {
 				return m.confirmDelete()
 			}
 		case "ctrl+s":
+			if m.state == stateSearchResults {
+				return m.beginSaveSmartFolder()
+			}
 			if m.state == stateEditor {
-				err := os.WriteFile(m.editing, []byte(m.textarea.Value()), 0644)
-				if err != nil {
-					m.status = "Error: " + err.Error()
-				} else {
-					m.status = "Saved: " + relOrBase(m.vault, m.editing)
+				if m.readOnly {
+					m.status = infoStatus("Read-only document: press Ctrl+R to convert to Markdown")
+					return m, nil
 				}
-				return m, nil
+				if m.cfg.Confirm.ReviewSave && m.dirty() {
+					return m.beginReviewSave(), nil
+				}
+				return m.saveFromEditor()
 			}
 		case "ctrl+n":
 			switch m.state {
 			case stateVaultSelect:
-				m = m.enterPrompt(stateVaultCreate, "New vault name")
+				m = m.enterPrompt(stateVaultCreate, tr("New vault name"))
 				return m, textinput.Blink
 			case stateFileList:
-				m = m.enterPrompt(stateFileCreate, "File name: letters and digits only")
+				m = m.enterPrompt(stateFileCreate, tr("File name: letters and digits only"))
+				return m, textinput.Blink
+			}
+		case "ctrl+e":
+			if m.state == stateFileList {
+				m = m.enterPrompt(stateTitleCreate, tr("Note title"))
 				return m, textinput.Blink
 			}
+		case "ctrl+^":
+			if m.state == stateEditor || (m.state == stateFileList && m.editing != "") {
+				return m.switchToPrevious()
+			}
+		case "tab":
+			if m.state == stateEditor && !m.readOnly {
+				if next, ok := m.completeMention(); ok {
+					return next, nil
+				}
+				width := m.cfg.Editor.TabWidth
+				m.textarea.InsertString(strings.Repeat(" ", width-editorColumn(m.textarea)%width))
+				return m, nil
+			}
+		case "f2":
+			if m.state == stateVaultSelect || m.state == stateFileList {
+				m.lastList = m.state
+				m.state = stateSettings
+				m = m.refreshSettingsList()
+				m.list.Select(0)
+				return m, nil
+			}
+		case "ctrl+f":
+			if m.state == stateFileList {
+				m = m.enterPrompt(stateSearch, tr("Search notes in vault"))
+				m.input.SetValue(m.query)
+				m.input.CursorEnd()
+				m, cmd := m.liveSearch()
+				return m, tea.Batch(textinput.Blink, cmd)
+			}
+			if m.state == stateEditor && !m.readOnly {
+				return m.beginFind()
+			}
+		case "ctrl+t":
+			if m.state == stateFileList {
+				return m.enterTemplateSelect()
+			}
+		case "ctrl+r":
+			if m.state == stateEditor && m.readOnly && isOrgFile(m.editing) {
+				return m.convertOrgToMarkdown()
+			}
+			if m.state == stateFileList {
+				return m.openRandomNote("")
+			}
+		case "alt+r":
+			if m.state == stateFileList {
+				return m.beginRandomByTag()
+			}
+		case "alt+e":
+			if m.state == stateFileList {
+				return m.toggleEncryption()
+			}
+		case "alt+k":
+			if m.state == stateFileList {
+				return m.showKeys()
+			}
+		case "alt+b":
+			if m.state == stateFileList {
+				return m.openKanban()
+			}
+		case "alt+i":
+			if m.state == stateFileList {
+				return m.beginImport()
+			}
+		case "alt+x":
+			if m.state == stateFileList {
+				return m.beginEmptyTrash()
+			}
+		case "alt+enter":
+			if m.state == stateImportPath {
+				return m.startImport(m.input.Value(), true)
+			}
+		case "alt+a":
+			if m.state == stateFileList {
+				return m.showAgenda(false)
+			}
 		case "ctrl+o":
 			if m.state == stateVaultSelect {
-				m = m.enterPrompt(stateVaultOpenPath, "Vault path (absolute or relative)")
+				m = m.enterPrompt(stateVaultOpenPath, tr("Vault path (absolute or relative)"))
 				return m, textinput.Blink
 			}
 		case "ctrl+p":
@@ -200,11 +524,156 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			}
 		case "ctrl+d":
 			if m.state == stateFileList {
-				m = m.enterPrompt(stateDirCreate, "New directory name (in current directory)")
+				m = m.enterPrompt(stateDirCreate, tr("New directory name (in current directory)"))
+				return m, textinput.Blink
+			}
+		case "ctrl+a":
+			if m.state == stateFileList {
+				return m.showActivity()
+			}
+		case "ctrl+k":
+			if m.state == stateFileList {
+				return m.showReminders()
+			}
+		case "alt+s":
+			if m.state == stateFileList {
+				return m.showStale()
+			}
+		case "alt+j":
+			if m.state == stateFileList {
+				return m.showJournal()
+			}
+		case "alt+c":
+			if m.state == stateFileList {
+				return m.cycleStatus()
+			}
+			if m.state == stateEditor && !m.readOnly {
+				return m.insertTOC()
+			}
+		case "f3":
+			if m.state == stateFileList {
+				return m.openTwoPane()
+			}
+		case "f4":
+			if m.state == stateVaultSelect {
+				return m.showProfiles()
+			}
+		case "/":
+			if m.state == stateFileList {
+				return m.startQuickFilter()
+			}
+		case ".":
+			if m.state == stateFileList {
+				return m.toggleHidden()
+			}
+		case "ctrl+g":
+			if m.state == stateFileList {
+				m = m.enterPrompt(stateGotoNote, tr("Note ID or name"))
 				return m, textinput.Blink
 			}
+		case "ctrl+l":
+			if m.state == stateEditor && !m.readOnly {
+				return m.beginLinkNote()
+			}
+		case "alt+g":
+			if m.state == stateEditor {
+				return m.toggleBlame()
+			}
+		case "alt+h":
+			if m.state == stateEditor {
+				return m.showGitLog()
+			}
+			if m.state == stateFileList {
+				return m.showLinkReport()
+			}
+		case "ctrl+y":
+			if m.state == stateEditor {
+				return m.copySecret()
+			}
+		case "alt+p":
+			if m.state == stateEditor && !m.readOnly {
+				return m.insertPassword()
+			}
+		case "alt+v":
+			if m.state == stateEditor && !m.readOnly {
+				return m.pasteImage()
+			}
+		case "alt+m":
+			if m.state == stateEditor {
+				return m.showDiagram()
+			}
+		case "alt+n":
+			if m.state == stateEditor && !m.readOnly {
+				return m.beginBeside()
+			}
+		case "alt+d":
+			if m.state == stateEditor && !m.readOnly {
+				return m.switchBesideFocus()
+			}
+		case "alt+,":
+			if m.state == stateFileList || m.state == stateEditor {
+				return m.resizeSplit(-splitStep)
+			}
+		case "alt+.":
+			if m.state == stateFileList || m.state == stateEditor {
+				return m.resizeSplit(splitStep)
+			}
+		case "alt+l":
+			if m.state == stateFileList || m.state == stateEditor {
+				return m.cyclePreset()
+			}
+		case "alt+w":
+			if m.state == stateEditor {
+				return m.showMentions()
+			}
+		case "alt+f":
+			if m.state == stateFileList {
+				return m.revealSelected()
+			}
+		case "alt+o":
+			if m.state == stateEditor {
+				return m.openURLUnderCursor()
+			}
+		case "alt+u":
+			if m.state == stateEditor {
+				return m.showNoteURLs()
+			}
+		case "alt+t":
+			if m.state == stateEditor {
+				return m.showKeywords()
+			}
+			if m.state == stateFileList {
+				return m.showTagToggle()
+			}
+		case "alt+|":
+			if m.state == stateEditor && !m.readOnly {
+				return m.beginPipe()
+			}
+		case "alt+1":
+			if m.state == stateEditor && !m.readOnly {
+				return m.insertDate(m.dates.Date)
+			}
+		case "alt+2":
+			if m.state == stateEditor && !m.readOnly {
+				return m.insertDate(m.dates.Time)
+			}
+		case "alt+3":
+			if m.state == stateEditor && !m.readOnly {
+				return m.insertDate(m.dates.Timestamp)
+			}
+		case "ctrl+@":
+			if m.state == stateEditor && !m.readOnly {
+				pos := editorCursor(m.textarea)
+				m.mark = &pos
+				m.status = infoStatus("Mark set")
+				return m, nil
+			}
 		case "ctrl+x":
 			switch m.state {
+			case stateEditor:
+				if !m.readOnly {
+					return m.beginExtract()
+				}
 			case stateVaultSelect:
 				selected := m.list.SelectedItem()
 				if selected == nil {
@@ -214,32 +683,37 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				if it.mode != "" {
 					return m, nil
 				}
-				m.pending = &deleteTarget{
+				if m.offline[it.path] {
+					return m.forgetVault(it.path)
+				}
+				return m.beginDelete(deleteTarget{
 					path:    it.path,
 					label:   filepath.Base(it.path),
 					isDir:   true,
 					isVault: true,
-				}
-				m.lastList = stateVaultSelect
-				m.state = stateConfirmDelete
-				return m, nil
+				})
 			case stateFileList:
 				selected := m.list.SelectedItem()
 				if selected == nil {
 					return m, nil
 				}
 				it := selected.(item)
-				if it.mode == "up" {
+				if it.mode == "smart" {
+					return m.removeSmartFolder(strings.TrimSuffix(it.title, "/"))
+				}
+				if it.mode != "" {
+					return m, nil
+				}
+				if dir, ok := protectedDir(m.vault, it.path); ok {
+					m.status = warnStatus("%s is an internal folder and cannot be deleted here", dir)
 					return m, nil
 				}
-				m.pending = &deleteTarget{
+				return m.beginDelete(deleteTarget{
 					path:  it.path,
 					label: relOrBase(m.vault, it.path),
 					isDir: it.isDir,
-				}
-				m.lastList = stateFileList
-				m.state = stateConfirmDelete
-				return m, nil
+					link:  it.link,
+				})
 			}
 		case "backspace":
 			if m.state == stateFileList {
@@ -252,24 +726,107 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			}
 			return m.handleEnter()
 		}
+	case blameMsg:
+		return m.applyBlame(msg), nil
+	case gitLogMsg:
+		return m.applyGitLog(msg), nil
+	case activityMsg:
+		return m.applyActivity(msg), nil
+	case remindersMsg:
+		return m.applyReminders(msg)
+	case vaultCheckMsg:
+		return m.applyVaultCheck(msg), nil
+	case liveSearchTickMsg:
+		return m.applyLiveSearchTick(msg)
+	case liveSearchMsg:
+		return m.applyLiveSearch(msg)
+	case staleMsg:
+		return m.applyStale(msg)
+	case journalMsg:
+		return m.applyJournal(msg)
+	case vaultTagsMsg:
+		return m.applyVaultTags(msg)
+	case pipeResultMsg:
+		return m.applyPipe(msg), nil
+	case clipboardClearMsg:
+		clearClipboard(msg.value)
+		return m, nil
+	case dirStatsMsg:
+		return m.applyDirStats(msg), nil
+	case deleteSummaryMsg:
+		return m.applyDeleteSummary(msg), nil
+	case indexReadyMsg:
+		m = m.finishJob(msg.job)
+		if msg.vault != m.vault {
+			return m, nil
+		}
+		m.index = msg.index
+		switch {
+		case msg.err != nil:
+			m.status = failStatus("index not saved: %v", msg.err)
+		case msg.cancelled:
+			m.status = infoStatus("Indexing cancelled: search covers the notes indexed so far")
+		}
+		return m, nil
+	case jobTickMsg:
+		return m.applyJobTick(msg)
+	case deleteDoneMsg:
+		return m.finishDelete(msg), nil
+	case trashPurgedMsg:
+		return m.applyTrashPurged(msg), nil
+	case signatureMsg:
+		return m.applySignature(msg), nil
+	case signedMsg:
+		return m.applySigned(msg), nil
+	case keysRotatedMsg:
+		return m.finishRotate(msg), nil
+	case importDoneMsg:
+		return m.finishImport(msg)
+	case keywordsDoneMsg:
+		return m.finishKeywords(msg)
+	case barTickMsg:
+		return m, tea.Batch(barInfoCmd(m.vault, m.cfg.StatusBar.Segments), barTick())
+	case barInfoMsg:
+		m.bar = barInfo(msg)
+		return m, nil
+	case tea.BlurMsg:
+		return m.saveOnBlur(), nil
 	case tea.WindowSizeMsg:
 		m.windowW = msg.Width
 		m.windowH = msg.Height
+		m = m.updateCompact()
 		m = m.applyResponsiveLayout()
 	}
 
 	m = m.applyResponsiveLayout()
 
 	switch m.state {
-	case stateVaultSelect, stateFileList:
+	case stateVaultSelect, stateFileList, stateTemplateSelect, stateSearchResults, stateSettings, stateGitLog, stateNoteURLs, stateKeywords, stateReminders, stateStale, stateLinkReport, stateJournal, stateVaultScaffold, stateKeys, stateProfiles:
 		m.list, cmd = m.list.Update(msg)
 		cmds = append(cmds, cmd)
+		m = m.loadVisibleDetails()
 	case stateEditor:
+		if keyMsg, ok := msg.(tea.KeyMsg); ok && m.readOnly && !isNavigationKey(keyMsg) {
+			break
+		}
+		if m.find != nil {
+			m.input, cmd = m.input.Update(msg)
+			cmds = append(cmds, cmd)
+			break
+		}
 		m.textarea, cmd = m.textarea.Update(msg)
 		cmds = append(cmds, cmd)
-	case stateVaultCreate, stateVaultOpenPath, stateFileCreate, stateDirCreate:
+	case stateVaultCreate, stateVaultOpenPath, stateFileCreate, stateTitleCreate, stateExtractNote, statePipeCommand, stateGotoNote, stateLinkNote, stateBesideNote, stateRandomTag, stateTagToggle, stateDirCreate, stateTemplatePrompt, stateKeyAdd, stateSmartFolderName, stateImportPath, stateProfileCreate:
 		m.input, cmd = m.input.Update(msg)
 		cmds = append(cmds, cmd)
+	case stateSearch:
+		m, cmd = m.updateSearchInput(msg)
+		cmds = append(cmds, cmd)
+	case stateConfirmDelete:
+		if m.pending != nil && m.pending.confirmName != "" {
+			m.input, cmd = m.input.Update(msg)
+			cmds = append(cmds, cmd)
+		}
 	}
 
 	return m, tea.Batch(cmds...)
@@ -284,22 +841,21 @@ func (m Model) handleEnter() (tea.Model, tea.Cmd) {
 		}
 		it := selected.(item)
 		if it.mode == "create-vault" {
-			m = m.enterPrompt(stateVaultCreate, "New vault name")
+			m = m.enterPrompt(stateVaultCreate, tr("New vault name"))
 			return m, textinput.Blink
 		}
 		if it.mode == "open-vault-path" {
-			m = m.enterPrompt(stateVaultOpenPath, "Vault path (absolute or relative)")
+			m = m.enterPrompt(stateVaultOpenPath, tr("Vault path (absolute or relative)"))
 			return m, textinput.Blink
 		}
 		if it.mode == "open-vault-explorer" {
 			return m.openVaultByExplorer()
 		}
-		m.vault = it.path
-		m.current = it.path
-		m.state = stateFileList
-		m.status = "Vault selected: " + filepath.Base(it.path)
-		m = m.refreshFileList()
-		return m, nil
+		if m.offline[it.path] {
+			return m.openOfflineVault(it.path)
+		}
+		m.status = okStatus("Vault selected: %s", filepath.Base(it.path))
+		return m.enterVault(it.path)
 	case stateFileList:
 		selected := m.list.SelectedItem()
 		if selected == nil {
@@ -310,94 +866,228 @@ func (m Model) handleEnter() (tea.Model, tea.Cmd) {
 			m = m.goParent()
 			return m, nil
 		}
-		if it.isDir {
-			m.current = it.path
+		if it.mode == "smart" {
+			return m.openSmartFolder(it)
+		}
+		if it.mode == "more" {
+			index := m.list.Index()
+			m.limit = maxInt(m.limit, m.cfg.List.PageSize) + m.cfg.List.PageSize
 			m = m.refreshFileList()
+			m.list.Select(index)
+			m = m.loadVisibleDetails()
 			return m, nil
 		}
-		content, err := os.ReadFile(it.path)
-		if err != nil {
-			m.status = "Error: " + err.Error()
+		if !m.allowedPath(it.path) {
+			m.status = failStatus("link points outside the vault")
 			return m, nil
 		}
-		m.editing = it.path
-		m.textarea.SetValue(string(content))
-		m.textarea.Focus()
-		m.state = stateEditor
-		return m, textarea.Blink
+		if it.isDir {
+			m.current = it.path
+			m.limit = 0
+			m = m.refreshFileList()
+			return m, nil
+		}
+		return m.openFile(it.path)
 	case stateVaultCreate:
 		name := strings.TrimSpace(m.input.Value())
 		if name == "" {
-			m.status = "Vault name cannot be empty"
+			m.status = infoStatus("Vault name cannot be empty")
 			return m, nil
 		}
-		path := filepath.Join(vaultStorageRoot(), name)
-		if err := os.Mkdir(path, 0755); err != nil {
-			m.status = "Error: " + err.Error()
+		if _, err := os.Lstat(filepath.Join(vaultStorageRoot(m.cfg.VaultDir), name)); err == nil {
+			m.status = failStatus("already exists: %s", filepath.Join(vaultStorageRoot(m.cfg.VaultDir), name))
 			return m, nil
 		}
-		abs, err := filepath.Abs(path)
-		if err != nil {
-			m.status = "Error: " + err.Error()
-			return m, nil
-		}
-		if err := registerVault(abs); err != nil {
-			m.status = "Vault created, but registry update failed: " + err.Error()
+		return m.enterScaffoldSelect(name)
+	case stateVaultScaffold:
+		selected := m.list.SelectedItem()
+		if selected == nil {
 			return m, nil
 		}
-		m.vault = abs
-		m.current = abs
-		m.state = stateFileList
-		m.status = "Vault created: " + filepath.Base(abs)
-		m = m.refreshFileList()
-		return m, nil
+		return m.createVault(m.newVault, selected.(item).path)
 	case stateVaultOpenPath:
 		return m.openVaultPath(m.input.Value())
 	case stateFileCreate:
 		baseName := strings.TrimSpace(m.input.Value())
 		if baseName == "" {
-			m.status = "File name cannot be empty"
+			m.status = infoStatus("File name cannot be empty")
 			return m, nil
 		}
 		if !isAlnumName(baseName) {
-			m.status = "Invalid file name: use only letters and digits"
+			m.status = infoStatus("Invalid file name: use only letters and digits")
 			return m, nil
 		}
-		name := baseName + ".md"
-		path, err := m.safePath(name)
+		path, err := m.safePath(m.noteName(baseName))
 		if err != nil {
-			m.status = "Error: " + err.Error()
+			m.status = errorStatus(err)
 			return m, nil
 		}
+		if m.tmpl != nil {
+			if _, statErr := os.Stat(path); statErr == nil {
+				m.status = failStatus("file already exists: %s", relOrBase(m.vault, path))
+				return m, nil
+			}
+			m.tmpl.target = path
+			m.tmpl.title = baseName
+			return m.nextTemplatePrompt()
+		}
 		file, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
 		if err != nil {
-			m.status = "Error: " + err.Error()
+			m.status = errorStatus(err)
 			return m, nil
 		}
 		_ = file.Close()
 		m.state = stateFileList
-		m.status = "File created: " + relOrBase(m.vault, path)
+		m.status = okStatus("File created: %s", relOrBase(m.vault, path))
+		m = m.logChange(journalCreate, path, false)
+		m = m.reindex(path)
 		m = m.refreshFileList()
 		return m, nil
+	case stateTitleCreate:
+		title := strings.TrimSpace(m.input.Value())
+		if title == "" {
+			m.status = infoStatus("Title cannot be empty")
+			return m, nil
+		}
+		path, err := m.safePath(m.noteName(slugify(title)))
+		if err != nil {
+			m.status = errorStatus(err)
+			return m, nil
+		}
+		path = uniquePath(path)
+		content := "# " + title + "\n\n"
+		file, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
+		if err != nil {
+			m.status = errorStatus(err)
+			return m, nil
+		}
+		_, err = file.WriteString(content)
+		if closeErr := file.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			m.status = errorStatus(err)
+			return m, nil
+		}
+		m.input.Blur()
+		m.status = okStatus("File created: %s", relOrBase(m.vault, path))
+		m = m.logChange(journalCreate, path, false)
+		m = m.reindex(path)
+		opened, cmd := m.openFile(path)
+		if next, ok := opened.(Model); ok && next.state == stateEditor {
+			setEditorCursor(&next.textarea, cursorPos{row: 2})
+			return next, cmd
+		}
+		return opened, cmd
+	case stateGotoNote:
+		return m.gotoNote(m.input.Value())
+	case stateRandomTag:
+		return m.openRandomNote(m.input.Value())
+	case stateTagToggle:
+		return m.toggleNoteTag(m.input.Value())
+	case stateBesideNote:
+		return m.openBeside(m.input.Value())
+	case stateProfiles:
+		return m.selectProfile()
+	case stateProfileCreate:
+		return m.createProfile(m.input.Value())
+	case stateLinkNote:
+		return m.insertNoteLink(m.input.Value())
+	case stateExtractNote:
+		title := strings.TrimSpace(m.input.Value())
+		if title == "" {
+			m.status = infoStatus("Title cannot be empty")
+			return m, nil
+		}
+		return m.extractNote(title)
+	case statePipeCommand:
+		return m.runPipe(m.input.Value())
 	case stateDirCreate:
 		name := strings.TrimSpace(m.input.Value())
 		if name == "" {
-			m.status = "Directory name cannot be empty"
+			m.status = infoStatus("Directory name cannot be empty")
 			return m, nil
 		}
 		path, err := m.safePath(name)
 		if err != nil {
-			m.status = "Error: " + err.Error()
+			m.status = errorStatus(err)
 			return m, nil
 		}
 		if err := os.MkdirAll(path, 0755); err != nil {
-			m.status = "Error: " + err.Error()
+			m.status = errorStatus(err)
 			return m, nil
 		}
 		m.state = stateFileList
-		m.status = "Directory created: " + relOrBase(m.vault, path)
+		m.status = okStatus("Directory created: %s", relOrBase(m.vault, path))
+		m = m.logChange(journalCreate, path, true)
+		m = m.invalidateDirStats(path)
 		m = m.refreshFileList()
 		return m, nil
+	case stateTemplateSelect:
+		selected := m.list.SelectedItem()
+		if selected == nil {
+			return m, nil
+		}
+		it := selected.(item)
+		content, err := os.ReadFile(it.path)
+		if err != nil {
+			m.status = errorStatus(err)
+			return m, nil
+		}
+		m.tmpl = &templateSession{
+			content: string(content),
+			prompts: templatePrompts(string(content)),
+			answers: make(map[string]string),
+		}
+		m = m.enterPrompt(stateFileCreate, tr("File name: letters and digits only"))
+		m.lastList = stateFileList
+		m.status = infoStatus("Template: %s", it.title)
+		return m, textinput.Blink
+	case stateSearch:
+		return m.runSearch(m.input.Value())
+	case stateSettings:
+		selected := m.list.SelectedItem()
+		if selected == nil {
+			return m, nil
+		}
+		return m.toggleSetting(selected.(item).path)
+	case stateGitLog:
+		m.state = stateEditor
+		m.textarea.Focus()
+		return m, textarea.Blink
+	case stateNoteURLs:
+		return m.openSelectedURL()
+	case stateKeywords:
+		return m.addKeywordTag()
+	case stateKeys:
+		return m.activateKeyItem()
+	case stateKeyAdd:
+		return m.addKeyRecipient(m.input.Value())
+	case stateSmartFolderName:
+		return m.saveSmartFolder(m.input.Value())
+	case stateImportPath:
+		return m.startImport(m.input.Value(), false)
+	case stateJournal:
+		return m.openJournalEntry()
+	case stateSearchResults, stateReminders, stateStale, stateLinkReport:
+		selected := m.list.SelectedItem()
+		if selected == nil {
+			return m, nil
+		}
+		it := selected.(item)
+		m.current = filepath.Dir(it.path)
+		m.lastList = stateFileList
+		return m.openFile(it.path)
+	case stateEditor:
+		return m.editorNewline()
+	case stateTemplatePrompt:
+		if m.tmpl == nil {
+			m.state = stateFileList
+			return m, nil
+		}
+		m.tmpl.answers[m.tmpl.prompts[m.tmpl.index]] = strings.TrimSpace(m.input.Value())
+		m.tmpl.index++
+		return m.nextTemplatePrompt()
 	default:
 		return m, nil
 	}
@@ -405,96 +1095,526 @@ func (m Model) handleEnter() (tea.Model, tea.Cmd) {
 
 func (m Model) View() string {
 	m = m.applyResponsiveLayout()
+	m.status = m.visibleStatus()
 	contentW, _ := m.contentDims()
+	screen := m.screenView(contentW)
+	if bar := m.statusBarView(contentW); bar != "" {
+		screen += "\n" + frameBar(bar)
+	}
+	return centerScreen(screen, m.windowW)
+}
+
+// screenView draws the current screen without the status bar.
+func (m Model) screenView(contentW int) string {
 
 	switch m.state {
 	case stateVaultSelect:
 		return renderScreen(
 			contentW,
-			"Vaults",
-			"Storage: "+shrinkText(vaultStorageRoot(), maxInt(24, contentW-10)),
+			tr("Vaults"),
+			m.vaultSubtitle(contentW),
 			m.list.View(),
-			vaultSelectHints(contentW),
+			m.vaultSelectHints(contentW),
 			m.status,
 		)
 	case stateFileList:
 		return renderScreen(
 			contentW,
-			"Vault: "+filepath.Base(m.vault),
-			"Path: "+shrinkText(relOrDot(m.vault, m.current), maxInt(24, contentW-7)),
-			m.list.View(),
-			fileListHints(contentW),
+			tr("Vault: %s", filepath.Base(m.vault)),
+			tr("Path: %s", shrinkText(relOrDot(m.vault, m.current), maxInt(24, contentW-7))),
+			m.fileListBody(contentW),
+			m.fileListScreenHints(contentW),
 			m.status,
 		)
 	case stateEditor:
+		if m.readOnly {
+			return renderScreen(
+				contentW,
+				tr("Viewing: %s", relOrBase(m.vault, m.editing)),
+				tr("Org document (read-only)"),
+				m.textarea.View(),
+				m.editorHints(),
+				m.status,
+			)
+		}
+		body := m.editorBody(contentW)
+		if m.find != nil {
+			body += "\n" + m.input.View()
+		}
+		subtitle := tr("Markdown editor | %s", m.cursorInfo())
+		if compactMode {
+			subtitle = m.cursorInfo()
+		}
 		return renderScreen(
 			contentW,
-			"Editing: "+relOrBase(m.vault, m.editing),
-			"Markdown editor",
-			m.textarea.View(),
-			"Ctrl+S: save | Esc: back",
+			m.editorTitle()+m.dirtyMark(),
+			subtitle,
+			body,
+			m.editorHints(),
 			m.status,
 		)
 	case stateVaultCreate:
 		return renderScreen(
 			contentW,
-			"Create Vault",
-			"Enter name and press Enter",
+			tr("Create Vault"),
+			tr("Enter name and press Enter"),
 			m.input.View(),
-			"Esc: cancel",
+			tr("Esc: cancel"),
 			m.status,
 		)
-	case stateVaultOpenPath:
+	case stateProfiles:
 		return renderScreen(
 			contentW,
-			"Open Vault By Path",
-			"Enter full or relative folder path",
-			m.input.View(),
-			"Esc: cancel",
+			tr("Profiles"),
+			tr("Each profile has its own vault list and settings"),
+			m.list.View(),
+			tr("Enter: switch | Esc: back"),
 			m.status,
 		)
-	case stateFileCreate:
+	case stateProfileCreate:
 		return renderScreen(
 			contentW,
-			"Create File",
-			"Use only letters and digits, .md is added automatically",
+			tr("New Profile"),
+			tr("Letters, digits, - and _; it starts with no vaults"),
 			m.input.View(),
-			"Esc: cancel",
+			tr("Enter: create and switch | Esc: cancel"),
 			m.status,
 		)
-	case stateDirCreate:
+	case stateVaultScaffold:
+		return renderScreen(
+			contentW,
+			tr("Create Vault"),
+			tr("Folders, templates and a README for a new vault"),
+			m.list.View(),
+			tr("Enter: create vault | Esc: cancel"),
+			m.status,
+		)
+	case stateVaultOpenPath:
 		return renderScreen(
 			contentW,
-			"Create Directory",
-			"Enter a directory name",
+			tr("Open Vault By Path"),
+			tr("Enter full or relative folder path"),
 			m.input.View(),
-			"Esc: cancel",
+			tr("Esc: cancel"),
 			m.status,
 		)
-	case stateConfirmDelete:
+	case stateFileCreate:
+		return renderScreen(
+			contentW,
+			tr("Create File"),
+			tr("Use only letters and digits, .md is added automatically"),
+			m.input.View(),
+			tr("Esc: cancel"),
+			m.status,
+		)
+	case stateTitleCreate:
+		return renderScreen(
+			contentW,
+			tr("Create Note From Title"),
+			tr("The file name is derived from the title, which becomes the first heading"),
+			m.input.View(),
+			tr("Esc: cancel"),
+			m.status,
+		)
+	case stateTagToggle:
+		return renderScreen(
+			contentW,
+			tr("Tags: %s", relOrBase(m.vault, m.tagNote)),
+			m.taggedLine(),
+			m.input.View(),
+			tr("Enter: add or remove | Tab: complete | Esc: done"),
+			m.status,
+		)
+	case stateRandomTag:
+		return renderScreen(
+			contentW,
+			tr("Random Note By Tag"),
+			tr("Picks a note with this tag in %s", relOrDot(m.vault, m.current)),
+			m.input.View(),
+			tr("Enter: open | Esc: cancel"),
+			m.status,
+		)
+	case stateGotoNote, stateLinkNote, stateBesideNote:
+		title := tr("Open Note")
+		switch m.state {
+		case stateLinkNote:
+			title = tr("Insert Link")
+		case stateBesideNote:
+			title = tr("Note Beside")
+		}
+		return renderScreen(
+			contentW,
+			title,
+			tr("Type a note ID (or its start) or part of the file name"),
+			m.input.View(),
+			tr("Enter: pick | Esc: cancel"),
+			m.status,
+		)
+	case stateExtractNote:
+		return renderScreen(
+			contentW,
+			tr("Extract Note"),
+			tr("The selection moves to a new note and is replaced by a link to it"),
+			m.input.View(),
+			tr("Esc: cancel"),
+			m.status,
+		)
+	case statePipeCommand:
+		return renderScreen(
+			contentW,
+			tr("Filter Through Command"),
+			tr("The selection (or the cursor line) is replaced by the command's output"),
+			m.input.View(),
+			tr("Enter: run | Esc: cancel"),
+			m.status,
+		)
+	case stateDirCreate:
+		return renderScreen(
+			contentW,
+			tr("Create Directory"),
+			tr("Enter a directory name"),
+			m.input.View(),
+			tr("Esc: cancel"),
+			m.status,
+		)
+	case stateTemplateSelect:
+		return renderScreen(
+			contentW,
+			tr("New Note From Template"),
+			tr("Templates: %s", templatesDirName+string(os.PathSeparator)),
+			m.list.View(),
+			tr("Enter: use template | Esc: cancel"),
+			m.status,
+		)
+	case stateTemplatePrompt:
+		subtitle := ""
+		if m.tmpl != nil {
+			subtitle = tr("Value %d of %d for %s", m.tmpl.index+1, len(m.tmpl.prompts), relOrBase(m.vault, m.tmpl.target))
+		}
+		return renderScreen(
+			contentW,
+			tr("Fill Template"),
+			subtitle,
+			m.input.View(),
+			tr("Enter: next | Esc: cancel"),
+			m.status,
+		)
+	case stateConfirmUnsaved:
+		question := tr("Save %s before leaving?", relOrBase(m.vault, m.editing))
+		switch {
+		case m.quitting:
+			question = tr("Save %s before quitting?", relOrBase(m.vault, m.editing))
+		case m.switchTo != "":
+			question = tr("Save %s before switching notes?", relOrBase(m.vault, m.editing))
+		}
+		body := ""
+		if m.quickDiff != nil {
+			body = m.quickDiff.summary() + "\n" + m.quickDiff.render(contentW, m.list.Height()-1)
+		}
+		return renderScreen(
+			contentW,
+			tr("Unsaved changes"),
+			question,
+			body,
+			m.unsavedHints(contentW),
+			m.status,
+		)
+	case stateSettings:
+		return renderScreen(
+			contentW,
+			tr("Settings"),
+			tr("Saved to %s", shrinkText(configPath(), maxInt(24, contentW-9))),
+			m.list.View(),
+			tr("Enter: change value | Esc: back"),
+			m.status,
+		)
+	case stateSearch:
+		return renderScreen(
+			contentW,
+			tr("Search Vault"),
+			tr("Words are matched in all notes; the last word may be a prefix. Frontmatter: status = active AND due < today"),
+			m.searchBody(),
+			tr("Enter: open | ↑/↓: select | Esc: cancel"),
+			m.status,
+		)
+	case stateGitLog:
+		return renderScreen(
+			contentW,
+			tr("Git log"),
+			relOrBase(m.vault, m.editing),
+			m.list.View(),
+			tr("Enter/Esc: back to the note"),
+			m.status,
+		)
+	case stateNoteURLs:
+		return renderScreen(
+			contentW,
+			tr("Links"),
+			relOrBase(m.vault, m.editing),
+			m.list.View(),
+			tr("Enter: open in browser | Esc: back to the note"),
+			m.status,
+		)
+	case stateKeywords:
+		return renderScreen(
+			contentW,
+			tr("Keywords"),
+			tr("Ranked by how often they occur here and how rarely elsewhere in the vault"),
+			m.list.View(),
+			tr("Enter: add the tag | Esc: back to the note"),
+			m.status,
+		)
+	case stateSearchResults:
+		return renderScreen(
+			contentW,
+			tr("Search: %s", shrinkText(m.query, maxInt(24, contentW-8))),
+			tr("Vault: %s", filepath.Base(m.vault)),
+			m.list.View(),
+			m.searchResultsHints(),
+			m.status,
+		)
+	case stateTwoPane:
+		return renderScreen(
+			contentW,
+			tr("Vault: %s", filepath.Base(m.vault)),
+			"",
+			m.twoPaneView(contentW, m.list.Height()),
+			twoPaneHints(contentW),
+			m.status,
+		)
+	case stateKanban:
+		return renderScreen(
+			contentW,
+			tr("Board: %s", relOrDot(m.vault, m.board.dir)),
+			tr("Notes grouped by %s", m.board.field),
+			m.kanbanView(contentW, m.list.Height()),
+			kanbanHints(contentW),
+			m.status,
+		)
+	case stateAgenda:
+		subtitle := tr("Tasks, reminders and daily notes for today")
+		if m.agenda.week {
+			subtitle = tr("Tasks, reminders and daily notes for this week")
+		}
+		return renderScreen(
+			contentW,
+			m.agenda.title,
+			subtitle,
+			m.agendaView(contentW, m.list.Height()),
+			agendaHints(contentW),
+			m.status,
+		)
+	case stateDiagram:
+		return renderScreen(
+			contentW,
+			tr("Diagram: %s", relOrBase(m.vault, m.editing)),
+			tr("Mermaid block drawn as text"),
+			m.diagramView(contentW, m.list.Height()),
+			tr("Up/Down: scroll | Esc: back to the note"),
+			m.status,
+		)
+	case stateMerge:
+		return renderScreen(
+			contentW,
+			tr("Merge: %s", relOrBase(m.vault, m.merge.path)),
+			m.mergeSubtitle(),
+			m.mergeBody(contentW, m.list.Height()),
+			mergeHints(contentW),
+			m.status,
+		)
+	case stateTable:
+		return renderScreen(
+			contentW,
+			tr("Viewing: %s", relOrBase(m.vault, m.table.path)),
+			m.tableSubtitle(),
+			m.tableView(contentW, m.list.Height()),
+			tableHints(contentW),
+			m.status,
+		)
+	case stateBinary:
+		return renderScreen(
+			contentW,
+			tr("Binary file: %s", relOrBase(m.vault, m.binary.path)),
+			m.binarySubtitle(),
+			m.binaryDumpView(contentW, m.list.Height()),
+			binaryHints(contentW),
+			m.status,
+		)
+	case stateKeys:
+		return renderScreen(
+			contentW,
+			tr("Encryption Keys"),
+			tr("Vault: %s", filepath.Base(m.vault)),
+			m.list.View(),
+			tr("Enter: select | Esc: back"),
+			m.status,
+		)
+	case stateSmartFolderName:
+		return renderScreen(
+			contentW,
+			tr("Save Smart Folder"),
+			shrinkText(m.query, maxInt(24, contentW)),
+			m.input.View(),
+			tr("Enter: save | Esc: cancel"),
+			m.status,
+		)
+	case stateImportPath:
+		return renderScreen(
+			contentW,
+			tr("Import Markdown Folder"),
+			tr("Copied into a new folder here, with names normalized and links fixed"),
+			m.input.View(),
+			importHints(contentW),
+			m.status,
+		)
+	case stateKeyAdd:
+		return renderScreen(
+			contentW,
+			tr("Add Recipient"),
+			tr("Notes saved from now on can be opened with this key"),
+			m.input.View(),
+			tr("Enter: add | Esc: cancel"),
+			m.status,
+		)
+	case stateReminders:
+		return renderScreen(
+			contentW,
+			tr("Reminders"),
+			tr("Vault: %s", filepath.Base(m.vault)),
+			m.list.View(),
+			tr("Enter: open | Esc: back"),
+			m.status,
+		)
+	case stateStale:
+		return renderScreen(
+			contentW,
+			tr("Stale Notes"),
+			tr("Neither opened nor modified in the last %s", trn("%d month", "%d months", m.cfg.StaleMonths)),
+			m.list.View(),
+			tr("Enter: open | Esc: back"),
+			m.status,
+		)
+	case stateLinkReport:
+		return renderScreen(
+			contentW,
+			tr("Link Report"),
+			tr("Vault: %s", filepath.Base(m.vault)),
+			m.list.View(),
+			tr("Enter: open | Esc: back"),
+			m.status,
+		)
+	case stateJournal:
+		return renderScreen(
+			contentW,
+			tr("Journal"),
+			tr("Notes and folders created, saved, moved or deleted in GoNo"),
+			m.list.View(),
+			tr("Enter: open | Esc: back"),
+			m.status,
+		)
+	case stateConfirmDelete:
 		if m.pending == nil {
 			return renderScreen(
 				contentW,
-				"Delete",
-				"Nothing selected for deletion",
+				tr("Delete"),
+				tr("Nothing selected for deletion"),
 				"",
-				"Esc: back",
+				tr("Esc: back"),
 				m.status,
 			)
 		}
-		target := "file"
+		title := tr("Delete file?")
 		if m.pending.isDir {
-			target = "directory"
+			title = tr("Delete directory?")
 		}
 		if m.pending.isVault {
-			target = "vault"
+			title = tr("Delete vault?")
+		}
+		switch {
+		case m.pending.purge:
+			title = tr("Empty the trash? This cannot be undone.")
+		case m.pending.trash && m.pending.isDir:
+			title = tr("Move directory to trash?")
+		case m.pending.trash:
+			title = tr("Move file to trash?")
+		}
+		if m.pending.guard != "" && !m.pending.isVault {
+			title = tr("Delete protected folder?")
+		}
+		details := m.pending.details()
+		if m.pending.confirmName != "" {
+			body := m.input.View()
+			if details != "" {
+				body = details + "\n\n" + body
+			}
+			return renderScreen(
+				contentW,
+				title,
+				m.pending.label,
+				body,
+				typeNameHints(contentW, m.pending.trash),
+				m.status,
+			)
+		}
+		body := m.pending.label
+		if details != "" {
+			body += "\n\n" + details
 		}
 		return renderScreen(
 			contentW,
-			"Delete "+target+"?",
+			title,
 			"",
-			m.pending.label,
-			deleteHints(contentW),
+			body,
+			deleteHints(contentW, m.pending.trash),
+			m.status,
+		)
+	case stateReviewSave:
+		body := ""
+		if m.quickDiff != nil {
+			body = m.quickDiff.summary() + "\n" + m.quickDiff.render(contentW, m.list.Height()-1)
+		}
+		return renderScreen(
+			contentW,
+			tr("Review changes"),
+			tr("Save these changes to %s?", relOrBase(m.vault, m.editing)),
+			body,
+			reviewSaveHints(contentW),
+			m.status,
+		)
+	case stateConfirmRemoveKey:
+		return renderScreen(
+			contentW,
+			tr("Remove recipient"),
+			m.removeKeyQuestion(),
+			"",
+			removeKeyHints(contentW),
+			m.status,
+		)
+	case stateConfirmOverwrite:
+		return renderScreen(
+			contentW,
+			tr("File changed on disk"),
+			tr("%s was modified outside GoNo. Overwrite it with your version?", relOrBase(m.vault, m.editing)),
+			"",
+			overwriteHints(contentW),
+			m.status,
+		)
+	case stateActivity:
+		return renderScreen(
+			contentW,
+			tr("Writing activity"),
+			tr("Vault: %s", filepath.Base(m.vault)),
+			m.activityView(contentW),
+			tr("Esc: back"),
+			m.status,
+		)
+	case stateErrorDetail:
+		return renderScreen(
+			contentW,
+			tr("Error details"),
+			"",
+			m.errorDetailView(contentW),
+			errorDetailHints(contentW),
 			m.status,
 		)
 	default:
@@ -502,18 +1622,22 @@ func (m Model) View() string {
 	}
 }
 
-func renderScreen(contentW int, title string, subtitle string, body string, hints string, status string) string {
+func renderScreen(contentW int, title string, subtitle string, body string, hints string, status statusLine) string {
 	if contentW < 20 {
 		contentW = 20
 	}
+	if compactMode {
+		title = shortTitle(title, contentW)
+	}
+	hints = fitHints(hints, contentW)
 	parts := make([]string, 0, 5)
-	if strings.TrimSpace(title) != "" {
+	if strings.TrimSpace(title) != "" && !titleInBorder() {
 		parts = append(parts, titleStyle.MaxWidth(contentW).Render(title))
 	}
 	if strings.TrimSpace(subtitle) != "" {
 		parts = append(parts, subtitleStyle.MaxWidth(contentW).Render(subtitle))
 	}
-	if strings.TrimSpace(status) != "" {
+	if strings.TrimSpace(status.text) != "" {
 		parts = append(parts, renderStatus(status, contentW))
 	}
 	if strings.TrimSpace(body) != "" {
@@ -522,25 +1646,64 @@ func renderScreen(contentW int, title string, subtitle string, body string, hint
 	if strings.TrimSpace(hints) != "" {
 		parts = append(parts, hintStyle.MaxWidth(contentW).Render(hints))
 	}
-	content := strings.Join(parts, "\n")
-	return appStyle.Render(panelStyle.Render(content))
+	return framePanel(title, strings.Join(parts, "\n"))
+}
+
+type statusKind int
+
+const (
+	statusInfo statusKind = iota
+	statusOK
+	statusWarn
+	statusError
+)
+
+// statusLine is the message shown under the screen title. The kind picks
+// the style, so rendering does not depend on the (translated) wording.
+type statusLine struct {
+	kind statusKind
+	text string
+	err  error
+}
+
+func infoStatus(format string, args ...any) statusLine {
+	return statusLine{kind: statusInfo, text: tr(format, args...)}
+}
+
+func okStatus(format string, args ...any) statusLine {
+	return statusLine{kind: statusOK, text: tr(format, args...)}
+}
+
+func warnStatus(format string, args ...any) statusLine {
+	return statusLine{kind: statusWarn, text: tr(format, args...)}
 }
 
-func renderStatus(status string, contentW int) string {
-	s := strings.TrimSpace(status)
+func failStatus(format string, args ...any) statusLine {
+	return statusLine{kind: statusError, text: tr("Error: %s", tr(format, args...))}
+}
+
+func errorStatus(err error) statusLine {
+	return statusLine{kind: statusError, text: tr("Error: %s", err.Error()), err: err}
+}
+
+func renderStatus(status statusLine, contentW int) string {
+	s := strings.TrimSpace(status.text)
 	if s == "" {
 		return ""
 	}
 	if contentW < 20 {
 		contentW = 20
 	}
-	lower := strings.ToLower(s)
-	switch {
-	case strings.HasPrefix(s, "Error:"):
+	switch status.kind {
+	case statusError:
+		if lipgloss.Width(s) > contentW {
+			more := " " + tr("(F1: details)")
+			s = runewidth.Truncate(s, maxInt(1, contentW-runewidth.StringWidth(more)), "…") + more
+		}
 		return statusErrStyle.MaxWidth(contentW).Render(s)
-	case strings.Contains(lower, "deleted"):
+	case statusWarn:
 		return statusWarnStyle.MaxWidth(contentW).Render(s)
-	case strings.Contains(lower, "created"), strings.Contains(lower, "saved"), strings.Contains(lower, "selected"):
+	case statusOK:
 		return statusOkStyle.MaxWidth(contentW).Render(s)
 	default:
 		return statusInfoStyle.MaxWidth(contentW).Render(s)
@@ -553,6 +1716,11 @@ type item struct {
 	path  string
 	isDir bool
 	mode  string
+	lazy  bool
+	link  bool
+	tasks bool
+	// status is the note's lifecycle status, shown as a badge.
+	status string
 }
 
 func (i item) Title() string {
@@ -567,32 +1735,31 @@ func (i item) FilterValue() string {
 	return i.title
 }
 
-func getVaults() []list.Item {
+// getVaults lists the registered vaults without checking them; those in
+// offline are marked unreachable (see checkVaultsCmd).
+func getVaults(offline map[string]bool) []list.Item {
 	paths, err := loadVaultRegistry()
 	if err != nil {
 		paths = []string{}
 	}
 
 	var dirs []item
-	var validPaths []string
 	for _, p := range paths {
 		abs, absErr := filepath.Abs(p)
 		if absErr != nil {
 			continue
 		}
-		info, statErr := os.Stat(abs)
-		if statErr != nil || !info.IsDir() {
-			continue
+		desc := tr("Created vault")
+		if offline[abs] {
+			desc = tr("Not reachable: %s", abs)
 		}
-		validPaths = append(validPaths, abs)
 		dirs = append(dirs, item{
 			title: filepath.Base(abs),
-			desc:  "Created vault",
+			desc:  desc,
 			path:  abs,
 			isDir: true,
 		})
 	}
-	_ = saveVaultRegistry(validPaths)
 
 	sort.Slice(dirs, func(i, j int) bool {
 		return strings.ToLower(dirs[i].title) < strings.ToLower(dirs[j].title)
@@ -603,18 +1770,18 @@ func getVaults() []list.Item {
 		items = append(items, d)
 	}
 	items = append(items, item{
-		title: "+ Create new vault",
-		desc:  "Create a new directory and open it as vault",
+		title: tr("+ Create new vault"),
+		desc:  tr("Create a new directory and open it as vault"),
 		mode:  "create-vault",
 	})
 	items = append(items, item{
-		title: "+ Open vault by path",
-		desc:  "Open any existing directory as vault",
+		title: tr("+ Open vault by path"),
+		desc:  tr("Open any existing directory as vault"),
 		mode:  "open-vault-path",
 	})
 	items = append(items, item{
-		title: "+ Open vault in explorer",
-		desc:  "Pick an existing directory in a folder dialog",
+		title: tr("+ Open vault in explorer"),
+		desc:  tr("Pick an existing directory in a folder dialog"),
 		mode:  "open-vault-explorer",
 	})
 	return items
@@ -623,102 +1790,336 @@ func getVaults() []list.Item {
 func (m Model) refreshFileList() Model {
 	files, err := os.ReadDir(m.current)
 	if err != nil {
-		m.status = "Error: " + err.Error()
+		m.status = errorStatus(err)
 		return m
 	}
 
 	entries := make([]item, 0, len(files))
 	for _, file := range files {
+		if isHiddenName(file.Name()) && !m.cfg.List.ShowHidden {
+			continue
+		}
 		p := filepath.Join(m.current, file.Name())
+		if isSignatureFile(p) {
+			continue
+		}
 		entry := item{
 			title: file.Name(),
 			desc:  "",
 			path:  p,
 			isDir: file.IsDir(),
 		}
-		if file.IsDir() {
+		if file.Type()&os.ModeSymlink != 0 {
+			link := inspectLink(m.vault, p)
+			entry.isDir = link.isDir
+			if link.isDir {
+				entry.title = file.Name() + string(os.PathSeparator)
+			}
+			entry.title += " @"
+			entry.desc = link.describe()
+			entry.link = true
+		} else if file.IsDir() {
 			entry.title = file.Name() + string(os.PathSeparator)
-			entry.desc = "Directory"
-		} else {
-			if info, infoErr := file.Info(); infoErr == nil {
-				entry.desc = "Modified: " + info.ModTime().Format("02 Jan 15:04")
+			entry.desc = tr("Directory")
+			if m.dirStats != nil {
+				if st, ok := m.dirStats.stats[p]; ok {
+					entry.desc = st.describe()
+				}
 			}
+		} else {
+			entry.lazy = true
 		}
 		entries = append(entries, entry)
 	}
 
-	sort.Slice(entries, func(i, j int) bool {
-		if entries[i].isDir != entries[j].isDir {
-			return entries[i].isDir
-		}
-		return strings.ToLower(entries[i].title) < strings.ToLower(entries[j].title)
-	})
-
-	items := make([]list.Item, 0, len(entries)+1)
-	if !samePath(m.current, m.vault) {
-		items = append(items, item{
-			title: "..",
-			desc:  "Go to parent directory",
-			path:  filepath.Dir(m.current),
-			isDir: true,
-			mode:  "up",
-		})
+	if m.fileStats == nil || !samePath(m.fileStats.dir, m.current) {
+		m.fileStats = newFileStatCache(m.current)
+	}
+	sortEntries(m.current, entries, m.cfg.List.FrontmatterOrder)
+	sortFiles(entries, m.cfg.List.Sort, m.fileStats)
+	if m.quick != nil && !samePath(m.quick.dir, m.current) {
+		m.quick = nil
+	}
+	total := len(entries)
+	if m.quick != nil {
+		entries = m.quick.filter(entries)
+	}
+
+	limit := m.limit
+	if limit <= 0 {
+		limit = m.cfg.List.PageSize
+	}
+	hidden := 0
+	if len(entries) > limit {
+		hidden = len(entries) - limit
+		entries = entries[:limit]
+	}
+
+	items := make([]list.Item, 0, len(entries)+1)
+	if samePath(m.current, m.vault) && m.quick == nil {
+		items = append(items, smartFolderItems(m.vault)...)
+	}
+	if !samePath(m.current, m.vault) && (m.quick == nil || m.quick.query == "") {
+		items = append(items, item{
+			title: "..",
+			desc:  tr("Go to parent directory"),
+			path:  filepath.Dir(m.current),
+			isDir: true,
+			mode:  "up",
+		})
+	}
+	for _, e := range entries {
+		items = append(items, e)
+	}
+	if hidden > 0 {
+		items = append(items, item{
+			title: tr("... %d more entries", hidden),
+			desc:  tr("Enter: load the next %d", minInt(hidden, m.cfg.List.PageSize)),
+			mode:  "more",
+		})
+	}
+
+	m.list.SetItems(items)
+	m.entries = total
+	m.list.Title = m.fileListTitle()
+	if m.quick != nil {
+		m.list.Title = tr("Filter: %s_ (%d of %d)", m.quick.query, len(entries)+hidden, total)
+	}
+	return m.loadVisibleDetails()
+}
+
+// loadVisibleDetails stats the files on the current list page only, so large
+// directories (or slow network mounts) do not stall while listing.
+func (m Model) loadVisibleDetails() Model {
+	if m.state != stateFileList {
+		return m
+	}
+	visible := m.list.VisibleItems()
+	start, end := m.list.Paginator.GetSliceBounds(len(visible))
+	pending := make(map[string]struct{})
+	for _, li := range visible[start:end] {
+		if it := li.(item); it.lazy {
+			pending[it.path] = struct{}{}
+		}
+	}
+	if len(pending) == 0 {
+		return m
+	}
+	for i, li := range m.list.Items() {
+		it := li.(item)
+		if _, ok := pending[it.path]; !ok {
+			continue
+		}
+		it.lazy = false
+		if info, ok := m.fileStats.lstat(it.path); ok {
+			it.desc = m.fileDetails(it.path, info)
+		}
+		if listIcons != iconsOff {
+			it.tasks = hasOpenTasks(it.path)
+		}
+		it.status = noteStatus(it.path)
+		m.list.SetItem(i, it)
+	}
+	return m
+}
+
+func (m Model) refreshVaultList() Model {
+	m.list.SetItems(getVaults(m.offline))
+	m.list.Title = m.vaultListTitle()
+	return m
+}
+
+func (m Model) enterPrompt(state viewState, placeholder string) Model {
+	m.lastList = m.state
+	m.state = state
+	m.input.SetValue("")
+	m.input.Placeholder = placeholder
+	m.input.ShowSuggestions = false
+	m.input.SetSuggestions(nil)
+	m.input.Focus()
+	return m
+}
+
+func (m Model) enterTemplateSelect() (tea.Model, tea.Cmd) {
+	templates := listTemplates(m.vault)
+	if len(templates) == 0 {
+		m.status = infoStatus("No templates found in %s", templatesDirName+string(os.PathSeparator))
+		return m, nil
+	}
+	m.lastList = stateFileList
+	m.state = stateTemplateSelect
+	m.list.SetItems(templates)
+	m.list.Title = tr("Select template (Enter)")
+	return m, nil
+}
+
+func (m Model) nextTemplatePrompt() (tea.Model, tea.Cmd) {
+	if m.tmpl.index < len(m.tmpl.prompts) {
+		m.state = stateTemplatePrompt
+		m.input.SetValue("")
+		m.input.Placeholder = m.tmpl.prompts[m.tmpl.index]
+		m.input.Focus()
+		m.status = statusLine{kind: statusInfo, text: m.tmpl.prompts[m.tmpl.index]}
+		return m, textinput.Blink
+	}
+
+	content := applyTemplate(m.tmpl.content, m.tmpl.title, m.tmpl.answers)
+	target := m.tmpl.target
+	m.tmpl = nil
+	m.input.Blur()
+	file, err := os.OpenFile(target, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
+	if err != nil {
+		m.state = stateFileList
+		m.status = errorStatus(err)
+		m = m.refreshFileList()
+		return m, nil
+	}
+	_, err = file.WriteString(content)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		m.state = stateFileList
+		m.status = errorStatus(err)
+		m = m.refreshFileList()
+		return m, nil
+	}
+
+	m.textarea.Focus()
+	m = m.setBuffer(target, content, false)
+	m.state = stateEditor
+	m.status = okStatus("File created: %s", relOrBase(m.vault, target))
+	m = m.logChange(journalCreate, target, false)
+	m = m.reindex(target)
+	return m, textarea.Blink
+}
+
+func (m Model) enterVault(path string) (Model, tea.Cmd) {
+	m.vault = path
+	m.current = path
+	m.editing = ""
+	m.prevNote = ""
+	m.beside = nil
+	m.limit = 0
+	m.state = stateFileList
+	m.query = ""
+	m.dirStats = newDirStatsCache()
+	m.dates = vaultDates(path, m.cfg.Dates)
+	_ = rememberLastVault(path)
+	m = m.refreshFileList()
+	m, indexCmd := m.startIndexing()
+	return m, tea.Batch(indexCmd, remindersCmd(path, true), barInfoCmd(path, m.cfg.StatusBar.Segments))
+}
+
+func (m Model) openFile(path string) (tea.Model, tea.Cmd) {
+	if command, ok := m.cfg.openerFor(path); ok {
+		return m.openExternally(path, command)
+	}
+	if isTableFile(path) {
+		return m.openTable(path)
+	}
+	var content []byte
+	var err error
+	if isEncryptedNote(path) {
+		content, err = readEncryptedNote(path, m.cfg.Encryption)
+	} else {
+		content, err = os.ReadFile(path)
+	}
+	if lock, ok, _ := loadPassphraseLock(m.vault); ok && errors.Is(err, errNoIdentity) {
+		if lock.Hint != "" {
+			m.status = warnStatus("No keyfile can open this note; gono emergency-export recovers it with the passphrase (hint: %s)", lock.Hint)
+		} else {
+			m.status = warnStatus("No keyfile can open this note; gono emergency-export recovers it with the passphrase")
+		}
+		return m, nil
+	}
+	if err != nil {
+		m.status = errorStatus(err)
+		return m, nil
+	}
+	text, encoding, ok := decodeText(content)
+	if !ok {
+		return m.openBinary(path, content)
+	}
+	if mv, ok := findMerge(path, text); ok {
+		return m.openMerge(mv)
+	}
+	if err := recordOpened(m.vault, path); err != nil {
+		m.status = errorStatus(err)
+	}
+	m.textarea.Focus()
+	m = m.setBuffer(path, expandTabs(text, m.cfg.Editor.TabWidth), isOrgFile(path))
+	m.encoding = encoding
+	m.state = stateEditor
+	m = m.mentionsStatus(path)
+	if encoding != encodingUTF8 {
+		m.status = infoStatus("Opened as %s, saved as %s", encodingLabel(encoding), encodingLabel(m.saveEncoding()))
+	}
+	return m, tea.Batch(textarea.Blink, verifyNoteCmd(path, text))
+}
+
+// reindex updates the index entry for a created, saved or deleted path and
+// persists the index. Failures are not fatal: the next vault open resyncs.
+func (m Model) reindex(path string) Model {
+	m = m.invalidateDirStats(path)
+	if m.index == nil || !insideVault(m.vault, path) {
+		return m
 	}
-	for _, e := range entries {
-		items = append(items, e)
+	if m.index.indexFile(m.vault, path) {
+		_ = m.index.save(m.vault)
 	}
-
-	m.list.SetItems(items)
-	m.list.Title = "Vault explorer"
 	return m
 }
 
-func (m Model) enterPrompt(state viewState, placeholder string) Model {
-	m.lastList = m.state
-	m.state = state
-	m.input.SetValue("")
-	m.input.Placeholder = placeholder
-	m.input.Focus()
-	return m
+func (m Model) runSearch(query string) (tea.Model, tea.Cmd) {
+	query = strings.TrimSpace(query)
+	if query == "" {
+		m.status = infoStatus("Search query cannot be empty")
+		return m, nil
+	}
+	if q, ok, err := asPropertyQuery(query); ok {
+		if err != nil {
+			m.status = failStatus("query: %v", err)
+			return m, nil
+		}
+		return m.runPropertySearch(query, q, tr("Notes where %s", query))
+	}
+	return m.openSearchHit()
 }
 
 func (m Model) openVaultPath(rawPath string) (tea.Model, tea.Cmd) {
 	cleanPath := strings.Trim(strings.TrimSpace(rawPath), "\"'")
 	if cleanPath == "" {
-		m.status = "Vault path cannot be empty"
+		m.status = infoStatus("Vault path cannot be empty")
 		return m, nil
 	}
 	abs, err := filepath.Abs(cleanPath)
 	if err != nil {
-		m.status = "Error: " + err.Error()
+		m.status = errorStatus(err)
 		return m, nil
 	}
 	info, err := os.Stat(abs)
 	if err != nil {
-		m.status = "Error: cannot access this path"
+		m.status = failStatus("cannot access this path")
 		return m, nil
 	}
 	if !info.IsDir() {
-		m.status = "Error: path must point to a directory"
+		m.status = failStatus("path must point to a directory")
 		return m, nil
 	}
 
-	m.vault = abs
-	m.current = abs
-	m.state = stateFileList
-	m.status = "Vault selected: " + filepath.Base(abs)
-	m = m.refreshFileList()
-	return m, nil
+	m.status = okStatus("Vault selected: %s", filepath.Base(abs))
+	return m.enterVault(abs)
 }
 
 func (m Model) openVaultByExplorer() (tea.Model, tea.Cmd) {
 	path, err := pickFolderInExplorer()
 	if err != nil {
 		if errors.Is(err, errFolderDialogCanceled) {
-			m.status = "Vault selection canceled"
+			m.status = infoStatus("Vault selection canceled")
 			return m, nil
 		}
-		m.status = "Error: " + err.Error()
+		m.status = errorStatus(err)
 		return m, nil
 	}
 	return m.openVaultPath(path)
@@ -729,38 +2130,497 @@ func (m Model) applyResponsiveLayout() Model {
 
 	m.input.Width = inputWidth(contentW)
 
+	collapsedHints = m.helpHint()
+
 	reserved := 0
 	switch m.state {
 	case stateVaultSelect:
-		reserved = reserved + 1 + 1 + wrappedLineCount(vaultSelectHints(contentW), contentW)
+		m.list.Title = m.vaultListTitle()
+		reserved = reserved + 1 + 1 + hintLines(m.vaultSelectHints(contentW), contentW)
 	case stateFileList:
-		reserved = reserved + 1 + 1 + wrappedLineCount(fileListHints(contentW), contentW)
+		reserved = reserved + 1 + 1 + hintLines(m.fileListScreenHints(contentW), contentW)
 	case stateEditor:
-		reserved = reserved + 1 + 1 + wrappedLineCount("Ctrl+S: save | Esc: back", contentW)
+		reserved = reserved + 1 + 1 + hintLines(m.editorHints(), contentW)
+		if m.find != nil {
+			reserved++
+		}
 	case stateVaultCreate:
-		reserved = reserved + 1 + 1 + wrappedLineCount("Esc: cancel", contentW)
+		reserved = reserved + 1 + 1 + hintLines(tr("Esc: cancel"), contentW)
+	case stateVaultScaffold:
+		reserved = reserved + 1 + 1 + hintLines(tr("Enter: create vault | Esc: cancel"), contentW)
+	case stateProfiles:
+		reserved = reserved + 1 + 1 + hintLines(tr("Enter: switch | Esc: back"), contentW)
+	case stateProfileCreate:
+		reserved = reserved + 1 + 1 + hintLines(tr("Enter: create and switch | Esc: cancel"), contentW)
 	case stateVaultOpenPath:
-		reserved = reserved + 1 + 1 + wrappedLineCount("Esc: cancel", contentW)
+		reserved = reserved + 1 + 1 + hintLines(tr("Esc: cancel"), contentW)
 	case stateFileCreate:
-		reserved = reserved + 1 + 1 + wrappedLineCount("Esc: cancel", contentW)
+		reserved = reserved + 1 + 1 + hintLines(tr("Esc: cancel"), contentW)
+	case stateRandomTag:
+		reserved = reserved + 1 + 1 + hintLines(tr("Enter: open | Esc: cancel"), contentW)
+	case stateTagToggle:
+		reserved = reserved + 1 + 1 + hintLines(tr("Enter: add or remove | Tab: complete | Esc: done"), contentW)
+	case stateGotoNote, stateLinkNote, stateBesideNote:
+		reserved = reserved + 1 + 1 + hintLines(tr("Enter: pick | Esc: cancel"), contentW)
+	case statePipeCommand:
+		reserved = reserved + 1 + 1 + hintLines(tr("Enter: run | Esc: cancel"), contentW)
+	case stateTitleCreate, stateExtractNote:
+		reserved = reserved + 1 + 1 + hintLines(tr("Esc: cancel"), contentW)
 	case stateDirCreate:
-		reserved = reserved + 1 + 1 + wrappedLineCount("Esc: cancel", contentW)
+		reserved = reserved + 1 + 1 + hintLines(tr("Esc: cancel"), contentW)
+	case stateTemplateSelect:
+		reserved = reserved + 1 + 1 + hintLines(tr("Enter: use template | Esc: cancel"), contentW)
+	case stateTemplatePrompt:
+		reserved = reserved + 1 + 1 + hintLines(tr("Enter: next | Esc: cancel"), contentW)
+	case stateSettings:
+		reserved = reserved + 1 + 1 + hintLines(tr("Enter: change value | Esc: back"), contentW)
+	case stateConfirmUnsaved:
+		reserved = reserved + 1 + 1 + hintLines(m.unsavedHints(contentW), contentW)
+	case stateSearch:
+		// The query input and a blank line sit above the results.
+		reserved = reserved + 1 + 1 + 2 + hintLines(tr("Enter: open | ↑/↓: select | Esc: cancel"), contentW)
+	case stateSearchResults:
+		reserved = reserved + 1 + 1 + hintLines(m.searchResultsHints(), contentW)
+	case stateReminders, stateStale, stateLinkReport, stateJournal:
+		reserved = reserved + 1 + 1 + hintLines(tr("Enter: open | Esc: back"), contentW)
+	case stateGitLog:
+		reserved = reserved + 1 + 1 + hintLines(tr("Enter/Esc: back to the note"), contentW)
+	case stateNoteURLs:
+		reserved = reserved + 1 + 1 + hintLines(tr("Enter: open in browser | Esc: back to the note"), contentW)
+	case stateKeywords:
+		reserved = reserved + 1 + 1 + hintLines(tr("Enter: add the tag | Esc: back to the note"), contentW)
+	case stateKeys:
+		reserved = reserved + 1 + 1 + hintLines(tr("Enter: select | Esc: back"), contentW)
+	case stateKeyAdd:
+		reserved = reserved + 1 + 1 + hintLines(tr("Enter: add | Esc: cancel"), contentW)
+	case stateSmartFolderName:
+		reserved = reserved + 1 + 1 + hintLines(tr("Enter: save | Esc: cancel"), contentW)
+	case stateImportPath:
+		reserved = reserved + 1 + 1 + hintLines(importHints(contentW), contentW)
 	case stateConfirmDelete:
-		reserved = reserved + 1 + wrappedLineCount(deleteHints(contentW), contentW)
-	}
-	if strings.TrimSpace(m.status) != "" {
-		reserved = reserved + wrappedLineCount(m.status, contentW)
+		if m.pending != nil && m.pending.confirmName != "" {
+			reserved = reserved + 1 + 1 + hintLines(typeNameHints(contentW, m.pending.trash), contentW)
+		} else {
+			reserved = reserved + 1 + hintLines(deleteHints(contentW, m.pending != nil && m.pending.trash), contentW)
+		}
+	case stateConfirmOverwrite:
+		reserved = reserved + 1 + 1 + hintLines(overwriteHints(contentW), contentW)
+	case stateConfirmRemoveKey:
+		reserved = reserved + 1 + 1 + hintLines(removeKeyHints(contentW), contentW)
+	case stateReviewSave:
+		reserved = reserved + 1 + 1 + hintLines(reviewSaveHints(contentW), contentW)
+	case stateErrorDetail:
+		reserved = reserved + 1 + 1 + hintLines(errorDetailHints(contentW), contentW)
+	case stateActivity:
+		reserved = reserved + 1 + 1 + hintLines(tr("Esc: back"), contentW)
+	case stateTwoPane:
+		reserved = reserved + 1 + hintLines(twoPaneHints(contentW), contentW)
+	case stateKanban:
+		reserved = reserved + 1 + 1 + hintLines(kanbanHints(contentW), contentW)
+	case stateAgenda:
+		reserved = reserved + 1 + 1 + hintLines(agendaHints(contentW), contentW)
+	case stateDiagram:
+		reserved = reserved + 1 + 1 + hintLines(tr("Up/Down: scroll | Esc: back to the note"), contentW)
+	case stateTable:
+		reserved = reserved + 1 + 1 + hintLines(tableHints(contentW), contentW)
+	case stateMerge:
+		reserved = reserved + 1 + 1 + hintLines(mergeHints(contentW), contentW)
+	case stateBinary:
+		reserved = reserved + 1 + 1 + hintLines(binaryHints(contentW), contentW)
+	}
+	if status := m.visibleStatus(); strings.TrimSpace(status.text) != "" {
+		reserved = reserved + wrappedLineCount(status.text, contentW)
+	}
+	if m.statusBarView(contentW) != "" {
+		reserved++
+	}
+	if titleInBorder() {
+		reserved--
 	}
 	reserved = reserved + 2
 
 	bodyH := maxInt(4, contentH-reserved)
 
-	m.list.SetSize(contentW, bodyH)
-	m.textarea.SetWidth(contentW)
+	listW, _, split := m.splitWidths(contentW)
+	if !split {
+		listW = contentW
+	}
+	m.list.SetSize(listW, bodyH)
+	m.textarea.SetWidth(m.textarea.MaxWidth)
 	m.textarea.SetHeight(maxInt(5, bodyH))
 	return m
 }
 
+// editorWidth returns the width the editor is drawn in: the content width,
+// narrowed to the line guide when one is set. The textarea itself is always
+// as wide as it can be, so it never wraps; see wrappedView.
+func (m Model) editorWidth(contentW int) int {
+	if guide := m.cfg.Editor.LineGuide; guide > 0 {
+		gutter := m.wrapGutter()
+		if guide+gutter < contentW {
+			return guide + gutter
+		}
+	}
+	return contentW
+}
+
+// editorView draws the editor, clipped to the content width.
+func (m Model) editorView(contentW int) string {
+	return lipgloss.NewStyle().MaxWidth(contentW).Render(m.withLinkPeek(m.wrappedView(contentW), contentW))
+}
+
+func (m Model) cursorInfo() string {
+	col := editorColumn(m.textarea) + 1
+	info := tr("Ln %d, Col %d", m.textarea.Line()+1, col)
+	if guide := m.cfg.Editor.LineGuide; guide > 0 && col > guide {
+		info += " " + tr("(past %d)", guide)
+	}
+	if m.lineEnd == lineEndingCRLF {
+		info += " | CRLF"
+	}
+	if goal := m.goalInfo(); goal != "" {
+		info += " | " + goal
+	}
+	if sig := m.signatureInfo(); sig != "" {
+		info += " | " + sig
+	}
+	return info
+}
+
+func (m Model) dirty() bool {
+	return !m.readOnly && m.editing != "" && m.textarea.Value() != m.saved
+}
+
+func (m Model) dirtyMark() string {
+	if m.dirty() {
+		return " " + tr("[modified]")
+	}
+	return ""
+}
+
+// saveBuffer writes the buffer unless the file was changed by another
+// program in the meantime and save conflicts are confirmed, in which case it
+// returns errSaveConflict.
+func (m Model) saveBuffer() (Model, error) {
+	if m.cfg.Confirm.SaveConflict != confirmOff && m.changedOnDisk() {
+		return m, errSaveConflict
+	}
+	return m.writeBuffer()
+}
+
+// resolveBufferDates replaces natural-language dates in the buffer the way
+// saving does.
+func (m Model) resolveBufferDates() Model {
+	if resolved, n := resolveNaturalDates(m.textarea.Value(), time.Now()); n > 0 {
+		pos := editorCursor(m.textarea)
+		m.textarea.SetValue(resolved)
+		setEditorCursor(&m.textarea, pos)
+	}
+	return m
+}
+
+func (m Model) writeBuffer() (Model, error) {
+	m = m.resolveBufferDates()
+	encoding := m.saveEncoding()
+	data, err := encodeText(withLineEnding(m.bufferForDisk(), m.lineEnd), encoding)
+	if err != nil {
+		return m, err
+	}
+	if isEncryptedNote(m.editing) {
+		err = writeEncryptedNote(m.vault, m.editing, data)
+	} else {
+		perm := os.FileMode(0644)
+		if info, statErr := os.Stat(m.editing); statErr == nil {
+			perm = info.Mode().Perm()
+		}
+		err = writeFileAtomic(m.editing, data, perm)
+	}
+	if err != nil {
+		return m, err
+	}
+	m.encoding = encoding
+	m = m.recordWords()
+	m.saved = m.textarea.Value()
+	m = m.logChange(journalSave, m.editing, false)
+	if info, err := os.Stat(m.editing); err == nil {
+		m.diskMod = info.ModTime()
+	}
+	m = m.reindex(m.editing)
+	m = m.signSaved()
+	return m, nil
+}
+
+// quitEditor quits from the editor or a screen opened over it, asking about
+// unsaved changes first, or saving them with save_all_on_exit.
+func (m Model) quitEditor() (tea.Model, tea.Cmd) {
+	if !m.dirty() {
+		return m, tea.Quit
+	}
+	m.state = stateEditor
+	m.input.Blur()
+	m.textarea.Focus()
+	if m.cfg.SaveAllOnExit {
+		saved, err := m.saveBuffer()
+		if err != nil {
+			m.status = errorStatus(err)
+			return m, nil
+		}
+		return saved.quitAfterSigning()
+	}
+	m.quitting = true
+	m.state = stateConfirmUnsaved
+	return m, nil
+}
+
+// handleUnsavedKey resolves the save/discard/cancel prompt shown when the
+// editor is left or the app is quit with a modified buffer.
+func (m Model) handleUnsavedKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "ctrl+c":
+		return m, tea.Quit
+	case "esc", "c":
+		m.state = stateEditor
+		m.quitting = false
+		m.switchTo = ""
+		return m, nil
+	case "v":
+		if m.quickDiff != nil {
+			m.quickDiff = nil
+		} else {
+			m.quickDiff = m.bufferDiff()
+		}
+		return m, nil
+	case "s", "y", "enter":
+		saved, err := m.saveBuffer()
+		if err != nil {
+			m.status = errorStatus(err)
+			m.state = stateEditor
+			return m, nil
+		}
+		m = saved
+		m.status = okStatus("Saved: %s", relOrBase(m.vault, m.editing))
+	case "d", "n":
+		m.saved = m.textarea.Value()
+		m.status = infoStatus("Changes discarded: %s", relOrBase(m.vault, m.editing))
+	default:
+		if m.quickDiff != nil {
+			m.quickDiff.scroll(msg, maxInt(1, m.list.Height()-1))
+		}
+		return m, nil
+	}
+	if m.quitting {
+		return m.quitAfterSigning()
+	}
+	if m.switchTo != "" {
+		target := m.switchTo
+		m.switchTo = ""
+		return m.openFile(target)
+	}
+	m.state = stateFileList
+	m.textarea.Blur()
+	m = m.refreshFileList()
+	return m, nil
+}
+
+// bufferForDisk returns the editor contents as they should be written,
+// restoring tab indentation when the editor is configured to use tabs.
+func (m Model) bufferForDisk() string {
+	return m.diskText(m.textarea.Value())
+}
+
+// diskText converts editor text (always indented with spaces) to the
+// configured indentation.
+func (m Model) diskText(value string) string {
+	if !m.cfg.Editor.ExpandTabs {
+		value = unexpandIndent(value, m.cfg.Editor.TabWidth)
+	}
+	return value
+}
+
+func (m Model) refreshSettingsList() Model {
+	onOff := func(v bool) string {
+		if v {
+			return tr("On")
+		}
+		return tr("Off")
+	}
+	guide := tr("Off")
+	if m.cfg.Editor.LineGuide > 0 {
+		guide = tr("%d columns", m.cfg.Editor.LineGuide)
+	}
+	peek := tr("Off")
+	if m.cfg.Editor.LinkPreview > 0 {
+		peek = trn("%d line", "%d lines", m.cfg.Editor.LinkPreview)
+	}
+	indent := tr("Spaces")
+	if !m.cfg.Editor.ExpandTabs {
+		indent = tr("Tabs")
+	}
+	items := []list.Item{
+		item{title: tr("Theme"), desc: themeLabel(m.cfg.Theme), path: "theme", mode: "setting"},
+		item{title: tr("Terminal background"), desc: backgroundLabel(m.cfg.Background), path: "background", mode: "setting"},
+		item{title: tr("File list icons"), desc: iconsLabel(m.cfg.List.Icons), path: "list.icons", mode: "setting"},
+		item{title: tr("Sort files by"), desc: fileSortLabel(m.cfg.List.Sort), path: "list.sort", mode: "setting"},
+		item{title: tr("Mark notes as large above"), desc: largeNoteLabel(m.cfg.List.LargeNoteKB), path: "list.large_note_kb", mode: "setting"},
+		item{title: tr("Show at startup"), desc: startupLabel(m.cfg.Startup.View), path: "startup.view", mode: "setting"},
+		item{title: tr("Tab width"), desc: strconv.Itoa(m.cfg.Editor.TabWidth), path: "tab_width", mode: "setting"},
+		item{title: tr("Indent with"), desc: indent, path: "expand_tabs", mode: "setting"},
+		item{title: tr("Soft wrap"), desc: onOff(m.cfg.Editor.SoftWrap), path: "soft_wrap", mode: "setting"},
+		item{title: tr("Line numbers"), desc: onOff(m.cfg.Editor.LineNumbers), path: "line_numbers", mode: "setting"},
+		item{title: tr("Line length guide"), desc: guide, path: "line_guide", mode: "setting"},
+		item{title: tr("Preview of the linked note under the cursor"), desc: peek, path: "editor.link_preview", mode: "setting"},
+		item{title: tr("Daily writing goal"), desc: dailyGoalLabel(m.cfg.Editor.DailyGoal), path: "editor.daily_goal", mode: "setting"},
+		item{title: tr("Save when leaving the editor"), desc: onOff(m.cfg.Editor.AutoSave), path: "editor.autosave", mode: "setting"},
+		item{title: tr("Close Markdown pairs as you type"), desc: onOff(m.cfg.Editor.AutoPair), path: "editor.auto_pair", mode: "setting"},
+		item{title: tr("Continue indentation and quotes on Enter"), desc: onOff(m.cfg.Editor.AutoIndent), path: "editor.auto_indent", mode: "setting"},
+		item{title: tr("Flush saves to disk (fsync)"), desc: onOff(m.cfg.Editor.Fsync), path: "editor.fsync", mode: "setting"},
+		item{title: tr("Save legacy encodings as"), desc: saveEncodingLabel(m.cfg.Editor.SaveEncoding), path: "editor.save_encoding", mode: "setting"},
+		item{title: tr("Line endings of new notes"), desc: lineEndingLabel(m.cfg.Editor.LineEndings), path: "editor.line_endings", mode: "setting"},
+		item{title: tr("Save unsaved changes on quit"), desc: onOff(m.cfg.SaveAllOnExit), path: "save_all_on_exit", mode: "setting"},
+		item{title: tr("Prefix new notes with a timestamp ID"), desc: onOff(m.cfg.ZettelIDs), path: "zettel_ids", mode: "setting"},
+		item{title: tr("Desktop notification for due reminders"), desc: onOff(m.cfg.Reminders.Notify), path: "reminders.notify", mode: "setting"},
+		item{title: tr("Stale notes after"), desc: trn("%d month", "%d months", m.cfg.StaleMonths), path: "stale_months", mode: "setting"},
+		item{title: tr("Follow links leaving the vault"), desc: onOff(m.cfg.FollowExternalLinks), path: "follow_external_links", mode: "setting"},
+		item{title: tr("Move deleted notes to the trash"), desc: onOff(m.cfg.Trash.Enabled), path: "trash.enabled", mode: "setting"},
+		item{title: tr("Keep deleted notes in the trash for"), desc: trashRetentionLabel(m.cfg.Trash.RetentionDays), path: "trash.retention_days", mode: "setting"},
+		item{title: tr("Trash size limit"), desc: trashSizeLabel(m.cfg.Trash.MaxSizeMB), path: "trash.max_size_mb", mode: "setting"},
+		item{title: tr("Sign notes on save (gpg)"), desc: signNotesLabel(m.cfg.Signing.Notes), path: "signing.notes", mode: "setting"},
+		item{title: tr("Confirm file deletion"), desc: confirmLabel(m.cfg.Confirm.DeleteFile), path: "confirm.delete_file", mode: "setting"},
+		item{title: tr("Confirm directory deletion"), desc: confirmLabel(m.cfg.Confirm.DeleteDir), path: "confirm.delete_dir", mode: "setting"},
+		item{title: tr("Confirm vault deletion"), desc: confirmLabel(m.cfg.Confirm.DeleteVault), path: "confirm.delete_vault", mode: "setting"},
+		item{title: tr("Confirm overwriting files changed on disk"), desc: confirmLabel(m.cfg.Confirm.SaveConflict), path: "confirm.save_conflict", mode: "setting"},
+		item{title: tr("Show changes when leaving an unsaved note"), desc: onOff(m.cfg.Confirm.ShowDiff), path: "confirm.show_diff", mode: "setting"},
+		item{title: tr("Review changes before saving (Ctrl+S)"), desc: onOff(m.cfg.Confirm.ReviewSave), path: "confirm.review_save", mode: "setting"},
+	}
+	items = append(items,
+		item{title: tr("Panel border"), desc: borderLabel(m.cfg.Layout.Border), path: "layout.border", mode: "setting"},
+		item{title: tr("Screen title in the border"), desc: onOff(m.cfg.Layout.Title), path: "layout.title_in_border", mode: "setting"},
+		item{title: tr("Padding"), desc: strconv.Itoa(m.cfg.Layout.PaddingX), path: "layout.padding_x", mode: "setting"},
+		item{title: tr("Maximum width"), desc: maxWidthLabel(m.cfg.Layout.MaxWidth), path: "layout.max_width", mode: "setting"},
+		item{title: tr("List beside the editor"), desc: splitLabel(m.cfg.Layout.Split), path: "layout.split", mode: "setting"},
+		item{title: tr("Layout preset"), desc: presetLabel(m.cfg.Layout.Preset), path: "layout.preset", mode: "setting"},
+		item{title: tr("Compact layout"), desc: compactLabel(m.cfg.Layout.Compact), path: "layout.compact", mode: "setting"},
+	)
+	for _, name := range barSegments {
+		items = append(items, item{
+			title: tr("Status bar: %s", segmentLabel(name)),
+			desc:  onOff(hasSegment(m.cfg.StatusBar.Segments, name)),
+			path:  "status_bar." + name,
+			mode:  "setting",
+		})
+	}
+	m.list.SetItems(items)
+	m.list.Title = tr("Settings")
+	return m
+}
+
+func (m Model) toggleSetting(key string) (tea.Model, tea.Cmd) {
+	switch key {
+	case "theme":
+		m.cfg.Theme = nextTheme(m.cfg.Theme)
+		applyTheme(m.cfg.Theme)
+		styleComponents(&m.list, &m.input, &m.textarea)
+	case "background":
+		m.cfg.Background = nextBackground(m.cfg.Background)
+		applyBackground(m.cfg.Background)
+	case "list.icons":
+		m.cfg.List.Icons = nextIcons(m.cfg.List.Icons)
+		listIcons = m.cfg.List.Icons
+	case "list.sort":
+		m.cfg.List.Sort = nextFileSort(m.cfg.List.Sort)
+	case "list.large_note_kb":
+		m.cfg.List.LargeNoteKB = nextChoice(largeNoteChoices, m.cfg.List.LargeNoteKB)
+	case "startup.view":
+		m.cfg.Startup.View = nextStartupView(m.cfg.Startup.View)
+	case "tab_width":
+		m.cfg.Editor.TabWidth = nextChoice(tabWidthChoices, m.cfg.Editor.TabWidth)
+	case "expand_tabs":
+		m.cfg.Editor.ExpandTabs = !m.cfg.Editor.ExpandTabs
+	case "soft_wrap":
+		m.cfg.Editor.SoftWrap = !m.cfg.Editor.SoftWrap
+	case "line_numbers":
+		m.cfg.Editor.LineNumbers = !m.cfg.Editor.LineNumbers
+	case "line_guide":
+		m.cfg.Editor.LineGuide = nextChoice(lineGuideChoices, m.cfg.Editor.LineGuide)
+	case "editor.link_preview":
+		m.cfg.Editor.LinkPreview = nextChoice(linkPreviewChoices, m.cfg.Editor.LinkPreview)
+	case "signing.notes":
+		m.cfg.Signing.Notes = nextSignNotes(m.cfg.Signing.Notes)
+	case "trash.enabled":
+		m.cfg.Trash.Enabled = !m.cfg.Trash.Enabled
+	case "trash.retention_days":
+		m.cfg.Trash.RetentionDays = nextChoice(trashRetentionChoices, m.cfg.Trash.RetentionDays)
+	case "trash.max_size_mb":
+		m.cfg.Trash.MaxSizeMB = nextChoice(trashSizeChoices, m.cfg.Trash.MaxSizeMB)
+	case "editor.daily_goal":
+		m.cfg.Editor.DailyGoal = nextChoice(dailyGoalChoices, m.cfg.Editor.DailyGoal)
+		m.words = nil
+	case "editor.autosave":
+		m.cfg.Editor.AutoSave = !m.cfg.Editor.AutoSave
+	case "editor.auto_pair":
+		m.cfg.Editor.AutoPair = !m.cfg.Editor.AutoPair
+	case "editor.auto_indent":
+		m.cfg.Editor.AutoIndent = !m.cfg.Editor.AutoIndent
+	case "editor.fsync":
+		m.cfg.Editor.Fsync = !m.cfg.Editor.Fsync
+		syncWrites = m.cfg.Editor.Fsync
+	case "editor.save_encoding":
+		m.cfg.Editor.SaveEncoding = nextSaveEncoding(m.cfg.Editor.SaveEncoding)
+	case "editor.line_endings":
+		m.cfg.Editor.LineEndings = nextLineEnding(m.cfg.Editor.LineEndings)
+	case "save_all_on_exit":
+		m.cfg.SaveAllOnExit = !m.cfg.SaveAllOnExit
+	case "reminders.notify":
+		m.cfg.Reminders.Notify = !m.cfg.Reminders.Notify
+	case "stale_months":
+		m.cfg.StaleMonths = nextChoice(staleMonthsChoices, m.cfg.StaleMonths)
+	case "follow_external_links":
+		m.cfg.FollowExternalLinks = !m.cfg.FollowExternalLinks
+	case "zettel_ids":
+		m.cfg.ZettelIDs = !m.cfg.ZettelIDs
+	case "confirm.delete_file":
+		m.cfg.Confirm.DeleteFile = nextConfirmLevel(deleteConfirmChoices, m.cfg.Confirm.DeleteFile)
+	case "confirm.delete_dir":
+		m.cfg.Confirm.DeleteDir = nextConfirmLevel(deleteConfirmChoices, m.cfg.Confirm.DeleteDir)
+	case "confirm.delete_vault":
+		m.cfg.Confirm.DeleteVault = nextConfirmLevel(deleteConfirmChoices, m.cfg.Confirm.DeleteVault)
+	case "confirm.save_conflict":
+		m.cfg.Confirm.SaveConflict = nextConfirmLevel(saveConfirmChoices, m.cfg.Confirm.SaveConflict)
+	case "confirm.show_diff":
+		m.cfg.Confirm.ShowDiff = !m.cfg.Confirm.ShowDiff
+	case "confirm.review_save":
+		m.cfg.Confirm.ReviewSave = !m.cfg.Confirm.ReviewSave
+	case "layout.border":
+		m.cfg.Layout.Border = nextBorder(m.cfg.Layout.Border)
+	case "layout.title_in_border":
+		m.cfg.Layout.Title = !m.cfg.Layout.Title
+	case "layout.padding_x":
+		m.cfg.Layout.PaddingX = nextChoice(paddingChoices, m.cfg.Layout.PaddingX)
+	case "layout.max_width":
+		m.cfg.Layout.MaxWidth = nextChoice(maxWidthChoices, m.cfg.Layout.MaxWidth)
+	case "layout.split":
+		m.cfg.Layout.Split = nextChoice(splitChoices, m.cfg.Layout.Split)
+	case "layout.preset":
+		m = m.applyPreset(nextPreset(m.cfg.Layout.Presets, m.cfg.Layout.Preset))
+	case "layout.compact":
+		m.cfg.Layout.Compact = nextCompact(m.cfg.Layout.Compact)
+	default:
+		if name, ok := strings.CutPrefix(key, "status_bar."); ok {
+			m.cfg.StatusBar.Segments = toggleSegment(m.cfg.StatusBar.Segments, name)
+		}
+	}
+	applyLayout(m.cfg.Layout)
+	m = m.updateCompact()
+	m.textarea.ShowLineNumbers = m.cfg.Editor.LineNumbers
+	if err := saveConfig(m.cfg); err != nil {
+		m.status = errorStatus(err)
+	} else {
+		m.status = okStatus("Settings saved")
+	}
+	m = m.refreshSettingsList()
+	return m, barInfoCmd(m.vault, m.cfg.StatusBar.Segments)
+}
+
 func (m Model) contentDims() (int, int) {
 	windowW := m.windowW
 	windowH := m.windowH
@@ -776,8 +2636,9 @@ func (m Model) goParent() Model {
 		return m
 	}
 	parent := filepath.Dir(m.current)
-	if insideVault(m.vault, parent) {
+	if pathWithin(m.vault, parent) {
 		m.current = parent
+		m.limit = 0
 		m = m.refreshFileList()
 	}
 	return m
@@ -785,32 +2646,42 @@ func (m Model) goParent() Model {
 
 func (m Model) safePath(name string) (string, error) {
 	target := filepath.Join(m.current, name)
-	if !insideVault(m.vault, target) {
+	if !m.allowedPath(target) {
 		return "", fmt.Errorf("path escapes vault")
 	}
 	return target, nil
 }
 
-func insideVault(vault string, p string) bool {
-	absVault, err := filepath.Abs(vault)
-	if err != nil {
-		return false
+// allowedPath reports whether p may be opened or written. Paths are checked
+// against their real location unless following links that leave the vault
+// is enabled, in which case only the path as navigated must be in the vault.
+func (m Model) allowedPath(p string) bool {
+	if m.cfg.FollowExternalLinks {
+		absVault, err := filepath.Abs(m.vault)
+		if err != nil {
+			return false
+		}
+		absPath, err := filepath.Abs(p)
+		if err != nil {
+			return false
+		}
+		return pathWithin(absVault, absPath)
 	}
-	absPath, err := filepath.Abs(p)
+	return insideVault(m.vault, p)
+}
+
+// insideVault reports whether p really lies inside the vault, with symlinks
+// in both paths resolved.
+func insideVault(vault string, p string) bool {
+	realVault, err := realPath(vault)
 	if err != nil {
 		return false
 	}
-	rel, err := filepath.Rel(absVault, absPath)
+	real, err := realPath(p)
 	if err != nil {
 		return false
 	}
-	if rel == "." {
-		return true
-	}
-	if rel == ".." {
-		return false
-	}
-	return !strings.HasPrefix(rel, ".."+string(os.PathSeparator))
+	return pathWithin(realVault, real)
 }
 
 func samePath(a string, b string) bool {
@@ -841,7 +2712,18 @@ func relOrBase(base string, p string) string {
 	return rel
 }
 
-func vaultStorageRoot() string {
+// vaultSubtitle shows where new vaults go, and the profile when one is in
+// use.
+func (m Model) vaultSubtitle(contentW int) string {
+	if activeProfile == "" {
+		return tr("Storage: %s", shrinkText(vaultStorageRoot(m.cfg.VaultDir), maxInt(24, contentW-10)))
+	}
+	prefix := tr("Profile: %s | ", activeProfile)
+	return prefix + tr("Storage: %s", shrinkText(vaultStorageRoot(m.cfg.VaultDir), maxInt(24, contentW-10-len(prefix))))
+}
+
+// homeDir is the user's home directory, or "." when it is not known.
+func homeDir() string {
 	home, err := os.UserHomeDir()
 	if err != nil || strings.TrimSpace(home) == "" {
 		return "."
@@ -850,7 +2732,7 @@ func vaultStorageRoot() string {
 }
 
 func vaultRegistryPath() string {
-	return filepath.Join(vaultStorageRoot(), ".gono_vaults.json")
+	return filepath.Join(appDataDir(), "vaults.json")
 }
 
 func loadVaultRegistry() ([]string, error) {
@@ -909,50 +2791,108 @@ func saveVaultRegistry(vaults []string) error {
 		return strings.ToLower(clean[i]) < strings.ToLower(clean[j])
 	})
 
-	reg := vaultRegistry{Vaults: clean}
+	reg := vaultRegistry{Vaults: clean, Last: lastVault()}
+	return writeVaultRegistry(reg)
+}
+
+func writeVaultRegistry(reg vaultRegistry) error {
 	data, err := json.MarshalIndent(reg, "", "  ")
 	if err != nil {
 		return err
 	}
-	return os.WriteFile(vaultRegistryPath(), data, 0644)
+	return writeFileAtomic(vaultRegistryPath(), data, 0644)
 }
 
-func registerVault(path string) error {
-	vaults, err := loadVaultRegistry()
+// lastVault returns the vault opened most recently, if it is still
+// registered.
+func lastVault() string {
+	data, err := os.ReadFile(vaultRegistryPath())
 	if err != nil {
-		return err
+		return ""
 	}
-	abs, err := filepath.Abs(path)
-	if err != nil {
-		return err
+	var reg vaultRegistry
+	if json.Unmarshal(data, &reg) != nil || reg.Last == "" {
+		return ""
 	}
-	for _, v := range vaults {
-		if samePath(v, abs) {
-			return nil
+	for _, v := range reg.Vaults {
+		if samePath(v, reg.Last) {
+			return reg.Last
 		}
 	}
-	vaults = append(vaults, abs)
-	return saveVaultRegistry(vaults)
+	return ""
 }
 
-func unregisterVault(path string) error {
-	vaults, err := loadVaultRegistry()
+func rememberLastVault(path string) error {
+	if samePath(lastVault(), path) {
+		return nil
+	}
+	return withFileLock(vaultRegistryPath(), func() error {
+		data, err := os.ReadFile(vaultRegistryPath())
+		if err != nil {
+			return err
+		}
+		var reg vaultRegistry
+		if err := json.Unmarshal(data, &reg); err != nil {
+			return err
+		}
+		reg.Last = path
+		return writeVaultRegistry(reg)
+	})
+}
+
+// updateVaultRegistry loads, changes and saves the registry while holding
+// its lock, so concurrent instances do not drop each other's changes.
+func updateVaultRegistry(change func(vaults []string) []string) error {
+	return withFileLock(vaultRegistryPath(), func() error {
+		vaults, err := loadVaultRegistry()
+		if err != nil {
+			return err
+		}
+		return saveVaultRegistry(change(vaults))
+	})
+}
+
+func registerVault(path string) error {
+	abs, err := filepath.Abs(path)
 	if err != nil {
 		return err
 	}
+	return updateVaultRegistry(func(vaults []string) []string {
+		for _, v := range vaults {
+			if samePath(v, abs) {
+				return vaults
+			}
+		}
+		return append(vaults, abs)
+	})
+}
+
+func unregisterVault(path string) error {
 	abs, err := filepath.Abs(path)
 	if err != nil {
 		return err
 	}
+	return updateVaultRegistry(func(vaults []string) []string {
+		filtered := make([]string, 0, len(vaults))
+		for _, v := range vaults {
+			if samePath(v, abs) {
+				continue
+			}
+			filtered = append(filtered, v)
+		}
+		return filtered
+	})
+}
 
-	filtered := make([]string, 0, len(vaults))
+// existingVaults drops registered vaults whose directory is gone.
+func existingVaults(vaults []string) []string {
+	out := make([]string, 0, len(vaults))
 	for _, v := range vaults {
-		if samePath(v, abs) {
-			continue
+		if info, err := os.Stat(v); err == nil && info.IsDir() {
+			out = append(out, v)
 		}
-		filtered = append(filtered, v)
 	}
-	return saveVaultRegistry(filtered)
+	return out
 }
 
 func (m Model) confirmDelete() (tea.Model, tea.Cmd) {
@@ -961,38 +2901,120 @@ func (m Model) confirmDelete() (tea.Model, tea.Cmd) {
 		return m, nil
 	}
 
+	if !m.typedNameMatches() {
+		m.status = infoStatus("Name does not match: type %s to confirm", m.pending.confirmName)
+		return m, nil
+	}
+	m.input.Blur()
+
 	target := *m.pending
-	var err error
+	m.pending = nil
+	m.state = m.lastList
+	if target.trash {
+		if _, err := moveToTrash(m.vault, target.path, time.Now()); err != nil {
+			m.status = errorStatus(err)
+			return m, nil
+		}
+		return m.finishDelete(deleteDoneMsg{target: target}), nil
+	}
 	if target.isDir {
-		err = os.RemoveAll(target.path)
-	} else {
-		err = os.Remove(target.path)
+		m.job = newJob(tr("Deleting %s", target.label))
+		m.status = statusLine{}
+		return m, deleteTreeCmd(target, m.job)
 	}
-	if err != nil {
-		m.status = "Error: " + err.Error()
-		m.pending = nil
-		m.state = m.lastList
+	if err := os.Remove(target.path); err != nil {
+		m.status = errorStatus(err)
 		return m, nil
 	}
+	return m.finishDelete(deleteDoneMsg{target: target}), nil
+}
 
-	if target.isVault {
+// finishDelete updates the lists and the index after target was removed,
+// completely or, when the deletion failed or was cancelled, in part.
+func (m Model) finishDelete(msg deleteDoneMsg) Model {
+	target := msg.target
+	if msg.job != nil {
+		m = m.finishJob(msg.job)
+	}
+	_, statErr := os.Lstat(target.path)
+	switch {
+	case !os.IsNotExist(statErr):
+		if msg.err != nil {
+			m.status = errorStatus(msg.err)
+		} else {
+			m.status = warnStatus("Deletion cancelled: %d of %d entries removed from %s", msg.removed, msg.job.total.Load(), target.label)
+		}
+		m = m.invalidateDirStats(target.path)
+	case target.isVault:
+		_ = os.Remove(indexPath(target.path))
 		if regErr := unregisterVault(target.path); regErr != nil {
-			m.status = "Vault deleted, but registry update failed: " + regErr.Error()
+			m.status = warnStatus("Vault deleted, but registry update failed: %v", regErr)
 		} else {
-			m.status = "Vault deleted: " + target.label
+			m.status = warnStatus("Vault deleted: %s", target.label)
 		}
-		m.list.SetItems(getVaults())
-		m.list.Title = "Select vault (Enter), create (Ctrl+N), open by path (Ctrl+O), open in explorer (Ctrl+P)"
-	} else {
-		m.status = "Deleted: " + target.label
+	case target.purge:
+		m.status = okStatus("Trash emptied")
+		m = m.invalidateDirStats(target.path)
+	default:
+		m.status = warnStatus("Deleted: %s", target.label)
+		if target.trash {
+			m.status = infoStatus("Moved to trash: %s", target.label)
+		}
+		m = m.logChange(journalDelete, target.path, target.isDir)
+		m = m.reindex(target.path)
+	}
+	switch m.state {
+	case stateVaultSelect:
+		m = m.refreshVaultList()
+	case stateFileList:
 		m = m.refreshFileList()
 	}
+	return m
+}
 
-	m.pending = nil
-	m.state = m.lastList
+func (m Model) convertOrgToMarkdown() (tea.Model, tea.Cmd) {
+	content, err := os.ReadFile(m.editing)
+	if err != nil {
+		m.status = errorStatus(err)
+		return m, nil
+	}
+	target := strings.TrimSuffix(m.editing, filepath.Ext(m.editing)) + ".md"
+	if !insideVault(m.vault, target) {
+		m.status = failStatus("path escapes vault")
+		return m, nil
+	}
+	file, err := os.OpenFile(target, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
+	if err != nil {
+		m.status = errorStatus(err)
+		return m, nil
+	}
+	converted := orgToMarkdown(string(content))
+	_, err = file.WriteString(converted)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		m.status = errorStatus(err)
+		return m, nil
+	}
+
+	m = m.setBuffer(target, converted, false)
+	m.status = okStatus("Markdown created: %s", relOrBase(m.vault, target))
+	m = m.logChange(journalCreate, target, false)
+	m = m.reindex(target)
 	return m, nil
 }
 
+func isNavigationKey(msg tea.KeyMsg) bool {
+	switch msg.Type {
+	case tea.KeyUp, tea.KeyDown, tea.KeyLeft, tea.KeyRight,
+		tea.KeyHome, tea.KeyEnd, tea.KeyPgUp, tea.KeyPgDown,
+		tea.KeyCtrlHome, tea.KeyCtrlEnd:
+		return true
+	}
+	return false
+}
+
 func pickFolderInExplorer() (string, error) {
 	switch runtime.GOOS {
 	case "windows":
@@ -1024,25 +3046,93 @@ func isAlnumName(s string) bool {
 	return true
 }
 
-func vaultSelectHints(width int) string {
+// slugify turns a title into a file name: letters and digits are kept in
+// lower case and every other run of characters becomes a single dash.
+func slugify(title string) string {
+	var b strings.Builder
+	dash := false
+	for _, r := range strings.ToLower(title) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			if dash && b.Len() > 0 {
+				b.WriteByte('-')
+			}
+			b.WriteRune(r)
+			dash = false
+			continue
+		}
+		dash = true
+	}
+	if b.Len() == 0 {
+		return "untitled"
+	}
+	return b.String()
+}
+
+// uniquePath appends -2, -3, ... to the file name until it does not exist.
+func uniquePath(path string) string {
+	ext := filepath.Ext(path)
+	stem := strings.TrimSuffix(path, ext)
+	candidate := path
+	for i := 2; ; i++ {
+		if _, err := os.Lstat(candidate); os.IsNotExist(err) {
+			return candidate
+		}
+		candidate = fmt.Sprintf("%s-%d%s", stem, i, ext)
+	}
+}
+
+func builtinVaultHints(width int) string {
 	if width < 72 {
-		return "Ctrl+N create | Ctrl+O path\nCtrl+P explorer | Ctrl+X delete"
+		return tr("Ctrl+N create | Ctrl+O path\nCtrl+P explorer | Ctrl+X delete\nF2 settings | F4 profiles")
 	}
-	return "Ctrl+N: create vault | Ctrl+O: open by path | Ctrl+P: open in explorer | Ctrl+X: delete vault"
+	return tr("Ctrl+N: create vault | Ctrl+O: open by path | Ctrl+P: open in explorer | Ctrl+X: delete vault\nF2: settings | F4: profiles | Ctrl+C: quit")
 }
 
 func fileListHints(width int) string {
 	if width < 72 {
-		return "Enter open | Backspace up | Ctrl+N file\nCtrl+D dir | Ctrl+X delete | Ctrl+C quit"
+		return tr("Enter open | Backspace up | Ctrl+N file\nCtrl+E titled note | Ctrl+T template | Ctrl+D dir\nCtrl+F search | Ctrl+G go to ID | Ctrl+R random\n/ filter | . hidden | Ctrl+A activity | Ctrl+K reminders | F3 two panes | Ctrl+X delete | F2 settings | Ctrl+C quit")
+	}
+	return tr("Enter: open | Backspace: up | Ctrl+N: new file | Ctrl+E: new from title | Ctrl+T: from template\nCtrl+D: new dir | Ctrl+F: search | Ctrl+G: open by ID | Ctrl+R/Alt+R: random note (by tag) | /: quick filter\n.: hidden files | Ctrl+A: writing activity | Ctrl+K: reminders | F3: two panes | Ctrl+X: delete | F2: settings | Ctrl+C: quit")
+}
+
+func (m Model) editorHints() string {
+	if m.readOnly {
+		return tr("Ctrl+R: convert to Markdown | Ctrl+^: previous note | Esc: back")
+	}
+	if m.find != nil {
+		return findHints()
 	}
-	return "Enter: open | Backspace: up | Ctrl+N: new file | Ctrl+D: new dir | Ctrl+X: delete | Ctrl+C: quit"
+	hints := tr("Ctrl+S: save | Ctrl+F: find | Ctrl+L: insert link | Ctrl+Space: set mark | Ctrl+X: extract selection | Ctrl+^: previous note | Esc: back")
+	if m.beside != nil {
+		hints += "\n" + m.besideHints()
+	}
+	return hints
+}
+
+func (m Model) unsavedHints(width int) string {
+	if m.quickDiff != nil {
+		if width < 58 {
+			return tr("S/Y: save | D/N: discard\nV: hide changes | Esc: keep editing")
+		}
+		return tr("S/Y: save | D/N: discard changes | ↑/↓: scroll | V: hide changes | Esc: keep editing")
+	}
+	if width < 58 {
+		return tr("S/Y: save | D/N: discard\nV: changes | Esc: keep editing")
+	}
+	return tr("S/Y: save | D/N: discard changes | V: show changes | Esc: keep editing | Ctrl+C: quit without saving")
 }
 
-func deleteHints(width int) string {
+func deleteHints(width int, trash bool) string {
+	switch {
+	case trash && width < 58:
+		return tr("Y/Enter: move to trash\nN/Esc: cancel")
+	case trash:
+		return tr("Y/Enter: move to trash | N/Esc: cancel")
+	}
 	if width < 58 {
-		return "Y/Enter: delete\nN/Esc: cancel"
+		return tr("Y/Enter: delete\nN/Esc: cancel")
 	}
-	return "Y/Enter: delete permanently | N/Esc: cancel"
+	return tr("Y/Enter: delete permanently | N/Esc: cancel")
 }
 
 func shrinkText(s string, max int) string {
@@ -1076,6 +3166,9 @@ func wrappedLineCount(s string, width int) int {
 }
 
 func contentSize(windowW int, windowH int) (int, int) {
+	if chrome.MaxWidth > 0 && windowW > chrome.MaxWidth {
+		windowW = chrome.MaxWidth
+	}
 	appW, appH := appStyle.GetFrameSize()
 	panelW, panelH := panelStyle.GetFrameSize()
 
@@ -1109,8 +3202,27 @@ func inputWidth(window int) int {
 }
 
 func main() {
-	p := tea.NewProgram(initialModel(), tea.WithAltScreen())
-	if _, err := p.Run(); err != nil {
+	if err := migrateAppFiles(); err != nil {
+		fmt.Fprintln(os.Stderr, "gono: could not move settings to", appConfigDir()+":", err)
+	}
+	profile, args, err := takeProfileFlag(os.Args[1:])
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Error:", err)
+		os.Exit(2)
+	}
+	activeProfile = profile
+	if len(args) > 0 {
+		os.Exit(runCommand(args, os.Stdout, os.Stderr))
+	}
+	p := tea.NewProgram(initialModel(), tea.WithAltScreen(), tea.WithReportFocus())
+	fmt.Print(colorSchemeReportsOn)
+	final, err := p.Run()
+	fmt.Print(colorSchemeReportsOff)
+	if m, ok := final.(Model); ok {
+		m.storeOpenPosition()
+		releaseNoteLock(m.locked.vault, m.locked.note)
+	}
+	if err != nil {
 		fmt.Println("Error:", err)
 		os.Exit(1)
 	}
@@ -0,0 +1,496 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/textarea"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/mattn/go-runewidth"
+)
+
+// Opening a note that git left conflict markers in, or one that gono sync
+// saved a conflict copy of (name.conflict-DEVICE.md), shows the merge view
+// instead of the editor: for each conflict our version and theirs side by
+// side, and below them the merged result as chosen so far. O takes ours, T
+// theirs and B both, ours first; N/P (Tab/Shift+Tab) go to the next and
+// previous conflict. Ctrl+S writes the result once every conflict is
+// resolved and opens it in the editor: the conflict copy then goes away (to
+// the trash, when it is on), and a file git reported is staged as resolved.
+// E edits the file as it is, Esc leaves it untouched.
+
+var conflictCopyRe = regexp.MustCompile(`^(.+)\.conflict-([0-9a-z]{0,6})(-\d+)?$`)
+
+var errBadMarkers = errors.New("unbalanced conflict markers")
+
+// mergeChunk is a run of lines both sides agree on, or a conflict.
+type mergeChunk struct {
+	common   []string
+	ours     []string
+	theirs   []string
+	conflict bool
+	// choice is 'o' (ours), 't' (theirs), 'b' (both) or 0 while open.
+	choice byte
+}
+
+type mergeView struct {
+	path   string
+	copy   string // the conflict copy of gono sync, or ""
+	git    bool
+	ours   string // labels of the two sides
+	theirs string
+	ending string
+	final  bool // the note ended with a line break
+	chunks []mergeChunk
+	// current is the chunk of the conflict shown, scroll the first line
+	// of it shown in the columns.
+	current int
+	scroll  int
+}
+
+func (c mergeChunk) result() []string {
+	switch {
+	case !c.conflict:
+		return c.common
+	case c.choice == 'o':
+		return c.ours
+	case c.choice == 't':
+		return c.theirs
+	case c.choice == 'b':
+		return append(append([]string(nil), c.ours...), c.theirs...)
+	}
+	return nil
+}
+
+// hasConflictMarkers reports whether text has a git conflict in it.
+func hasConflictMarkers(text string) bool {
+	return strings.HasPrefix(text, "<<<<<<< ") || strings.Contains(text, "\n<<<<<<< ")
+}
+
+// parseConflictMarkers splits text at git's conflict markers, diff3 style
+// included; the base version of a diff3 conflict is left out.
+func parseConflictMarkers(text string) ([]mergeChunk, string, string, error) {
+	var chunks []mergeChunk
+	var common []string
+	var cur *mergeChunk
+	ours, theirs := "", ""
+	section := 0 // 1 ours, 2 base, 3 theirs
+	for _, line := range splitDiffLines(text) {
+		switch {
+		case strings.HasPrefix(line, "<<<<<<<") && cur == nil:
+			if len(common) > 0 {
+				chunks = append(chunks, mergeChunk{common: common})
+				common = nil
+			}
+			cur = &mergeChunk{conflict: true}
+			section = 1
+			if ours == "" {
+				ours = strings.TrimSpace(strings.TrimPrefix(line, "<<<<<<<"))
+			}
+		case strings.HasPrefix(line, "|||||||") && cur != nil && section == 1:
+			section = 2
+		case line == "=======" && cur != nil && section != 3:
+			section = 3
+		case strings.HasPrefix(line, ">>>>>>>") && cur != nil && section == 3:
+			if theirs == "" {
+				theirs = strings.TrimSpace(strings.TrimPrefix(line, ">>>>>>>"))
+			}
+			chunks = append(chunks, *cur)
+			cur = nil
+		case cur == nil:
+			common = append(common, line)
+		case section == 1:
+			cur.ours = append(cur.ours, line)
+		case section == 3:
+			cur.theirs = append(cur.theirs, line)
+		}
+	}
+	if cur != nil {
+		return nil, "", "", errBadMarkers
+	}
+	if len(common) > 0 {
+		chunks = append(chunks, mergeChunk{common: common})
+	}
+	return chunks, ours, theirs, nil
+}
+
+// diffChunks turns the differences between two versions into conflicts.
+func diffChunks(ours []string, theirs []string) []mergeChunk {
+	var chunks []mergeChunk
+	for _, l := range diffLines(ours, theirs) {
+		last := len(chunks) - 1
+		switch {
+		case l.op == ' ' && (last < 0 || chunks[last].conflict):
+			chunks = append(chunks, mergeChunk{common: []string{l.text}})
+		case l.op == ' ':
+			chunks[last].common = append(chunks[last].common, l.text)
+		case last < 0 || !chunks[last].conflict:
+			chunks = append(chunks, mergeChunk{conflict: true})
+			fallthrough
+		default:
+			c := &chunks[len(chunks)-1]
+			if l.op == '-' {
+				c.ours = append(c.ours, l.text)
+			} else {
+				c.theirs = append(c.theirs, l.text)
+			}
+		}
+	}
+	return chunks
+}
+
+// conflictCopies returns the conflict copies gono sync left of path.
+func conflictCopies(path string) []string {
+	ext := filepath.Ext(path)
+	stem := strings.TrimSuffix(filepath.Base(path), ext)
+	entries, err := os.ReadDir(filepath.Dir(path))
+	if err != nil {
+		return nil
+	}
+	var copies []string
+	for _, e := range entries {
+		name := e.Name()
+		if e.IsDir() || filepath.Ext(name) != ext {
+			continue
+		}
+		if match := conflictCopyRe.FindStringSubmatch(strings.TrimSuffix(name, ext)); match != nil && match[1] == stem {
+			copies = append(copies, filepath.Join(filepath.Dir(path), name))
+		}
+	}
+	return copies
+}
+
+// conflictOriginal returns the note a conflict copy was made of.
+func conflictOriginal(path string) (string, bool) {
+	ext := filepath.Ext(path)
+	match := conflictCopyRe.FindStringSubmatch(strings.TrimSuffix(filepath.Base(path), ext))
+	if match == nil {
+		return "", false
+	}
+	original := filepath.Join(filepath.Dir(path), match[1]+ext)
+	if _, err := os.Stat(original); err != nil {
+		return "", false
+	}
+	return original, true
+}
+
+// findMerge returns the merge to resolve before path can be edited: the
+// conflicts git marked in text, or those with a conflict copy of the note.
+func findMerge(path string, text string) (*mergeView, bool) {
+	if !isIndexedNote(path) || isEncryptedNote(path) {
+		return nil, false
+	}
+	if hasConflictMarkers(text) {
+		chunks, ours, theirs, err := parseConflictMarkers(text)
+		if err != nil {
+			return nil, false
+		}
+		return newMergeView(path, "", text, chunks, ours, theirs), true
+	}
+	note, other := path, ""
+	if original, ok := conflictOriginal(path); ok {
+		note, other = original, path
+	} else if copies := conflictCopies(path); len(copies) > 0 {
+		other = copies[0]
+	}
+	if other == "" {
+		return nil, false
+	}
+	ourData, err := os.ReadFile(note)
+	if err != nil {
+		return nil, false
+	}
+	theirData, err := os.ReadFile(other)
+	if err != nil {
+		return nil, false
+	}
+	ourText, _, ok1 := decodeText(ourData)
+	theirText, _, ok2 := decodeText(theirData)
+	if !ok1 || !ok2 {
+		return nil, false
+	}
+	chunks := diffChunks(splitDiffLines(ourText), splitDiffLines(theirText))
+	match := conflictCopyRe.FindStringSubmatch(strings.TrimSuffix(filepath.Base(other), filepath.Ext(other)))
+	mv := newMergeView(note, other, ourText, chunks, tr("this device"), tr("device %s", match[2]))
+	return mv, true
+}
+
+func newMergeView(path string, other string, text string, chunks []mergeChunk, ours string, theirs string) *mergeView {
+	if ours == "" {
+		ours = tr("ours")
+	}
+	if theirs == "" {
+		theirs = tr("theirs")
+	}
+	mv := &mergeView{
+		path:   path,
+		copy:   other,
+		git:    other == "",
+		ours:   ours,
+		theirs: theirs,
+		ending: detectLineEnding(text, lineEndingLF),
+		final:  strings.HasSuffix(text, "\n"),
+		chunks: chunks,
+	}
+	if len(mv.chunks) == 0 {
+		mv.chunks = []mergeChunk{{}}
+	}
+	mv.current = mv.nextConflict(-1, 1)
+	return mv
+}
+
+// nextConflict returns the conflict after (dir 1) or before (dir -1) the
+// chunk from, wrapping around, or from itself when there is no other.
+func (mv *mergeView) nextConflict(from int, dir int) int {
+	n := len(mv.chunks)
+	for i := 1; i <= n; i++ {
+		at := ((from+dir*i)%n + n) % n
+		if mv.chunks[at].conflict {
+			return at
+		}
+	}
+	return maxInt(0, from)
+}
+
+// counts returns the number of conflicts, those resolved, and the number
+// of the current one.
+func (mv *mergeView) counts() (int, int, int) {
+	total, resolved, number := 0, 0, 0
+	for i, c := range mv.chunks {
+		if !c.conflict {
+			continue
+		}
+		total++
+		if c.choice != 0 {
+			resolved++
+		}
+		if i == mv.current {
+			number = total
+		}
+	}
+	return total, resolved, number
+}
+
+func (mv *mergeView) text() string {
+	var lines []string
+	for _, c := range mv.chunks {
+		lines = append(lines, c.result()...)
+	}
+	text := strings.Join(lines, "\n")
+	if mv.final && len(lines) > 0 {
+		text += "\n"
+	}
+	return withLineEnding(text, mv.ending)
+}
+
+// openMerge shows the merge view for mv.
+func (m Model) openMerge(mv *mergeView) (tea.Model, tea.Cmd) {
+	m.merge = mv
+	m.state = stateMerge
+	total, _, _ := mv.counts()
+	m.status = warnStatus("%s to resolve in %s", trn("%d conflict", "%d conflicts", total), relOrBase(m.vault, mv.path))
+	if total == 0 {
+		m.status = infoStatus("Both versions are the same; Ctrl+S keeps one")
+	}
+	return m, nil
+}
+
+func (m Model) handleMergeKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	mv := m.merge
+	m.status = statusLine{}
+	switch msg.String() {
+	case "ctrl+c":
+		return m, tea.Quit
+	case "esc", "q":
+		m.merge = nil
+		m.state = stateFileList
+		m = m.refreshFileList()
+		m.status = infoStatus("Not merged: %s", relOrBase(m.vault, mv.path))
+		return m, nil
+	case "e":
+		m.merge = nil
+		return m.editAsIs(mv.path)
+	case "o", "t", "b":
+		if mv.chunks[mv.current].conflict {
+			mv.chunks[mv.current].choice = msg.String()[0]
+			if total, resolved, _ := mv.counts(); resolved < total {
+				mv.current = mv.nextConflict(mv.current, 1)
+				mv.scroll = 0
+			} else {
+				m.status = okStatus("All conflicts resolved; Ctrl+S saves the result")
+			}
+		}
+	case "u":
+		mv.chunks[mv.current].choice = 0
+	case "n", "tab":
+		mv.current = mv.nextConflict(mv.current, 1)
+		mv.scroll = 0
+	case "p", "shift+tab":
+		mv.current = mv.nextConflict(mv.current, -1)
+		mv.scroll = 0
+	case "up", "k":
+		mv.scroll = maxInt(0, mv.scroll-1)
+	case "down", "j":
+		c := mv.chunks[mv.current]
+		mv.scroll = minInt(maxInt(0, maxInt(len(c.ours), len(c.theirs))-1), mv.scroll+1)
+	case "ctrl+s":
+		return m.saveMerge()
+	}
+	return m, nil
+}
+
+// editAsIs opens path in the editor without looking for conflicts.
+func (m Model) editAsIs(path string) (tea.Model, tea.Cmd) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		m.state = stateFileList
+		m.status = errorStatus(err)
+		return m, nil
+	}
+	text, encoding, _ := decodeText(content)
+	m.textarea.Focus()
+	m = m.setBuffer(path, expandTabs(text, m.cfg.Editor.TabWidth), isOrgFile(path))
+	m.encoding = encoding
+	m.state = stateEditor
+	return m, textarea.Blink
+}
+
+// saveMerge writes the merged note, puts the conflict copy away or tells
+// git the conflict is resolved, and opens the result.
+func (m Model) saveMerge() (tea.Model, tea.Cmd) {
+	mv := m.merge
+	if total, resolved, _ := mv.counts(); resolved < total {
+		m.status = infoStatus("%s still open: O, T or B resolves it", trn("%d conflict", "%d conflicts", total-resolved))
+		for i, c := range mv.chunks {
+			if c.conflict && c.choice == 0 {
+				mv.current, mv.scroll = i, 0
+				break
+			}
+		}
+		return m, nil
+	}
+	mode := os.FileMode(0644)
+	if info, err := os.Stat(mv.path); err == nil {
+		mode = info.Mode().Perm()
+	}
+	if err := writeFileAtomic(mv.path, []byte(mv.text()), mode); err != nil {
+		m.status = errorStatus(err)
+		return m, nil
+	}
+	m = m.logChange(journalSave, mv.path, false)
+	m = m.reindex(mv.path)
+	note := okStatus("Merged: %s", relOrBase(m.vault, mv.path))
+	switch {
+	case mv.copy != "":
+		var err error
+		if m.usesTrash(deleteTarget{path: mv.copy}) {
+			_, err = moveToTrash(m.vault, mv.copy, time.Now())
+		} else {
+			err = os.Remove(mv.copy)
+		}
+		if err != nil {
+			note = warnStatus("Merged, but the conflict copy stays: %v", err)
+		}
+		m = m.reindex(mv.copy)
+	case mv.git:
+		if _, err := runGit(filepath.Dir(mv.path), "add", "--", filepath.Base(mv.path)); err != nil && !errors.Is(err, errNotGitRepo) {
+			note = warnStatus("Merged, but git add failed: %v", err)
+		}
+	}
+	m.merge = nil
+	model, cmd := m.openFile(mv.path)
+	if next, ok := model.(Model); ok {
+		if next.status.kind != statusError {
+			next.status = note
+		}
+		return next, cmd
+	}
+	return model, cmd
+}
+
+func (m Model) mergeSubtitle() string {
+	total, resolved, number := m.merge.counts()
+	if total == 0 {
+		return tr("No differences")
+	}
+	return tr("Conflict %d of %d, %d resolved | ours: %s | theirs: %s", number, total, resolved, m.merge.ours, m.merge.theirs)
+}
+
+// mergeBody draws the current conflict, ours and theirs side by side, over
+// the merged result around it.
+func (m Model) mergeBody(width int, height int) string {
+	mv := m.merge
+	label := lipgloss.NewStyle().Bold(true)
+	oursStyle := lipgloss.NewStyle().Foreground(colorError)
+	theirsStyle := lipgloss.NewStyle().Foreground(colorSuccess)
+	colW := maxInt(8, (width-3)/2)
+	c := mv.chunks[minInt(mv.current, len(mv.chunks)-1)]
+	top := maxInt(3, minInt((height-1)/2, maxInt(len(c.ours), len(c.theirs))+1))
+
+	cell := func(lines []string, i int, style lipgloss.Style) string {
+		if i >= len(lines) {
+			return strings.Repeat(" ", colW)
+		}
+		text := runewidth.Truncate(strings.ReplaceAll(lines[i], "\t", "    "), colW, "…")
+		return style.Render(runewidth.FillRight(text, colW))
+	}
+	choice := func(side byte, name string) string {
+		text := runewidth.Truncate(name, colW-2, "…")
+		if c.choice == side || c.choice == 'b' {
+			text = "✓ " + text
+		}
+		return label.Render(runewidth.FillRight(text, colW))
+	}
+	out := []string{choice('o', tr("Ours: %s", mv.ours)) + " │ " + choice('t', tr("Theirs: %s", mv.theirs))}
+	for i := 0; i < top-1; i++ {
+		row := mv.scroll + i
+		if i == top-2 && row < maxInt(len(c.ours), len(c.theirs))-1 {
+			out = append(out, hintStyle.Render(tr("… more lines: Down scrolls")))
+			break
+		}
+		out = append(out, cell(c.ours, row, oursStyle)+" │ "+cell(c.theirs, row, theirsStyle))
+	}
+
+	out = append(out, label.Render(tr("Result")))
+	type resultLine struct {
+		text  string
+		style lipgloss.Style
+	}
+	var result []resultLine
+	start := 0
+	for i, chunk := range mv.chunks {
+		if i == mv.current {
+			start = len(result)
+		}
+		switch {
+		case chunk.conflict && chunk.choice == 0:
+			result = append(result, resultLine{tr("[conflict: O ours, T theirs, B both]"), lipgloss.NewStyle().Foreground(colorWarning)})
+		case chunk.conflict:
+			for _, line := range chunk.result() {
+				result = append(result, resultLine{line, lipgloss.NewStyle().Foreground(colorPrimary)})
+			}
+		default:
+			for _, line := range chunk.common {
+				result = append(result, resultLine{line, lipgloss.NewStyle()})
+			}
+		}
+	}
+	rest := maxInt(1, height-len(out))
+	from := maxInt(0, minInt(start-2, len(result)-rest))
+	for _, line := range result[from:minInt(len(result), from+rest)] {
+		out = append(out, line.style.Render(runewidth.Truncate("  "+strings.ReplaceAll(line.text, "\t", "    "), width, "…")))
+	}
+	return strings.Join(out, "\n")
+}
+
+func mergeHints(width int) string {
+	if width < 72 {
+		return tr("O ours | T theirs | B both | N/P next/prev\nCtrl+S save | E edit as text | Esc back")
+	}
+	return tr("O: take ours | T: take theirs | B: both | U: undo | N/P: next/previous conflict | Up/Down: scroll\nCtrl+S: save the result | E: edit as text | Esc: back")
+}
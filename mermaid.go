@@ -0,0 +1,414 @@
+package main
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textarea"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/mattn/go-runewidth"
+)
+
+// Alt+M in the editor draws the ```mermaid block at the cursor as text.
+// Flowcharts (graph/flowchart) are laid out in layers, top-down or
+// left-to-right, with the edges listed below; sequence diagrams are drawn
+// as one arrow per message. Other diagram types show their source. The HTML
+// export (export-dir) renders all types with mermaid.js.
+
+var (
+	mermaidHeaderRe = regexp.MustCompile(`^(?:graph|flowchart)(?:\s+(TD|TB|BT|LR|RL))?\s*$`)
+	mermaidNodeRe   = regexp.MustCompile(`^([\p{L}\p{N}_]+)\s*(?:\[\[(.*?)\]\]|\[\((.*?)\)\]|\(\((.*?)\)\)|\[(.*?)\]|\((.*?)\)|\{\{(.*?)\}\}|\{(.*?)\}|>(.*?)\])?\s*(?::::\S+)?$`)
+	mermaidLinkRe   = regexp.MustCompile(`\s*(?:(?:--|==|-\.)\s*([^-=.|>\s][^|>]*?)\s*(?:-->|==>|\.->|---)|<?(?:-{2,}|={2,}|-\.+-)[>ox]?(?:\s*\|([^|]*)\|)?)\s*`)
+	mermaidSeqRe    = regexp.MustCompile(`^\s*([^-:>]+?)\s*(-{1,2}>>|-{1,2}>|-{1,2}[x)])\s*([^:]+?)\s*:\s*(.*)$`)
+	mermaidActorRe  = regexp.MustCompile(`^\s*(?:participant|actor)\s+(\S+)(?:\s+as\s+(.*))?$`)
+)
+
+type mermaidNode struct {
+	id    string
+	label string
+	shape string
+}
+
+type mermaidEdge struct {
+	from  string
+	to    string
+	label string
+}
+
+type diagramView struct {
+	lines []string
+	top   int
+}
+
+// mermaidBlockAt returns the mermaid fence containing row, or the first one
+// after it.
+func mermaidBlockAt(text string, row int) (string, bool) {
+	lines := strings.Split(text, "\n")
+	var found string
+	ok := false
+	for i := 0; i < len(lines); i++ {
+		if !strings.EqualFold(strings.TrimSpace(lines[i]), "```mermaid") {
+			continue
+		}
+		start := i
+		var body []string
+		for i++; i < len(lines) && !strings.HasPrefix(strings.TrimSpace(lines[i]), "```"); i++ {
+			body = append(body, lines[i])
+		}
+		if row >= start && row <= i {
+			return strings.Join(body, "\n"), true
+		}
+		if start > row && !ok {
+			found, ok = strings.Join(body, "\n"), true
+		}
+	}
+	return found, ok
+}
+
+// renderMermaid draws a diagram's source as terminal text.
+func renderMermaid(src string) string {
+	var statements []string
+	for _, line := range strings.Split(src, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "%%") {
+			continue
+		}
+		statements = append(statements, line)
+	}
+	if len(statements) == 0 {
+		return ""
+	}
+	head := strings.Fields(statements[0])[0]
+	switch {
+	case mermaidHeaderRe.MatchString(statements[0]):
+		direction := mermaidHeaderRe.FindStringSubmatch(statements[0])[1]
+		return renderFlowchart(statements[1:], direction == "LR" || direction == "RL")
+	case head == "sequenceDiagram":
+		return renderSequence(statements[1:])
+	}
+	return hintStyle.Render(tr("%s diagrams are shown as source; the HTML export draws them.", head)) + "\n\n" + src
+}
+
+func parseFlowchart(statements []string) ([]mermaidNode, []mermaidEdge) {
+	var nodes []mermaidNode
+	index := make(map[string]int)
+	addNode := func(token string) []string {
+		var ids []string
+		for _, part := range strings.Split(token, "&") {
+			m := mermaidNodeRe.FindStringSubmatch(strings.TrimSpace(part))
+			if m == nil {
+				continue
+			}
+			id, label, shape := m[1], "", "rect"
+			for i, s := range []string{"subroutine", "cylinder", "circle", "rect", "round", "hexagon", "rhombus", "flag"} {
+				if m[i+2] != "" {
+					label, shape = strings.Trim(m[i+2], `"`), s
+				}
+			}
+			if i, ok := index[id]; ok {
+				if label != "" {
+					nodes[i].label, nodes[i].shape = label, shape
+				}
+			} else {
+				if label == "" {
+					label = id
+				}
+				index[id] = len(nodes)
+				nodes = append(nodes, mermaidNode{id: id, label: label, shape: shape})
+			}
+			ids = append(ids, id)
+		}
+		return ids
+	}
+
+	var edges []mermaidEdge
+	for _, statement := range statements {
+		for _, s := range strings.Split(statement, ";") {
+			s = strings.TrimSpace(s)
+			if s == "" || s == "end" {
+				continue
+			}
+			switch strings.Fields(s)[0] {
+			case "subgraph", "direction", "classDef", "class", "style", "linkStyle", "click":
+				continue
+			}
+			links := mermaidLinkRe.FindAllStringSubmatchIndex(s, -1)
+			prev := addNode(s[:firstIndex(links, len(s))])
+			for i, l := range links {
+				end := len(s)
+				if i+1 < len(links) {
+					end = links[i+1][0]
+				}
+				label := ""
+				for _, g := range []int{2, 4} {
+					if l[g] >= 0 {
+						label = strings.TrimSpace(strings.Trim(s[l[g]:l[g+1]], `"`))
+					}
+				}
+				next := addNode(s[l[1]:end])
+				for _, from := range prev {
+					for _, to := range next {
+						edges = append(edges, mermaidEdge{from: from, to: to, label: label})
+					}
+				}
+				prev = next
+			}
+		}
+	}
+	return nodes, edges
+}
+
+func firstIndex(matches [][]int, fallback int) int {
+	if len(matches) == 0 {
+		return fallback
+	}
+	return matches[0][0]
+}
+
+func renderFlowchart(statements []string, horizontal bool) string {
+	nodes, edges := parseFlowchart(statements)
+	if len(nodes) == 0 {
+		return ""
+	}
+	// Longest-path layering over the edges that do not close a cycle.
+	forward := acyclicEdges(nodes, edges)
+	layer := make(map[string]int)
+	for round := 0; round < len(nodes); round++ {
+		changed := false
+		for _, e := range forward {
+			if layer[e.to] < layer[e.from]+1 {
+				layer[e.to] = layer[e.from] + 1
+				changed = true
+			}
+		}
+		if !changed {
+			break
+		}
+	}
+	depth := 0
+	for _, n := range nodes {
+		depth = maxInt(depth, layer[n.id]+1)
+	}
+	layers := make([][]string, depth)
+	labels := make(map[string]string)
+	for _, n := range nodes {
+		layers[layer[n.id]] = append(layers[layer[n.id]], mermaidBox(n))
+		labels[n.id] = n.label
+	}
+
+	var parts []string
+	for i, boxes := range layers {
+		if i > 0 {
+			if horizontal {
+				parts = append(parts, " ──▶ ")
+			} else {
+				parts = append(parts, "│\n▼")
+			}
+		}
+		if horizontal {
+			parts = append(parts, lipgloss.JoinVertical(lipgloss.Center, boxes...))
+		} else {
+			parts = append(parts, lipgloss.JoinHorizontal(lipgloss.Top, spaced(boxes)...))
+		}
+	}
+	var graph string
+	if horizontal {
+		graph = lipgloss.JoinHorizontal(lipgloss.Center, parts...)
+	} else {
+		graph = lipgloss.JoinVertical(lipgloss.Center, parts...)
+	}
+
+	lines := []string{graph, ""}
+	for _, e := range edges {
+		line := labels[e.from] + " → " + labels[e.to]
+		if e.label != "" {
+			line += "  (" + e.label + ")"
+		}
+		lines = append(lines, hintStyle.Render(line))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// acyclicEdges drops the edges that lead back to a node on the current
+// depth-first path, so loops do not stretch the layout.
+func acyclicEdges(nodes []mermaidNode, edges []mermaidEdge) []mermaidEdge {
+	out := make(map[string][]int)
+	for i, e := range edges {
+		out[e.from] = append(out[e.from], i)
+	}
+	const (
+		unvisited = iota
+		onPath
+		done
+	)
+	state := make(map[string]int)
+	back := make(map[int]bool)
+	var visit func(id string)
+	visit = func(id string) {
+		state[id] = onPath
+		for _, i := range out[id] {
+			switch state[edges[i].to] {
+			case onPath:
+				back[i] = true
+			case unvisited:
+				visit(edges[i].to)
+			}
+		}
+		state[id] = done
+	}
+	for _, n := range nodes {
+		if state[n.id] == unvisited {
+			visit(n.id)
+		}
+	}
+	var forward []mermaidEdge
+	for i, e := range edges {
+		if !back[i] {
+			forward = append(forward, e)
+		}
+	}
+	return forward
+}
+
+func spaced(boxes []string) []string {
+	out := make([]string, 0, 2*len(boxes))
+	for i, b := range boxes {
+		if i > 0 {
+			out = append(out, "  ")
+		}
+		out = append(out, b)
+	}
+	return out
+}
+
+// mermaidBox draws a node; the corners hint at its shape.
+func mermaidBox(n mermaidNode) string {
+	corners := [4]string{"┌", "┐", "└", "┘"}
+	label := n.label
+	switch n.shape {
+	case "round", "cylinder", "circle":
+		corners = [4]string{"╭", "╮", "╰", "╯"}
+	case "rhombus", "hexagon":
+		corners = [4]string{"◇", "◇", "◇", "◇"}
+	}
+	label = strings.ReplaceAll(strings.ReplaceAll(label, "<br>", " "), "<br/>", " ")
+	w := runewidth.StringWidth(label)
+	return corners[0] + strings.Repeat("─", w+2) + corners[1] + "\n" +
+		"│ " + label + " │\n" +
+		corners[2] + strings.Repeat("─", w+2) + corners[3]
+}
+
+func renderSequence(statements []string) string {
+	var actors []string
+	names := make(map[string]string)
+	seen := make(map[string]bool)
+	addActor := func(id string) {
+		if !seen[id] {
+			seen[id] = true
+			actors = append(actors, id)
+		}
+	}
+	type message struct {
+		from, arrow, to, text string
+		note                  bool
+	}
+	var messages []message
+	for _, s := range statements {
+		if m := mermaidActorRe.FindStringSubmatch(s); m != nil {
+			addActor(m[1])
+			if m[2] != "" {
+				names[m[1]] = strings.TrimSpace(m[2])
+			}
+			continue
+		}
+		m := mermaidSeqRe.FindStringSubmatch(s)
+		if m == nil {
+			if fields := strings.Fields(s); len(fields) > 0 && (fields[0] == "Note" || fields[0] == "note") {
+				if _, text, ok := strings.Cut(s, ":"); ok {
+					messages = append(messages, message{text: strings.TrimSpace(text), note: true})
+				}
+			}
+			continue
+		}
+		addActor(m[1])
+		addActor(m[3])
+		arrow := "──▶"
+		if strings.HasPrefix(m[2], "--") {
+			arrow = "╌╌▶"
+		}
+		messages = append(messages, message{from: m[1], arrow: arrow, to: m[3], text: m[4]})
+	}
+	label := func(id string) string {
+		if name, ok := names[id]; ok {
+			return name
+		}
+		return id
+	}
+	width := 0
+	for _, a := range actors {
+		width = maxInt(width, runewidth.StringWidth(label(a)))
+	}
+	boxes := make([]string, 0, len(actors))
+	for _, a := range actors {
+		boxes = append(boxes, mermaidBox(mermaidNode{label: label(a)}))
+	}
+	out := []string{lipgloss.JoinHorizontal(lipgloss.Top, spaced(boxes)...), ""}
+	for _, msg := range messages {
+		if msg.note {
+			out = append(out, hintStyle.Render("  ✎ "+msg.text))
+			continue
+		}
+		out = append(out, runewidth.FillRight(label(msg.from), width)+" "+msg.arrow+" "+runewidth.FillRight(label(msg.to), width)+"  "+msg.text)
+	}
+	return strings.Join(out, "\n")
+}
+
+func (m Model) showDiagram() (tea.Model, tea.Cmd) {
+	src, ok := mermaidBlockAt(m.textarea.Value(), editorCursor(m.textarea).row)
+	if !ok {
+		m.status = infoStatus("No mermaid diagram at or after the cursor")
+		return m, nil
+	}
+	m.diagram = &diagramView{lines: strings.Split(renderMermaid(src), "\n")}
+	m.textarea.Blur()
+	m.state = stateDiagram
+	m.status = statusLine{}
+	return m, nil
+}
+
+func (m Model) handleDiagramKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	d := m.diagram
+	page := maxInt(1, m.list.Height())
+	last := maxInt(0, len(d.lines)-page)
+	switch msg.String() {
+	case "ctrl+c":
+		m.diagram = nil
+		m.textarea.Focus()
+		return m.quitEditor()
+	case "esc", "enter", "q", "alt+m":
+		m.diagram = nil
+		m.state = stateEditor
+		m.textarea.Focus()
+		return m, textarea.Blink
+	case "up", "k":
+		d.top = maxInt(0, d.top-1)
+	case "down", "j":
+		d.top = minInt(last, d.top+1)
+	case "pgup":
+		d.top = maxInt(0, d.top-page)
+	case "pgdown", " ":
+		d.top = minInt(last, d.top+page)
+	}
+	return m, nil
+}
+
+func (m Model) diagramView(contentW int, height int) string {
+	d := m.diagram
+	end := minInt(len(d.lines), d.top+height)
+	lines := make([]string, 0, end-d.top)
+	for _, l := range d.lines[d.top:end] {
+		lines = append(lines, lipgloss.NewStyle().MaxWidth(contentW).Render(l))
+	}
+	return strings.Join(lines, "\n")
+}
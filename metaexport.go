@@ -0,0 +1,242 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// "gono export-meta" dumps the metadata graph of a vault for analysis
+// elsewhere: every note with its title, tags, word count and dates, the
+// links between notes and how often each tag is used. JSON holds all of it;
+// CSV holds one table at a time (-table notes, links or tags) so it loads
+// straight into a spreadsheet or a dataframe.
+
+type metaNote struct {
+	Path     string   `json:"path"`
+	Title    string   `json:"title"`
+	Tags     []string `json:"tags"`
+	Words    int      `json:"words"`
+	Created  string   `json:"created,omitempty"`
+	Modified string   `json:"modified"`
+	Status   string   `json:"status,omitempty"`
+	Outbound int      `json:"outbound"`
+	Inbound  int      `json:"inbound"`
+}
+
+// metaLink is one link between notes. To is the vault path of the linked
+// note, or the link target as written when no note has that name.
+type metaLink struct {
+	From     string `json:"from"`
+	To       string `json:"to"`
+	Resolved bool   `json:"resolved"`
+}
+
+type metaTag struct {
+	Tag   string `json:"tag"`
+	Notes int    `json:"notes"`
+}
+
+type vaultMeta struct {
+	Vault     string     `json:"vault"`
+	Generated string     `json:"generated"`
+	Notes     []metaNote `json:"notes"`
+	Links     []metaLink `json:"links"`
+	Tags      []metaTag  `json:"tags"`
+}
+
+// collectVaultMeta reads the notes of vault and the links between them.
+// Several links from one note to another count once.
+func collectVaultMeta(vault string, now time.Time) (vaultMeta, error) {
+	meta := vaultMeta{
+		Vault:     vault,
+		Generated: now.Format(time.RFC3339),
+		Notes:     []metaNote{},
+		Links:     []metaLink{},
+		Tags:      []metaTag{},
+	}
+	byKey := make(map[string]int)
+	texts := make(map[int]string)
+	err := walkVault(vault, func(p string, d fs.DirEntry) error {
+		if !isIndexedNote(p) {
+			return nil
+		}
+		content, err := os.ReadFile(p)
+		if err != nil {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return nil
+		}
+		text, _, ok := decodeText(content)
+		if !ok {
+			return nil
+		}
+		rel := relOrBase(vault, p)
+		title, body := splitNoteTitle(p, text)
+		n := metaNote{
+			Path:     filepath.ToSlash(rel),
+			Title:    title,
+			Tags:     sortedTags(noteTags(text)),
+			Words:    len(normalizedWords(body)),
+			Modified: info.ModTime().Format(time.RFC3339),
+		}
+		if fields, ok := parseFrontmatter(text); ok {
+			for _, key := range []string{"created", "date"} {
+				if value := unquote(fields[key]); value != "" {
+					n.Created = value
+					break
+				}
+			}
+			n.Status = strings.ToLower(unquote(fields[statusField]))
+		}
+		i := len(meta.Notes)
+		meta.Notes = append(meta.Notes, n)
+		texts[i] = text
+		key := strings.ToLower(strings.TrimSuffix(rel, filepath.Ext(rel)))
+		byKey[key] = i
+		if _, taken := byKey[filepath.Base(key)]; !taken {
+			byKey[filepath.Base(key)] = i
+		}
+		return nil
+	})
+	if err != nil {
+		return meta, err
+	}
+
+	tagCount := make(map[string]int)
+	for i := range meta.Notes {
+		n := &meta.Notes[i]
+		for _, tag := range n.Tags {
+			tagCount[tag]++
+		}
+		seen := make(map[string]bool)
+		path := filepath.Join(vault, filepath.FromSlash(n.Path))
+		for _, key := range linkKeys(vault, path, texts[i]) {
+			link := metaLink{From: n.Path, To: key}
+			if t, ok := byKey[key]; ok {
+				if t == i {
+					continue
+				}
+				link.To, link.Resolved = meta.Notes[t].Path, true
+			}
+			if seen[link.To] {
+				continue
+			}
+			seen[link.To] = true
+			meta.Links = append(meta.Links, link)
+			n.Outbound++
+			if link.Resolved {
+				meta.Notes[byKey[key]].Inbound++
+			}
+		}
+	}
+	for tag, count := range tagCount {
+		meta.Tags = append(meta.Tags, metaTag{Tag: tag, Notes: count})
+	}
+	sort.Slice(meta.Tags, func(i, j int) bool {
+		if meta.Tags[i].Notes != meta.Tags[j].Notes {
+			return meta.Tags[i].Notes > meta.Tags[j].Notes
+		}
+		return meta.Tags[i].Tag < meta.Tags[j].Tag
+	})
+	return meta, nil
+}
+
+func writeMetaJSON(w io.Writer, meta vaultMeta) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(meta)
+}
+
+// writeMetaCSV writes one table of meta with a header row. Lists such as
+// the tags of a note are joined with semicolons.
+func writeMetaCSV(w io.Writer, meta vaultMeta, table string) error {
+	out := csv.NewWriter(w)
+	switch table {
+	case "notes":
+		_ = out.Write([]string{"path", "title", "tags", "words", "created", "modified", "status", "outbound", "inbound"})
+		for _, n := range meta.Notes {
+			_ = out.Write([]string{n.Path, n.Title, strings.Join(n.Tags, ";"), strconv.Itoa(n.Words), n.Created, n.Modified, n.Status, strconv.Itoa(n.Outbound), strconv.Itoa(n.Inbound)})
+		}
+	case "links":
+		_ = out.Write([]string{"from", "to", "resolved"})
+		for _, l := range meta.Links {
+			_ = out.Write([]string{l.From, l.To, strconv.FormatBool(l.Resolved)})
+		}
+	case "tags":
+		_ = out.Write([]string{"tag", "notes"})
+		for _, t := range meta.Tags {
+			_ = out.Write([]string{t.Tag, strconv.Itoa(t.Notes)})
+		}
+	}
+	out.Flush()
+	return out.Error()
+}
+
+func exportMetaCommand(args []string, stdout io.Writer) error {
+	flags := flag.NewFlagSet("export-meta", flag.ContinueOnError)
+	flags.SetOutput(io.Discard)
+	format := flags.String("format", "", "")
+	table := flags.String("table", "notes", "")
+	usage := errors.New("usage: gono export-meta [-format json|csv] [-table notes|links|tags] VAULT [FILE]")
+	if err := flags.Parse(args); err != nil || flags.NArg() < 1 || flags.NArg() > 2 {
+		return usage
+	}
+	out := ""
+	if flags.NArg() == 2 {
+		out = flags.Arg(1)
+	}
+	if *format == "" {
+		*format = "json"
+		if strings.EqualFold(filepath.Ext(out), ".csv") {
+			*format = "csv"
+		}
+	}
+	switch {
+	case *format != "json" && *format != "csv":
+		return usage
+	case *table != "notes" && *table != "links" && *table != "tags":
+		return usage
+	}
+	vault, err := filepath.Abs(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+	if info, err := os.Stat(vault); err != nil || !info.IsDir() {
+		return fmt.Errorf("vault not found: %s", vault)
+	}
+	meta, err := collectVaultMeta(vault, time.Now())
+	if err != nil {
+		return err
+	}
+	var b strings.Builder
+	if *format == "csv" {
+		err = writeMetaCSV(&b, meta, *table)
+	} else {
+		err = writeMetaJSON(&b, meta)
+	}
+	if err != nil {
+		return err
+	}
+	if out == "" {
+		_, err = io.WriteString(stdout, b.String())
+		return err
+	}
+	if err := os.WriteFile(out, []byte(b.String()), 0644); err != nil {
+		return err
+	}
+	fmt.Fprintf(stdout, "Exported %s and %s to %s\n", pluralize(len(meta.Notes), "note", "notes"), pluralize(len(meta.Links), "link", "links"), out)
+	return nil
+}
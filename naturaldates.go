@@ -0,0 +1,357 @@
+package main
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Reminders can be written the way one would say them: "remind me next
+// friday" or "remind me in two weeks at 9am" anywhere in a note, or
+// "remind: tomorrow" in the frontmatter (also expires: and due:). When the
+// note is saved the phrase is replaced by the date it stands for, so the
+// note keeps a fixed date that the reminder list and the agenda can read
+// and that does not move when the note is opened on another day.
+
+var (
+	remindMeRe = regexp.MustCompile(`(?i)\bremind me\b`)
+	// reminderAnnotationRe finds resolved "remind me" annotations in the body.
+	reminderAnnotationRe = regexp.MustCompile(`(?i)\bremind me (?:on )?(\d{4}-\d{2}-\d{2}(?: \d{2}:\d{2})?)`)
+	clockRe              = regexp.MustCompile(`^(\d{1,2})(?::(\d{2}))?(am|pm)?$`)
+)
+
+// naturalDateFields are the frontmatter fields whose values are resolved.
+var naturalDateFields = []string{"remind", "expires", "due"}
+
+// maxNaturalWords bounds how many words after "remind me" are tried.
+const maxNaturalWords = 7
+
+var numberWords = map[string]int{
+	"a": 1, "an": 1, "one": 1, "two": 2, "three": 3, "four": 4, "five": 5, "six": 6,
+	"seven": 7, "eight": 8, "nine": 9, "ten": 10, "eleven": 11, "twelve": 12,
+}
+
+var weekdayNames = map[string]time.Weekday{
+	"sunday": time.Sunday, "sun": time.Sunday, "monday": time.Monday, "mon": time.Monday,
+	"tuesday": time.Tuesday, "tue": time.Tuesday, "tues": time.Tuesday,
+	"wednesday": time.Wednesday, "wed": time.Wednesday, "thursday": time.Thursday,
+	"thu": time.Thursday, "thurs": time.Thursday, "friday": time.Friday, "fri": time.Friday,
+	"saturday": time.Saturday, "sat": time.Saturday,
+}
+
+// parseNaturalDate reads a date phrase such as "tomorrow", "next friday at
+// 9", "in 3 days" or "june 3rd". All words must belong to the phrase;
+// clock reports whether it named a time of day.
+func parseNaturalDate(words []string, now time.Time) (at time.Time, clock bool, ok bool) {
+	if len(words) > 0 && words[0] == "on" {
+		words = words[1:]
+	}
+	day := startOfDay(now)
+	n := 0
+	switch {
+	case len(words) == 0:
+		return time.Time{}, false, false
+	case words[0] == "today":
+		n = 1
+	case words[0] == "tonight":
+		day, clock, n = day.Add(20*time.Hour), true, 1
+	case words[0] == "tomorrow":
+		day, n = day.AddDate(0, 0, 1), 1
+	case words[0] == "in" && len(words) >= 3:
+		count, found := numberWords[words[1]]
+		if !found {
+			parsed, err := strconv.Atoi(words[1])
+			if err != nil || parsed < 1 {
+				return time.Time{}, false, false
+			}
+			count = parsed
+		}
+		switch strings.TrimSuffix(words[2], "s") {
+		case "minute":
+			return now.Add(time.Duration(count) * time.Minute).Truncate(time.Minute), true, len(words) == 3
+		case "hour":
+			return now.Add(time.Duration(count) * time.Hour).Truncate(time.Minute), true, len(words) == 3
+		case "day":
+			day = day.AddDate(0, 0, count)
+		case "week":
+			day = day.AddDate(0, 0, 7*count)
+		case "month":
+			day = day.AddDate(0, count, 0)
+		case "year":
+			day = day.AddDate(count, 0, 0)
+		default:
+			return time.Time{}, false, false
+		}
+		n = 3
+	case words[0] == "next" && len(words) >= 2:
+		switch words[1] {
+		case "week":
+			day = day.AddDate(0, 0, 7-(int(day.Weekday())+6)%7)
+		case "month":
+			day = time.Date(day.Year(), day.Month()+1, 1, 0, 0, 0, 0, day.Location())
+		case "year":
+			day = time.Date(day.Year()+1, time.January, 1, 0, 0, 0, 0, day.Location())
+		default:
+			weekday, found := weekdayNames[words[1]]
+			if !found {
+				return time.Time{}, false, false
+			}
+			day = nextWeekday(day, weekday)
+		}
+		n = 2
+	case len(words) >= 3 && words[0] == "end" && words[1] == "of":
+		switch words[2] {
+		case "week":
+			day = day.AddDate(0, 0, 6-(int(day.Weekday())+6)%7)
+		case "month":
+			day = time.Date(day.Year(), day.Month()+1, 0, 0, 0, 0, 0, day.Location())
+		default:
+			return time.Time{}, false, false
+		}
+		n = 3
+	default:
+		if weekday, found := weekdayNames[words[0]]; found {
+			day, n = nextWeekday(day, weekday), 1
+			break
+		}
+		parsed, used, found := parseMonthDay(words, day)
+		if !found {
+			return time.Time{}, false, false
+		}
+		day, n = parsed, used
+	}
+	rest := words[n:]
+	if len(rest) == 0 {
+		return day, clock, true
+	}
+	if clock {
+		return time.Time{}, false, false
+	}
+	explicit := rest[0] == "at"
+	if explicit {
+		rest = rest[1:]
+	}
+	if len(rest) == 2 && (rest[1] == "am" || rest[1] == "pm") {
+		rest = []string{rest[0] + rest[1]}
+	}
+	if len(rest) != 1 {
+		return time.Time{}, false, false
+	}
+	offset, found := parseClock(rest[0], explicit)
+	if !found {
+		return time.Time{}, false, false
+	}
+	return day.Add(offset), true, true
+}
+
+// nextWeekday returns the first day after day falling on weekday.
+func nextWeekday(day time.Time, weekday time.Weekday) time.Time {
+	diff := (int(weekday) - int(day.Weekday()) + 7) % 7
+	if diff == 0 {
+		diff = 7
+	}
+	return day.AddDate(0, 0, diff)
+}
+
+// parseMonthDay reads "june 3", "june 3rd", "3 june" or "3rd of june",
+// optionally followed by a year. Without a year, a day already past this
+// year means next year.
+func parseMonthDay(words []string, today time.Time) (time.Time, int, bool) {
+	var month time.Month
+	day, n := 0, 0
+	if len(words) >= 2 {
+		if m, ok := monthName(words[0]); ok {
+			if d, ok := ordinalDay(words[1]); ok {
+				month, day, n = m, d, 2
+			}
+		} else if d, ok := ordinalDay(words[0]); ok {
+			rest := words[1:]
+			skip := 1
+			if rest[0] == "of" && len(rest) >= 2 {
+				rest, skip = rest[1:], 2
+			}
+			if m, ok := monthName(rest[0]); ok {
+				month, day, n = m, d, 1+skip
+			}
+		}
+	}
+	if n == 0 {
+		return time.Time{}, 0, false
+	}
+	year := today.Year()
+	explicit := false
+	if n < len(words) && len(words[n]) == 4 {
+		if y, err := strconv.Atoi(words[n]); err == nil {
+			year, explicit, n = y, true, n+1
+		}
+	}
+	date := time.Date(year, month, day, 0, 0, 0, 0, today.Location())
+	if date.Day() != day {
+		return time.Time{}, 0, false
+	}
+	if !explicit && date.Before(today) {
+		date = date.AddDate(1, 0, 0)
+	}
+	return date, n, true
+}
+
+func monthName(word string) (time.Month, bool) {
+	if len(word) < 3 {
+		return 0, false
+	}
+	for m := time.January; m <= time.December; m++ {
+		name := strings.ToLower(m.String())
+		if word == name || word == name[:3] || (word == "sept" && m == time.September) {
+			return m, true
+		}
+	}
+	return 0, false
+}
+
+func ordinalDay(word string) (int, bool) {
+	for _, suffix := range []string{"st", "nd", "rd", "th"} {
+		word = strings.TrimSuffix(word, suffix)
+	}
+	d, err := strconv.Atoi(word)
+	return d, err == nil && d >= 1 && d <= 31
+}
+
+// parseClock reads "9am", "9:30", "14:30", "9:30pm" or "noon". A bare hour
+// such as "9" only counts after "at".
+func parseClock(word string, explicit bool) (time.Duration, bool) {
+	switch word {
+	case "noon":
+		return 12 * time.Hour, true
+	case "midnight":
+		return 0, true
+	}
+	match := clockRe.FindStringSubmatch(word)
+	if match == nil || (!explicit && match[2] == "" && match[3] == "") {
+		return 0, false
+	}
+	hour, _ := strconv.Atoi(match[1])
+	minute := 0
+	if match[2] != "" {
+		minute, _ = strconv.Atoi(match[2])
+	}
+	switch match[3] {
+	case "am", "pm":
+		if hour < 1 || hour > 12 {
+			return 0, false
+		}
+		hour %= 12
+		if match[3] == "pm" {
+			hour += 12
+		}
+	}
+	if hour > 23 || minute > 59 {
+		return 0, false
+	}
+	return time.Duration(hour)*time.Hour + time.Duration(minute)*time.Minute, true
+}
+
+func formatReminderTime(at time.Time, clock bool) string {
+	if clock {
+		return at.Format("2006-01-02 15:04")
+	}
+	return at.Format("2006-01-02")
+}
+
+// resolveNaturalDates replaces the date phrases of text with the dates they
+// stand for on now and reports how many it replaced.
+func resolveNaturalDates(text string, now time.Time) (string, int) {
+	count := 0
+	if fields, ok := parseFrontmatter(text); ok {
+		for _, key := range naturalDateFields {
+			value, found := fields[key]
+			if !found || strings.TrimSpace(value) == "" {
+				continue
+			}
+			if _, ok := parseReminderTime(value); ok {
+				continue
+			}
+			if at, clock, ok := parseNaturalDate(strings.Fields(strings.ToLower(value)), now); ok {
+				text = setFrontmatterField(text, key, formatReminderTime(at, clock))
+				count++
+			}
+		}
+	}
+	var out strings.Builder
+	last := 0
+	for _, loc := range remindMeRe.FindAllStringIndex(text, -1) {
+		if loc[0] < last {
+			continue
+		}
+		start, end, replacement, ok := naturalPhraseAfter(text, loc[1], now)
+		if !ok {
+			continue
+		}
+		out.WriteString(text[last:start])
+		out.WriteString(replacement)
+		last = end
+		count++
+	}
+	if last == 0 {
+		return text, count
+	}
+	out.WriteString(text[last:])
+	return out.String(), count
+}
+
+// naturalPhraseAfter finds the longest date phrase starting after offset
+// on the same line and returns its byte range and the formatted date.
+func naturalPhraseAfter(text string, offset int, now time.Time) (int, int, string, bool) {
+	lineEnd := strings.IndexByte(text[offset:], '\n')
+	if lineEnd < 0 {
+		lineEnd = len(text) - offset
+	}
+	line := text[offset : offset+lineEnd]
+	if !strings.HasPrefix(line, " ") {
+		return 0, 0, "", false
+	}
+	var words []string
+	var ends []int
+	pos := 0
+	for len(words) < maxNaturalWords {
+		for pos < len(line) && line[pos] == ' ' {
+			pos++
+		}
+		if pos == len(line) {
+			break
+		}
+		wordEnd := strings.IndexAny(line[pos:], " \t\r")
+		if wordEnd < 0 {
+			wordEnd = len(line) - pos
+		}
+		words = append(words, line[pos:pos+wordEnd])
+		ends = append(ends, pos+wordEnd)
+		pos += wordEnd
+	}
+	for k := len(words); k >= 1; k-- {
+		phrase := make([]string, k)
+		for i, w := range words[:k] {
+			phrase[i] = strings.ToLower(w)
+		}
+		end := ends[k-1]
+		// Punctuation closing the sentence stays in place.
+		trimmed := strings.TrimRight(phrase[k-1], ".,;:!?)")
+		end -= len(phrase[k-1]) - len(trimmed)
+		phrase[k-1] = trimmed
+		if at, clock, ok := parseNaturalDate(phrase, now); ok {
+			return offset + 1, offset + end, formatReminderTime(at, clock), true
+		}
+	}
+	return 0, 0, "", false
+}
+
+// bodyReminders returns the resolved "remind me" dates in content.
+func bodyReminders(content string) []time.Time {
+	var out []time.Time
+	for _, match := range reminderAnnotationRe.FindAllStringSubmatch(content, -1) {
+		if at, ok := parseReminderTime(match[1]); ok {
+			out = append(out, at)
+		}
+	}
+	return out
+}
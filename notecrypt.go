@@ -0,0 +1,448 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdh"
+	"crypto/hkdf"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Notes can be encrypted, age-style, to the recipients of their vault. The
+// recipients are X25519 public keys listed in .gono/recipients; every device
+// or person holds the matching private key in a keyfile. An encrypted note
+// "name.md" is stored as "name.md.gnc": a random file key seals the note
+// with AES-256-GCM, and the header holds one copy of the file key per
+// recipient, wrapped with a key agreed between a one-time X25519 key and
+// the recipient's key.
+
+const (
+	encryptedExt    = ".gnc"
+	encryptedMagic  = "GONO-ENCRYPTED 1\n"
+	headerEnd       = "---\n"
+	publicKeyPrefix = "gonopub-"
+	secretKeyPrefix = "GONO-SECRET-KEY-"
+	recipientsName  = "recipients"
+	defaultKeyfile  = ".gono_key"
+	stanzaPrefix    = "-> x25519 "
+	wrapInfo        = "gono-x25519"
+	fileKeySize     = 32
+)
+
+var errNoIdentity = errors.New("no keyfile can open this note")
+
+// recipient is a public key of the vault with the name it was added under.
+type recipient struct {
+	key  *ecdh.PublicKey
+	name string
+}
+
+func isEncryptedNote(path string) bool {
+	return strings.EqualFold(filepath.Ext(path), encryptedExt)
+}
+
+func encodePublicKey(k *ecdh.PublicKey) string {
+	return publicKeyPrefix + base64.RawURLEncoding.EncodeToString(k.Bytes())
+}
+
+func parsePublicKey(s string) (*ecdh.PublicKey, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(strings.TrimPrefix(strings.TrimSpace(s), publicKeyPrefix))
+	if err != nil || !strings.HasPrefix(strings.TrimSpace(s), publicKeyPrefix) {
+		return nil, fmt.Errorf("not a GoNo public key: %s", s)
+	}
+	return ecdh.X25519().NewPublicKey(raw)
+}
+
+// generateKeyfile writes a new private key to path, which must not exist,
+// and returns its public key.
+func generateKeyfile(path string) (*ecdh.PublicKey, error) {
+	key, err := ecdh.X25519().GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	content := fmt.Sprintf("# created: %s\n# public key: %s\n%s%s\n",
+		time.Now().Format(time.RFC3339), encodePublicKey(key.PublicKey()),
+		secretKeyPrefix, base64.RawURLEncoding.EncodeToString(key.Bytes()))
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return nil, err
+	}
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0600)
+	if err != nil {
+		return nil, err
+	}
+	_, err = file.WriteString(content)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return nil, err
+	}
+	return key.PublicKey(), nil
+}
+
+// readKeyfile returns the private keys in a keyfile; "#" lines are comments.
+func readKeyfile(path string) ([]*ecdh.PrivateKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var keys []*ecdh.PrivateKey
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if !strings.HasPrefix(line, secretKeyPrefix) {
+			continue
+		}
+		raw, err := base64.RawURLEncoding.DecodeString(strings.TrimPrefix(line, secretKeyPrefix))
+		if err != nil {
+			return nil, fmt.Errorf("%s: damaged key", path)
+		}
+		key, err := ecdh.X25519().NewPrivateKey(raw)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		keys = append(keys, key)
+	}
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("%s: no key found", path)
+	}
+	return keys, nil
+}
+
+// keyfilePaths lists the keyfiles to try: GONO_KEYFILE, the configured
+// ones, or ~/.gono_key.
+func keyfilePaths(cfg encryptionConfig) []string {
+	var paths []string
+	if env := os.Getenv("GONO_KEYFILE"); env != "" {
+		paths = append(paths, env)
+	}
+	for _, p := range cfg.Keyfiles {
+		paths = append(paths, expandHome(p))
+	}
+	if len(paths) == 0 {
+		paths = append(paths, filepath.Join(homeDir(), defaultKeyfile))
+	}
+	return paths
+}
+
+// loadIdentities reads the private keys of every keyfile that exists.
+func loadIdentities(cfg encryptionConfig) ([]*ecdh.PrivateKey, error) {
+	var keys []*ecdh.PrivateKey
+	for _, p := range keyfilePaths(cfg) {
+		found, err := readKeyfile(p)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, found...)
+	}
+	return keys, nil
+}
+
+func recipientsPath(vault string) string {
+	return filepath.Join(appDir(vault), recipientsName)
+}
+
+// loadRecipients reads .gono/recipients: one public key per line, followed
+// by an optional name.
+func loadRecipients(vault string) ([]recipient, error) {
+	data, err := os.ReadFile(recipientsPath(vault))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var out []recipient
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		keyText, name, _ := strings.Cut(line, " ")
+		key, err := parsePublicKey(keyText)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", recipientsPath(vault), err)
+		}
+		out = append(out, recipient{key: key, name: strings.TrimSpace(name)})
+	}
+	return out, nil
+}
+
+func saveRecipients(vault string, recipients []recipient) error {
+	var b strings.Builder
+	b.WriteString("# Public keys notes in this vault are encrypted to, one per line.\n")
+	for _, r := range recipients {
+		b.WriteString(encodePublicKey(r.key))
+		if r.name != "" {
+			b.WriteString(" " + r.name)
+		}
+		b.WriteString("\n")
+	}
+	if err := os.MkdirAll(appDir(vault), 0755); err != nil {
+		return err
+	}
+	return writeFileAtomic(recipientsPath(vault), []byte(b.String()), 0644)
+}
+
+// addRecipient adds key to the vault unless it is already there.
+func addRecipient(vault string, key *ecdh.PublicKey, name string) (bool, error) {
+	recipients, err := loadRecipients(vault)
+	if err != nil {
+		return false, err
+	}
+	for _, r := range recipients {
+		if r.key.Equal(key) {
+			return false, nil
+		}
+	}
+	return true, saveRecipients(vault, append(recipients, recipient{key: key, name: name}))
+}
+
+var errLastRecipient = errors.New("the last recipient cannot be removed: nobody could open new notes")
+
+func removeRecipient(vault string, key *ecdh.PublicKey) (bool, error) {
+	recipients, err := loadRecipients(vault)
+	if err != nil {
+		return false, err
+	}
+	kept := recipients[:0]
+	for _, r := range recipients {
+		if !r.key.Equal(key) {
+			kept = append(kept, r)
+		}
+	}
+	if len(kept) == len(recipients) {
+		return false, nil
+	}
+	if len(kept) == 0 {
+		return false, errLastRecipient
+	}
+	return true, saveRecipients(vault, kept)
+}
+
+func wrapKey(shared []byte, ephemeral []byte, to []byte) (cipher.AEAD, error) {
+	key, err := hkdf.Key(sha256.New, shared, append(append([]byte{}, ephemeral...), to...), wrapInfo, 32)
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// encryptNote seals plain for recipients.
+func encryptNote(plain []byte, recipients []recipient) ([]byte, error) {
+	if len(recipients) == 0 {
+		return nil, errors.New("the vault has no recipients: add a key first (Alt+K)")
+	}
+	fileKey := make([]byte, fileKeySize)
+	if _, err := rand.Read(fileKey); err != nil {
+		return nil, err
+	}
+	var header bytes.Buffer
+	header.WriteString(encryptedMagic)
+	for _, r := range recipients {
+		ephemeral, err := ecdh.X25519().GenerateKey(rand.Reader)
+		if err != nil {
+			return nil, err
+		}
+		shared, err := ephemeral.ECDH(r.key)
+		if err != nil {
+			return nil, err
+		}
+		aead, err := wrapKey(shared, ephemeral.PublicKey().Bytes(), r.key.Bytes())
+		if err != nil {
+			return nil, err
+		}
+		// The wrapping key is used once, so a zero nonce is safe.
+		wrapped := aead.Seal(nil, make([]byte, aead.NonceSize()), fileKey, nil)
+		fmt.Fprintf(&header, "%s%s %s\n", stanzaPrefix,
+			base64.RawStdEncoding.EncodeToString(ephemeral.PublicKey().Bytes()),
+			base64.RawStdEncoding.EncodeToString(wrapped))
+	}
+	header.WriteString(headerEnd)
+
+	block, err := aes.NewCipher(fileKey)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	out := append(header.Bytes(), nonce...)
+	return gcm.Seal(out, nonce, plain, header.Bytes()), nil
+}
+
+// decryptNote opens an encrypted note with the first identity that was one
+// of its recipients.
+func decryptNote(sealed []byte, identities []*ecdh.PrivateKey) ([]byte, error) {
+	r := bufio.NewReader(bytes.NewReader(sealed))
+	magic, err := r.ReadString('\n')
+	if err != nil || magic != encryptedMagic {
+		return nil, errors.New("not an encrypted GoNo note")
+	}
+	headerLen := len(magic)
+	var fileKey []byte
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return nil, errors.New("encrypted note header is truncated")
+		}
+		headerLen += len(line)
+		if line == headerEnd {
+			break
+		}
+		if fileKey != nil || !strings.HasPrefix(line, stanzaPrefix) {
+			continue
+		}
+		fields := strings.Fields(strings.TrimPrefix(line, stanzaPrefix))
+		if len(fields) != 2 {
+			continue
+		}
+		ephemeralRaw, err1 := base64.RawStdEncoding.DecodeString(fields[0])
+		wrapped, err2 := base64.RawStdEncoding.DecodeString(fields[1])
+		ephemeral, err3 := ecdh.X25519().NewPublicKey(ephemeralRaw)
+		if err1 != nil || err2 != nil || err3 != nil {
+			continue
+		}
+		for _, id := range identities {
+			shared, err := id.ECDH(ephemeral)
+			if err != nil {
+				continue
+			}
+			aead, err := wrapKey(shared, ephemeralRaw, id.PublicKey().Bytes())
+			if err != nil {
+				return nil, err
+			}
+			if key, err := aead.Open(nil, make([]byte, aead.NonceSize()), wrapped, nil); err == nil {
+				fileKey = key
+				break
+			}
+		}
+	}
+	if fileKey == nil {
+		return nil, errNoIdentity
+	}
+	block, err := aes.NewCipher(fileKey)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	body := sealed[headerLen:]
+	if len(body) < gcm.NonceSize() {
+		return nil, errors.New("encrypted note is truncated")
+	}
+	plain, err := gcm.Open(nil, body[:gcm.NonceSize()], body[gcm.NonceSize():], sealed[:headerLen])
+	if err != nil {
+		return nil, errors.New("encrypted note is damaged")
+	}
+	return plain, nil
+}
+
+// readEncryptedNote decrypts the note at path with the configured keyfiles.
+func readEncryptedNote(path string, cfg encryptionConfig) ([]byte, error) {
+	sealed, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	identities, err := loadIdentities(cfg)
+	if err != nil {
+		return nil, err
+	}
+	if len(identities) == 0 {
+		return nil, errors.New("no keyfile found: create one with Alt+K or gono keys gen")
+	}
+	return decryptNote(sealed, identities)
+}
+
+// writeEncryptedNote seals plain for the vault's recipients and writes it
+// to path.
+func writeEncryptedNote(vault string, path string, plain []byte) error {
+	recipients, err := loadRecipients(vault)
+	if err != nil {
+		return err
+	}
+	sealed, err := encryptNote(plain, recipients)
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(path, sealed, 0600)
+}
+
+// rotateVault re-encrypts every encrypted note of the vault with a new file
+// key for the current recipients, so removed recipients can no longer open
+// them. Notes no keyfile can open are left as they are and listed.
+func rotateVault(vault string, cfg encryptionConfig, j *job) (int, []string, error) {
+	recipients, err := loadRecipients(vault)
+	if err != nil {
+		return 0, nil, err
+	}
+	if len(recipients) == 0 {
+		return 0, nil, errors.New("the vault has no recipients")
+	}
+	identities, err := loadIdentities(cfg)
+	if err != nil {
+		return 0, nil, err
+	}
+	var notes []string
+	_ = walkVault(vault, func(p string, d fs.DirEntry) error {
+		if isEncryptedNote(p) {
+			notes = append(notes, p)
+		}
+		return nil
+	})
+	if j != nil {
+		j.total.Store(int64(len(notes)))
+	}
+	rotated := 0
+	var skipped []string
+	for _, p := range notes {
+		if j != nil {
+			if j.cancelled() {
+				break
+			}
+			j.step()
+		}
+		sealed, err := os.ReadFile(p)
+		if err != nil {
+			return rotated, skipped, err
+		}
+		plain, err := decryptNote(sealed, identities)
+		if err != nil {
+			skipped = append(skipped, relOrBase(vault, p))
+			continue
+		}
+		resealed, err := encryptNote(plain, recipients)
+		if err != nil {
+			return rotated, skipped, err
+		}
+		if err := writeFileAtomic(p, resealed, 0600); err != nil {
+			return rotated, skipped, err
+		}
+		rotated++
+	}
+	return rotated, skipped, nil
+}
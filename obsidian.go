@@ -0,0 +1,218 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+const obsidianAttachmentsDir = "attachments"
+
+var markdownLinkRe = regexp.MustCompile(`(!?)\[([^\]]*)\]\(([^)\s]+)(?:\s+"[^"]*")?\)`)
+
+// obsidianExport copies a vault into dest using Obsidian conventions: notes
+// keep their paths, other files move to attachments/, relative Markdown
+// links become [[wiki-links]] and a minimal .obsidian config is written.
+type obsidianExport struct {
+	vault       string
+	dest        string
+	attachments map[string]string
+	usedNames   map[string]struct{}
+	notes       int
+}
+
+func exportObsidianCommand(args []string, stdout io.Writer) error {
+	if len(args) != 2 {
+		return errors.New("usage: gono export-obsidian VAULT DEST")
+	}
+	vault, err := filepath.Abs(args[0])
+	if err != nil {
+		return err
+	}
+	dest, err := filepath.Abs(args[1])
+	if err != nil {
+		return err
+	}
+	if pathWithin(vault, dest) {
+		return errors.New("destination must be outside the vault")
+	}
+	if entries, readErr := os.ReadDir(dest); readErr == nil && len(entries) > 0 {
+		return fmt.Errorf("destination is not empty: %s", dest)
+	}
+
+	exp := &obsidianExport{
+		vault:       vault,
+		dest:        dest,
+		attachments: make(map[string]string),
+		usedNames:   make(map[string]struct{}),
+	}
+	if err := exp.run(); err != nil {
+		return err
+	}
+	fmt.Fprintf(stdout, "Exported %s and %s to %s\n",
+		pluralize(exp.notes, "note", "notes"),
+		pluralize(len(exp.attachments), "attachment", "attachments"),
+		dest)
+	return nil
+}
+
+func (e *obsidianExport) run() error {
+	var notes []string
+	err := walkVault(e.vault, func(p string, d fs.DirEntry) error {
+		rel, err := filepath.Rel(e.vault, p)
+		if err != nil {
+			return err
+		}
+		if isExportedNote(p) {
+			notes = append(notes, p)
+			return nil
+		}
+		if d.Name() == orderFileName {
+			return nil
+		}
+		name := e.attachmentName(d.Name())
+		e.attachments[filepath.ToSlash(rel)] = name
+		return copyFile(p, filepath.Join(e.dest, obsidianAttachmentsDir, name))
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, p := range notes {
+		if err := e.exportNote(p); err != nil {
+			return err
+		}
+	}
+	return writeObsidianConfig(e.dest)
+}
+
+func isExportedNote(path string) bool {
+	ext := strings.ToLower(filepath.Ext(path))
+	return ext == ".md" || ext == ".markdown" || ext == ".org"
+}
+
+// attachmentName returns a file name that is unique inside attachments/.
+func (e *obsidianExport) attachmentName(name string) string {
+	ext := filepath.Ext(name)
+	stem := strings.TrimSuffix(name, ext)
+	candidate := name
+	for i := 1; ; i++ {
+		if _, taken := e.usedNames[strings.ToLower(candidate)]; !taken {
+			e.usedNames[strings.ToLower(candidate)] = struct{}{}
+			return candidate
+		}
+		candidate = fmt.Sprintf("%s-%d%s", stem, i, ext)
+	}
+}
+
+func (e *obsidianExport) exportNote(path string) error {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	rel, err := filepath.Rel(e.vault, path)
+	if err != nil {
+		return err
+	}
+	text := string(content)
+	if isOrgFile(path) {
+		text = orgToMarkdown(text)
+		rel = strings.TrimSuffix(rel, filepath.Ext(rel)) + ".md"
+	}
+	text = e.rewriteLinks(filepath.Dir(path), text)
+
+	target := filepath.Join(e.dest, rel)
+	if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		return err
+	}
+	e.notes++
+	return os.WriteFile(target, []byte(text), 0644)
+}
+
+// rewriteLinks turns relative links to notes and attachments into
+// Obsidian wiki-links; external URLs and unresolvable links are kept.
+func (e *obsidianExport) rewriteLinks(dir string, text string) string {
+	return markdownLinkRe.ReplaceAllStringFunc(text, func(m string) string {
+		parts := markdownLinkRe.FindStringSubmatch(m)
+		embed, label, target := parts[1] == "!", parts[2], parts[3]
+		if strings.Contains(target, "://") || strings.HasPrefix(target, "mailto:") || strings.HasPrefix(target, "#") {
+			return m
+		}
+		anchor := ""
+		if i := strings.Index(target, "#"); i >= 0 {
+			target, anchor = target[:i], target[i:]
+		}
+		if unescaped, err := url.PathUnescape(target); err == nil {
+			target = unescaped
+		}
+		abs := filepath.Join(dir, filepath.FromSlash(target))
+		rel, err := filepath.Rel(e.vault, abs)
+		if err != nil || !pathWithin(e.vault, abs) {
+			return m
+		}
+		rel = filepath.ToSlash(rel)
+
+		if name, ok := e.attachments[rel]; ok {
+			if embed {
+				return "![[" + name + "]]"
+			}
+			return wikiLink(name, anchor, label)
+		}
+		if isExportedNote(rel) {
+			note := strings.TrimSuffix(rel, filepath.Ext(rel))
+			return wikiLink(note, anchor, label)
+		}
+		return m
+	})
+}
+
+func wikiLink(target string, anchor string, label string) string {
+	link := target + anchor
+	if label == "" || label == filepath.Base(target) || label == link {
+		return "[[" + link + "]]"
+	}
+	return "[[" + link + "|" + label + "]]"
+}
+
+func writeObsidianConfig(dest string) error {
+	dir := filepath.Join(dest, ".obsidian")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(map[string]any{
+		"attachmentFolderPath": obsidianAttachmentsDir,
+		"newLinkFormat":        "absolute",
+		"useMarkdownLinks":     false,
+	}, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(dir, "app.json"), data, 0644)
+}
+
+func copyFile(src string, dst string) error {
+	if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+		return err
+	}
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := os.OpenFile(dst, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		_ = out.Close()
+		return err
+	}
+	return out.Close()
+}
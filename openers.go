@@ -0,0 +1,96 @@
+package main
+
+import (
+	"errors"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// Files can be handed to other programs by extension with the "openers"
+// config, e.g. {".xlsx": "libreoffice --calc", ".png": "feh -."}: Enter on
+// such a file starts the program instead of loading the file into the
+// editor. The file is passed as the last argument, or wherever {file}
+// appears in the command; "default" uses the desktop's default handler.
+// Openers are started in the background, so they should be graphical
+// programs rather than ones that need the terminal.
+
+const openerDefault = "default"
+
+// normalizedOpeners lowercases the extensions of openers, adds the leading
+// dot where it is missing and drops empty commands.
+func normalizedOpeners(openers map[string]string) map[string]string {
+	out := make(map[string]string, len(openers))
+	for ext, command := range openers {
+		ext = strings.ToLower(strings.TrimSpace(ext))
+		command = strings.TrimSpace(command)
+		if ext == "" || ext == "." || command == "" {
+			continue
+		}
+		if !strings.HasPrefix(ext, ".") {
+			ext = "." + ext
+		}
+		out[ext] = command
+	}
+	return out
+}
+
+// openerFor returns the command configured for the extension of path.
+func (c appConfig) openerFor(path string) (string, bool) {
+	command, ok := c.Openers[strings.ToLower(filepath.Ext(path))]
+	return command, ok
+}
+
+// openerCommand builds the command line opening path with command.
+func openerCommand(command string, path string) (*exec.Cmd, error) {
+	args := strings.Fields(command)
+	if len(args) == 0 {
+		return nil, errors.New("empty opener command")
+	}
+	placed := false
+	for i, arg := range args {
+		if strings.Contains(arg, "{file}") {
+			args[i] = strings.ReplaceAll(arg, "{file}", path)
+			placed = true
+		}
+	}
+	if !placed {
+		args = append(args, path)
+	}
+	cmd := exec.Command(args[0], args[1:]...)
+	cmd.Dir = filepath.Dir(path)
+	return cmd, nil
+}
+
+// openWithOpener starts the configured opener for path without waiting for
+// it to exit.
+func openWithOpener(command string, path string) error {
+	if command == openerDefault {
+		// The default handler takes file paths as well as URLs.
+		return openInBrowser(path)
+	}
+	cmd, err := openerCommand(command, path)
+	if err != nil {
+		return err
+	}
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	go cmd.Wait()
+	return nil
+}
+
+func (m Model) openExternally(path string, command string) (tea.Model, tea.Cmd) {
+	if err := openWithOpener(command, path); err != nil {
+		m.status = failStatus("%s: %v", filepath.Base(path), err)
+		return m, nil
+	}
+	if command == openerDefault {
+		m.status = okStatus("Opened %s", relOrBase(m.vault, path))
+	} else {
+		m.status = okStatus("Opened %s with %s", relOrBase(m.vault, path), strings.Fields(command)[0])
+	}
+	return m, nil
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const orderFileName = ".order"
+
+// readOrderFile returns the position of each name listed in dir/.order, one
+// name per line. Blank lines and lines starting with # are ignored; a
+// trailing path separator on directory names is optional.
+func readOrderFile(dir string) map[string]int {
+	data, err := os.ReadFile(filepath.Join(dir, orderFileName))
+	if err != nil {
+		return nil
+	}
+	positions := make(map[string]int)
+	for _, line := range strings.Split(string(data), "\n") {
+		name := strings.TrimRight(strings.TrimSpace(line), `/\`)
+		if name == "" || strings.HasPrefix(name, "#") {
+			continue
+		}
+		if _, ok := positions[name]; !ok {
+			positions[name] = len(positions)
+		}
+	}
+	return positions
+}
+
+// frontmatterOrders caches the "order:" field of notes by path, so a refresh
+// only reads the notes that changed since the last one.
+var frontmatterOrders = struct {
+	sync.Mutex
+	byPath map[string]cachedOrder
+}{byPath: make(map[string]cachedOrder)}
+
+type cachedOrder struct {
+	modTime time.Time
+	size    int64
+	value   float64
+	ok      bool
+}
+
+// frontmatterOrder returns the numeric "order:" frontmatter field of the
+// note at path.
+func frontmatterOrder(path string) (float64, bool) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return 0, false
+	}
+	frontmatterOrders.Lock()
+	cached, hit := frontmatterOrders.byPath[path]
+	frontmatterOrders.Unlock()
+	if hit && cached.modTime.Equal(info.ModTime()) && cached.size == info.Size() {
+		return cached.value, cached.ok
+	}
+	cached = cachedOrder{modTime: info.ModTime(), size: info.Size()}
+	if value, ok := readFrontmatter(path)["order"]; ok {
+		if n, err := strconv.ParseFloat(value, 64); err == nil {
+			cached.value, cached.ok = n, true
+		}
+	}
+	frontmatterOrders.Lock()
+	frontmatterOrders.byPath[path] = cached
+	frontmatterOrders.Unlock()
+	return cached.value, cached.ok
+}
+
+// sortEntries orders directory entries: directories first, then names listed
+// in .order, then notes with a numeric "order:" frontmatter field, then the
+// rest alphabetically.
+func sortEntries(dir string, entries []item, useFrontmatter bool) {
+	positions := readOrderFile(dir)
+	fmOrder := make(map[string]float64)
+	if useFrontmatter {
+		for _, e := range entries {
+			if e.isDir || !strings.EqualFold(filepath.Ext(e.path), ".md") {
+				continue
+			}
+			if n, ok := frontmatterOrder(e.path); ok {
+				fmOrder[e.path] = n
+			}
+		}
+	}
+
+	sort.SliceStable(entries, func(i, j int) bool {
+		a, b := entries[i], entries[j]
+		if a.isDir != b.isDir {
+			return a.isDir
+		}
+		pa, aListed := positions[filepath.Base(a.path)]
+		pb, bListed := positions[filepath.Base(b.path)]
+		if aListed != bListed {
+			return aListed
+		}
+		if aListed && pa != pb {
+			return pa < pb
+		}
+		oa, aOrdered := fmOrder[a.path]
+		ob, bOrdered := fmOrder[b.path]
+		if aOrdered != bOrdered {
+			return aOrdered
+		}
+		if aOrdered && oa != ob {
+			return oa < ob
+		}
+		return strings.ToLower(a.title) < strings.ToLower(b.title)
+	})
+}
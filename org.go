@@ -0,0 +1,141 @@
+package main
+
+import (
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+var (
+	orgLinkRe     = regexp.MustCompile(`\[\[([^\]]+)\]\[([^\]]+)\]\]`)
+	orgBareLinkRe = regexp.MustCompile(`\[\[([^\]]+)\]\]`)
+	orgBoldRe     = regexp.MustCompile(`(^|[\s(])\*([^\s*](?:[^*]*[^\s*])?)\*($|[\s).,:;!?])`)
+	orgItalicRe   = regexp.MustCompile(`(^|[\s(])/([^\s/](?:[^/]*[^\s/])?)/($|[\s).,:;!?])`)
+	orgCodeRe     = regexp.MustCompile(`(^|[\s(])=([^\s=](?:[^=]*[^\s=])?)=($|[\s).,:;!?])`)
+	orgVerbatimRe = regexp.MustCompile(`(^|[\s(])~([^\s~](?:[^~]*[^\s~])?)~($|[\s).,:;!?])`)
+	orgStrikeRe   = regexp.MustCompile(`(^|[\s(])\+([^\s+](?:[^+]*[^\s+])?)\+($|[\s).,:;!?])`)
+)
+
+func isOrgFile(path string) bool {
+	return strings.EqualFold(filepath.Ext(path), ".org")
+}
+
+// orgToMarkdown converts the commonly used subset of Org syntax to Markdown:
+// headlines, emphasis, links, lists, quote/source blocks and #+TITLE.
+// Drawers and other #+ keywords are dropped.
+func orgToMarkdown(src string) string {
+	lines := strings.Split(strings.ReplaceAll(src, "\r\n", "\n"), "\n")
+	out := make([]string, 0, len(lines))
+	inSrc := false
+	inQuote := false
+	inDrawer := false
+
+	for _, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		upper := strings.ToUpper(trimmed)
+
+		if inSrc {
+			if strings.HasPrefix(upper, "#+END_SRC") || strings.HasPrefix(upper, "#+END_EXAMPLE") {
+				out = append(out, "```")
+				inSrc = false
+				continue
+			}
+			out = append(out, line)
+			continue
+		}
+		if inDrawer {
+			if upper == ":END:" {
+				inDrawer = false
+			}
+			continue
+		}
+
+		switch {
+		case strings.HasPrefix(upper, "#+BEGIN_SRC"), strings.HasPrefix(upper, "#+BEGIN_EXAMPLE"):
+			lang := ""
+			if fields := strings.Fields(trimmed); len(fields) > 1 && strings.HasPrefix(upper, "#+BEGIN_SRC") {
+				lang = fields[1]
+			}
+			out = append(out, "```"+lang)
+			inSrc = true
+			continue
+		case strings.HasPrefix(upper, "#+BEGIN_QUOTE"):
+			inQuote = true
+			continue
+		case strings.HasPrefix(upper, "#+END_QUOTE"):
+			inQuote = false
+			continue
+		case strings.HasPrefix(upper, "#+TITLE:"):
+			out = append(out, "# "+strings.TrimSpace(trimmed[len("#+TITLE:"):]))
+			continue
+		case strings.HasPrefix(trimmed, "#+"):
+			continue
+		case trimmed == ":PROPERTIES:" || trimmed == ":LOGBOOK:":
+			inDrawer = true
+			continue
+		}
+
+		converted := orgLine(line)
+		if inQuote {
+			converted = "> " + converted
+		}
+		out = append(out, converted)
+	}
+	if inSrc {
+		out = append(out, "```")
+	}
+	return strings.Join(out, "\n")
+}
+
+func orgLine(line string) string {
+	if level := orgHeadingLevel(line); level > 0 {
+		text := strings.TrimSpace(line[level:])
+		return strings.Repeat("#", minInt(level, 6)) + " " + orgInline(text)
+	}
+
+	indent := line[:len(line)-len(strings.TrimLeft(line, " \t"))]
+	rest := line[len(indent):]
+	if strings.HasPrefix(rest, "+ ") || (indent != "" && strings.HasPrefix(rest, "* ")) {
+		rest = "- " + rest[2:]
+	}
+	return indent + orgInline(rest)
+}
+
+func orgHeadingLevel(line string) int {
+	level := 0
+	for level < len(line) && line[level] == '*' {
+		level++
+	}
+	if level == 0 || level >= len(line) || line[level] != ' ' {
+		return 0
+	}
+	return level
+}
+
+func orgInline(s string) string {
+	s = orgLinkRe.ReplaceAllString(s, "[$2]($1)")
+	s = orgBareLinkRe.ReplaceAllStringFunc(s, func(m string) string {
+		target := orgBareLinkRe.FindStringSubmatch(m)[1]
+		if strings.Contains(target, "://") {
+			return "<" + target + ">"
+		}
+		return "[" + target + "](" + target + ")"
+	})
+	s = orgCodeRe.ReplaceAllString(s, "$1`$2`$3")
+	s = orgVerbatimRe.ReplaceAllString(s, "$1`$2`$3")
+	// Markup inside =code= and ~verbatim~ is taken literally.
+	parts := strings.Split(s, "`")
+	for i := 0; i < len(parts); i += 2 {
+		parts[i] = orgBoldRe.ReplaceAllString(parts[i], "$1**$2**$3")
+		parts[i] = orgItalicRe.ReplaceAllString(parts[i], "$1*$2*$3")
+		parts[i] = orgStrikeRe.ReplaceAllString(parts[i], "$1~~$2~~$3")
+	}
+	return strings.Join(parts, "`")
+}
+
+func minInt(a int, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,23 @@
+package main
+
+import "testing"
+
+func TestOrgInline(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"use =code= here", "use `code` here"},
+		{"use ~verbatim~ here", "use `verbatim` here"},
+		{"~a = b~", "`a = b`"},
+		{"=a~b=", "`a~b`"},
+		{"~/tmp/x~", "`/tmp/x`"},
+		{"=x~", "=x~"},
+		{"*bold* and ~v~", "**bold** and `v`"},
+		{"+gone+", "~~gone~~"},
+	}
+	for _, tt := range tests {
+		if got := orgInline(tt.in); got != tt.want {
+			t.Errorf("orgInline(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
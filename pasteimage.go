@@ -0,0 +1,87 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+const assetsDirName = "assets"
+
+var (
+	pngSignature        = []byte("\x89PNG\r\n\x1a\n")
+	errNoClipboardImage = errors.New("no image in the clipboard")
+)
+
+// clipboardImage returns the clipboard image as PNG. The terminal only passes
+// text through, so it is read with the platform's clipboard tool.
+func clipboardImage() ([]byte, error) {
+	var candidates [][]string
+	switch runtime.GOOS {
+	case "darwin":
+		candidates = [][]string{{"pngpaste", "-"}}
+	case "windows":
+		candidates = [][]string{{"powershell", "-NoProfile", "-Command",
+			"Add-Type -AssemblyName System.Windows.Forms; $i = [System.Windows.Forms.Clipboard]::GetImage(); " +
+				"if ($i) { $s = New-Object System.IO.MemoryStream; $i.Save($s, [System.Drawing.Imaging.ImageFormat]::Png); " +
+				"$o = [Console]::OpenStandardOutput(); $s.WriteTo($o); $o.Flush() }"}}
+	default:
+		if os.Getenv("WAYLAND_DISPLAY") != "" {
+			candidates = append(candidates, []string{"wl-paste", "--no-newline", "--type", "image/png"})
+		}
+		candidates = append(candidates, []string{"xclip", "-selection", "clipboard", "-target", "image/png", "-out"})
+	}
+
+	var missing []string
+	for _, c := range candidates {
+		if _, err := exec.LookPath(c[0]); err != nil {
+			missing = append(missing, c[0])
+			continue
+		}
+		out, err := exec.Command(c[0], c[1:]...).Output()
+		if err != nil || !bytes.HasPrefix(out, pngSignature) {
+			return nil, errNoClipboardImage
+		}
+		return out, nil
+	}
+	return nil, fmt.Errorf("reading images from the clipboard needs %s: %w", strings.Join(missing, " or "), exec.ErrNotFound)
+}
+
+// pasteImage saves the clipboard image under the vault's assets/ folder and
+// inserts a Markdown image reference to it at the cursor.
+func (m Model) pasteImage() (tea.Model, tea.Cmd) {
+	data, err := clipboardImage()
+	if err != nil {
+		m.status = errorStatus(err)
+		return m, nil
+	}
+	stem := slugify(strings.TrimSuffix(filepath.Base(m.editing), filepath.Ext(m.editing)))
+	if stem == "" {
+		stem = "image"
+	}
+	name := stem + "-" + time.Now().Format("20060102-150405") + ".png"
+	target := uniquePath(filepath.Join(m.vault, assetsDirName, name))
+	if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		m.status = errorStatus(err)
+		return m, nil
+	}
+	if err := os.WriteFile(target, data, 0644); err != nil {
+		m.status = errorStatus(err)
+		return m, nil
+	}
+	rel, err := filepath.Rel(filepath.Dir(m.editing), target)
+	if err != nil {
+		rel = target
+	}
+	m.textarea.InsertString("![](" + filepath.ToSlash(rel) + ")")
+	m.status = okStatus("Image saved: %s", relOrBase(m.vault, target))
+	return m, nil
+}
@@ -0,0 +1,324 @@
+package main
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/charmbracelet/bubbles/list"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// People are notes in the vault's people folder (people.dir, "people" by
+// default). Other notes mention them as @slug, the person note's file name
+// without extension, or link to them with [[people/slug]]. Tab after an
+// @ in the editor completes the name, and Alt+W on a person note lists the
+// notes that mention it, newest first.
+
+type personNote struct {
+	path string
+	slug string
+	name string
+}
+
+func (m Model) peopleDir() string {
+	dir := strings.TrimSpace(m.cfg.People.Dir)
+	if dir == "" {
+		dir = "people"
+	}
+	return filepath.Join(m.vault, filepath.FromSlash(dir))
+}
+
+// loadPeople returns the person notes under dir, sorted by name.
+func loadPeople(dir string) []personNote {
+	var people []personNote
+	_ = walkVault(dir, func(p string, d fs.DirEntry) error {
+		if !isIndexedNote(p) || isEncryptedNote(p) {
+			return nil
+		}
+		content, err := os.ReadFile(p)
+		if err != nil {
+			return nil
+		}
+		name, _ := splitNoteTitle(p, string(content))
+		people = append(people, personNote{
+			path: p,
+			slug: strings.TrimSuffix(filepath.Base(p), filepath.Ext(p)),
+			name: name,
+		})
+		return nil
+	})
+	sort.Slice(people, func(i, j int) bool { return strings.ToLower(people[i].name) < strings.ToLower(people[j].name) })
+	return people
+}
+
+func isMentionRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || r == '-' || r == '_'
+}
+
+// mentionBefore returns the partial @mention ending at col in line, without
+// the @. An @ inside a word, as in an email address, does not start one.
+func mentionBefore(line []rune, col int) (string, bool) {
+	col = minInt(col, len(line))
+	start := col
+	for start > 0 && isMentionRune(line[start-1]) {
+		start--
+	}
+	if start == 0 || line[start-1] != '@' {
+		return "", false
+	}
+	if at := start - 1; at > 0 && (isMentionRune(line[at-1]) || line[at-1] == '.') {
+		return "", false
+	}
+	return string(line[start:col]), true
+}
+
+// mentionAt returns the @mention under col in line, without the @.
+func mentionAt(line []rune, col int) (string, bool) {
+	col = minInt(col, len(line))
+	end := col
+	for end < len(line) && isMentionRune(line[end]) {
+		end++
+	}
+	if end < len(line) && line[end] == '@' && col == end {
+		end++
+		for end < len(line) && isMentionRune(line[end]) {
+			end++
+		}
+	}
+	slug, ok := mentionBefore(line, end)
+	if !ok || slug == "" {
+		return "", false
+	}
+	return slug, true
+}
+
+// matchPeople returns the people whose slug, or a word of whose name,
+// starts with prefix.
+func matchPeople(people []personNote, prefix string) []personNote {
+	prefix = strings.ToLower(prefix)
+	var out []personNote
+	for _, p := range people {
+		if strings.HasPrefix(strings.ToLower(p.slug), prefix) {
+			out = append(out, p)
+			continue
+		}
+		for _, word := range strings.Fields(strings.ToLower(p.name)) {
+			if strings.HasPrefix(word, prefix) {
+				out = append(out, p)
+				break
+			}
+		}
+	}
+	return out
+}
+
+func commonSlugPrefix(people []personNote) string {
+	prefix := people[0].slug
+	for _, p := range people[1:] {
+		for !strings.HasPrefix(strings.ToLower(p.slug), strings.ToLower(prefix)) {
+			prefix = prefix[:len(prefix)-1]
+		}
+	}
+	return prefix
+}
+
+func (m Model) cursorLine() []rune {
+	lines := strings.Split(m.textarea.Value(), "\n")
+	if row := m.textarea.Line(); row < len(lines) {
+		return []rune(lines[row])
+	}
+	return nil
+}
+
+// completeMention completes the @mention before the cursor. It reports
+// false when the cursor is not after an @, so Tab indents as usual.
+func (m Model) completeMention() (Model, bool) {
+	typed, ok := mentionBefore(m.cursorLine(), editorColumn(m.textarea))
+	if !ok {
+		return m, false
+	}
+	matches := matchPeople(loadPeople(m.peopleDir()), typed)
+	switch len(matches) {
+	case 0:
+		m.status = infoStatus("No person matches @%s", typed)
+		return m, true
+	case 1:
+		m = m.replaceMention(typed, matches[0].slug+" ")
+		m.status = infoStatus("Mentioned %s", matches[0].name)
+		return m, true
+	}
+	if prefix := commonSlugPrefix(matches); len(prefix) > len(typed) {
+		m = m.replaceMention(typed, prefix)
+	}
+	names := make([]string, 0, len(matches))
+	for _, p := range matches {
+		names = append(names, "@"+p.slug)
+	}
+	m.status = infoStatus("%s", strings.Join(names, "  "))
+	return m, true
+}
+
+func (m Model) replaceMention(typed string, slug string) Model {
+	for range []rune(typed) {
+		m.textarea, _ = m.textarea.Update(tea.KeyMsg{Type: tea.KeyBackspace})
+	}
+	m.textarea.InsertString(slug)
+	return m
+}
+
+// mentionPattern matches @slug and wiki links to the person note.
+func mentionPattern(vault string, p personNote) *regexp.Regexp {
+	slug := regexp.QuoteMeta(p.slug)
+	link := strings.TrimSuffix(strings.TrimPrefix(noteLink(vault, p.path), "[["), "]]")
+	return regexp.MustCompile(`(?i)(?:^|[^\pL\pN_.@-])@` + slug + `(?:[^\pL\pN_-]|$)|\[\[(?:` +
+		regexp.QuoteMeta(link) + `|` + slug + `)(?:\.md)?(?:[|#][^\]]*)?\]\]`)
+}
+
+type mention struct {
+	path    string
+	line    string
+	modTime int64
+}
+
+// findMentions returns the notes mentioning p, newest first. The search
+// index narrows the candidates to notes containing the words of the slug;
+// without an index every note is read.
+func findMentions(vault string, ix *noteIndex, p personNote) []mention {
+	pattern := mentionPattern(vault, p)
+	var candidates []string
+	if terms := tokenize(p.slug); ix != nil && len(terms) > 0 {
+		for rel := range ix.Terms[terms[0]] {
+			all := true
+			for _, term := range terms[1:] {
+				if _, ok := ix.Terms[term][rel]; !ok {
+					all = false
+					break
+				}
+			}
+			if all {
+				candidates = append(candidates, filepath.Join(vault, filepath.FromSlash(rel)))
+			}
+		}
+	} else {
+		_ = walkVault(vault, func(path string, d fs.DirEntry) error {
+			if isIndexedNote(path) {
+				candidates = append(candidates, path)
+			}
+			return nil
+		})
+	}
+	var out []mention
+	for _, path := range candidates {
+		if path == p.path {
+			continue
+		}
+		content, err := os.ReadFile(path)
+		if err != nil {
+			continue
+		}
+		loc := pattern.FindIndex(content)
+		if loc == nil {
+			continue
+		}
+		start := strings.LastIndexByte(string(content[:loc[0]]), '\n') + 1
+		end := len(content)
+		if i := strings.IndexByte(string(content[loc[1]-1:]), '\n'); i >= 0 {
+			end = loc[1] - 1 + i
+		}
+		var modTime int64
+		if info, err := os.Stat(path); err == nil {
+			modTime = info.ModTime().UnixNano()
+		}
+		out = append(out, mention{path: path, line: strings.TrimSpace(string(content[start:end])), modTime: modTime})
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].modTime != out[j].modTime {
+			return out[i].modTime > out[j].modTime
+		}
+		return out[i].path < out[j].path
+	})
+	return out
+}
+
+func (m Model) personFor(path string) (personNote, bool) {
+	if !isIndexedNote(path) || !pathWithin(m.peopleDir(), path) {
+		return personNote{}, false
+	}
+	for _, p := range loadPeople(m.peopleDir()) {
+		if p.path == path {
+			return p, true
+		}
+	}
+	return personNote{}, false
+}
+
+// mentionsStatus tells how often the person note just opened is mentioned.
+func (m Model) mentionsStatus(path string) Model {
+	p, ok := m.personFor(path)
+	if !ok {
+		return m
+	}
+	n := len(findMentions(m.vault, m.index, p))
+	if n == 0 {
+		m.status = infoStatus("%s is not mentioned in any note yet", p.name)
+		return m
+	}
+	m.status = statusLine{kind: statusInfo, text: trn("Mentioned in %d note, Alt+W lists it", "Mentioned in %d notes, Alt+W lists them", n)}
+	return m
+}
+
+// showMentions lists the notes mentioning the person note being edited, or
+// opens the person under the cursor when editing any other note.
+func (m Model) showMentions() (tea.Model, tea.Cmd) {
+	p, ok := m.personFor(m.editing)
+	if !ok {
+		slug, found := mentionAt(m.cursorLine(), editorColumn(m.textarea))
+		if !found {
+			m.status = infoStatus("Not a person note; put the cursor on an @mention to open that person")
+			return m, nil
+		}
+		for _, person := range loadPeople(m.peopleDir()) {
+			if !strings.EqualFold(person.slug, slug) {
+				continue
+			}
+			if m.dirty() {
+				m.switchTo = person.path
+				m.state = stateConfirmUnsaved
+				return m, nil
+			}
+			return m.openFile(person.path)
+		}
+		m.status = infoStatus("No person note for @%s in %s", slug, relOrDot(m.vault, m.peopleDir()))
+		return m, nil
+	}
+	if m.dirty() {
+		m.status = warnStatus("Save %s before leaving it", filepath.Base(m.editing))
+		return m, nil
+	}
+	mentions := findMentions(m.vault, m.index, p)
+	if len(mentions) == 0 {
+		m.status = infoStatus("%s is not mentioned in any note yet", p.name)
+		return m, nil
+	}
+	items := make([]list.Item, 0, len(mentions))
+	for _, mn := range mentions {
+		items = append(items, item{
+			title: filepath.ToSlash(relOrBase(m.vault, mn.path)),
+			desc:  mn.line,
+			path:  mn.path,
+		})
+	}
+	m.query = "@" + p.slug
+	m.state = stateSearchResults
+	m.lastList = stateFileList
+	m.list.SetItems(items)
+	m.list.Title = tr("Mentions of %s", p.name)
+	m.list.Select(0)
+	m.status = statusLine{kind: statusInfo, text: trn("%d note found", "%d notes found", len(mentions))}
+	return m, nil
+}
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// Alt+| pipes the selection (or the cursor line when nothing is selected)
+// through a shell command and replaces it with the output, like vim's !
+// filter: sort, fmt -w 72, jq ., pandoc -t gfm. The command runs in the
+// note's directory and its output is only applied when it succeeds and the
+// note was not edited meanwhile.
+
+const pipeTimeout = 30 * time.Second
+
+type pipeResultMsg struct {
+	note     string
+	original string
+	start    int
+	end      int
+	output   string
+	err      error
+}
+
+// pipeRange returns the rune range to filter: the selection, or else the
+// whole cursor line including its line break.
+func (m Model) pipeRange() (int, int) {
+	if start, end, ok := m.selection(); ok {
+		return start, end
+	}
+	lines := splitRuneLines(m.textarea.Value())
+	row := editorCursor(m.textarea).row
+	start := runeOffset(lines, cursorPos{row: row})
+	end := start + len(lines[row])
+	if row+1 < len(lines) {
+		end++
+	}
+	return start, end
+}
+
+func (m Model) beginPipe() (tea.Model, tea.Cmd) {
+	m = m.enterPrompt(statePipeCommand, tr("Shell command, e.g. sort or fmt -w 72"))
+	m.input.SetValue(m.lastPipe)
+	m.input.CursorEnd()
+	return m, textinput.Blink
+}
+
+func (m Model) runPipe(command string) (tea.Model, tea.Cmd) {
+	command = strings.TrimSpace(command)
+	if command == "" {
+		m.status = infoStatus("Command cannot be empty")
+		return m, nil
+	}
+	m.lastPipe = command
+	m.input.Blur()
+	m.state = stateEditor
+	value := m.textarea.Value()
+	start, end := m.pipeRange()
+	input := string([]rune(value)[start:end])
+	dir := filepath.Dir(m.editing)
+	note := m.editing
+	m.status = infoStatus("Running %s...", command)
+	return m, func() tea.Msg {
+		out, err := filterText(dir, command, input)
+		return pipeResultMsg{note: note, original: value, start: start, end: end, output: out, err: err}
+	}
+}
+
+// filterText runs command with the platform shell, input on stdin.
+func filterText(dir string, command string, input string) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), pipeTimeout)
+	defer cancel()
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		cmd = exec.CommandContext(ctx, "cmd", "/C", command)
+	} else {
+		cmd = exec.CommandContext(ctx, "sh", "-c", command)
+	}
+	cmd.Dir = dir
+	cmd.Stdin = strings.NewReader(input)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	err := cmd.Run()
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return "", fmt.Errorf("%s: timed out after %s", command, pipeTimeout)
+	}
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", fmt.Errorf("%s: %s", command, strings.SplitN(msg, "\n", 2)[0])
+		}
+		return "", fmt.Errorf("%s: %w", command, err)
+	}
+	out := strings.ReplaceAll(stdout.String(), "\r\n", "\n")
+	if !strings.HasSuffix(input, "\n") {
+		out = strings.TrimSuffix(out, "\n")
+	} else if out != "" && !strings.HasSuffix(out, "\n") {
+		out += "\n"
+	}
+	return out, nil
+}
+
+func (m Model) applyPipe(msg pipeResultMsg) Model {
+	if msg.err != nil {
+		m.status = errorStatus(msg.err)
+		return m
+	}
+	if msg.note != m.editing || m.textarea.Value() != msg.original {
+		m.status = warnStatus("The note changed while the command ran; output discarded")
+		return m
+	}
+	value := []rune(msg.original)
+	m.textarea.SetValue(string(value[:msg.start]) + msg.output + string(value[msg.end:]))
+	lines := splitRuneLines(string(value[:msg.start]) + msg.output)
+	setEditorCursor(&m.textarea, cursorPos{row: len(lines) - 1, col: len(lines[len(lines)-1])})
+	m.mark = nil
+	m.status = okStatus("Replaced with the output of %s", m.lastPipe)
+	return m
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// Cursor and scroll positions are kept per vault in .gono/positions.json, so
+// a note reopens where it was left, also after GoNo was restarted.
+
+const (
+	positionsFileName = "positions.json"
+	maxPositions      = 1000
+)
+
+type savedPosition struct {
+	Row  int       `json:"row"`
+	Col  int       `json:"col"`
+	Top  int       `json:"top"`
+	Left time.Time `json:"left"`
+}
+
+func positionsPath(vault string) string {
+	return filepath.Join(appDir(vault), positionsFileName)
+}
+
+func loadPositions(vault string) map[string]savedPosition {
+	positions := make(map[string]savedPosition)
+	data, err := os.ReadFile(positionsPath(vault))
+	if err != nil {
+		return positions
+	}
+	_ = json.Unmarshal(data, &positions)
+	return positions
+}
+
+// savedPositionFor returns the stored position of note, if any.
+func savedPositionFor(vault string, note string) (savedPosition, bool) {
+	if vault == "" || !insideVault(vault, note) {
+		return savedPosition{}, false
+	}
+	pos, ok := loadPositions(vault)[relOrBase(vault, note)]
+	return pos, ok
+}
+
+// storePosition records where note was left. The oldest entries are dropped
+// once there are more than maxPositions.
+func storePosition(vault string, note string, pos savedPosition) error {
+	if vault == "" || note == "" || !insideVault(vault, note) {
+		return nil
+	}
+	if err := os.MkdirAll(appDir(vault), 0755); err != nil {
+		return err
+	}
+	path := positionsPath(vault)
+	return withFileLock(path, func() error {
+		positions := loadPositions(vault)
+		positions[relOrBase(vault, note)] = pos
+		if len(positions) > maxPositions {
+			keys := make([]string, 0, len(positions))
+			for k := range positions {
+				keys = append(keys, k)
+			}
+			sort.Slice(keys, func(i, j int) bool {
+				return positions[keys[i]].Left.Before(positions[keys[j]].Left)
+			})
+			for _, k := range keys[:len(keys)-maxPositions] {
+				delete(positions, k)
+			}
+		}
+		data, err := json.Marshal(positions)
+		if err != nil {
+			return err
+		}
+		return writeFileAtomic(path, data, 0644)
+	})
+}
+
+// rememberPosition stores the position of the note prev had open when the
+// update moved away from it.
+func (m Model) rememberPosition(prev Model) {
+	if m.noteOpen() && m.editing == prev.editing {
+		return
+	}
+	prev.storeOpenPosition()
+}
+
+// storeOpenPosition stores the position in the note open in the editor.
+func (m Model) storeOpenPosition() {
+	if !m.noteOpen() {
+		return
+	}
+	cursor := editorCursor(m.textarea)
+	_ = storePosition(m.vault, m.editing, savedPosition{
+		Row:  cursor.row,
+		Col:  cursor.col,
+		Top:  m.wrapTop,
+		Left: time.Now(),
+	})
+}
@@ -0,0 +1,170 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/list"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// A profile is a separate set of GoNo's own files: its own vault registry
+// and config (theme included), under profiles/NAME in the config and data
+// directories. "gono --profile work" (or GONO_PROFILE=work) starts with
+// one, for the UI and the commands alike; F4 on the vault screen switches
+// between them. Without a profile the files outside profiles/ are used, as
+// before. Search indexes are kept per vault and shared.
+
+const profileEnv = "GONO_PROFILE"
+
+var profileNameRe = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_-]{0,39}$`)
+
+// activeProfile is the profile in use; empty for the default files.
+var activeProfile string
+
+// profileDir returns the directory of the active profile within base.
+func profileDir(base string) string {
+	if activeProfile == "" {
+		return base
+	}
+	return filepath.Join(base, "profiles", activeProfile)
+}
+
+// takeProfileFlag removes a leading "--profile NAME" or "--profile=NAME"
+// from args and returns the profile, or the one in GONO_PROFILE.
+func takeProfileFlag(args []string) (string, []string, error) {
+	profile := os.Getenv(profileEnv)
+	if len(args) > 0 {
+		switch {
+		case args[0] == "--profile" || args[0] == "-profile":
+			if len(args) < 2 {
+				return "", nil, errors.New("usage: gono --profile NAME [COMMAND]")
+			}
+			profile, args = args[1], args[2:]
+		case strings.HasPrefix(args[0], "--profile="):
+			profile, args = strings.TrimPrefix(args[0], "--profile="), args[1:]
+		}
+	}
+	if profile != "" && !profileNameRe.MatchString(profile) {
+		return "", nil, fmt.Errorf("invalid profile name %q: use letters, digits, - and _", profile)
+	}
+	return profile, args, nil
+}
+
+// listProfiles returns the names of the profiles created so far.
+func listProfiles() []string {
+	seen := make(map[string]bool)
+	for _, base := range []string{baseConfigDir(), baseDataDir()} {
+		entries, err := os.ReadDir(filepath.Join(base, "profiles"))
+		if err != nil {
+			continue
+		}
+		for _, e := range entries {
+			if e.IsDir() && profileNameRe.MatchString(e.Name()) {
+				seen[e.Name()] = true
+			}
+		}
+	}
+	names := make([]string, 0, len(seen))
+	for name := range seen {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func profileLabel(name string) string {
+	if name == "" {
+		return tr("default")
+	}
+	return name
+}
+
+func profilesCommand(args []string, stdout io.Writer) error {
+	if len(args) != 0 {
+		return errors.New("usage: gono profiles")
+	}
+	for _, name := range append([]string{""}, listProfiles()...) {
+		mark := " "
+		if name == activeProfile {
+			mark = "*"
+		}
+		fmt.Fprintf(stdout, "%s %s\n", mark, profileLabel(name))
+	}
+	return nil
+}
+
+func (m Model) showProfiles() (tea.Model, tea.Cmd) {
+	items := make([]list.Item, 0)
+	for _, name := range append([]string{""}, listProfiles()...) {
+		desc := tr("Config and vaults in %s", baseConfigDir())
+		if name != "" {
+			desc = tr("Config and vaults in %s", filepath.Join(baseConfigDir(), "profiles", name))
+		}
+		if name == activeProfile {
+			desc = tr("In use")
+		}
+		items = append(items, item{title: profileLabel(name), desc: desc, path: name, mode: "profile"})
+	}
+	items = append(items, item{title: tr("+ New profile"), desc: tr("Start with an empty vault list and the default settings"), mode: "create-profile"})
+	m.lastList = stateVaultSelect
+	m.state = stateProfiles
+	m.status = statusLine{}
+	m.list.SetItems(items)
+	m.list.Title = tr("Profiles (Enter switches)")
+	m.list.Select(0)
+	return m, nil
+}
+
+func (m Model) selectProfile() (tea.Model, tea.Cmd) {
+	selected, ok := m.list.SelectedItem().(item)
+	if !ok {
+		return m, nil
+	}
+	if selected.mode == "create-profile" {
+		m = m.enterPrompt(stateProfileCreate, tr("Profile name, e.g. work"))
+		return m, textinput.Blink
+	}
+	return m.switchProfile(selected.path)
+}
+
+func (m Model) createProfile(name string) (tea.Model, tea.Cmd) {
+	name = strings.TrimSpace(name)
+	if !profileNameRe.MatchString(name) {
+		m.status = failStatus("invalid profile name: use letters, digits, - and _")
+		return m, nil
+	}
+	if err := os.MkdirAll(filepath.Join(baseConfigDir(), "profiles", name), 0755); err != nil {
+		m.status = errorStatus(err)
+		return m, nil
+	}
+	m.input.Blur()
+	return m.switchProfile(name)
+}
+
+// switchProfile starts over with the config and vaults of profile.
+func (m Model) switchProfile(profile string) (tea.Model, tea.Cmd) {
+	if profile == activeProfile {
+		m.state = stateVaultSelect
+		m = m.refreshVaultList()
+		m.status = infoStatus("Profile %s is in use", profileLabel(profile))
+		return m, nil
+	}
+	m.storeOpenPosition()
+	releaseNoteLock(m.locked.vault, m.locked.note)
+	activeProfile = profile
+	next := initialModel()
+	next.windowW, next.windowH = m.windowW, m.windowH
+	next = next.applyResponsiveLayout()
+	if next.status.text == "" {
+		next.status = okStatus("Profile: %s", profileLabel(profile))
+	}
+	return next, next.Init()
+}
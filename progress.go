@@ -0,0 +1,194 @@
+package main
+
+import (
+	"context"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// Long operations (building the search index, deleting a folder) run as
+// jobs in the background. While one runs the status line shows a spinner, a
+// progress bar and a count, and Esc cancels it. A cancelled job stops at
+// the next file and keeps what it has done so far.
+
+const (
+	jobTickInterval = 100 * time.Millisecond
+	// jobQuietPeriod keeps quick jobs from flashing a progress line.
+	jobQuietPeriod = 300 * time.Millisecond
+	jobBarWidth    = 20
+)
+
+var spinnerFrames = []string{"⠋", "⠙", "⠹", "⠸", "⠼", "⠴", "⠦", "⠧", "⠇", "⠏"}
+
+type job struct {
+	label    string
+	index    bool
+	started  time.Time
+	ctx      context.Context
+	cancel   context.CancelFunc
+	done     atomic.Int64
+	total    atomic.Int64
+	finished atomic.Bool
+}
+
+type jobTickMsg struct {
+	job *job
+}
+
+func newJob(label string) *job {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &job{label: label, started: time.Now(), ctx: ctx, cancel: cancel}
+}
+
+func (j *job) cancelled() bool {
+	return j.ctx.Err() != nil
+}
+
+func (j *job) step() {
+	j.done.Add(1)
+}
+
+func jobTick(j *job) tea.Cmd {
+	return tea.Tick(jobTickInterval, func(time.Time) tea.Msg {
+		return jobTickMsg{job: j}
+	})
+}
+
+// run wraps the work of j into a command and starts the ticks that redraw
+// its progress.
+func (j *job) run(work func() tea.Msg) tea.Cmd {
+	return tea.Batch(func() tea.Msg {
+		defer j.finished.Store(true)
+		return work()
+	}, jobTick(j))
+}
+
+// visible reports whether the job has run long enough to be shown.
+func (j *job) visible() bool {
+	return !j.finished.Load() && time.Since(j.started) >= jobQuietPeriod
+}
+
+func (j *job) status() statusLine {
+	elapsed := time.Since(j.started)
+	frame := spinnerFrames[int(elapsed/jobTickInterval)%len(spinnerFrames)]
+	if j.cancelled() {
+		return infoStatus("%s %s: cancelling...", frame, j.label)
+	}
+	done, total := j.done.Load(), j.total.Load()
+	if total <= 0 {
+		return infoStatus("%s %s: %d | Esc: cancel", frame, j.label, done)
+	}
+	filled := int(done * jobBarWidth / total)
+	bar := strings.Repeat("█", minInt(filled, jobBarWidth)) + strings.Repeat("░", maxInt(0, jobBarWidth-filled))
+	return infoStatus("%s %s %s %d/%d | Esc: cancel", frame, j.label, bar, done, total)
+}
+
+// visibleStatus is the status line to draw: the progress of the running
+// job, or else the last message.
+func (m Model) visibleStatus() statusLine {
+	if m.job != nil && m.job.visible() {
+		return m.job.status()
+	}
+	return m.status
+}
+
+func (m Model) applyJobTick(msg jobTickMsg) (Model, tea.Cmd) {
+	if msg.job != m.job || msg.job.finished.Load() {
+		return m, nil
+	}
+	return m, jobTick(msg.job)
+}
+
+func (m Model) cancelJob() Model {
+	m.job.cancel()
+	m.status = infoStatus("%s: cancelling...", m.job.label)
+	return m
+}
+
+// finishJob clears the job shown in the status line when j was it.
+func (m Model) finishJob(j *job) Model {
+	if m.job == j {
+		m.job = nil
+	}
+	return m
+}
+
+// startIndexing builds the search index of the current vault in the background.
+// An index build still running for another vault is cancelled.
+func (m Model) startIndexing() (Model, tea.Cmd) {
+	if m.job != nil && m.job.index {
+		m.job.cancel()
+	}
+	m.index = nil
+	m.job = newJob(tr("Indexing notes"))
+	m.job.index = true
+	return m, m.indexCmd()
+}
+
+func (m Model) indexCmd() tea.Cmd {
+	vault, j := m.vault, m.job
+	return j.run(func() tea.Msg {
+		ix := loadIndex(vault)
+		total := 0
+		_ = walkVault(vault, func(p string, d fs.DirEntry) error {
+			if j.cancelled() {
+				return filepath.SkipAll
+			}
+			if isIndexedNote(p) {
+				total++
+			}
+			return nil
+		})
+		j.total.Store(int64(total))
+		var err error
+		if ix.sync(vault, j) {
+			err = ix.save(vault)
+		}
+		return indexReadyMsg{vault: vault, index: ix, job: j, cancelled: j.cancelled(), err: err}
+	})
+}
+
+type deleteDoneMsg struct {
+	job     *job
+	target  deleteTarget
+	removed int64
+	err     error
+}
+
+// deleteTreeCmd removes a folder file by file, deepest entries first, so a
+// cancelled deletion leaves whole files behind.
+func deleteTreeCmd(target deleteTarget, j *job) tea.Cmd {
+	return j.run(func() tea.Msg {
+		var paths []string
+		err := filepath.WalkDir(target.path, func(p string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if j.cancelled() {
+				return filepath.SkipAll
+			}
+			paths = append(paths, p)
+			return nil
+		})
+		if err != nil {
+			return deleteDoneMsg{job: j, target: target, err: err}
+		}
+		j.total.Store(int64(len(paths)))
+		for i := len(paths) - 1; i >= 0; i-- {
+			if j.cancelled() {
+				break
+			}
+			if err := os.Remove(paths[i]); err != nil && !os.IsNotExist(err) {
+				return deleteDoneMsg{job: j, target: target, removed: j.done.Load(), err: err}
+			}
+			j.step()
+		}
+		return deleteDoneMsg{job: j, target: target, removed: j.done.Load()}
+	})
+}
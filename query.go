@@ -0,0 +1,558 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/charmbracelet/bubbles/list"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// Property queries filter notes by their frontmatter, for example
+//
+//	status = active AND (due < 2024-07-01 OR priority >= 2)
+//	tags ~ work AND NOT archived
+//
+// Comparisons are =, !=, <, <=, >, >= and ~ (contains). Values that read as
+// numbers or dates compare as such, "today" is today's date, and anything
+// else compares as case-insensitive text. A list field such as tags matches
+// = and ~ when any element does. A field name on its own tests that the
+// field is set. A search (Ctrl+F) containing a comparison runs as a
+// property query, and can be saved as a smart folder shown at the top of
+// the vault.
+
+var queryOperators = []string{"!=", "<=", ">=", "=", "<", ">", "~"}
+
+type propertyQuery struct {
+	op          string // "and", "or", "not", a comparison, or "" for "field is set"
+	left, right *propertyQuery
+	field       string
+	value       string
+}
+
+// smartFolder is a saved property query, stored in .gono/settings.json.
+type smartFolder struct {
+	Name  string `json:"name"`
+	Query string `json:"query"`
+}
+
+type queryToken struct {
+	text   string
+	quoted bool
+}
+
+func tokenizeQuery(s string) ([]queryToken, error) {
+	var tokens []queryToken
+	runes := []rune(s)
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case r == '(' || r == ')':
+			tokens = append(tokens, queryToken{text: string(r)})
+			i++
+		case r == '"' || r == '\'':
+			end := i + 1
+			for end < len(runes) && runes[end] != r {
+				end++
+			}
+			if end == len(runes) {
+				return nil, errors.New("unterminated quote")
+			}
+			tokens = append(tokens, queryToken{text: string(runes[i+1 : end]), quoted: true})
+			i = end + 1
+		default:
+			if op := operatorAt(runes[i:]); op != "" {
+				tokens = append(tokens, queryToken{text: op})
+				i += len(op)
+				continue
+			}
+			start := i
+			for i < len(runes) && !unicode.IsSpace(runes[i]) && runes[i] != '(' && runes[i] != ')' && operatorAt(runes[i:]) == "" {
+				i++
+			}
+			tokens = append(tokens, queryToken{text: string(runes[start:i])})
+		}
+	}
+	return tokens, nil
+}
+
+func operatorAt(runes []rune) string {
+	for _, op := range queryOperators {
+		if strings.HasPrefix(string(runes[:minInt(len(runes), 2)]), op) {
+			return op
+		}
+	}
+	return ""
+}
+
+func isComparison(op string) bool {
+	for _, o := range queryOperators {
+		if o == op {
+			return true
+		}
+	}
+	return false
+}
+
+type queryParser struct {
+	tokens []queryToken
+	pos    int
+}
+
+// parsePropertyQuery parses a query; AND binds tighter than OR.
+func parsePropertyQuery(s string) (*propertyQuery, error) {
+	tokens, err := tokenizeQuery(s)
+	if err != nil {
+		return nil, err
+	}
+	if len(tokens) == 0 {
+		return nil, errors.New("empty query")
+	}
+	p := &queryParser{tokens: tokens}
+	q, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if p.pos < len(p.tokens) {
+		return nil, fmt.Errorf("unexpected %q", p.tokens[p.pos].text)
+	}
+	return q, nil
+}
+
+func (p *queryParser) keyword(word string) bool {
+	if p.pos < len(p.tokens) && !p.tokens[p.pos].quoted && strings.EqualFold(p.tokens[p.pos].text, word) {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *queryParser) parseOr() (*propertyQuery, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.keyword("or") {
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = &propertyQuery{op: "or", left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *queryParser) parseAnd() (*propertyQuery, error) {
+	left, err := p.parseNot()
+	if err != nil {
+		return nil, err
+	}
+	for p.keyword("and") {
+		right, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		left = &propertyQuery{op: "and", left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *queryParser) parseNot() (*propertyQuery, error) {
+	if p.keyword("not") {
+		inner, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		return &propertyQuery{op: "not", left: inner}, nil
+	}
+	return p.parseCondition()
+}
+
+func (p *queryParser) parseCondition() (*propertyQuery, error) {
+	if p.pos >= len(p.tokens) {
+		return nil, errors.New("query ends too early")
+	}
+	tok := p.tokens[p.pos]
+	p.pos++
+	if tok.text == "(" && !tok.quoted {
+		inner, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if p.pos >= len(p.tokens) || p.tokens[p.pos].text != ")" {
+			return nil, errors.New("missing )")
+		}
+		p.pos++
+		return inner, nil
+	}
+	if tok.quoted || tok.text == ")" || isComparison(tok.text) {
+		return nil, fmt.Errorf("expected a field name, got %q", tok.text)
+	}
+	field := strings.ToLower(tok.text)
+	if p.pos >= len(p.tokens) || p.tokens[p.pos].quoted || !isComparison(p.tokens[p.pos].text) {
+		return &propertyQuery{field: field}, nil
+	}
+	op := p.tokens[p.pos].text
+	p.pos++
+	if p.pos >= len(p.tokens) {
+		return nil, fmt.Errorf("missing value after %s %s", field, op)
+	}
+	value := p.tokens[p.pos]
+	if !value.quoted && (value.text == "(" || value.text == ")" || isComparison(value.text)) {
+		return nil, fmt.Errorf("missing value after %s %s", field, op)
+	}
+	p.pos++
+	return &propertyQuery{op: op, field: field, value: value.text}, nil
+}
+
+// hasComparison reports whether q compares a field with a value; a search
+// is only taken as a property query when it does.
+func (q *propertyQuery) hasComparison() bool {
+	if q == nil {
+		return false
+	}
+	return isComparison(q.op) || q.left.hasComparison() || q.right.hasComparison()
+}
+
+// fieldNames lists the fields q looks at, in order of appearance.
+func (q *propertyQuery) fieldNames(out []string) []string {
+	if q == nil {
+		return out
+	}
+	if q.field != "" {
+		for _, f := range out {
+			if f == q.field {
+				return out
+			}
+		}
+		return append(out, q.field)
+	}
+	return q.right.fieldNames(q.left.fieldNames(out))
+}
+
+func (q *propertyQuery) match(fields map[string]string, now time.Time) bool {
+	switch q.op {
+	case "and":
+		return q.left.match(fields, now) && q.right.match(fields, now)
+	case "or":
+		return q.left.match(fields, now) || q.right.match(fields, now)
+	case "not":
+		return !q.left.match(fields, now)
+	}
+	actual, ok := fields[q.field]
+	if q.op == "" {
+		return ok && strings.TrimSpace(actual) != "" && actual != "[]"
+	}
+	if !ok {
+		return q.op == "!="
+	}
+	want := q.value
+	if strings.EqualFold(want, "today") {
+		want = now.Format("2006-01-02")
+	}
+	values := []string{actual}
+	if strings.HasPrefix(strings.TrimSpace(actual), "[") {
+		values = frontmatterList(actual)
+	}
+	switch q.op {
+	case "=":
+		return anyValue(values, func(v string) bool { return compareProperty(v, want) == 0 })
+	case "!=":
+		return !anyValue(values, func(v string) bool { return compareProperty(v, want) == 0 })
+	case "~":
+		return anyValue(values, func(v string) bool {
+			return strings.Contains(strings.ToLower(v), strings.ToLower(want))
+		})
+	}
+	c := compareProperty(actual, want)
+	switch q.op {
+	case "<":
+		return c < 0
+	case "<=":
+		return c <= 0
+	case ">":
+		return c > 0
+	case ">=":
+		return c >= 0
+	}
+	return false
+}
+
+func anyValue(values []string, ok func(string) bool) bool {
+	for _, v := range values {
+		if ok(v) {
+			return true
+		}
+	}
+	return false
+}
+
+// compareProperty compares two values as numbers, dates or text, in that
+// order of preference.
+func compareProperty(a string, b string) int {
+	a, b = strings.TrimSpace(a), strings.TrimSpace(b)
+	if x, err := strconv.ParseFloat(a, 64); err == nil {
+		if y, err := strconv.ParseFloat(b, 64); err == nil {
+			switch {
+			case x < y:
+				return -1
+			case x > y:
+				return 1
+			}
+			return 0
+		}
+	}
+	if x, ok := parseReminderTime(a); ok {
+		if y, ok := parseReminderTime(b); ok {
+			return x.Compare(y)
+		}
+	}
+	return strings.Compare(strings.ToLower(a), strings.ToLower(b))
+}
+
+type queryHit struct {
+	path   string
+	fields map[string]string
+}
+
+// queryVault returns the notes of vault whose frontmatter matches q, by path.
+func queryVault(vault string, q *propertyQuery) []queryHit {
+	now := time.Now()
+	var hits []queryHit
+	_ = walkVault(vault, func(p string, d fs.DirEntry) error {
+		if !isIndexedNote(p) {
+			return nil
+		}
+		fields := readFrontmatter(p)
+		if fields == nil {
+			fields = map[string]string{}
+		}
+		if q.match(fields, now) {
+			hits = append(hits, queryHit{path: p, fields: fields})
+		}
+		return nil
+	})
+	sort.Slice(hits, func(i, j int) bool { return hits[i].path < hits[j].path })
+	return hits
+}
+
+// describeHit shows the fields a query looked at, e.g. "status: active".
+func describeHit(hit queryHit, names []string) string {
+	var parts []string
+	for _, name := range names {
+		if v, ok := hit.fields[name]; ok {
+			parts = append(parts, name+": "+v)
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+// asPropertyQuery parses a search as a property query; ok is false for
+// plain text searches.
+func asPropertyQuery(search string) (*propertyQuery, bool, error) {
+	if usesSearchFilters(search) {
+		return nil, false, nil
+	}
+	tokens, err := tokenizeQuery(search)
+	if err != nil {
+		return nil, false, nil
+	}
+	comparison := false
+	for _, t := range tokens {
+		comparison = comparison || (!t.quoted && isComparison(t.text))
+	}
+	if !comparison {
+		return nil, false, nil
+	}
+	q, err := parsePropertyQuery(search)
+	if err != nil {
+		return nil, true, err
+	}
+	return q, q.hasComparison(), nil
+}
+
+func queryCommand(args []string, stdout io.Writer) error {
+	if len(args) != 2 {
+		return errors.New("usage: gono query VAULT QUERY")
+	}
+	vault, err := filepath.Abs(args[0])
+	if err != nil {
+		return err
+	}
+	if info, err := os.Stat(vault); err != nil || !info.IsDir() {
+		return fmt.Errorf("vault not found: %s", vault)
+	}
+	q, err := parsePropertyQuery(args[1])
+	if err != nil {
+		return fmt.Errorf("query: %w", err)
+	}
+	names := q.fieldNames(nil)
+	for _, hit := range queryVault(vault, q) {
+		rel := filepath.ToSlash(relOrBase(vault, hit.path))
+		if desc := describeHit(hit, names); desc != "" {
+			fmt.Fprintf(stdout, "%s\t%s\n", rel, desc)
+		} else {
+			fmt.Fprintln(stdout, rel)
+		}
+	}
+	return nil
+}
+
+// runPropertySearch lists the notes matching q as search results.
+func (m Model) runPropertySearch(query string, q *propertyQuery, title string) (tea.Model, tea.Cmd) {
+	hits := queryVault(m.vault, q)
+	if len(hits) == 0 {
+		m.status = infoStatus("No notes match: %s", query)
+		return m, nil
+	}
+	names := q.fieldNames(nil)
+	items := make([]list.Item, 0, len(hits))
+	for _, hit := range hits {
+		items = append(items, item{
+			title: filepath.ToSlash(relOrBase(m.vault, hit.path)),
+			desc:  describeHit(hit, names),
+			path:  hit.path,
+		})
+	}
+	m.query = query
+	m.input.Blur()
+	m.state = stateSearchResults
+	m.lastList = stateFileList
+	m.list.SetItems(items)
+	m.list.Title = title
+	m.list.Select(0)
+	m.status = statusLine{kind: statusInfo, text: trn("%d note found", "%d notes found", len(hits))}
+	return m, nil
+}
+
+func (m Model) openSmartFolder(it item) (tea.Model, tea.Cmd) {
+	q, err := parsePropertyQuery(it.path)
+	if err != nil {
+		m.status = failStatus("smart folder %s: %v", strings.TrimSuffix(it.title, "/"), err)
+		return m, nil
+	}
+	return m.runPropertySearch(it.path, q, tr("Smart folder: %s", strings.TrimSuffix(it.title, "/")))
+}
+
+func (m Model) searchResultsHints() string {
+	if _, ok, err := asPropertyQuery(m.query); ok && err == nil {
+		return tr("Enter: open | Ctrl+S: save as smart folder | Esc: back")
+	}
+	return tr("Enter: open | Esc: back")
+}
+
+// beginSaveSmartFolder asks for a name for the property query shown in the
+// search results.
+func (m Model) beginSaveSmartFolder() (tea.Model, tea.Cmd) {
+	if _, ok, err := asPropertyQuery(m.query); !ok || err != nil {
+		m.status = infoStatus("Only property queries can be saved as smart folders")
+		return m, nil
+	}
+	m = m.enterPrompt(stateSmartFolderName, tr("Smart folder name"))
+	return m, textinput.Blink
+}
+
+func (m Model) saveSmartFolder(name string) (tea.Model, tea.Cmd) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		m.status = infoStatus("Smart folder name cannot be empty")
+		return m, nil
+	}
+	folders := loadSmartFolders(m.vault)
+	replaced := false
+	for i := range folders {
+		if folders[i].Name == name {
+			folders[i].Query = m.query
+			replaced = true
+		}
+	}
+	if !replaced {
+		folders = append(folders, smartFolder{Name: name, Query: m.query})
+	}
+	if err := saveSmartFolders(m.vault, folders); err != nil {
+		m.status = errorStatus(err)
+		return m, nil
+	}
+	m.input.Blur()
+	m.state = stateFileList
+	m.current = m.vault
+	m.limit = 0
+	m = m.refreshFileList()
+	m.status = okStatus("Smart folder %s saved at the top of the vault", name)
+	return m, nil
+}
+
+func (m Model) removeSmartFolder(name string) (tea.Model, tea.Cmd) {
+	folders := loadSmartFolders(m.vault)
+	kept := folders[:0]
+	for _, f := range folders {
+		if f.Name != name {
+			kept = append(kept, f)
+		}
+	}
+	if err := saveSmartFolders(m.vault, kept); err != nil {
+		m.status = errorStatus(err)
+		return m, nil
+	}
+	m = m.refreshFileList()
+	m.status = okStatus("Smart folder %s removed", name)
+	return m, nil
+}
+
+func smartFolderItems(vault string) []list.Item {
+	var items []list.Item
+	for _, f := range loadSmartFolders(vault) {
+		items = append(items, item{
+			title: f.Name + "/",
+			desc:  tr("Smart folder: %s", f.Query),
+			path:  f.Query,
+			mode:  "smart",
+		})
+	}
+	return items
+}
+
+func loadSmartFolders(vault string) []smartFolder {
+	return loadVaultSettings(vault).SmartFolders
+}
+
+// saveSmartFolders rewrites the smart_folders key of the vault settings and
+// keeps every other key as it was.
+func saveSmartFolders(vault string, folders []smartFolder) error {
+	settings := make(map[string]json.RawMessage)
+	if data, err := os.ReadFile(vaultSettingsPath(vault)); err == nil {
+		if err := json.Unmarshal(data, &settings); err != nil {
+			return fmt.Errorf("%s: %w", vaultSettingsPath(vault), err)
+		}
+	}
+	if len(folders) == 0 {
+		delete(settings, "smart_folders")
+	} else {
+		raw, err := json.Marshal(folders)
+		if err != nil {
+			return err
+		}
+		settings["smart_folders"] = raw
+	}
+	data, err := json.MarshalIndent(settings, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(appDir(vault), 0755); err != nil {
+		return err
+	}
+	return writeFileAtomic(vaultSettingsPath(vault), append(data, '\n'), 0644)
+}
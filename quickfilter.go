@@ -0,0 +1,143 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// The quick filter (/ in the file list) narrows the current directory as
+// you type. Unlike the list's own fuzzy filter it matches substrings of the
+// name, the description and the tags of each note, and it stays in place
+// while notes are opened, until Esc clears it or another directory is shown.
+
+type quickFilter struct {
+	dir   string
+	query string
+	tags  map[string]string
+}
+
+func (m Model) startQuickFilter() (tea.Model, tea.Cmd) {
+	m.quick = &quickFilter{dir: m.current, tags: directoryTags(m.current)}
+	m.status = statusLine{}
+	m = m.refreshFileList()
+	return m, nil
+}
+
+// directoryTags reads the tags and the status ("status:done") of the notes
+// directly in dir, joined by spaces and keyed by path.
+func directoryTags(dir string) map[string]string {
+	tags := make(map[string]string)
+	files, err := os.ReadDir(dir)
+	if err != nil {
+		return tags
+	}
+	for _, f := range files {
+		p := filepath.Join(dir, f.Name())
+		if f.IsDir() || !isIndexedNote(p) {
+			continue
+		}
+		content, err := os.ReadFile(p)
+		if err != nil {
+			continue
+		}
+		set := noteTags(string(content))
+		names := make([]string, 0, len(set)+1)
+		for t := range set {
+			names = append(names, "#"+t)
+		}
+		sort.Strings(names)
+		if fields, ok := parseFrontmatter(string(content)); ok && fields[statusField] != "" {
+			names = append(names, statusField+":"+strings.ToLower(unquote(fields[statusField])))
+		}
+		if len(names) > 0 {
+			tags[p] = strings.Join(names, " ")
+		}
+	}
+	return tags
+}
+
+// handleQuickFilterKey edits the filter. Keys it does not use are reported
+// as unhandled and take their usual effect in the file list.
+func (m Model) handleQuickFilterKey(msg tea.KeyMsg) (Model, bool) {
+	q := m.quick
+	switch msg.Type {
+	case tea.KeyRunes:
+		q.query += string(msg.Runes)
+	case tea.KeySpace:
+		q.query += " "
+	case tea.KeyBackspace:
+		if q.query == "" {
+			m.quick = nil
+			break
+		}
+		runes := []rune(q.query)
+		q.query = string(runes[:len(runes)-1])
+	case tea.KeyEsc:
+		m.quick = nil
+	case tea.KeyEnter:
+		if selected := m.list.SelectedItem(); selected != nil && selected.(item).isDir {
+			m.quick = nil
+		}
+		return m, false
+	default:
+		return m, false
+	}
+	var path string
+	if selected := m.list.SelectedItem(); selected != nil {
+		path = selected.(item).path
+	}
+	m = m.refreshFileList()
+	m.list.Select(0)
+	for i, li := range m.list.Items() {
+		if path != "" && li.(item).path == path {
+			m.list.Select(i)
+			break
+		}
+	}
+	m = m.loadVisibleDetails()
+	return m, true
+}
+
+// filter keeps the entries matching every word of the quick filter.
+func (q *quickFilter) filter(entries []item) []item {
+	words := strings.Fields(strings.ToLower(q.query))
+	if len(words) == 0 {
+		return entries
+	}
+	kept := entries[:0]
+	for _, e := range entries {
+		text := strings.ToLower(e.title + " " + e.desc + " " + q.tags[e.path])
+		match := true
+		for _, w := range words {
+			if !strings.Contains(text, w) {
+				match = false
+				break
+			}
+		}
+		if match {
+			kept = append(kept, e)
+		}
+	}
+	return kept
+}
+
+func (m Model) fileListScreenHints(width int) string {
+	if m.quick != nil {
+		return quickFilterHints(width)
+	}
+	if m.cfg.Titles.FileHints != "" {
+		return m.expandTitle(m.cfg.Titles.FileHints, m.entries)
+	}
+	return fileListHints(width)
+}
+
+func quickFilterHints(width int) string {
+	if width < 72 {
+		return tr("Type to filter | Enter open | Backspace delete\nUp/Down select | Esc clear filter")
+	}
+	return tr("Type to filter by name, description or #tag | Enter: open | Backspace: delete character\nUp/Down: select | Esc: clear filter | Ctrl+C: quit")
+}
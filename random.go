@@ -0,0 +1,86 @@
+package main
+
+import (
+	"io/fs"
+	"math/rand/v2"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+var inlineTagRe = regexp.MustCompile(`(?:^|\s)#([\p{L}\p{N}_/-]+)`)
+
+// noteTags returns the lower-cased tags of a note: the frontmatter "tags"
+// (or "tag") field plus inline #tags in the text.
+func noteTags(content string) map[string]struct{} {
+	tags := make(map[string]struct{})
+	if fields, ok := parseFrontmatter(content); ok {
+		for _, key := range []string{"tags", "tag"} {
+			for _, tag := range frontmatterList(fields[key]) {
+				tags[strings.ToLower(strings.TrimPrefix(tag, "#"))] = struct{}{}
+			}
+		}
+	}
+	for _, match := range inlineTagRe.FindAllStringSubmatch(content, -1) {
+		tags[strings.ToLower(match[1])] = struct{}{}
+	}
+	return tags
+}
+
+// randomNote picks a note below dir, optionally only notes tagged tag.
+// The note open in the editor is avoided when there is another choice.
+func (m Model) randomNote(dir string, tag string) string {
+	tag = strings.ToLower(strings.TrimPrefix(strings.TrimSpace(tag), "#"))
+	var notes []string
+	_ = walkVault(dir, func(p string, d fs.DirEntry) error {
+		if !isIndexedNote(p) {
+			return nil
+		}
+		if tag != "" {
+			content, err := os.ReadFile(p)
+			if err != nil {
+				return nil
+			}
+			if _, ok := noteTags(string(content))[tag]; !ok {
+				return nil
+			}
+		}
+		notes = append(notes, p)
+		return nil
+	})
+	if len(notes) > 1 && m.editing != "" {
+		for i, p := range notes {
+			if p == m.editing {
+				notes = append(notes[:i], notes[i+1:]...)
+				break
+			}
+		}
+	}
+	if len(notes) == 0 {
+		return ""
+	}
+	return notes[rand.IntN(len(notes))]
+}
+
+func (m Model) openRandomNote(tag string) (tea.Model, tea.Cmd) {
+	path := m.randomNote(m.current, tag)
+	if path == "" {
+		if strings.TrimSpace(tag) != "" {
+			m.status = infoStatus("No notes tagged %s in %s", tag, relOrDot(m.vault, m.current))
+		} else {
+			m.status = infoStatus("No notes in %s", relOrDot(m.vault, m.current))
+		}
+		return m, nil
+	}
+	m.input.Blur()
+	m.status = infoStatus("Random note: %s", relOrBase(m.vault, path))
+	return m.openFile(path)
+}
+
+func (m Model) beginRandomByTag() (tea.Model, tea.Cmd) {
+	m = m.enterPrompt(stateRandomTag, tr("Tag, e.g. #review"))
+	return m, textinput.Blink
+}
@@ -0,0 +1,246 @@
+package main
+
+import (
+	"crypto/ecdh"
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// An encrypted vault can also be opened with a passphrase, for when every
+// keyfile is lost. "gono keys passphrase" derives an X25519 key from the
+// passphrase and a random salt and adds its public key as one more
+// recipient; .gono/passphrase keeps the salt, the public key and an
+// optional hint, never the passphrase. "gono emergency-export" asks for the
+// passphrase and writes decrypted copies of the encrypted notes to another
+// folder. The hint is shown after a wrong passphrase.
+
+const (
+	passphraseName     = "passphrase"
+	passphraseAttempts = 3
+)
+
+// passphraseLock is the content of .gono/passphrase.
+type passphraseLock struct {
+	Salt   string `json:"salt"`
+	Public string `json:"public"`
+	Hint   string `json:"hint,omitempty"`
+}
+
+func passphrasePath(vault string) string {
+	return filepath.Join(appDir(vault), passphraseName)
+}
+
+// loadPassphraseLock returns false when the vault has no passphrase.
+func loadPassphraseLock(vault string) (passphraseLock, bool, error) {
+	var lock passphraseLock
+	data, err := os.ReadFile(passphrasePath(vault))
+	if os.IsNotExist(err) {
+		return lock, false, nil
+	}
+	if err != nil {
+		return lock, false, err
+	}
+	if err := json.Unmarshal(data, &lock); err != nil {
+		return lock, false, fmt.Errorf("%s: %w", passphrasePath(vault), err)
+	}
+	return lock, true, nil
+}
+
+func savePassphraseLock(vault string, lock passphraseLock) error {
+	data, err := json.MarshalIndent(lock, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(appDir(vault), 0755); err != nil {
+		return err
+	}
+	return writeFileAtomic(passphrasePath(vault), append(data, '\n'), 0644)
+}
+
+// passphraseKey derives the vault's passphrase identity.
+func passphraseKey(password []byte, lock passphraseLock) (*ecdh.PrivateKey, error) {
+	salt, err := base64.RawURLEncoding.DecodeString(lock.Salt)
+	if err != nil || len(salt) == 0 {
+		return nil, errors.New("passphrase salt is damaged")
+	}
+	raw, err := pbkdf2.Key(sha256.New, string(password), salt, bundleIterations, 32)
+	if err != nil {
+		return nil, err
+	}
+	return ecdh.X25519().NewPrivateKey(raw)
+}
+
+// setPassphrase makes password open the vault, replacing an earlier
+// passphrase recipient.
+func setPassphrase(vault string, password []byte, hint string) error {
+	old, ok, err := loadPassphraseLock(vault)
+	if err != nil {
+		return err
+	}
+	salt := make([]byte, bundleSaltSize)
+	if _, err := rand.Read(salt); err != nil {
+		return err
+	}
+	lock := passphraseLock{Salt: base64.RawURLEncoding.EncodeToString(salt), Hint: hint}
+	key, err := passphraseKey(password, lock)
+	if err != nil {
+		return err
+	}
+	lock.Public = encodePublicKey(key.PublicKey())
+	if _, err := addRecipient(vault, key.PublicKey(), "passphrase"); err != nil {
+		return err
+	}
+	// The old key goes after the new one is in, so a vault whose only
+	// recipient is the passphrase never runs out of recipients.
+	if ok {
+		if oldKey, err := parsePublicKey(old.Public); err == nil {
+			if _, err := removeRecipient(vault, oldKey); err != nil {
+				return err
+			}
+		}
+	}
+	return savePassphraseLock(vault, lock)
+}
+
+func wrongPassphrase(lock passphraseLock) error {
+	if lock.Hint == "" {
+		return errors.New("wrong passphrase")
+	}
+	return fmt.Errorf("wrong passphrase (hint: %s)", lock.Hint)
+}
+
+// askPassphrase reads the passphrase until it matches the lock, at most
+// passphraseAttempts times on a terminal and once from GONO_PASSWORD.
+func askPassphrase(lock passphraseLock) (*ecdh.PrivateKey, error) {
+	for attempt := 1; ; attempt++ {
+		password, err := readPassword(false)
+		if err != nil {
+			return nil, err
+		}
+		key, err := passphraseKey(password, lock)
+		if err != nil {
+			return nil, err
+		}
+		if encodePublicKey(key.PublicKey()) == lock.Public {
+			return key, nil
+		}
+		if os.Getenv("GONO_PASSWORD") != "" || attempt == passphraseAttempts {
+			return nil, wrongPassphrase(lock)
+		}
+		if lock.Hint != "" {
+			fmt.Fprintln(os.Stderr, "Wrong passphrase. Hint:", lock.Hint)
+		} else {
+			fmt.Fprintln(os.Stderr, "Wrong passphrase")
+		}
+	}
+}
+
+// emergencyExport writes a decrypted copy of every encrypted note of vault
+// that one of identities opens to dest, under its path without the .gnc
+// extension. The notes no identity opens are returned.
+func emergencyExport(vault string, dest string, identities []*ecdh.PrivateKey) (int, []string, error) {
+	var notes []string
+	err := walkVault(vault, func(p string, d fs.DirEntry) error {
+		if isEncryptedNote(p) {
+			notes = append(notes, p)
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, nil, err
+	}
+	exported := 0
+	var skipped []string
+	for _, p := range notes {
+		sealed, err := os.ReadFile(p)
+		if err != nil {
+			return exported, skipped, err
+		}
+		plain, err := decryptNote(sealed, identities)
+		if err != nil {
+			skipped = append(skipped, relOrBase(vault, p))
+			continue
+		}
+		rel, err := filepath.Rel(vault, strings.TrimSuffix(p, filepath.Ext(p)))
+		if err != nil {
+			return exported, skipped, err
+		}
+		target := filepath.Join(dest, rel)
+		if err := os.MkdirAll(filepath.Dir(target), 0700); err != nil {
+			return exported, skipped, err
+		}
+		if err := writeNewFile(target, plain, 0600); err != nil {
+			return exported, skipped, err
+		}
+		exported++
+	}
+	return exported, skipped, nil
+}
+
+func emergencyExportCommand(args []string, stdout io.Writer) error {
+	flags := flag.NewFlagSet("emergency-export", flag.ContinueOnError)
+	flags.SetOutput(io.Discard)
+	usage := errors.New("usage: gono emergency-export VAULT DEST")
+	if err := flags.Parse(args); err != nil || flags.NArg() != 2 {
+		return usage
+	}
+	vault, err := filepath.Abs(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+	if info, err := os.Stat(vault); err != nil || !info.IsDir() {
+		return fmt.Errorf("vault not found: %s", vault)
+	}
+	dest, err := filepath.Abs(flags.Arg(1))
+	if err != nil {
+		return err
+	}
+	if pathWithin(vault, dest) {
+		return errors.New("the destination must be outside the vault")
+	}
+	// Refusing up front keeps an export from stopping halfway at the first
+	// note that is already there.
+	if entries, err := os.ReadDir(dest); err == nil && len(entries) > 0 {
+		return fmt.Errorf("%s is not empty: export to a new or empty folder", dest)
+	}
+	lock, ok, err := loadPassphraseLock(vault)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return errors.New("the vault has no passphrase: set one with gono keys passphrase")
+	}
+	key, err := askPassphrase(lock)
+	if err != nil {
+		return err
+	}
+	identities := []*ecdh.PrivateKey{key}
+	cfg, err := loadConfig()
+	if err == nil {
+		// Keyfiles that are still around open the notes encrypted before
+		// the passphrase was set; unreadable ones are no reason to stop.
+		if found, err := loadIdentities(cfg.Encryption); err == nil {
+			identities = append(identities, found...)
+		}
+	}
+	exported, skipped, err := emergencyExport(vault, dest, identities)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(stdout, "Decrypted %s to %s\n", pluralize(exported, "note", "notes"), dest)
+	for _, rel := range skipped {
+		fmt.Fprintf(stdout, "  skipped %s: not encrypted for the passphrase; run gono keys rotate with a keyfile\n", rel)
+	}
+	return nil
+}
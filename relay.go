@@ -0,0 +1,169 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// gono relay is the server side of encrypted sync (see sync.go). It is a
+// dumb mailbox: clients append opaque blobs to a channel and read back the
+// blobs after a sequence number. Channels are named by a hash of the sync
+// key, and blobs are sealed before they leave the client, so the relay
+// never sees note names or contents.
+//
+//	POST /v1/CHANNEL            append the request body, answers {"seq": N}
+//	GET  /v1/CHANNEL?after=N    blobs with a higher sequence number, oldest first
+
+const (
+	relayMaxBlob    = 32 << 20
+	relayMaxReply   = 64 << 20
+	relayBlobLayout = "%012d"
+)
+
+var relayChannelRe = regexp.MustCompile(`^[0-9a-f]{32}$`)
+
+type relayBlob struct {
+	Seq  int64  `json:"seq"`
+	Data []byte `json:"data"`
+}
+
+type relayServer struct {
+	dir string
+	mu  sync.Mutex
+}
+
+func relayCommand(args []string, stdout io.Writer) error {
+	flags := flag.NewFlagSet("relay", flag.ContinueOnError)
+	flags.SetOutput(io.Discard)
+	addr := flags.String("addr", ":8750", "")
+	if err := flags.Parse(args); err != nil || flags.NArg() != 1 {
+		return errors.New("usage: gono relay [-addr HOST:PORT] DIR")
+	}
+	dir, err := filepath.Abs(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return err
+	}
+	server := &http.Server{
+		Addr:              *addr,
+		Handler:           &relayServer{dir: dir},
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	fmt.Fprintf(stdout, "Relaying on %s, storing in %s\n", *addr, dir)
+	return server.ListenAndServe()
+}
+
+func (s *relayServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	channel, ok := strings.CutPrefix(r.URL.Path, "/v1/")
+	if !ok || !relayChannelRe.MatchString(channel) {
+		http.NotFound(w, r)
+		return
+	}
+	switch r.Method {
+	case http.MethodPost:
+		data, err := io.ReadAll(http.MaxBytesReader(w, r.Body, relayMaxBlob))
+		if err != nil {
+			http.Error(w, "blob too large", http.StatusRequestEntityTooLarge)
+			return
+		}
+		seq, err := s.appendBlob(channel, data)
+		if err != nil {
+			http.Error(w, "cannot store blob", http.StatusInternalServerError)
+			return
+		}
+		writeRelayJSON(w, map[string]int64{"seq": seq})
+	case http.MethodGet:
+		after, _ := strconv.ParseInt(r.URL.Query().Get("after"), 10, 64)
+		blobs, err := s.readBlobs(channel, after)
+		if err != nil {
+			http.Error(w, "cannot read blobs", http.StatusInternalServerError)
+			return
+		}
+		writeRelayJSON(w, blobs)
+	default:
+		w.Header().Set("Allow", "GET, POST")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func writeRelayJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func (s *relayServer) sequences(channel string) ([]int64, error) {
+	entries, err := os.ReadDir(filepath.Join(s.dir, channel))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var seqs []int64
+	for _, e := range entries {
+		if seq, err := strconv.ParseInt(e.Name(), 10, 64); err == nil {
+			seqs = append(seqs, seq)
+		}
+	}
+	sort.Slice(seqs, func(i, j int) bool { return seqs[i] < seqs[j] })
+	return seqs, nil
+}
+
+func (s *relayServer) appendBlob(channel string, data []byte) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	seqs, err := s.sequences(channel)
+	if err != nil {
+		return 0, err
+	}
+	seq := int64(1)
+	if len(seqs) > 0 {
+		seq = seqs[len(seqs)-1] + 1
+	}
+	dir := filepath.Join(s.dir, channel)
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return 0, err
+	}
+	return seq, writeFileAtomic(filepath.Join(dir, fmt.Sprintf(relayBlobLayout, seq)), data, 0600)
+}
+
+// readBlobs returns the blobs after seq, stopping before the reply would
+// grow past relayMaxReply; clients ask again from the last one they got.
+func (s *relayServer) readBlobs(channel string, after int64) ([]relayBlob, error) {
+	s.mu.Lock()
+	seqs, err := s.sequences(channel)
+	s.mu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+	blobs := []relayBlob{}
+	size := 0
+	for _, seq := range seqs {
+		if seq <= after {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(s.dir, channel, fmt.Sprintf(relayBlobLayout, seq)))
+		if err != nil {
+			return nil, err
+		}
+		if size += len(data); size > relayMaxReply && len(blobs) > 0 {
+			break
+		}
+		blobs = append(blobs, relayBlob{Seq: seq, Data: data})
+	}
+	return blobs, nil
+}
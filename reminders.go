@@ -0,0 +1,192 @@
+package main
+
+import (
+	"io/fs"
+	"os"
+	"os/exec"
+	"runtime"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/list"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// Notes can carry "remind: 2024-07-01" (optionally with a time, "2024-07-01
+// 09:30") and "expires: 2024-12-31" in their frontmatter, or "remind me
+// 2024-07-01" in the text. Due reminders and expired notes are announced when
+// a vault is opened; Ctrl+K lists them all.
+
+var reminderLayouts = []string{"2006-01-02 15:04", "2006-01-02T15:04", time.RFC3339, "2006-01-02"}
+
+type reminder struct {
+	path    string
+	at      time.Time
+	expires bool
+}
+
+type remindersMsg struct {
+	vault     string
+	reminders []reminder
+	startup   bool
+}
+
+func parseReminderTime(value string) (time.Time, bool) {
+	value = strings.TrimSpace(value)
+	for _, layout := range reminderLayouts {
+		if t, err := time.ParseInLocation(layout, value, time.Local); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// collectReminders returns the reminder and expiry dates of all notes in
+// vault, earliest first.
+func collectReminders(vault string) []reminder {
+	var out []reminder
+	_ = walkVault(vault, func(p string, d fs.DirEntry) error {
+		if !isIndexedNote(p) {
+			return nil
+		}
+		content, err := os.ReadFile(p)
+		if err != nil {
+			return nil
+		}
+		fields, _ := parseFrontmatter(string(content))
+		if at, ok := parseReminderTime(fields["remind"]); ok {
+			out = append(out, reminder{path: p, at: at})
+		}
+		if at, ok := parseReminderTime(fields["expires"]); ok {
+			out = append(out, reminder{path: p, at: at, expires: true})
+		}
+		for _, at := range bodyReminders(string(content)) {
+			out = append(out, reminder{path: p, at: at})
+		}
+		return nil
+	})
+	sort.Slice(out, func(i, j int) bool {
+		return out[i].at.Before(out[j].at)
+	})
+	return out
+}
+
+func dueReminders(all []reminder, now time.Time) []reminder {
+	var due []reminder
+	for _, r := range all {
+		if !r.at.After(now) {
+			due = append(due, r)
+		}
+	}
+	return due
+}
+
+func remindersCmd(vault string, startup bool) tea.Cmd {
+	return func() tea.Msg {
+		return remindersMsg{vault: vault, reminders: collectReminders(vault), startup: startup}
+	}
+}
+
+func (m Model) showReminders() (tea.Model, tea.Cmd) {
+	m.status = infoStatus("Collecting reminders...")
+	return m, remindersCmd(m.vault, false)
+}
+
+func (m Model) applyReminders(msg remindersMsg) (Model, tea.Cmd) {
+	if msg.vault != m.vault {
+		return m, nil
+	}
+	now := time.Now()
+	if msg.startup {
+		due := dueReminders(msg.reminders, now)
+		if len(due) == 0 {
+			return m, nil
+		}
+		if m.status.text == "" {
+			m.status = statusLine{kind: statusWarn, text: trn("%d reminder due, Ctrl+K to list", "%d reminders due, Ctrl+K to list", len(due))}
+		}
+		if m.cfg.Reminders.Notify {
+			return m, notifyCmd("GoNo", remindersSummary(m.vault, due))
+		}
+		return m, nil
+	}
+	if m.state != stateFileList {
+		return m, nil
+	}
+	if len(msg.reminders) == 0 {
+		m.status = infoStatus("No notes with remind: or expires: dates")
+		return m, nil
+	}
+	items := make([]list.Item, 0, len(msg.reminders))
+	for _, r := range msg.reminders {
+		items = append(items, item{
+			title: relOrBase(m.vault, r.path),
+			desc:  reminderLabel(r, now),
+			path:  r.path,
+			mode:  "reminder",
+		})
+	}
+	m.lastList = stateFileList
+	m.state = stateReminders
+	m.status = statusLine{}
+	m.list.SetItems(items)
+	m.list.Title = tr("Reminders")
+	m.list.Select(0)
+	return m, nil
+}
+
+func reminderLabel(r reminder, now time.Time) string {
+	when := r.at.Format("2006-01-02")
+	if r.at.Hour() != 0 || r.at.Minute() != 0 {
+		when = r.at.Format("2006-01-02 15:04")
+	}
+	switch {
+	case r.expires && !r.at.After(now):
+		return tr("Expired %s", when)
+	case r.expires:
+		return tr("Expires %s", when)
+	case !r.at.After(now):
+		return tr("Due %s", when)
+	default:
+		return tr("Remind %s", when)
+	}
+}
+
+func remindersSummary(vault string, due []reminder) string {
+	names := make([]string, 0, len(due))
+	for _, r := range due {
+		names = append(names, relOrBase(vault, r.path))
+		if len(names) == 3 {
+			break
+		}
+	}
+	summary := strings.Join(names, ", ")
+	if len(due) > len(names) {
+		summary += ", ..."
+	}
+	return trn("%d reminder due: ", "%d reminders due: ", len(due)) + summary
+}
+
+// notifyCmd shows a desktop notification with the OS notifier, where there
+// is one. Failures are ignored: the status line already shows the reminder.
+func notifyCmd(title string, body string) tea.Cmd {
+	return func() tea.Msg {
+		var cmd *exec.Cmd
+		switch runtime.GOOS {
+		case "darwin":
+			script := "display notification " + appleScriptString(body) + " with title " + appleScriptString(title)
+			cmd = exec.Command("osascript", "-e", script)
+		case "windows":
+			return nil
+		default:
+			cmd = exec.Command("notify-send", "--app-name=GoNo", title, body)
+		}
+		_ = cmd.Run()
+		return nil
+	}
+}
+
+func appleScriptString(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s) + `"`
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// Alt+F in the file list shows the selected file or folder in the system's
+// file manager: Explorer and Finder select it in its folder; elsewhere the
+// file manager is asked over D-Bus and, when none answers, xdg-open opens
+// the containing folder. Handy for dropping attachments next to a note.
+
+// revealCommand returns the command showing path in the file manager.
+func revealCommand(path string, isDir bool) *exec.Cmd {
+	switch runtime.GOOS {
+	case "darwin":
+		if isDir {
+			return exec.Command("open", path)
+		}
+		return exec.Command("open", "-R", path)
+	case "windows":
+		if isDir {
+			return exec.Command("explorer", path)
+		}
+		return exec.Command("explorer", "/select,", path)
+	}
+	if isDir {
+		return exec.Command("xdg-open", path)
+	}
+	if _, err := exec.LookPath("dbus-send"); err == nil {
+		u := url.URL{Scheme: "file", Path: filepath.ToSlash(path)}
+		return exec.Command("dbus-send", "--session", "--print-reply", "--reply-timeout=2000", "--dest=org.freedesktop.FileManager1",
+			"--type=method_call", "/org/freedesktop/FileManager1", "org.freedesktop.FileManager1.ShowItems",
+			"array:string:"+u.String(), "string:")
+	}
+	return exec.Command("xdg-open", filepath.Dir(path))
+}
+
+// revealInFileManager shows path and waits only for commands that report
+// whether a file manager took the request.
+func revealInFileManager(path string, isDir bool) error {
+	cmd := revealCommand(path, isDir)
+	if filepath.Base(cmd.Path) == "dbus-send" {
+		if err := cmd.Run(); err == nil {
+			return nil
+		}
+		cmd = exec.Command("xdg-open", filepath.Dir(path))
+	}
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	go cmd.Wait()
+	return nil
+}
+
+// revealTarget is the entry Alt+F shows: the selected entry, or the
+// current folder on "..".
+func (m Model) revealTarget() (string, bool) {
+	if selected, ok := m.list.SelectedItem().(item); ok && selected.path != "" && selected.mode == "" {
+		return selected.path, selected.isDir
+	}
+	return m.current, true
+}
+
+func (m Model) revealSelected() (tea.Model, tea.Cmd) {
+	path, isDir := m.revealTarget()
+	if path == "" {
+		return m, nil
+	}
+	if _, err := os.Stat(path); err != nil {
+		m.status = errorStatus(err)
+		return m, nil
+	}
+	if err := revealInFileManager(path, isDir); err != nil {
+		m.status = errorStatus(err)
+		return m, nil
+	}
+	m.status = okStatus("Shown in the file manager: %s", relOrDot(m.vault, path))
+	return m, nil
+}
@@ -0,0 +1,215 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/list"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// A new vault can start from a scaffold: a folder structure, a few
+// templates (see templates.go) and a README explaining the layout. Paths
+// ending in "/" are folders; everything else is written as a file.
+
+type vaultScaffold struct {
+	key   string
+	name  string
+	desc  string
+	files map[string]string
+}
+
+var vaultScaffolds = []vaultScaffold{
+	{
+		key:  "zettelkasten",
+		name: "Zettelkasten",
+		desc: "Atomic notes with IDs, literature notes and an inbox",
+		files: map[string]string{
+			"inbox/":      "",
+			"zettel/":     "",
+			"literature/": "",
+			"structure/":  "",
+			".order":      "inbox\nzettel\nliterature\nstructure\n",
+			"templates/zettel.md": "# {{title}}\n\n" +
+				"{{prompt:The idea, in one sentence}}\n\n" +
+				"## Links\n\n- \n\n## Source\n\n",
+			"templates/literature.md": "---\nsource: {{prompt:Source (book, article, URL)}}\nread: {{date}}\n---\n\n# {{title}}\n\n" +
+				"## Summary\n\n## Notes\n\n## Ideas to turn into zettels\n\n",
+			"structure/index.md": "# Index\n\nEntry points into the slip box. Link structure notes here.\n",
+			"README.md": "# {{vault}}\n\n" +
+				"A Zettelkasten: one idea per note, linked to related notes.\n\n" +
+				"- `inbox/` - quick captures, to be processed into zettels.\n" +
+				"- `zettel/` - permanent notes, one idea each, written in your own words.\n" +
+				"- `literature/` - notes on what you read, with the source.\n" +
+				"- `structure/` - index and overview notes that link zettels together.\n\n" +
+				"Turn on note IDs in the settings (F2) to prefix new notes with a timestamp ID; " +
+				"Ctrl+G opens a note by its ID and Ctrl+L inserts a link.\n",
+		},
+	},
+	{
+		key:  "para",
+		name: "PARA",
+		desc: "Projects, Areas, Resources, Archive",
+		files: map[string]string{
+			"projects/":  "",
+			"areas/":     "",
+			"resources/": "",
+			"archive/":   "",
+			".order":     "projects\nareas\nresources\narchive\n",
+			"templates/project.md": "---\nstatus: active\ndeadline: {{prompt:Deadline (YYYY-MM-DD)}}\n---\n\n# {{title}}\n\n" +
+				"## Goal\n\n{{prompt:Goal}}\n\n## Tasks\n\n- [ ] \n\n## Notes\n\n",
+			"templates/area.md": "# {{title}}\n\n## Standard to maintain\n\n## Current projects\n\n## Notes\n\n",
+			"README.md": "# {{vault}}\n\n" +
+				"Organized with PARA, by how actionable the information is.\n\n" +
+				"- `projects/` - short-term efforts with a goal and a deadline.\n" +
+				"- `areas/` - ongoing responsibilities without an end date.\n" +
+				"- `resources/` - topics of interest and reference material.\n" +
+				"- `archive/` - inactive items from the other three.\n\n" +
+				"Move finished projects to `archive/` (F3 opens the two-pane browser).\n",
+		},
+	},
+	{
+		key:  "journal",
+		name: "Journal",
+		desc: "Daily notes by date, weekly reviews",
+		files: map[string]string{
+			"daily/":                     "",
+			"weekly/":                    "",
+			"ideas/":                     "",
+			".order":                     "daily\nweekly\nideas\n",
+			"templates/day.md":           "# {{date}}\n\n## Plan\n\n- \n\n## Log\n\n## Gratitude\n\n",
+			"templates/weekly-review.md": "# Week of {{date}}\n\n## Wins\n\n## Lessons\n\n## Next week\n\n",
+			"README.md": "# {{vault}}\n\n" +
+				"A journal with one note per day.\n\n" +
+				"- `daily/` - daily notes named `YYYY-MM-DD.md`.\n" +
+				"- `weekly/` - weekly reviews.\n" +
+				"- `ideas/` - anything worth keeping beyond a single day.\n\n" +
+				"Set the startup view to \"daily\" in the settings (F2) to open today's note when GoNo starts; " +
+				"Alt+1 inserts today's date.\n",
+		},
+	},
+	{
+		key:  "project",
+		name: "Project docs",
+		desc: "Decisions, meeting notes, specs and runbooks",
+		files: map[string]string{
+			"decisions/": "",
+			"meetings/":  "",
+			"specs/":     "",
+			"runbooks/":  "",
+			".order":     "README.md\nspecs\ndecisions\nmeetings\nrunbooks\n",
+			"templates/decision.md": "---\nstatus: proposed\ndate: {{date}}\n---\n\n# {{title}}\n\n" +
+				"## Context\n\n{{prompt:Context}}\n\n## Decision\n\n## Consequences\n\n",
+			"templates/meeting.md": "# {{title}}\n\nDate: {{date}} {{time}}\nAttendees: {{prompt:Attendees}}\n\n" +
+				"## Agenda\n\n## Notes\n\n## Action items\n\n- [ ] \n",
+			"templates/runbook.md": "# {{title}}\n\n## When to use\n\n## Steps\n\n1. \n\n## Rollback\n\n",
+			"README.md": "# {{vault}}\n\n" +
+				"Documentation for the project.\n\n" +
+				"- `specs/` - what is being built and why.\n" +
+				"- `decisions/` - decision records: context, decision, consequences.\n" +
+				"- `meetings/` - meeting notes with action items.\n" +
+				"- `runbooks/` - step-by-step operational procedures.\n\n" +
+				"Ctrl+T creates a note from the templates in `templates/`.\n",
+		},
+	},
+}
+
+func findScaffold(key string) (vaultScaffold, bool) {
+	for _, s := range vaultScaffolds {
+		if s.key == key {
+			return s, true
+		}
+	}
+	return vaultScaffold{}, false
+}
+
+// applyScaffold creates the scaffold's folders and files in vault. Existing
+// files are left alone.
+func applyScaffold(vault string, s vaultScaffold) error {
+	paths := make([]string, 0, len(s.files))
+	for p := range s.files {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+	for _, p := range paths {
+		target := filepath.Join(vault, filepath.FromSlash(strings.TrimSuffix(p, "/")))
+		if strings.HasSuffix(p, "/") {
+			if err := os.MkdirAll(target, 0755); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+			return err
+		}
+		content := strings.ReplaceAll(s.files[p], "{{vault}}", filepath.Base(vault))
+		file, err := os.OpenFile(target, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
+		if os.IsExist(err) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		_, err = file.WriteString(content)
+		if closeErr := file.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m Model) enterScaffoldSelect(name string) (tea.Model, tea.Cmd) {
+	m.newVault = name
+	m.input.Blur()
+	m.lastList = stateVaultSelect
+	m.state = stateVaultScaffold
+	items := []list.Item{item{
+		title: tr("Empty vault"),
+		desc:  tr("Start with an empty folder"),
+		mode:  "scaffold",
+	}}
+	for _, s := range vaultScaffolds {
+		items = append(items, item{title: tr(s.name), desc: tr(s.desc), path: s.key, mode: "scaffold"})
+	}
+	m.list.SetItems(items)
+	m.list.Title = tr("Start %s from a scaffold (Enter)", name)
+	m.list.Select(0)
+	return m, nil
+}
+
+// createVault creates the vault directory name in the storage root, sets
+// up the scaffold, if any, registers the vault and opens it.
+func (m Model) createVault(name string, scaffold string) (tea.Model, tea.Cmd) {
+	root := vaultStorageRoot(m.cfg.VaultDir)
+	if err := os.MkdirAll(root, 0755); err != nil {
+		m.status = errorStatus(err)
+		return m, nil
+	}
+	path := filepath.Join(root, name)
+	if err := os.Mkdir(path, 0755); err != nil {
+		m.status = errorStatus(err)
+		return m, nil
+	}
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		m.status = errorStatus(err)
+		return m, nil
+	}
+	if s, ok := findScaffold(scaffold); ok {
+		if err := applyScaffold(abs, s); err != nil {
+			m.status = failStatus("scaffold not complete: %v", err)
+			return m, nil
+		}
+	}
+	if err := registerVault(abs); err != nil {
+		m.status = warnStatus("Vault created, but registry update failed: %v", err)
+		return m, nil
+	}
+	m.status = okStatus("Vault created: %s", filepath.Base(abs))
+	return m.enterVault(abs)
+}
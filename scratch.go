@@ -0,0 +1,113 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// The scratch buffer is one text per vault, kept in .gono/scratch.md, for
+// things not yet worth a note. Ctrl+` (or Alt+` in the editor, where Ctrl+`
+// sets the mark) opens it from any screen and returns to where you were.
+// It is saved as you type, so leaving it never asks about unsaved changes,
+// and it is not listed, indexed or synced like notes.
+
+const scratchFileName = "scratch.md"
+
+func scratchPath(vault string) string {
+	return filepath.Join(appDir(vault), scratchFileName)
+}
+
+func (m Model) isScratch(path string) bool {
+	return m.vault != "" && path != "" && path == scratchPath(m.vault)
+}
+
+// isScratchKey reports whether msg opens or closes the scratch buffer in the
+// current state. Confirmation prompts keep their keys.
+func (m Model) isScratchKey(msg tea.KeyMsg) bool {
+	switch m.state {
+	case stateConfirmUnsaved, stateConfirmOverwrite, stateReviewSave, stateConfirmDelete, stateConfirmRemoveKey:
+		return false
+	}
+	switch msg.String() {
+	case "alt+`":
+		return true
+	case "ctrl+@":
+		return m.state != stateEditor || m.readOnly
+	}
+	return false
+}
+
+func (m Model) toggleScratch() (tea.Model, tea.Cmd) {
+	if m.vault == "" {
+		m.status = infoStatus("Open a vault to use its scratch buffer")
+		return m, nil
+	}
+	path := scratchPath(m.vault)
+	if m.state == stateEditor && m.editing == path {
+		return m.leaveScratch()
+	}
+	if err := createIfMissing(path, ""); err != nil {
+		m.status = errorStatus(err)
+		return m, nil
+	}
+	m.returnTo = m.state
+	if m.state == stateEditor && m.dirty() {
+		m.switchTo = path
+		m.state = stateConfirmUnsaved
+		return m, nil
+	}
+	next, cmd := m.openFile(path)
+	if nm, ok := next.(Model); ok && nm.editing == path {
+		nm.status = infoStatus("Scratch buffer: saved as you type")
+		return nm, cmd
+	}
+	return next, cmd
+}
+
+// leaveScratch goes back to the note or screen the scratch buffer was
+// opened from.
+func (m Model) leaveScratch() (tea.Model, tea.Cmd) {
+	m = m.flushScratch()
+	back := m.returnTo
+	if back == stateEditor && m.prevNote != "" {
+		return m.openFile(m.prevNote)
+	}
+	m.textarea.Blur()
+	if back == stateEditor || back == stateVaultSelect {
+		back = stateFileList
+	}
+	m.state = back
+	if back == stateFileList {
+		m = m.refreshFileList()
+	}
+	return m, nil
+}
+
+func (m Model) editorTitle() string {
+	if m.isScratch(m.editing) {
+		return tr("Scratch buffer")
+	}
+	return tr("Editing: %s", relOrBase(m.vault, m.editing))
+}
+
+// flushScratch writes the scratch buffer when it has changed.
+func (m Model) flushScratch() Model {
+	if !m.isScratch(m.editing) || !m.dirty() {
+		return m
+	}
+	if err := os.MkdirAll(appDir(m.vault), 0755); err != nil {
+		m.status = errorStatus(err)
+		return m
+	}
+	if err := writeFileAtomic(m.editing, []byte(m.bufferForDisk()), 0644); err != nil {
+		m.status = errorStatus(err)
+		return m
+	}
+	m.saved = m.textarea.Value()
+	if info, err := os.Stat(m.editing); err == nil {
+		m.diskMod = info.ModTime()
+	}
+	return m
+}
@@ -0,0 +1,359 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// Vault search takes filters besides plain words:
+//
+//	tag:#go path:work/ modified:>2024-05-01 "exact phrase" -draft
+//
+// tag: keeps the notes with a tag (in the frontmatter or as #tag in the
+// text), path: those whose path in the vault starts with the value, and
+// modified: those changed after (>, >=), before (<, <=) or on a day, or
+// within a range (2024-05-01..2024-05-31); today and yesterday work as
+// dates. A quoted phrase must occur as written, case aside. A leading -
+// leaves out the notes with a word, a phrase, a tag or a path. All parts must
+// match; OR between them lists the notes matching either side. Words and
+// dates are looked up in the index, so notes are only read to check tags
+// and phrases.
+
+var searchFilterKeys = []string{"tag:", "path:", "modified:"}
+
+// searchClause is a part of a search that must match as a whole.
+type searchClause struct {
+	words      []string
+	phrases    []string
+	tags       []string
+	paths      []string
+	notWords   []string
+	notPhrases []string
+	notTags    []string
+	notPaths   []string
+	after      time.Time // modified at or after
+	before     time.Time // modified before
+	// prefixLast is set when the clause ends with a word still being
+	// typed, which then matches the words it begins.
+	prefixLast bool
+}
+
+// searchQuery is a parsed search; its clauses are joined by OR.
+type searchQuery struct {
+	clauses []searchClause
+}
+
+// usesSearchFilters reports whether s has tag:, path: or modified: filters,
+// so that it is not taken for a property query.
+func usesSearchFilters(s string) bool {
+	for _, field := range strings.Fields(s) {
+		field = strings.ToLower(strings.TrimPrefix(field, "-"))
+		for _, key := range searchFilterKeys {
+			if strings.HasPrefix(field, key) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// splitSearch splits s at spaces outside of double quotes. An unterminated
+// quote runs to the end, as it does while the query is being typed.
+func splitSearch(s string) []string {
+	var fields []string
+	var b strings.Builder
+	quoted := false
+	for _, r := range s {
+		switch {
+		case r == '"':
+			quoted = !quoted
+			b.WriteRune(r)
+		case unicode.IsSpace(r) && !quoted:
+			if b.Len() > 0 {
+				fields = append(fields, b.String())
+				b.Reset()
+			}
+		default:
+			b.WriteRune(r)
+		}
+	}
+	if b.Len() > 0 {
+		fields = append(fields, b.String())
+	}
+	return fields
+}
+
+func parseSearch(s string) (searchQuery, error) {
+	var q searchQuery
+	var c searchClause
+	empty := true
+	flush := func() {
+		if !empty {
+			q.clauses = append(q.clauses, c)
+		}
+		c, empty = searchClause{}, true
+	}
+	for _, field := range splitSearch(s) {
+		if field == "OR" {
+			flush()
+			continue
+		}
+		if field == "AND" {
+			continue
+		}
+		negated := len(field) > 1 && field[0] == '-'
+		if negated {
+			field = field[1:]
+		}
+		c.prefixLast = false
+		key, value, filter := strings.Cut(field, ":")
+		key = strings.ToLower(key)
+		if filter && key != "tag" && key != "path" && key != "modified" {
+			filter = false
+		}
+		switch {
+		case strings.HasPrefix(field, `"`):
+			phrase := strings.ToLower(strings.TrimSpace(strings.Trim(field, `"`)))
+			if phrase == "" {
+				continue
+			}
+			if negated {
+				c.notPhrases = append(c.notPhrases, phrase)
+			} else {
+				c.phrases = append(c.phrases, phrase)
+			}
+		case filter && key == "tag":
+			tag := strings.ToLower(strings.TrimPrefix(strings.Trim(value, `"`), "#"))
+			if tag == "" {
+				continue
+			}
+			if negated {
+				c.notTags = append(c.notTags, tag)
+			} else {
+				c.tags = append(c.tags, tag)
+			}
+		case filter && key == "path":
+			p := strings.ToLower(strings.TrimLeft(filepath.ToSlash(strings.Trim(value, `"`)), "/"))
+			if p == "" {
+				continue
+			}
+			if negated {
+				c.notPaths = append(c.notPaths, p)
+			} else {
+				c.paths = append(c.paths, p)
+			}
+		case filter:
+			if value == "" {
+				continue
+			}
+			if negated {
+				return q, fmt.Errorf("-modified: is not supported, use modified:<DATE or modified:>DATE")
+			}
+			if err := c.parseModified(value); err != nil {
+				return q, err
+			}
+		default:
+			words := tokenize(field)
+			if len(words) == 0 {
+				continue
+			}
+			if negated {
+				c.notWords = append(c.notWords, words...)
+			} else {
+				c.words = append(c.words, words...)
+				c.prefixLast = true
+			}
+		}
+		empty = false
+	}
+	flush()
+	return q, nil
+}
+
+// parseModified sets the date range of a modified: filter.
+func (c *searchClause) parseModified(value string) error {
+	if from, to, ok := strings.Cut(value, ".."); ok {
+		if from != "" {
+			day, err := parseSearchDate(from)
+			if err != nil {
+				return err
+			}
+			c.after = day
+		}
+		if to != "" {
+			day, err := parseSearchDate(to)
+			if err != nil {
+				return err
+			}
+			c.before = day.AddDate(0, 0, 1)
+		}
+		return nil
+	}
+	op := ""
+	for _, prefix := range []string{">=", "<=", ">", "<", "="} {
+		if strings.HasPrefix(value, prefix) {
+			op, value = prefix, value[len(prefix):]
+			break
+		}
+	}
+	if value == "" {
+		return nil
+	}
+	day, err := parseSearchDate(value)
+	if err != nil {
+		return err
+	}
+	switch op {
+	case ">=":
+		c.after = day
+	case ">":
+		c.after = day.AddDate(0, 0, 1)
+	case "<=":
+		c.before = day.AddDate(0, 0, 1)
+	case "<":
+		c.before = day
+	default:
+		c.after, c.before = day, day.AddDate(0, 0, 1)
+	}
+	return nil
+}
+
+// parseSearchDate reads a day as 2006-01-02, today or yesterday.
+func parseSearchDate(s string) (time.Time, error) {
+	now := time.Now()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.Local)
+	switch strings.ToLower(s) {
+	case "today":
+		return today, nil
+	case "yesterday":
+		return today.AddDate(0, 0, -1), nil
+	}
+	day, err := time.ParseInLocation(dayLayout, s, time.Local)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("modified: %q is not a date like 2024-05-01", s)
+	}
+	return day, nil
+}
+
+// plain reports whether q is words only, searched as before filters.
+func (q searchQuery) plain() bool {
+	if len(q.clauses) != 1 {
+		return false
+	}
+	c := q.clauses[0]
+	return len(c.phrases)+len(c.tags)+len(c.paths)+len(c.notWords)+len(c.notPhrases)+len(c.notTags)+len(c.notPaths) == 0 &&
+		c.after.IsZero() && c.before.IsZero()
+}
+
+// words returns the words and phrases q looks for, to rank the results
+// and pick the line shown with each.
+func (q searchQuery) words() string {
+	var parts []string
+	for _, c := range q.clauses {
+		parts = append(parts, c.phrases...)
+		parts = append(parts, c.words...)
+	}
+	return strings.Join(parts, " ")
+}
+
+// find returns the notes matching q, best first.
+func (ix *noteIndex) find(vault string, q searchQuery) []searchHit {
+	if q.plain() {
+		return ix.search(q.words())
+	}
+	scores := make(map[string]int)
+	for _, c := range q.clauses {
+		for rel, score := range ix.matchClause(vault, c) {
+			if old, ok := scores[rel]; !ok || score > old {
+				scores[rel] = score
+			}
+		}
+	}
+	return ix.topHits(scores, tokenize(q.words()))
+}
+
+// matchClause scores the notes matching c.
+func (ix *noteIndex) matchClause(vault string, c searchClause) map[string]int {
+	var terms []string
+	for _, phrase := range c.phrases {
+		terms = append(terms, tokenize(phrase)...)
+	}
+	terms = append(terms, c.words...)
+	candidates := ix.match(terms, c.prefixLast)
+	if len(terms) == 0 {
+		candidates = make(map[string]int, len(ix.Docs))
+		for rel := range ix.Docs {
+			candidates[rel] = 0
+		}
+	}
+	out := make(map[string]int)
+	for rel, score := range candidates {
+		if c.matchesDoc(ix, vault, rel) {
+			out[rel] = score
+		}
+	}
+	return out
+}
+
+// matchesDoc checks the filters of c against the note rel: paths, dates
+// and excluded words from the index first, then tags and phrases from the
+// note itself.
+func (c searchClause) matchesDoc(ix *noteIndex, vault string, rel string) bool {
+	lowerRel := strings.ToLower(rel)
+	for _, p := range c.paths {
+		if !strings.HasPrefix(lowerRel, p) {
+			return false
+		}
+	}
+	for _, p := range c.notPaths {
+		if strings.HasPrefix(lowerRel, p) {
+			return false
+		}
+	}
+	modified := time.Unix(0, ix.Docs[rel].ModTime)
+	if (!c.after.IsZero() && modified.Before(c.after)) || (!c.before.IsZero() && !modified.Before(c.before)) {
+		return false
+	}
+	for _, word := range c.notWords {
+		if ix.Terms[word][rel] > 0 {
+			return false
+		}
+	}
+	if len(c.phrases)+len(c.notPhrases)+len(c.tags)+len(c.notTags) == 0 {
+		return true
+	}
+	data, err := os.ReadFile(filepath.Join(vault, filepath.FromSlash(rel)))
+	if err != nil {
+		return false
+	}
+	content := string(data)
+	lower := strings.ToLower(content)
+	for _, phrase := range c.phrases {
+		if !strings.Contains(lower, phrase) {
+			return false
+		}
+	}
+	for _, phrase := range c.notPhrases {
+		if strings.Contains(lower, phrase) {
+			return false
+		}
+	}
+	if len(c.tags)+len(c.notTags) > 0 {
+		tags := noteTags(content)
+		for _, tag := range c.tags {
+			if _, ok := tags[tag]; !ok {
+				return false
+			}
+		}
+		for _, tag := range c.notTags {
+			if _, ok := tags[tag]; ok {
+				return false
+			}
+		}
+	}
+	return true
+}
@@ -0,0 +1,168 @@
+package main
+
+import (
+	"crypto/rand"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"math/big"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/atotto/clipboard"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// Secret fields are written as {{secret:VALUE}}. The value is masked in the
+// editor unless the cursor is on its line, and Ctrl+Y copies it to the
+// clipboard, which is cleared again after a while.
+var secretFieldRe = regexp.MustCompile(`\{\{secret:([^}]*)\}\}`)
+
+const (
+	passwordLetters = "abcdefghijkmnopqrstuvwxyzABCDEFGHJKLMNPQRSTUVWXYZ"
+	passwordDigits  = "23456789"
+	passwordSymbols = "!#$%&*+-=?@^_~"
+)
+
+type clipboardClearMsg struct {
+	value string
+}
+
+// maskSecrets replaces the values of secret fields with bullets of the same
+// length, so wrapping and cursor columns stay the same.
+func maskSecrets(line []rune) []rune {
+	s := string(line)
+	if !strings.Contains(s, "{{secret:") {
+		return line
+	}
+	masked := secretFieldRe.ReplaceAllStringFunc(s, func(field string) string {
+		value := secretFieldRe.FindStringSubmatch(field)[1]
+		return "{{secret:" + strings.Repeat("•", len([]rune(value))) + "}}"
+	})
+	return []rune(masked)
+}
+
+// secretAt returns the value of the secret field under or nearest before
+// col on line, or the only one on the line.
+func secretAt(line string, col int) (string, bool) {
+	matches := secretFieldRe.FindAllStringSubmatchIndex(line, -1)
+	if len(matches) == 0 {
+		return "", false
+	}
+	best := matches[0]
+	for _, match := range matches {
+		if len([]rune(line[:match[0]])) <= col {
+			best = match
+		}
+	}
+	return line[best[2]:best[3]], true
+}
+
+func (m Model) copySecret() (tea.Model, tea.Cmd) {
+	lines := strings.Split(m.textarea.Value(), "\n")
+	row := m.textarea.Line()
+	if row >= len(lines) {
+		return m, nil
+	}
+	value, ok := secretAt(lines[row], editorColumn(m.textarea))
+	if !ok {
+		m.status = infoStatus("No secret field on this line")
+		return m, nil
+	}
+	if err := clipboard.WriteAll(value); err != nil {
+		m.status = errorStatus(err)
+		return m, nil
+	}
+	seconds := m.cfg.Secrets.ClipboardClearSeconds
+	if seconds <= 0 {
+		m.status = okStatus("Secret copied to clipboard")
+		return m, nil
+	}
+	m.status = okStatus("Secret copied, clipboard is cleared in %d s", seconds)
+	return m, tea.Tick(time.Duration(seconds)*time.Second, func(time.Time) tea.Msg {
+		return clipboardClearMsg{value: value}
+	})
+}
+
+// clearClipboard empties the clipboard unless something else was copied
+// since the secret.
+func clearClipboard(value string) {
+	if current, err := clipboard.ReadAll(); err == nil && current == value {
+		_ = clipboard.WriteAll("")
+	}
+}
+
+func (m Model) insertPassword() (tea.Model, tea.Cmd) {
+	password, err := generatePassword(m.cfg.Secrets.PasswordLength, true)
+	if err != nil {
+		m.status = errorStatus(err)
+		return m, nil
+	}
+	m.textarea.InsertString("{{secret:" + password + "}}")
+	m.status = okStatus("Password generated")
+	return m, nil
+}
+
+// generatePassword returns a random password with at least one letter,
+// digit and (when symbols is set) symbol. Look-alike characters are left out.
+func generatePassword(length int, symbols bool) (string, error) {
+	if length < 8 {
+		return "", errors.New("password length must be at least 8")
+	}
+	classes := []string{passwordLetters, passwordDigits}
+	if symbols {
+		classes = append(classes, passwordSymbols)
+	}
+	all := strings.Join(classes, "")
+	out := make([]byte, length)
+	for i := range out {
+		set := all
+		if i < len(classes) {
+			set = classes[i]
+		}
+		c, err := randomIndex(len(set))
+		if err != nil {
+			return "", err
+		}
+		out[i] = set[c]
+	}
+	// Move the guaranteed characters to random positions.
+	for i := len(out) - 1; i > 0; i-- {
+		j, err := randomIndex(i + 1)
+		if err != nil {
+			return "", err
+		}
+		out[i], out[j] = out[j], out[i]
+	}
+	return string(out), nil
+}
+
+func randomIndex(n int) (int, error) {
+	v, err := rand.Int(rand.Reader, big.NewInt(int64(n)))
+	if err != nil {
+		return 0, err
+	}
+	return int(v.Int64()), nil
+}
+
+func passwordCommand(args []string, stdout io.Writer) error {
+	flags := flag.NewFlagSet("password", flag.ContinueOnError)
+	flags.SetOutput(io.Discard)
+	length := flags.Int("length", 0, "")
+	noSymbols := flags.Bool("no-symbols", false, "")
+	if err := flags.Parse(args); err != nil || flags.NArg() > 0 {
+		return errors.New("usage: gono password [-length N] [-no-symbols]")
+	}
+	if *length == 0 {
+		cfg, _ := loadConfig()
+		*length = cfg.Secrets.PasswordLength
+	}
+	password, err := generatePassword(*length, !*noSymbols)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintln(stdout, password)
+	return nil
+}
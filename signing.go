@@ -0,0 +1,334 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// Notes can carry a detached GPG signature next to them (name.md.asc), so a
+// team can tell that a runbook was not changed behind its back. With
+// signing.notes set to "marked", notes with "signed: true" in their
+// frontmatter, and notes that have a signature already, are signed with gpg
+// each time they are saved; "all" signs every note of the vault.
+// signing.key picks the key (gpg's default key otherwise). When a note with
+// a signature is opened, gpg checks it in the background and the editor's
+// subtitle shows the result: verified with the signer, signed by a key that
+// is not trusted, expired or unknown, or not matching, which means the note
+// changed after it was signed. Signing runs in the background after the
+// save, and quitting waits for it. Signatures are left out of the file list
+// while their note is there.
+
+const (
+	signatureExt = ".asc"
+	gpgTimeout   = 20 * time.Second
+)
+
+const (
+	signOff    = "off"
+	signMarked = "marked"
+	signAll    = "all"
+)
+
+var signNoteChoices = []string{signOff, signMarked, signAll}
+
+// signingConfig sets which notes are signed on save and with which key.
+type signingConfig struct {
+	Notes string `json:"notes"`
+	Key   string `json:"key"`
+}
+
+// Results of checking a signature.
+const (
+	sigVerified  = "verified"
+	sigUntrusted = "untrusted"
+	sigExpired   = "expired"
+	sigBad       = "bad"
+	sigNoKey     = "nokey"
+	sigMissing   = "missing"
+	sigFailed    = "failed"
+	sigSigning   = "signing"
+)
+
+type signatureState struct {
+	kind   string
+	signer string
+	detail string
+}
+
+type signatureMsg struct {
+	path  string
+	state signatureState
+}
+
+// signedMsg reports a signature written after a save.
+type signedMsg struct {
+	path string
+	key  string
+	err  error
+}
+
+func validSignNotes(value string) string {
+	for _, choice := range signNoteChoices {
+		if value == choice {
+			return value
+		}
+	}
+	return signOff
+}
+
+func nextSignNotes(current string) string {
+	for i, choice := range signNoteChoices {
+		if choice == current {
+			return signNoteChoices[(i+1)%len(signNoteChoices)]
+		}
+	}
+	return signNoteChoices[0]
+}
+
+func signNotesLabel(value string) string {
+	switch value {
+	case signMarked:
+		return tr("Notes marked signed: true")
+	case signAll:
+		return tr("All notes")
+	default:
+		return tr("Off")
+	}
+}
+
+func signaturePath(path string) string {
+	return path + signatureExt
+}
+
+// isSignatureFile reports whether path is the signature of a note that
+// exists.
+func isSignatureFile(path string) bool {
+	if !strings.HasSuffix(strings.ToLower(path), signatureExt) {
+		return false
+	}
+	info, err := os.Stat(path[:len(path)-len(signatureExt)])
+	return err == nil && !info.IsDir()
+}
+
+func hasSignature(path string) bool {
+	_, err := os.Stat(signaturePath(path))
+	return err == nil
+}
+
+// markedSigned reports whether the frontmatter of text asks for a
+// signature.
+func markedSigned(text string) bool {
+	fields, ok := parseFrontmatter(text)
+	return ok && strings.EqualFold(unquote(fields["signed"]), "true")
+}
+
+// signsOnSave reports whether saving the open note signs it.
+func (m Model) signsOnSave() bool {
+	if m.vault == "" || m.editing == "" || m.readOnly || !insideVault(m.vault, m.editing) || m.isScratch(m.editing) {
+		return false
+	}
+	switch m.cfg.Signing.Notes {
+	case signAll:
+		return true
+	case signMarked:
+		return hasSignature(m.editing) || markedSigned(m.textarea.Value())
+	}
+	return false
+}
+
+func runGPG(args ...string) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), gpgTimeout)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, "gpg", append([]string{"--batch", "--no-tty"}, args...)...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if errors.Is(err, exec.ErrNotFound) {
+		return out, errors.New("gpg is not installed")
+	}
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			lines := strings.Split(msg, "\n")
+			return out, errors.New(strings.TrimPrefix(lines[len(lines)-1], "gpg: "))
+		}
+	}
+	return out, err
+}
+
+// signFile writes a detached, armored signature of path.
+func signFile(path string, key string) error {
+	args := []string{"--yes", "--armor", "--detach-sign", "--output", signaturePath(path)}
+	if key != "" {
+		args = append(args, "--local-user", key)
+	}
+	_, err := runGPG(append(args, "--", path)...)
+	return err
+}
+
+// verifyFile checks the signature of path with gpg's status output.
+func verifyFile(path string) signatureState {
+	out, err := runGPG("--status-fd", "1", "--verify", "--", signaturePath(path), path)
+	state := signatureState{kind: sigFailed}
+	for _, line := range strings.Split(string(out), "\n") {
+		fields := strings.Fields(strings.TrimPrefix(line, "[GNUPG:] "))
+		if len(fields) == 0 || !strings.HasPrefix(line, "[GNUPG:] ") {
+			continue
+		}
+		signer := ""
+		if len(fields) > 2 {
+			signer = strings.Join(fields[2:], " ")
+		}
+		switch fields[0] {
+		case "GOODSIG":
+			state = signatureState{kind: sigUntrusted, signer: signer}
+		case "EXPSIG", "EXPKEYSIG", "REVKEYSIG":
+			state = signatureState{kind: sigExpired, signer: signer}
+		case "BADSIG":
+			return signatureState{kind: sigBad, signer: signer}
+		case "ERRSIG":
+			if len(fields) > 1 {
+				state = signatureState{kind: sigNoKey, signer: fields[1]}
+			}
+		case "TRUST_FULLY", "TRUST_ULTIMATE":
+			if state.kind == sigUntrusted {
+				state.kind = sigVerified
+			}
+		}
+	}
+	if state.kind == sigFailed && err != nil {
+		state.detail = err.Error()
+	}
+	return state
+}
+
+// verifyNoteCmd checks the signature of a note that was opened. Notes
+// without one need no check, unless their frontmatter asks to be signed.
+func verifyNoteCmd(path string, text string) tea.Cmd {
+	if !hasSignature(path) {
+		if !markedSigned(text) {
+			return nil
+		}
+		return func() tea.Msg {
+			return signatureMsg{path: path, state: signatureState{kind: sigMissing}}
+		}
+	}
+	return func() tea.Msg {
+		return signatureMsg{path: path, state: verifyFile(path)}
+	}
+}
+
+func (m Model) applySignature(msg signatureMsg) Model {
+	if msg.path != m.editing {
+		return m
+	}
+	state := msg.state
+	m.signature = &state
+	if state.kind == sigBad {
+		m.status = warnStatus("The signature of %s does not match: the note changed after it was signed", relOrBase(m.vault, m.editing))
+	}
+	return m
+}
+
+// signSaved queues the note just saved for signing when it should be
+// signed, and notes when a signature was left behind by the change.
+func (m Model) signSaved() Model {
+	if !m.signsOnSave() {
+		if hasSignature(m.editing) {
+			m.signature = &signatureState{kind: sigBad, detail: tr("signing is off")}
+		}
+		return m
+	}
+	for _, p := range m.toSign {
+		if p == m.editing {
+			return m
+		}
+	}
+	m.toSign = append(m.toSign, m.editing)
+	m.signature = &signatureState{kind: sigSigning}
+	return m
+}
+
+// signCmd signs the notes queued by signSaved.
+func (m Model) signCmd() tea.Cmd {
+	if len(m.toSign) == 0 {
+		return nil
+	}
+	key := m.cfg.Signing.Key
+	cmds := make([]tea.Cmd, 0, len(m.toSign))
+	for _, p := range m.toSign {
+		cmds = append(cmds, func() tea.Msg {
+			return signedMsg{path: p, key: key, err: signFile(p, key)}
+		})
+	}
+	return tea.Batch(cmds...)
+}
+
+// quitAfterSigning quits once the notes just saved are signed.
+func (m Model) quitAfterSigning() (Model, tea.Cmd) {
+	cmd := m.signCmd()
+	m.toSign = nil
+	if cmd == nil {
+		return m, tea.Quit
+	}
+	return m, tea.Sequence(cmd, tea.Quit)
+}
+
+func (m Model) applySigned(msg signedMsg) Model {
+	name := relOrBase(m.vault, msg.path)
+	if msg.err != nil {
+		m.status = warnStatus("Saved, but not signed: %s: %v", name, msg.err)
+	} else {
+		m.status = okStatus("Saved and signed: %s", name)
+	}
+	if msg.path != m.editing {
+		return m
+	}
+	if msg.err != nil {
+		m.signature = &signatureState{kind: sigFailed, detail: msg.err.Error()}
+	} else {
+		m.signature = &signatureState{kind: sigVerified, signer: msg.key}
+	}
+	return m
+}
+
+// signatureInfo is the badge of the open note for the editor's subtitle.
+func (m Model) signatureInfo() string {
+	s := m.signature
+	if s == nil {
+		return ""
+	}
+	switch s.kind {
+	case sigVerified:
+		if s.signer == "" {
+			return tr("✓ signed")
+		}
+		return tr("✓ signed by %s", s.signer)
+	case sigUntrusted:
+		return tr("signed by %s (key not trusted)", s.signer)
+	case sigExpired:
+		return tr("signed by %s (key expired or revoked)", s.signer)
+	case sigBad:
+		if s.detail != "" {
+			return tr("✗ signature does not match (%s)", s.detail)
+		}
+		return tr("✗ signature does not match")
+	case sigNoKey:
+		return tr("signed with unknown key %s", s.signer)
+	case sigMissing:
+		return tr("not signed")
+	case sigSigning:
+		return tr("signing…")
+	}
+	if s.detail != "" {
+		return fmt.Sprintf("%s: %s", tr("signature not checked"), s.detail)
+	}
+	return tr("signature not checked")
+}
@@ -0,0 +1,349 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/mattn/go-runewidth"
+)
+
+// On wide terminals the file list and the editor can share the screen:
+// layout.split is the share of the width, in percent, given to the list.
+// The file list then previews the selected note on the right, and the
+// editor shows the notes of its folder on the left. Alt+, and Alt+. make
+// the list narrower or wider (narrowing past the minimum closes the split).
+// Layout presets bundle the split with the maximum width, soft wrap and line
+// numbers; Alt+L switches between them, and adjusting the split while a
+// preset is active stores the new width in that preset.
+
+const (
+	splitMinWidth   = 100
+	splitMinPercent = 15
+	splitMaxPercent = 70
+	splitStep       = 5
+	splitMaxPreview = 64 * 1024
+)
+
+var splitChoices = []int{0, 25, 30, 35, 40, 50}
+
+// layoutPreset is a named combination of layout and editor settings.
+type layoutPreset struct {
+	Split       int  `json:"split"`
+	MaxWidth    int  `json:"max_width"`
+	SoftWrap    bool `json:"soft_wrap"`
+	LineNumbers bool `json:"line_numbers"`
+}
+
+func defaultLayoutPresets() map[string]layoutPreset {
+	return map[string]layoutPreset{
+		"writing":  {Split: 0, MaxWidth: 100, SoftWrap: true},
+		"browsing": {Split: 35, SoftWrap: true},
+		"review":   {Split: 25, SoftWrap: true, LineNumbers: true},
+	}
+}
+
+// sidePane caches what the second pane shows, so drawing it does not read
+// the disk on every frame.
+type sidePane struct {
+	dir     string
+	dirMod  time.Time
+	entries []string
+
+	path    string
+	pathMod time.Time
+	preview []string
+}
+
+// splitWidths returns the widths of the list and the document pane, or
+// false when the screen is not split.
+func (m Model) splitWidths(contentW int) (int, int, bool) {
+	split := m.cfg.Layout.Split
+	if split <= 0 || contentW < splitMinWidth {
+		return 0, contentW, false
+	}
+	switch m.state {
+	case stateFileList:
+	case stateEditor:
+		if m.readOnly {
+			return 0, contentW, false
+		}
+	default:
+		return 0, contentW, false
+	}
+	listW := maxInt(20, contentW*minInt(split, splitMaxPercent)/100)
+	return listW, contentW - listW - 1, true
+}
+
+// editorPaneWidth is the width the editor text is laid out in.
+func (m Model) editorPaneWidth(contentW int) int {
+	if editW, _, ok := m.besideWidths(contentW); ok {
+		return editW
+	}
+	_, docW, _ := m.splitWidths(contentW)
+	return docW
+}
+
+func joinPanes(left string, leftW int, right string, height int) string {
+	sep := hintStyle.Render(strings.TrimSuffix(strings.Repeat("│\n", maxInt(1, height)), "\n"))
+	left = lipgloss.NewStyle().Width(leftW).MaxWidth(leftW).Render(left)
+	return lipgloss.JoinHorizontal(lipgloss.Top, left, sep, right)
+}
+
+// fileListBody draws the file list, with a preview of the selected entry
+// beside it when the screen is split.
+func (m Model) fileListBody(contentW int) string {
+	listW, docW, ok := m.splitWidths(contentW)
+	if !ok {
+		return m.list.View()
+	}
+	height := m.list.Height()
+	preview := ""
+	if selected := m.list.SelectedItem(); selected != nil {
+		preview = m.previewLines(selected.(item), docW, height)
+	}
+	return joinPanes(m.list.View(), listW, preview, height)
+}
+
+// editorBody draws the editor, with the note beside it or the notes of its
+// folder beside it when the screen is split.
+func (m Model) editorBody(contentW int) string {
+	if editW, besideW, ok := m.besideWidths(contentW); ok {
+		height := m.textarea.Height()
+		return joinPanes(m.editorView(editW), editW, m.besideView(besideW, height), height)
+	}
+	listW, docW, ok := m.splitWidths(contentW)
+	if !ok {
+		return m.editorView(contentW)
+	}
+	height := m.textarea.Height()
+	return joinPanes(m.folderLines(listW, height), listW, m.editorView(docW), height)
+}
+
+// folderLines lists the folder of the open note, keeping the note in view.
+func (m Model) folderLines(width int, height int) string {
+	dir := filepath.Dir(m.editing)
+	var entries []string
+	if m.side != nil {
+		entries = m.side.folder(dir)
+	} else {
+		entries = readFolder(dir)
+	}
+	current := filepath.Base(m.editing)
+	selected := 0
+	for i, name := range entries {
+		if name == current {
+			selected = i
+		}
+	}
+	top := maxInt(0, minInt(selected-height/2, len(entries)-height))
+	lines := make([]string, 0, height)
+	for i := top; i < len(entries) && len(lines) < height; i++ {
+		name := runewidth.FillRight(runewidth.Truncate(" "+entries[i], width, "…"), width)
+		if i == selected && entries[i] == current {
+			name = lipgloss.NewStyle().Reverse(true).Render(name)
+		} else if strings.HasSuffix(entries[i], "/") {
+			name = hintStyle.Render(name)
+		}
+		lines = append(lines, name)
+	}
+	return strings.Join(lines, "\n")
+}
+
+func (s *sidePane) folder(dir string) []string {
+	info, err := os.Stat(dir)
+	if err != nil {
+		return nil
+	}
+	if s.dir != dir || !s.dirMod.Equal(info.ModTime()) {
+		s.dir = dir
+		s.dirMod = info.ModTime()
+		s.entries = readFolder(dir)
+	}
+	return s.entries
+}
+
+// readFolder returns the visible folders (with a trailing slash) and files
+// of dir, folders first.
+func readFolder(dir string) []string {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil
+	}
+	var dirs, files []string
+	for _, e := range entries {
+		if strings.HasPrefix(e.Name(), ".") {
+			continue
+		}
+		if e.IsDir() {
+			dirs = append(dirs, e.Name()+"/")
+		} else {
+			files = append(files, e.Name())
+		}
+	}
+	sort.Strings(dirs)
+	sort.Strings(files)
+	return append(dirs, files...)
+}
+
+// previewLines renders the start of the selected note, or the contents of
+// the selected folder.
+func (m Model) previewLines(it item, width int, height int) string {
+	if it.mode != "" || it.path == "" {
+		return ""
+	}
+	var lines []string
+	switch {
+	case it.isDir:
+		lines = readFolder(it.path)
+		if len(lines) == 0 {
+			lines = []string{tr("Empty folder")}
+		}
+	case isEncryptedNote(it.path):
+		lines = []string{tr("Encrypted note: open it to read")}
+	case m.side != nil:
+		lines = m.side.previewOf(it.path)
+	default:
+		lines = readPreview(it.path)
+	}
+	out := make([]string, 0, height)
+	for _, line := range lines {
+		if len(out) == height {
+			break
+		}
+		line = strings.ReplaceAll(line, "\t", "    ")
+		out = append(out, runewidth.Truncate(line, width, "…"))
+	}
+	return lipgloss.NewStyle().Width(width).Render(strings.Join(out, "\n"))
+}
+
+func (s *sidePane) previewOf(path string) []string {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil
+	}
+	if s.path != path || !s.pathMod.Equal(info.ModTime()) {
+		s.path = path
+		s.pathMod = info.ModTime()
+		s.preview = readPreview(path)
+	}
+	return s.preview
+}
+
+func readPreview(path string) []string {
+	if !isIndexedNote(path) && !isTableFile(path) {
+		return []string{tr("No preview for this file type")}
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		return []string{err.Error()}
+	}
+	defer file.Close()
+	buf := make([]byte, splitMaxPreview)
+	n, _ := file.Read(buf)
+	if isBinaryContent(buf[:n]) {
+		return []string{tr("Binary file")}
+	}
+	return strings.Split(strings.ReplaceAll(string(buf[:n]), "\r\n", "\n"), "\n")
+}
+
+// resizeSplit makes the list pane wider (step > 0) or narrower.
+func (m Model) resizeSplit(step int) (tea.Model, tea.Cmd) {
+	split := m.cfg.Layout.Split
+	switch {
+	case split <= 0 && step > 0:
+		split = splitMinPercent
+	case split <= splitMinPercent && step < 0:
+		split = 0
+	default:
+		split = maxInt(splitMinPercent, minInt(splitMaxPercent, split+step))
+	}
+	m.cfg.Layout.Split = split
+	if preset, ok := m.cfg.Layout.Presets[m.cfg.Layout.Preset]; ok {
+		preset.Split = split
+		m.cfg.Layout.Presets[m.cfg.Layout.Preset] = preset
+	}
+	contentW, _ := m.contentDims()
+	switch {
+	case split == 0:
+		m.status = infoStatus("Split closed")
+	case contentW < splitMinWidth:
+		m.status = infoStatus("List pane %d%%, shown when the window is at least %d columns wide", split, splitMinWidth)
+	default:
+		m.status = infoStatus("List pane %d%%", split)
+	}
+	if err := saveConfig(m.cfg); err != nil {
+		m.status = errorStatus(err)
+	}
+	return m, nil
+}
+
+func presetNames(presets map[string]layoutPreset) []string {
+	names := make([]string, 0, len(presets))
+	for name := range presets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func nextPreset(presets map[string]layoutPreset, current string) string {
+	names := presetNames(presets)
+	if len(names) == 0 {
+		return ""
+	}
+	for i, name := range names {
+		if name == current {
+			return names[(i+1)%len(names)]
+		}
+	}
+	return names[0]
+}
+
+// applyPreset switches to the named layout preset.
+func (m Model) applyPreset(name string) Model {
+	preset, ok := m.cfg.Layout.Presets[name]
+	if !ok {
+		return m
+	}
+	m.cfg.Layout.Preset = name
+	m.cfg.Layout.Split = preset.Split
+	m.cfg.Layout.MaxWidth = preset.MaxWidth
+	m.cfg.Editor.SoftWrap = preset.SoftWrap
+	m.cfg.Editor.LineNumbers = preset.LineNumbers
+	applyLayout(m.cfg.Layout)
+	m.textarea.ShowLineNumbers = m.cfg.Editor.LineNumbers
+	return m
+}
+
+func (m Model) cyclePreset() (tea.Model, tea.Cmd) {
+	name := nextPreset(m.cfg.Layout.Presets, m.cfg.Layout.Preset)
+	if name == "" {
+		m.status = infoStatus("No layout presets in the config")
+		return m, nil
+	}
+	m = m.applyPreset(name)
+	m.status = infoStatus("Layout: %s", name)
+	if err := saveConfig(m.cfg); err != nil {
+		m.status = errorStatus(err)
+	}
+	return m, nil
+}
+
+func splitLabel(split int) string {
+	if split <= 0 {
+		return tr("Off")
+	}
+	return tr("%d%% of the width", split)
+}
+
+func presetLabel(name string) string {
+	if name == "" {
+		return tr("None")
+	}
+	return name
+}
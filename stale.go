@@ -0,0 +1,179 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/charmbracelet/bubbles/list"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// GoNo records when each note was last opened in .gono/opened.json. Notes
+// neither opened nor modified for stale_months months are stale: Alt+S in
+// the file list and "gono stale" list them, oldest first, for periodic
+// review and pruning.
+
+const (
+	openedFileName     = "opened.json"
+	defaultStaleMonths = 6
+)
+
+type staleNote struct {
+	path     string
+	opened   time.Time // zero when never opened since tracking started
+	modified time.Time
+}
+
+// lastTouched is the later of the last open and the last modification.
+func (n staleNote) lastTouched() time.Time {
+	if n.opened.After(n.modified) {
+		return n.opened
+	}
+	return n.modified
+}
+
+type staleMsg struct {
+	vault  string
+	months int
+	notes  []staleNote
+}
+
+func openedPath(vault string) string {
+	return filepath.Join(appDir(vault), openedFileName)
+}
+
+func loadOpened(vault string) map[string]time.Time {
+	opened := make(map[string]time.Time)
+	data, err := os.ReadFile(openedPath(vault))
+	if err != nil {
+		return opened
+	}
+	_ = json.Unmarshal(data, &opened)
+	return opened
+}
+
+// recordOpened stores now as the time note was last opened.
+func recordOpened(vault string, note string) error {
+	if vault == "" || note == "" || !insideVault(vault, note) {
+		return nil
+	}
+	if err := os.MkdirAll(appDir(vault), 0755); err != nil {
+		return err
+	}
+	path := openedPath(vault)
+	return withFileLock(path, func() error {
+		opened := loadOpened(vault)
+		opened[filepath.ToSlash(relOrBase(vault, note))] = time.Now().Truncate(time.Second)
+		data, err := json.Marshal(opened)
+		if err != nil {
+			return err
+		}
+		return writeFileAtomic(path, data, 0644)
+	})
+}
+
+// staleNotes returns the notes of vault last opened and modified before
+// cutoff, the longest untouched first.
+func staleNotes(vault string, cutoff time.Time) []staleNote {
+	opened := loadOpened(vault)
+	var out []staleNote
+	_ = walkVault(vault, func(p string, d fs.DirEntry) error {
+		if !isIndexedNote(p) && !isEncryptedNote(p) {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return nil
+		}
+		n := staleNote{path: p, opened: opened[filepath.ToSlash(relOrBase(vault, p))], modified: info.ModTime()}
+		if n.lastTouched().Before(cutoff) {
+			out = append(out, n)
+		}
+		return nil
+	})
+	sort.Slice(out, func(i, j int) bool {
+		return out[i].lastTouched().Before(out[j].lastTouched())
+	})
+	return out
+}
+
+func staleCutoff(months int, now time.Time) time.Time {
+	return now.AddDate(0, -months, 0)
+}
+
+func staleLabel(n staleNote) string {
+	modified := n.modified.Format("2006-01-02")
+	if n.opened.IsZero() {
+		return tr("No open recorded, modified %s", modified)
+	}
+	return tr("Opened %s, modified %s", n.opened.Format("2006-01-02"), modified)
+}
+
+func staleCommand(args []string, stdout io.Writer) error {
+	flags := flag.NewFlagSet("stale", flag.ContinueOnError)
+	flags.SetOutput(io.Discard)
+	months := flags.Int("months", defaultStaleMonths, "")
+	usage := errors.New("usage: gono stale [-months N] VAULT")
+	if err := flags.Parse(args); err != nil || flags.NArg() != 1 || *months < 1 {
+		return usage
+	}
+	vault, err := filepath.Abs(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+	if info, err := os.Stat(vault); err != nil || !info.IsDir() {
+		return fmt.Errorf("vault not found: %s", vault)
+	}
+	notes := staleNotes(vault, staleCutoff(*months, time.Now()))
+	if len(notes) == 0 {
+		fmt.Fprintf(stdout, "No notes untouched for %s\n", pluralize(*months, "month", "months"))
+		return nil
+	}
+	fmt.Fprintf(stdout, "%s untouched for %s:\n", pluralize(len(notes), "note", "notes"), pluralize(*months, "month", "months"))
+	for _, n := range notes {
+		fmt.Fprintf(stdout, "  %s  (%s)\n", relOrBase(vault, n.path), staleLabel(n))
+	}
+	return nil
+}
+
+func (m Model) showStale() (tea.Model, tea.Cmd) {
+	m.status = infoStatus("Looking for stale notes...")
+	vault, months := m.vault, m.cfg.StaleMonths
+	return m, func() tea.Msg {
+		return staleMsg{vault: vault, months: months, notes: staleNotes(vault, staleCutoff(months, time.Now()))}
+	}
+}
+
+func (m Model) applyStale(msg staleMsg) (tea.Model, tea.Cmd) {
+	if msg.vault != m.vault || m.state != stateFileList {
+		return m, nil
+	}
+	if len(msg.notes) == 0 {
+		m.status = okStatus("No notes untouched for %s", trn("%d month", "%d months", msg.months))
+		return m, nil
+	}
+	items := make([]list.Item, 0, len(msg.notes))
+	for _, n := range msg.notes {
+		items = append(items, item{
+			title: relOrBase(m.vault, n.path),
+			desc:  staleLabel(n),
+			path:  n.path,
+			mode:  "stale",
+		})
+	}
+	m.lastList = stateFileList
+	m.state = stateStale
+	m.status = statusLine{}
+	m.list.SetItems(items)
+	m.list.Title = tr("%s untouched for %s", trn("%d note", "%d notes", len(msg.notes)), trn("%d month", "%d months", msg.months))
+	m.list.Select(0)
+	return m, nil
+}
@@ -0,0 +1,149 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/charmbracelet/bubbles/textarea"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// Startup views, set with startup.view in the config.
+const (
+	startupVaults    = "vaults"
+	startupLastVault = "last_vault"
+	startupDaily     = "daily"
+	startupInbox     = "inbox"
+)
+
+var startupChoices = []string{startupVaults, startupLastVault, startupDaily, startupInbox}
+
+// startupConfig picks the first screen. The vault for the last_vault, daily
+// and inbox views is Vault, the last opened vault or the first registered
+// one, in that order.
+type startupConfig struct {
+	View     string `json:"view"`
+	Vault    string `json:"vault"`
+	DailyDir string `json:"daily_dir"`
+}
+
+func validStartupView(view string) string {
+	for _, c := range startupChoices {
+		if c == view {
+			return view
+		}
+	}
+	return startupVaults
+}
+
+func nextStartupView(view string) string {
+	for i, c := range startupChoices {
+		if c == view {
+			return startupChoices[(i+1)%len(startupChoices)]
+		}
+	}
+	return startupChoices[0]
+}
+
+func startupLabel(view string) string {
+	switch view {
+	case startupLastVault:
+		return tr("Last vault")
+	case startupDaily:
+		return tr("Today's daily note")
+	case startupInbox:
+		return tr("Inbox note")
+	default:
+		return tr("Vault list")
+	}
+}
+
+func (c startupConfig) vault() (string, error) {
+	if c.Vault != "" {
+		return filepath.Abs(expandHome(c.Vault))
+	}
+	if last := lastVault(); last != "" {
+		return last, nil
+	}
+	vaults, err := loadVaultRegistry()
+	if err != nil {
+		return "", err
+	}
+	if len(vaults) == 0 {
+		return "", errors.New("no vault registered")
+	}
+	return vaults[0], nil
+}
+
+// dailyNotePath is <vault>/<daily_dir>/YYYY-MM-DD.md.
+func (c startupConfig) dailyNotePath(vault string, day time.Time) string {
+	return filepath.Join(vault, c.DailyDir, day.Format("2006-01-02")+".md")
+}
+
+// applyStartup moves a freshly built model to the configured first screen.
+// Problems leave it on the vault list with a message.
+func (m Model) applyStartup() Model {
+	view := m.cfg.Startup.View
+	if view == startupVaults {
+		return m
+	}
+	vault, err := m.cfg.Startup.vault()
+	if err == nil {
+		if info, statErr := os.Stat(vault); statErr != nil || !info.IsDir() {
+			err = errors.New(tr("vault not found: %s", vault))
+		}
+	}
+	if err != nil {
+		m.status = failStatus("startup view not shown: %v", err)
+		return m
+	}
+	m, _ = m.enterVault(vault)
+
+	var note, initial string
+	switch view {
+	case startupDaily:
+		note = m.cfg.Startup.dailyNotePath(vault, time.Now())
+		initial = "# " + time.Now().Format(m.dates.Date) + "\n\n"
+	case startupInbox:
+		note = filepath.Join(vault, m.cfg.Capture.Inbox)
+	default:
+		return m
+	}
+	if !insideVault(vault, note) {
+		m.status = failStatus("startup note must be inside the vault: %s", note)
+		return m
+	}
+	if err := createIfMissing(note, initial); err != nil {
+		m.status = errorStatus(err)
+		return m
+	}
+	m.current = filepath.Dir(note)
+	next, _ := m.openFile(note)
+	return next.(Model)
+}
+
+func createIfMissing(path string, content string) error {
+	if _, err := os.Stat(path); err == nil {
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, []byte(content), 0644)
+}
+
+// startupCmd starts what enterVault and openFile would have returned as
+// commands when the model is built on a vault. The index job itself was
+// created by enterVault.
+func (m Model) startupCmd() tea.Cmd {
+	if m.vault == "" || m.job == nil {
+		return nil
+	}
+	cmd := tea.Batch(m.indexCmd(), remindersCmd(m.vault, true))
+	if m.state == stateEditor {
+		return tea.Batch(cmd, textarea.Blink)
+	}
+	return cmd
+}
@@ -0,0 +1,293 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/mattn/go-runewidth"
+)
+
+// The status bar is the last line of every screen. It is made of segments
+// (what the screen is doing, the vault, unsaved changes, the git branch,
+// the last sync and the clock); status_bar.segments picks which are shown
+// and in what order, and status_bar.colors overrides their colors. Git and
+// sync details are read in the background and refreshed every
+// barRefreshInterval, so drawing the bar never runs git.
+
+const (
+	segmentMode  = "mode"
+	segmentVault = "vault"
+	segmentDirty = "dirty"
+	segmentGit   = "git"
+	segmentSync  = "sync"
+	segmentClock = "clock"
+
+	barRefreshInterval = 30 * time.Second
+)
+
+var barSegments = []string{segmentMode, segmentVault, segmentDirty, segmentGit, segmentSync, segmentClock}
+
+// barInfo is what the bar knows about the vault beyond the model itself.
+type barInfo struct {
+	vault  string
+	branch string
+	ahead  int
+	behind int
+	synced time.Time
+	relay  bool
+}
+
+type barInfoMsg barInfo
+
+type barTickMsg struct{}
+
+func barTick() tea.Cmd {
+	return tea.Tick(barRefreshInterval, func(time.Time) tea.Msg { return barTickMsg{} })
+}
+
+func barInfoCmd(vault string, segments []string) tea.Cmd {
+	if vault == "" || !(hasSegment(segments, segmentGit) || hasSegment(segments, segmentSync)) {
+		return nil
+	}
+	return func() tea.Msg {
+		info := barInfo{vault: vault}
+		if hasSegment(segments, segmentGit) {
+			info.branch, info.ahead, info.behind = gitBranchStatus(vault)
+		}
+		if hasSegment(segments, segmentSync) {
+			if fi, err := os.Stat(syncStatePath(vault)); err == nil {
+				info.relay = true
+				info.synced = fi.ModTime()
+			}
+		}
+		return barInfoMsg(info)
+	}
+}
+
+// gitBranchStatus returns the branch checked out in dir and how many commits
+// it is ahead of and behind its upstream. The branch is empty outside a
+// repository.
+func gitBranchStatus(dir string) (string, int, int) {
+	out, err := runGit(dir, "status", "--porcelain=v2", "--branch", "--untracked-files=no")
+	if err != nil {
+		return "", 0, 0
+	}
+	branch, ahead, behind := "", 0, 0
+	for _, line := range strings.Split(string(out), "\n") {
+		switch {
+		case strings.HasPrefix(line, "# branch.head "):
+			branch = strings.TrimPrefix(line, "# branch.head ")
+		case strings.HasPrefix(line, "# branch.ab "):
+			fields := strings.Fields(strings.TrimPrefix(line, "# branch.ab "))
+			if len(fields) == 2 {
+				ahead, _ = strconv.Atoi(strings.TrimPrefix(fields[0], "+"))
+				behind, _ = strconv.Atoi(strings.TrimPrefix(fields[1], "-"))
+			}
+		}
+	}
+	return branch, ahead, behind
+}
+
+func hasSegment(segments []string, name string) bool {
+	for _, s := range segments {
+		if s == name {
+			return true
+		}
+	}
+	return false
+}
+
+// toggleSegment shows or hides a segment; a newly shown one goes last.
+func toggleSegment(segments []string, name string) []string {
+	out := make([]string, 0, len(segments)+1)
+	for _, s := range segments {
+		if s != name {
+			out = append(out, s)
+		}
+	}
+	if len(out) == len(segments) {
+		out = append(out, name)
+	}
+	return out
+}
+
+func segmentLabel(name string) string {
+	switch name {
+	case segmentMode:
+		return tr("Mode")
+	case segmentVault:
+		return tr("Vault name")
+	case segmentDirty:
+		return tr("Unsaved changes")
+	case segmentGit:
+		return tr("Git branch")
+	case segmentSync:
+		return tr("Last sync")
+	case segmentClock:
+		return tr("Clock")
+	}
+	return name
+}
+
+// modeLabel is the short name of what the current screen does.
+func (m Model) modeLabel() string {
+	switch m.state {
+	case stateVaultSelect, stateVaultCreate, stateVaultOpenPath, stateVaultScaffold, stateProfiles, stateProfileCreate:
+		return tr("VAULTS")
+	case stateEditor:
+		if m.isScratch(m.editing) {
+			return tr("SCRATCH")
+		}
+		if m.readOnly {
+			return tr("VIEW")
+		}
+		if m.find != nil {
+			return tr("FIND")
+		}
+		return tr("EDIT")
+	case stateSearch, stateSearchResults:
+		return tr("SEARCH")
+	case stateSettings:
+		return tr("SETTINGS")
+	case stateConfirmDelete, stateConfirmUnsaved, stateConfirmOverwrite, stateReviewSave, stateConfirmRemoveKey:
+		return tr("CONFIRM")
+	case stateTwoPane:
+		return tr("MOVE")
+	case stateKanban:
+		return tr("BOARD")
+	case stateMerge:
+		return tr("MERGE")
+	case stateAgenda:
+		return tr("AGENDA")
+	case stateTable, stateBinary, stateDiagram, stateGitLog, stateNoteURLs, stateKeywords, stateActivity, stateReminders, stateStale, stateLinkReport, stateJournal:
+		return tr("VIEW")
+	case stateKeys, stateKeyAdd:
+		return tr("KEYS")
+	case stateFileList:
+		if m.quick != nil {
+			return tr("FILTER")
+		}
+		return tr("FILES")
+	default:
+		return tr("INPUT")
+	}
+}
+
+// segmentText renders the text of one segment; empty hides it.
+func (m Model) segmentText(name string, now time.Time) string {
+	switch name {
+	case segmentMode:
+		return m.modeLabel()
+	case segmentVault:
+		if m.vault == "" {
+			return ""
+		}
+		return filepath.Base(m.vault)
+	case segmentDirty:
+		if m.dirty() {
+			return tr("● modified")
+		}
+	case segmentGit:
+		if m.bar.vault != m.vault || m.bar.branch == "" {
+			return ""
+		}
+		text := "⎇ " + m.bar.branch
+		if m.bar.ahead > 0 {
+			text += " ↑" + strconv.Itoa(m.bar.ahead)
+		}
+		if m.bar.behind > 0 {
+			text += " ↓" + strconv.Itoa(m.bar.behind)
+		}
+		return text
+	case segmentSync:
+		if m.bar.vault != m.vault || !m.bar.relay {
+			return ""
+		}
+		return tr("synced %s", sinceLabel(now.Sub(m.bar.synced)))
+	case segmentClock:
+		layout := m.dates.Time
+		if layout == "" {
+			layout = m.cfg.Dates.Time
+		}
+		return now.Format(layout)
+	}
+	return ""
+}
+
+func sinceLabel(d time.Duration) string {
+	switch {
+	case d < time.Minute:
+		return tr("just now")
+	case d < time.Hour:
+		return tr("%d min ago", int(d/time.Minute))
+	case d < 48*time.Hour:
+		return tr("%d h ago", int(d/time.Hour))
+	default:
+		return tr("%d days ago", int(d/(24*time.Hour)))
+	}
+}
+
+func segmentStyle(name string, colors map[string]string) lipgloss.Style {
+	if plainMode {
+		return lipgloss.NewStyle()
+	}
+	var style lipgloss.Style
+	switch name {
+	case segmentMode:
+		style = lipgloss.NewStyle().Bold(true).Foreground(colorPrimary)
+	case segmentDirty:
+		style = lipgloss.NewStyle().Bold(true).Foreground(colorWarning)
+	case segmentGit:
+		style = lipgloss.NewStyle().Foreground(colorSuccess)
+	default:
+		style = lipgloss.NewStyle().Foreground(colorMuted)
+	}
+	if c := colors[name]; c != "" {
+		style = style.Foreground(lipgloss.Color(c))
+	}
+	return style
+}
+
+// statusBarView draws the bar with the clock pushed to the right edge. It is
+// empty when no segment has anything to say.
+func (m Model) statusBarView(contentW int) string {
+	now := time.Now()
+	separator := "  "
+	if plainMode {
+		separator = " | "
+	}
+	var left []string
+	clock := ""
+	for _, name := range m.cfg.StatusBar.Segments {
+		text := m.segmentText(name, now)
+		if text == "" {
+			continue
+		}
+		rendered := segmentStyle(name, m.cfg.StatusBar.Colors).Render(text)
+		if name == segmentClock {
+			clock = rendered
+			continue
+		}
+		left = append(left, rendered)
+	}
+	line := strings.Join(left, separator)
+	if clock != "" {
+		gap := contentW - lipgloss.Width(line) - lipgloss.Width(clock)
+		if gap < runewidth.StringWidth(separator) {
+			gap = runewidth.StringWidth(separator)
+		}
+		if line == "" {
+			gap = maxInt(0, contentW-lipgloss.Width(clock))
+		}
+		line += strings.Repeat(" ", gap) + clock
+	}
+	if strings.TrimSpace(line) == "" {
+		return ""
+	}
+	return lipgloss.NewStyle().MaxWidth(contentW).Render(line)
+}
@@ -0,0 +1,453 @@
+package main
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// gono sync exchanges changes with other devices through a relay (see
+// relay.go). Every push is one delta: the files that changed since the last
+// sync, sealed with AES-256-GCM under a key derived from the sync password
+// and the group name. Devices in the same group use the same password and
+// group; the relay only stores the sealed deltas.
+//
+// A delta change carries the new content (or a deletion) and the hash of
+// the version the sender started from. A change is applied when the local
+// file is still the version last synced; when both sides edited the file,
+// the incoming version is saved next to it as a conflict copy.
+
+const (
+	syncFileName    = "sync.json"
+	syncMaxDelta    = 16 << 20
+	syncHTTPTimeout = 2 * time.Minute
+)
+
+type syncState struct {
+	Relay  string            `json:"relay"`
+	Group  string            `json:"group"`
+	Device string            `json:"device"`
+	Seq    int64             `json:"seq"`
+	Base   map[string]string `json:"base"`
+}
+
+type syncDelta struct {
+	Device  string       `json:"device"`
+	Changes []syncChange `json:"changes"`
+}
+
+// syncChange is one file of a delta. An empty Hash means the file was
+// deleted.
+type syncChange struct {
+	Path    string `json:"path"`
+	Hash    string `json:"hash"`
+	Base    string `json:"base"`
+	Content []byte `json:"content,omitempty"`
+}
+
+type syncResult struct {
+	pulled    int
+	pushed    int
+	conflicts []string
+	// tooLarge lists files that do not fit into a delta the relay accepts.
+	tooLarge []string
+}
+
+func syncCommand(args []string, stdout io.Writer) error {
+	flags := flag.NewFlagSet("sync", flag.ContinueOnError)
+	flags.SetOutput(io.Discard)
+	relay := flags.String("relay", "", "")
+	group := flags.String("group", "", "")
+	usage := errors.New("usage: gono sync [-relay URL] [-group NAME] VAULT")
+	if err := flags.Parse(args); err != nil || flags.NArg() != 1 {
+		return usage
+	}
+	vault, err := filepath.Abs(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+	if info, err := os.Stat(vault); err != nil || !info.IsDir() {
+		return fmt.Errorf("vault not found: %s", vault)
+	}
+	state, err := loadSyncState(vault)
+	if err != nil {
+		return err
+	}
+	if *relay != "" {
+		state.Relay = strings.TrimSuffix(*relay, "/")
+	}
+	if *group != "" {
+		state.Group = *group
+	}
+	if state.Relay == "" {
+		return errors.New("no relay configured: pass -relay URL once")
+	}
+	if state.Group == "" {
+		state.Group = filepath.Base(vault)
+	}
+
+	password, err := readPassword(state.Seq == 0 && len(state.Base) == 0)
+	if err != nil {
+		return err
+	}
+	key, err := syncKey(password, state.Group)
+	if err != nil {
+		return err
+	}
+	result, err := runSync(vault, &state, key)
+	if saveErr := saveSyncState(vault, state); err == nil {
+		err = saveErr
+	}
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(stdout, "Pulled %s, pushed %s\n", pluralize(result.pulled, "change", "changes"), pluralize(result.pushed, "change", "changes"))
+	for _, c := range result.conflicts {
+		fmt.Fprintln(stdout, "Conflict, other version saved as:", c)
+	}
+	if len(result.conflicts) > 0 {
+		fmt.Fprintln(stdout, "Open the note in GoNo to merge the two versions.")
+	}
+	for _, p := range result.tooLarge {
+		fmt.Fprintln(stdout, "Too large to sync, not pushed:", p)
+	}
+	return nil
+}
+
+func syncStatePath(vault string) string {
+	return filepath.Join(appDir(vault), syncFileName)
+}
+
+func loadSyncState(vault string) (syncState, error) {
+	state := syncState{Base: make(map[string]string)}
+	data, err := os.ReadFile(syncStatePath(vault))
+	if os.IsNotExist(err) {
+		id := make([]byte, 8)
+		if _, err := rand.Read(id); err != nil {
+			return state, err
+		}
+		state.Device = hex.EncodeToString(id)
+		return state, nil
+	}
+	if err != nil {
+		return state, err
+	}
+	if err := json.Unmarshal(data, &state); err != nil {
+		return state, fmt.Errorf("%s: %w", syncStatePath(vault), err)
+	}
+	if state.Base == nil {
+		state.Base = make(map[string]string)
+	}
+	return state, nil
+}
+
+func saveSyncState(vault string, state syncState) error {
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(appDir(vault), 0755); err != nil {
+		return err
+	}
+	return writeFileAtomic(syncStatePath(vault), data, 0600)
+}
+
+// syncKey derives the group key. The salt is the group name, so every
+// device of the group derives the same key without exchanging anything.
+func syncKey(password []byte, group string) ([]byte, error) {
+	return pbkdf2.Key(sha256.New, string(password), []byte("gono-sync:"+group), bundleIterations, 32)
+}
+
+// syncChannel names the relay channel after the key without revealing it.
+func syncChannel(key []byte) string {
+	sum := sha256.Sum256(append([]byte("gono-sync-channel:"), key...))
+	return hex.EncodeToString(sum[:16])
+}
+
+func sealDelta(key []byte, delta syncDelta) ([]byte, error) {
+	plain, err := json.Marshal(delta)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := syncCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return gcm.Seal(nonce, nonce, plain, nil), nil
+}
+
+func openDelta(key []byte, sealed []byte) (syncDelta, error) {
+	var delta syncDelta
+	gcm, err := syncCipher(key)
+	if err != nil {
+		return delta, err
+	}
+	if len(sealed) < gcm.NonceSize() {
+		return delta, errors.New("delta is truncated")
+	}
+	plain, err := gcm.Open(nil, sealed[:gcm.NonceSize()], sealed[gcm.NonceSize():], nil)
+	if err != nil {
+		return delta, errors.New("cannot decrypt a delta: wrong password or group")
+	}
+	return delta, json.Unmarshal(plain, &delta)
+}
+
+func syncCipher(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+func contentHash(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// runSync pulls the deltas of other devices, then pushes local changes.
+// state is updated as far as the sync got, also when it fails.
+func runSync(vault string, state *syncState, key []byte) (syncResult, error) {
+	var result syncResult
+	client := &http.Client{Timeout: syncHTTPTimeout}
+	endpoint := state.Relay + "/v1/" + syncChannel(key)
+
+	for {
+		blobs, err := fetchBlobs(client, endpoint, state.Seq)
+		if err != nil {
+			return result, err
+		}
+		if len(blobs) == 0 {
+			break
+		}
+		for _, blob := range blobs {
+			delta, err := openDelta(key, blob.Data)
+			if err != nil {
+				return result, err
+			}
+			if delta.Device != state.Device {
+				for _, c := range delta.Changes {
+					applied, conflict, err := applyChange(vault, state, delta.Device, c)
+					if err != nil {
+						return result, fmt.Errorf("%s: %w", c.Path, err)
+					}
+					if applied {
+						result.pulled++
+					}
+					if conflict != "" {
+						result.conflicts = append(result.conflicts, conflict)
+					}
+				}
+			}
+			state.Seq = blob.Seq
+		}
+	}
+
+	changes, err := localChanges(vault, state.Base)
+	if err != nil {
+		return result, err
+	}
+	for len(changes) > 0 {
+		delta := syncDelta{Device: state.Device}
+		size := 0
+		for len(changes) > 0 && (len(delta.Changes) == 0 || size+len(changes[0].Content) <= syncMaxDelta) {
+			size += len(changes[0].Content)
+			delta.Changes = append(delta.Changes, changes[0])
+			changes = changes[1:]
+		}
+		sealed, err := sealDelta(key, delta)
+		if err != nil {
+			return result, err
+		}
+		// Encoding and encryption make the delta larger than its contents;
+		// what no longer fits waits for the next delta.
+		for len(sealed) > relayMaxBlob && len(delta.Changes) > 1 {
+			last := len(delta.Changes) - 1
+			changes = append([]syncChange{delta.Changes[last]}, changes...)
+			delta.Changes = delta.Changes[:last]
+			if sealed, err = sealDelta(key, delta); err != nil {
+				return result, err
+			}
+		}
+		if len(sealed) > relayMaxBlob {
+			result.tooLarge = append(result.tooLarge, delta.Changes[0].Path)
+			continue
+		}
+		if err := postBlob(client, endpoint, sealed); err != nil {
+			return result, err
+		}
+		for _, c := range delta.Changes {
+			if c.Hash == "" {
+				delete(state.Base, c.Path)
+			} else {
+				state.Base[c.Path] = c.Hash
+			}
+			result.pushed++
+		}
+	}
+	return result, nil
+}
+
+func fetchBlobs(client *http.Client, endpoint string, after int64) ([]relayBlob, error) {
+	resp, err := client.Get(endpoint + "?after=" + url.QueryEscape(fmt.Sprint(after)))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("relay: %s", resp.Status)
+	}
+	var blobs []relayBlob
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 2*relayMaxReply)).Decode(&blobs); err != nil {
+		return nil, fmt.Errorf("relay: %w", err)
+	}
+	return blobs, nil
+}
+
+func postBlob(client *http.Client, endpoint string, data []byte) error {
+	resp, err := client.Post(endpoint, "application/octet-stream", bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("relay: %s", resp.Status)
+	}
+	return nil
+}
+
+// syncTarget maps a delta path to a file in the vault, rejecting paths that
+// would leave it or touch GoNo's own data.
+func syncTarget(vault string, rel string) (string, error) {
+	clean := path.Clean(rel)
+	if rel == "" || path.IsAbs(clean) || clean == ".." || strings.HasPrefix(clean, "../") || strings.HasPrefix(clean, ".") || strings.Contains(clean, "/.") {
+		return "", errors.New("unsafe path in delta")
+	}
+	return filepath.Join(vault, filepath.FromSlash(clean)), nil
+}
+
+func localHash(p string) (string, error) {
+	data, err := os.ReadFile(p)
+	if os.IsNotExist(err) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	return contentHash(data), nil
+}
+
+// applyChange brings one incoming change into the vault. It reports whether
+// the local file changed and the name of the conflict copy, if one was made.
+func applyChange(vault string, state *syncState, device string, c syncChange) (bool, string, error) {
+	target, err := syncTarget(vault, c.Path)
+	if err != nil {
+		return false, "", err
+	}
+	local, err := localHash(target)
+	if err != nil {
+		return false, "", err
+	}
+	known := state.Base[c.Path]
+	setBase := func() {
+		if c.Hash == "" {
+			delete(state.Base, c.Path)
+		} else {
+			state.Base[c.Path] = c.Hash
+		}
+	}
+	switch {
+	case local == c.Hash:
+		setBase()
+		return false, "", nil
+	case local == known:
+		if c.Hash == "" {
+			if err := os.Remove(target); err != nil && !os.IsNotExist(err) {
+				return false, "", err
+			}
+		} else {
+			if contentHash(c.Content) != c.Hash {
+				return false, "", errors.New("content does not match its hash")
+			}
+			if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+				return false, "", err
+			}
+			if err := writeFileAtomic(target, c.Content, 0644); err != nil {
+				return false, "", err
+			}
+		}
+		setBase()
+		return true, "", nil
+	default:
+		// Both sides changed the file. The local version stays and is pushed
+		// as the next change; the incoming one is kept as a copy.
+		setBase()
+		if c.Hash == "" {
+			return false, "", nil
+		}
+		ext := filepath.Ext(target)
+		label := strings.Map(func(r rune) rune {
+			if r < '0' || r > 'z' || (r > '9' && r < 'a') {
+				return -1
+			}
+			return r
+		}, device)
+		copyPath := uniquePath(strings.TrimSuffix(target, ext) + ".conflict-" + label[:minInt(6, len(label))] + ext)
+		if err := writeFileAtomic(copyPath, c.Content, 0644); err != nil {
+			return false, "", err
+		}
+		return true, relOrBase(vault, copyPath), nil
+	}
+}
+
+// localChanges lists the files that differ from the last synced versions,
+// and the synced files that were deleted since.
+func localChanges(vault string, base map[string]string) ([]syncChange, error) {
+	var changes []syncChange
+	seen := make(map[string]bool)
+	err := walkVault(vault, func(p string, d fs.DirEntry) error {
+		rel := filepath.ToSlash(relOrBase(vault, p))
+		if strings.HasPrefix(d.Name(), ".") {
+			return nil
+		}
+		data, err := os.ReadFile(p)
+		if err != nil {
+			return err
+		}
+		seen[rel] = true
+		if hash := contentHash(data); hash != base[rel] {
+			changes = append(changes, syncChange{Path: rel, Hash: hash, Base: base[rel], Content: data})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	for rel, hash := range base {
+		if !seen[rel] {
+			changes = append(changes, syncChange{Path: rel, Base: hash})
+		}
+	}
+	return changes, nil
+}
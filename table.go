@@ -0,0 +1,214 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textarea"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/mattn/go-runewidth"
+)
+
+// .csv and .tsv files open in a table viewer: columns are aligned, the first
+// row stays on top as the header, and the cursor moves by cell. E opens the
+// raw text in the editor instead.
+
+const maxTableColumnWidth = 32
+
+type tableView struct {
+	path   string
+	rows   [][]string
+	widths []int
+	row    int
+	col    int
+	top    int
+	left   int
+}
+
+func isTableFile(path string) bool {
+	ext := strings.ToLower(filepath.Ext(path))
+	return ext == ".csv" || ext == ".tsv"
+}
+
+func parseTable(path string, content []byte) ([][]string, error) {
+	r := csv.NewReader(bytes.NewReader(bytes.TrimPrefix(content, []byte("\ufeff"))))
+	r.FieldsPerRecord = -1
+	r.LazyQuotes = true
+	if strings.EqualFold(filepath.Ext(path), ".tsv") {
+		r.Comma = '\t'
+	}
+	return r.ReadAll()
+}
+
+func (m Model) openTable(path string) (tea.Model, tea.Cmd) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		m.status = errorStatus(err)
+		return m, nil
+	}
+	rows, err := parseTable(path, content)
+	if err != nil {
+		m.status = errorStatus(err)
+		return m, nil
+	}
+	t := &tableView{path: path, rows: rows, row: maxInt(0, minInt(1, len(rows)-1))}
+	for _, row := range rows {
+		for i, cell := range row {
+			if i == len(t.widths) {
+				t.widths = append(t.widths, 1)
+			}
+			t.widths[i] = minInt(maxTableColumnWidth, maxInt(t.widths[i], runewidth.StringWidth(cell)))
+		}
+	}
+	m.table = t
+	m.state = stateTable
+	m.status = statusLine{}
+	if len(rows) == 0 {
+		m.status = infoStatus("The table is empty")
+	}
+	return m, nil
+}
+
+// cell returns the text at row, col; short rows read as empty cells.
+func (t *tableView) cell(row int, col int) string {
+	if row < 0 || row >= len(t.rows) || col < 0 || col >= len(t.rows[row]) {
+		return ""
+	}
+	return t.rows[row][col]
+}
+
+func (m Model) handleTableKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	t := m.table
+	page := maxInt(1, m.list.Height()-1)
+	last := maxInt(1, len(t.rows)-1)
+	m.status = statusLine{}
+	switch msg.String() {
+	case "ctrl+c":
+		return m, tea.Quit
+	case "esc", "q":
+		m.table = nil
+		m.state = stateFileList
+		m = m.refreshFileList()
+		return m, nil
+	case "e":
+		content, err := os.ReadFile(t.path)
+		if err != nil {
+			m.status = errorStatus(err)
+			return m, nil
+		}
+		m.table = nil
+		m.textarea.Focus()
+		m = m.setBuffer(t.path, string(content), false)
+		m.state = stateEditor
+		return m, textarea.Blink
+	case "up", "k":
+		t.row = maxInt(1, t.row-1)
+	case "down", "j":
+		t.row = minInt(last, t.row+1)
+	case "pgup":
+		t.row = maxInt(1, t.row-page)
+	case "pgdown", " ":
+		t.row = minInt(last, t.row+page)
+	case "home", "g":
+		t.row = 1
+	case "end", "G":
+		t.row = last
+	case "left", "h", "shift+tab":
+		t.col = maxInt(0, t.col-1)
+	case "right", "l", "tab":
+		t.col = minInt(len(t.widths)-1, t.col+1)
+	case "0":
+		t.col = 0
+	case "$":
+		t.col = len(t.widths) - 1
+	case "enter":
+		if value := t.cell(t.row, t.col); value != "" {
+			m.status = infoStatus("%s: %s", t.cell(0, t.col), value)
+		}
+	}
+	return m, nil
+}
+
+// tableView draws the header and the rows that fit, scrolled so the cursor
+// cell is visible.
+func (m Model) tableView(contentW int, height int) string {
+	t := m.table
+	if len(t.rows) == 0 {
+		return ""
+	}
+	t.row = maxInt(1, minInt(t.row, len(t.rows)-1))
+	if len(t.rows) == 1 {
+		t.row = 0
+	}
+	rows := maxInt(1, height-1)
+	if t.row < t.top+1 {
+		t.top = t.row - 1
+	}
+	if t.row >= t.top+1+rows {
+		t.top = t.row - rows
+	}
+	t.top = maxInt(0, t.top)
+
+	if t.col < t.left {
+		t.left = t.col
+	}
+	for t.left < t.col && t.columnsWidth(t.left, t.col) > contentW {
+		t.left++
+	}
+	last := t.left
+	for last+1 < len(t.widths) && t.columnsWidth(t.left, last+1) <= contentW {
+		last++
+	}
+
+	sep := hintStyle.Render(" │ ")
+	line := func(r int, header bool) string {
+		cells := make([]string, 0, last-t.left+1)
+		for c := t.left; c <= last; c++ {
+			text := runewidth.FillRight(runewidth.Truncate(strings.ReplaceAll(t.cell(r, c), "\n", " "), t.widths[c], "…"), t.widths[c])
+			style := lipgloss.NewStyle()
+			switch {
+			case header:
+				style = style.Bold(true).Underline(c == t.col)
+			case r == t.row && c == t.col:
+				style = style.Reverse(true)
+			case r == t.row:
+				style = style.Underline(true)
+			}
+			cells = append(cells, style.Render(text))
+		}
+		return strings.Join(cells, sep)
+	}
+
+	lines := []string{line(0, true)}
+	for r := t.top + 1; r < len(t.rows) && r < t.top+1+rows; r++ {
+		lines = append(lines, line(r, false))
+	}
+	return strings.Join(lines, "\n")
+}
+
+func (t *tableView) columnsWidth(from int, to int) int {
+	width := 0
+	for c := from; c <= to; c++ {
+		width += t.widths[c]
+	}
+	return width + 3*(to-from)
+}
+
+func (m Model) tableSubtitle() string {
+	t := m.table
+	if len(t.rows) == 0 {
+		return ""
+	}
+	return tr("Row %d of %d | Column %d of %d: %s", t.row, len(t.rows)-1, t.col+1, len(t.widths), t.cell(0, t.col))
+}
+
+func tableHints(width int) string {
+	if width < 72 {
+		return tr("Arrows move | Enter show cell\nE edit as text | Esc back")
+	}
+	return tr("Up/Down/PgUp/PgDn: rows | Left/Right/Tab: columns | Enter: show full cell\nE: edit as text | Esc: back")
+}
@@ -0,0 +1,173 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// Alt+T in the file list adds or removes tags of the selected note without
+// opening it: each tag entered is added to the note's "tags:" frontmatter,
+// or removed when the note already has it. Tab completes the tags used in
+// the vault, which are collected in the background when the prompt opens.
+
+type vaultTagsMsg struct {
+	vault string
+	tags  []string
+}
+
+func (m Model) showTagToggle() (tea.Model, tea.Cmd) {
+	selected, ok := m.list.SelectedItem().(item)
+	if !ok || selected.mode != "" || selected.isDir || !isIndexedNote(selected.path) {
+		m.status = infoStatus("Select a note to tag")
+		return m, nil
+	}
+	content, err := os.ReadFile(selected.path)
+	if err != nil {
+		m.status = errorStatus(err)
+		return m, nil
+	}
+	text, _, _ := decodeText(content)
+	m = m.enterPrompt(stateTagToggle, tr("Tag to add or remove"))
+	m.tagNote = selected.path
+	m.tagged = sortedTags(noteTags(text))
+	m.input.ShowSuggestions = true
+	vault := m.vault
+	return m, tea.Batch(textinput.Blink, func() tea.Msg {
+		all, _ := collectVaultTags(vault)
+		tags := make([]string, 0, len(all))
+		for tag := range all {
+			tags = append(tags, tag)
+		}
+		sort.Strings(tags)
+		return vaultTagsMsg{vault: vault, tags: tags}
+	})
+}
+
+func (m Model) applyVaultTags(msg vaultTagsMsg) (tea.Model, tea.Cmd) {
+	if msg.vault == m.vault && m.state == stateTagToggle {
+		m.input.SetSuggestions(msg.tags)
+	}
+	return m, nil
+}
+
+func sortedTags(tags map[string]struct{}) []string {
+	out := make([]string, 0, len(tags))
+	for tag := range tags {
+		out = append(out, tag)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// toggleTag adds tag to the frontmatter tags of content, or removes it when
+// it is there already, and reports whether it was added.
+func toggleTag(content string, tag string) (string, bool, error) {
+	fields, _ := parseFrontmatter(content)
+	key := "tags"
+	if _, ok := fields["tags"]; !ok {
+		if _, ok := fields["tag"]; ok {
+			key = "tag"
+		}
+	}
+	var kept []string
+	removed := false
+	for _, t := range frontmatterList(fields[key]) {
+		if strings.EqualFold(strings.TrimPrefix(t, "#"), tag) {
+			removed = true
+			continue
+		}
+		kept = append(kept, t)
+	}
+	if !removed {
+		if _, inline := noteTags(content)[strings.ToLower(tag)]; inline {
+			return "", false, fmt.Errorf("#%s is written in the text; remove it in the editor", tag)
+		}
+		kept = append(kept, tag)
+	}
+	return setFrontmatterField(content, key, "["+strings.Join(kept, ", ")+"]"), !removed, nil
+}
+
+// toggleNoteTag adds or removes the tag typed in the prompt and stays in
+// the prompt for the next one.
+func (m Model) toggleNoteTag(value string) (tea.Model, tea.Cmd) {
+	tag := strings.TrimPrefix(strings.TrimSpace(value), "#")
+	if tag == "" {
+		m.status = infoStatus("Tag cannot be empty")
+		return m, nil
+	}
+	if strings.ContainsAny(tag, " ,[]#") {
+		m.status = failStatus("a tag is one word: %s", tag)
+		return m, nil
+	}
+	path := m.tagNote
+	var added bool
+	m, updated, err := m.rewriteNote(path, func(text string) (string, error) {
+		var err error
+		text, added, err = toggleTag(text, tag)
+		return text, err
+	})
+	if err != nil {
+		m.status = errorStatus(err)
+		return m, nil
+	}
+	m.tagged = sortedTags(noteTags(updated))
+	m.input.SetValue("")
+	if added {
+		m.status = okStatus("Tagged %s with #%s", relOrBase(m.vault, path), tag)
+	} else {
+		m.status = okStatus("Removed #%s from %s", tag, relOrBase(m.vault, path))
+	}
+	return m, nil
+}
+
+// rewriteNote applies change to the text of the note at path, in place and
+// in its encoding, and reloads the editor when the note is open there. A
+// note with unsaved changes in the editor is left alone.
+func (m Model) rewriteNote(path string, change func(string) (string, error)) (Model, string, error) {
+	if m.editing == path && m.dirty() {
+		return m, "", fmt.Errorf("%s has unsaved changes", relOrBase(m.vault, path))
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return m, "", err
+	}
+	text, encoding, ok := decodeText(content)
+	if !ok {
+		return m, "", fmt.Errorf("%s is not a text file", filepath.Base(path))
+	}
+	updated, err := change(text)
+	if err != nil {
+		return m, "", err
+	}
+	data, err := encodeText(updated, encoding)
+	if err != nil {
+		return m, "", err
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return m, "", err
+	}
+	if err := writeFileAtomic(path, data, info.Mode().Perm()); err != nil {
+		return m, "", err
+	}
+	if m.editing == path {
+		m = m.setBuffer(path, expandTabs(updated, m.cfg.Editor.TabWidth), m.readOnly)
+		m.encoding = encoding
+	}
+	m = m.logChange(journalSave, path, false)
+	m = m.reindex(path)
+	return m, updated, nil
+}
+
+func (m Model) taggedLine() string {
+	if len(m.tagged) == 0 {
+		return tr("No tags yet")
+	}
+	return tr("Tagged: %s", "#"+strings.Join(m.tagged, " #"))
+}
@@ -0,0 +1,324 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/mattn/go-runewidth"
+)
+
+// "gono import-templates SOURCE VAULT" brings in templates and themes
+// shared by others. SOURCE is the URL of one .md template or .json theme,
+// a git repository (cloned with git), or a local file or folder. From a
+// repository or folder, the .md files in templates/ (or at the top, READMEs
+// and the like aside) become templates of the vault and the .json files in
+// themes/ become themes. Nothing is written before the files were listed,
+// with the first lines and prompts of each template, and the import was
+// confirmed; -yes skips the question. Files that exist are kept unless
+// -overwrite is given. Symlinks and files over 1 MB are skipped.
+
+const (
+	sharedMaxBytes    = 1 << 20
+	sharedHTTPTimeout = 30 * time.Second
+)
+
+const (
+	sharedTemplate = "template"
+	sharedTheme    = "theme"
+)
+
+type sharedFile struct {
+	kind   string
+	name   string
+	data   []byte
+	dest   string
+	exists bool
+	note   string
+}
+
+var sharedSkipNames = map[string]bool{
+	"readme.md":          true,
+	"license.md":         true,
+	"changelog.md":       true,
+	"contributing.md":    true,
+	"code_of_conduct.md": true,
+}
+
+func importTemplatesCommand(args []string, stdin io.Reader, stdout io.Writer) error {
+	flags := flag.NewFlagSet("import-templates", flag.ContinueOnError)
+	flags.SetOutput(io.Discard)
+	yes := flags.Bool("yes", false, "")
+	overwrite := flags.Bool("overwrite", false, "")
+	if err := flags.Parse(args); err != nil || flags.NArg() != 2 {
+		return errors.New("usage: gono import-templates [-yes] [-overwrite] URL|REPO|PATH VAULT")
+	}
+	source := flags.Arg(0)
+	vault, err := filepath.Abs(flags.Arg(1))
+	if err != nil {
+		return err
+	}
+	if info, err := os.Stat(vault); err != nil || !info.IsDir() {
+		return fmt.Errorf("vault not found: %s", vault)
+	}
+	files, err := fetchSharedFiles(source)
+	if err != nil {
+		return err
+	}
+	files = checkSharedFiles(files, vault)
+	writable := 0
+	for _, f := range files {
+		if f.note == "" && (!f.exists || *overwrite) {
+			writable++
+		}
+	}
+	printSharedPreview(stdout, source, vault, files, *overwrite)
+	if writable == 0 {
+		return errors.New("nothing to import")
+	}
+	if !*yes {
+		fmt.Fprintf(stdout, "Import %s into %s? [y/N] ", pluralize(writable, "file", "files"), vault)
+		answer, _ := bufio.NewReader(stdin).ReadString('\n')
+		if a := strings.ToLower(strings.TrimSpace(answer)); a != "y" && a != "yes" {
+			fmt.Fprintln(stdout, "Nothing imported.")
+			return nil
+		}
+	}
+	templates, themes := 0, 0
+	for _, f := range files {
+		if f.note != "" || (f.exists && !*overwrite) {
+			continue
+		}
+		if err := os.MkdirAll(filepath.Dir(f.dest), 0755); err != nil {
+			return err
+		}
+		if err := writeFileAtomic(f.dest, f.data, 0644); err != nil {
+			return err
+		}
+		if f.kind == sharedTheme {
+			themes++
+		} else {
+			templates++
+		}
+	}
+	fmt.Fprintf(stdout, "Imported %s and %s.\n", pluralize(templates, "template", "templates"), pluralize(themes, "theme", "themes"))
+	if themes > 0 {
+		fmt.Fprintln(stdout, "Pick a theme under Settings (F2) > Theme.")
+	}
+	return nil
+}
+
+// fetchSharedFiles collects the templates and themes of source.
+func fetchSharedFiles(source string) ([]sharedFile, error) {
+	if info, err := os.Stat(source); err == nil {
+		if info.IsDir() {
+			return collectSharedDir(source)
+		}
+		f, err := readSharedFile(source, filepath.Base(source))
+		if err != nil {
+			return nil, err
+		}
+		return []sharedFile{f}, nil
+	}
+	if isGitSource(source) {
+		return cloneSharedRepo(source)
+	}
+	if u, err := url.Parse(source); err == nil && (u.Scheme == "http" || u.Scheme == "https") {
+		return downloadSharedFile(u)
+	}
+	return nil, fmt.Errorf("not a file, folder, URL or git repository: %s", source)
+}
+
+// isGitSource tells repository URLs from links to single files.
+func isGitSource(source string) bool {
+	if strings.HasPrefix(source, "git@") || strings.HasPrefix(source, "git://") || strings.HasPrefix(source, "ssh://") {
+		return true
+	}
+	u, err := url.Parse(source)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+		return false
+	}
+	ext := strings.ToLower(path.Ext(u.Path))
+	return ext == ".git" || (ext != ".md" && ext != ".json")
+}
+
+func sharedKind(name string) string {
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".md":
+		return sharedTemplate
+	case ".json":
+		return sharedTheme
+	}
+	return ""
+}
+
+func readSharedFile(p string, name string) (sharedFile, error) {
+	info, err := os.Lstat(p)
+	if err != nil {
+		return sharedFile{}, err
+	}
+	if !info.Mode().IsRegular() {
+		return sharedFile{}, fmt.Errorf("%s is not a regular file", name)
+	}
+	if info.Size() > sharedMaxBytes {
+		return sharedFile{}, fmt.Errorf("%s is larger than %s", name, formatSize(sharedMaxBytes))
+	}
+	data, err := os.ReadFile(p)
+	if err != nil {
+		return sharedFile{}, err
+	}
+	kind := sharedKind(name)
+	if kind == "" {
+		return sharedFile{}, fmt.Errorf("%s is neither a template (.md) nor a theme (.json)", name)
+	}
+	return sharedFile{kind: kind, name: name, data: data}, nil
+}
+
+// collectSharedDir reads the templates and themes of a folder.
+func collectSharedDir(dir string) ([]sharedFile, error) {
+	templates := filepath.Join(dir, templatesDirName)
+	if info, err := os.Stat(templates); err != nil || !info.IsDir() {
+		templates = dir
+	}
+	var files []sharedFile
+	for _, from := range []struct{ dir, kind string }{{templates, sharedTemplate}, {filepath.Join(dir, "themes"), sharedTheme}} {
+		entries, err := os.ReadDir(from.dir)
+		if err != nil {
+			continue
+		}
+		for _, e := range entries {
+			if !e.Type().IsRegular() || sharedKind(e.Name()) != from.kind || sharedSkipNames[strings.ToLower(e.Name())] {
+				continue
+			}
+			f, err := readSharedFile(filepath.Join(from.dir, e.Name()), e.Name())
+			if err != nil {
+				f = sharedFile{kind: from.kind, name: e.Name(), note: err.Error()}
+			}
+			files = append(files, f)
+		}
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no templates (.md) or themes (themes/*.json) in %s", dir)
+	}
+	return files, nil
+}
+
+func cloneSharedRepo(source string) ([]sharedFile, error) {
+	tmp, err := os.MkdirTemp("", "gono-templates-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(tmp)
+	if _, err := runGit(tmp, "clone", "--quiet", "--depth", "1", "--", source, "repo"); err != nil {
+		return nil, fmt.Errorf("git clone %s: %w", source, err)
+	}
+	return collectSharedDir(filepath.Join(tmp, "repo"))
+}
+
+func downloadSharedFile(u *url.URL) ([]sharedFile, error) {
+	client := &http.Client{Timeout: sharedHTTPTimeout}
+	resp, err := client.Get(u.String())
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s: %s", u, resp.Status)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, sharedMaxBytes+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > sharedMaxBytes {
+		return nil, fmt.Errorf("%s is larger than %s", u, formatSize(sharedMaxBytes))
+	}
+	name := path.Base(u.Path)
+	if unescaped, err := url.PathUnescape(name); err == nil {
+		name = unescaped
+	}
+	return []sharedFile{{kind: sharedKind(name), name: name, data: data}}, nil
+}
+
+// checkSharedFiles validates the files and sets where each goes. Files that
+// cannot be imported get a note saying why.
+func checkSharedFiles(files []sharedFile, vault string) []sharedFile {
+	for i := range files {
+		f := &files[i]
+		if f.note != "" {
+			continue
+		}
+		base := strings.TrimSuffix(f.name, filepath.Ext(f.name))
+		switch {
+		case f.name != filepath.Base(f.name) || strings.HasPrefix(f.name, "."):
+			f.note = "invalid file name"
+		case !utf8.Valid(f.data):
+			f.note = "not UTF-8 text"
+		case f.kind == sharedTheme && (!themeNameRe.MatchString(base) || isBuiltinTheme(base)):
+			f.note = "theme names are letters, digits, - and _, other than the built-in ones"
+		case f.kind == sharedTheme:
+			if _, _, err := parseThemeFile(f.data); err != nil {
+				f.note = "invalid theme: " + err.Error()
+			}
+			f.dest = filepath.Join(themesDir(), base+".json")
+		default:
+			f.dest = filepath.Join(templatesDir(vault), f.name)
+		}
+		if f.dest != "" {
+			_, err := os.Stat(f.dest)
+			f.exists = err == nil
+		}
+	}
+	return files
+}
+
+func printSharedPreview(w io.Writer, source string, vault string, files []sharedFile, overwrite bool) {
+	fmt.Fprintf(w, "From %s:\n", source)
+	for _, f := range files {
+		state := ""
+		switch {
+		case f.note != "":
+			state = "skipped: " + f.note
+		case f.exists && overwrite:
+			state = "replaces " + relOrBase(vault, f.dest)
+		case f.exists:
+			state = "exists, kept (-overwrite replaces it)"
+		case f.kind == sharedTheme:
+			state = "new theme in " + themesDir()
+		default:
+			state = "new in " + relOrBase(vault, f.dest)
+		}
+		fmt.Fprintf(w, "  %-8s  %-28s  %s\n", f.kind, f.name, state)
+		if f.note != "" {
+			continue
+		}
+		if f.kind == sharedTheme {
+			if _, n, err := parseThemeFile(f.data); err == nil {
+				fmt.Fprintf(w, "            sets %s\n", pluralize(n, "color", "colors"))
+			}
+			continue
+		}
+		if prompts := templatePrompts(string(f.data)); len(prompts) > 0 {
+			fmt.Fprintf(w, "            asks for %s\n", strings.Join(prompts, ", "))
+		}
+		shown := 0
+		for _, line := range strings.Split(normalizeLineEndings(string(f.data)), "\n") {
+			if strings.TrimSpace(line) == "" {
+				continue
+			}
+			fmt.Fprintf(w, "            | %s\n", runewidth.Truncate(strings.ReplaceAll(line, "\t", "    "), 64, "…"))
+			if shown++; shown == 3 {
+				break
+			}
+		}
+	}
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/list"
+)
+
+const templatesDirName = "templates"
+
+var templatePromptRe = regexp.MustCompile(`\{\{\s*prompt:([^}]+?)\s*\}\}`)
+
+type templateSession struct {
+	content string
+	target  string
+	title   string
+	prompts []string
+	answers map[string]string
+	index   int
+}
+
+func templatesDir(vault string) string {
+	return filepath.Join(vault, templatesDirName)
+}
+
+func listTemplates(vault string) []list.Item {
+	files, err := os.ReadDir(templatesDir(vault))
+	if err != nil {
+		return nil
+	}
+	items := make([]list.Item, 0, len(files))
+	for _, file := range files {
+		if file.IsDir() || !strings.EqualFold(filepath.Ext(file.Name()), ".md") {
+			continue
+		}
+		p := filepath.Join(templatesDir(vault), file.Name())
+		desc := tr("Template")
+		if content, readErr := os.ReadFile(p); readErr == nil {
+			if n := len(templatePrompts(string(content))); n > 0 {
+				desc = trn("Template, asks for %d value", "Template, asks for %d values", n)
+			}
+		}
+		items = append(items, item{
+			title: strings.TrimSuffix(file.Name(), filepath.Ext(file.Name())),
+			desc:  desc,
+			path:  p,
+			mode:  "template",
+		})
+	}
+	sort.Slice(items, func(i, j int) bool {
+		return strings.ToLower(items[i].(item).title) < strings.ToLower(items[j].(item).title)
+	})
+	return items
+}
+
+// templatePrompts returns the distinct {{prompt:Label}} labels in the order
+// they first appear in the template.
+func templatePrompts(content string) []string {
+	seen := make(map[string]struct{})
+	var prompts []string
+	for _, match := range templatePromptRe.FindAllStringSubmatch(content, -1) {
+		label := strings.TrimSpace(match[1])
+		if _, ok := seen[label]; ok {
+			continue
+		}
+		seen[label] = struct{}{}
+		prompts = append(prompts, label)
+	}
+	return prompts
+}
+
+// applyTemplate substitutes prompt answers and the built-in {{title}},
+// {{date}} and {{time}} variables in a single pass, so values that contain
+// placeholders themselves are inserted as typed.
+func applyTemplate(content string, title string, answers map[string]string) string {
+	now := time.Now()
+	pairs := []string{
+		"{{title}}", title,
+		"{{date}}", now.Format("2006-01-02"),
+		"{{time}}", now.Format("15:04"),
+	}
+	for _, match := range templatePromptRe.FindAllStringSubmatch(content, -1) {
+		pairs = append(pairs, match[0], answers[strings.TrimSpace(match[1])])
+	}
+	return strings.NewReplacer(pairs...).Replace(content)
+}
+
+func pluralize(n int, singular string, plural string) string {
+	if n == 1 {
+		return "1 " + singular
+	}
+	return strconv.Itoa(n) + " " + plural
+}
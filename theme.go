@@ -0,0 +1,163 @@
+package main
+
+import (
+	"github.com/charmbracelet/bubbles/list"
+	"github.com/charmbracelet/bubbles/paginator"
+	"github.com/charmbracelet/bubbles/textarea"
+	"github.com/charmbracelet/bubbles/textinput"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/muesli/termenv"
+)
+
+const (
+	themeDefault      = "default"
+	themeHighContrast = "high-contrast"
+	themePlain        = "plain"
+)
+
+var themeChoices = []string{themeDefault, themeHighContrast, themePlain}
+
+// plainMode drops colors and decorative borders so the screen reads as plain
+// lines of text, which works better with screen readers and braille displays.
+var plainMode bool
+
+// applyTheme sets the palette and rebuilds the shared styles. A name that
+// is not built in is a theme file (see themefiles.go). NO_COLOR (or
+// CLICOLOR=0) removes colors from any theme but keeps bold text and layout.
+func applyTheme(name string) {
+	plainMode = name == themePlain
+	switch name {
+	case themeHighContrast:
+		colorPrimary = lipgloss.AdaptiveColor{Light: "#000000", Dark: "#FFFFFF"}
+		colorMuted = colorPrimary
+		colorBorder = colorPrimary
+		colorSuccess = lipgloss.AdaptiveColor{Light: "#005A00", Dark: "#5FFF5F"}
+		colorWarning = lipgloss.AdaptiveColor{Light: "#7A3E00", Dark: "#FFD75F"}
+		colorError = lipgloss.AdaptiveColor{Light: "#A00000", Dark: "#FF6E6E"}
+	default:
+		colorPrimary = lipgloss.AdaptiveColor{Light: "#0F4C5C", Dark: "#7AD9F5"}
+		colorMuted = lipgloss.AdaptiveColor{Light: "#475467", Dark: "#D4DEE8"}
+		colorBorder = lipgloss.AdaptiveColor{Light: "#CBD5E1", Dark: "#3B4A5A"}
+		colorSuccess = lipgloss.AdaptiveColor{Light: "#1F7A3F", Dark: "#67D08B"}
+		colorWarning = lipgloss.AdaptiveColor{Light: "#B54708", Dark: "#FDBA74"}
+		colorError = lipgloss.AdaptiveColor{Light: "#B42318", Dark: "#FF8D8D"}
+		if name != themeDefault {
+			applyThemeFile(name)
+		}
+	}
+
+	if plainMode || termenv.EnvNoColor() {
+		lipgloss.SetColorProfile(termenv.Ascii)
+	} else {
+		lipgloss.SetColorProfile(termenv.EnvColorProfile())
+	}
+
+	if plainMode {
+		titleStyle = lipgloss.NewStyle()
+		subtitleStyle = lipgloss.NewStyle()
+		hintStyle = lipgloss.NewStyle()
+		statusInfoStyle = lipgloss.NewStyle()
+		statusOkStyle = lipgloss.NewStyle()
+		statusWarnStyle = lipgloss.NewStyle()
+		statusErrStyle = lipgloss.NewStyle()
+		return
+	}
+	titleStyle = lipgloss.NewStyle().Bold(true).Foreground(colorPrimary)
+	subtitleStyle = lipgloss.NewStyle().Foreground(colorMuted)
+	hintStyle = lipgloss.NewStyle().Foreground(colorMuted)
+	statusInfoStyle = lipgloss.NewStyle().Bold(true).Foreground(colorPrimary)
+	statusOkStyle = lipgloss.NewStyle().Bold(true).Foreground(colorSuccess)
+	statusWarnStyle = lipgloss.NewStyle().Bold(true).Foreground(colorWarning)
+	statusErrStyle = lipgloss.NewStyle().Bold(true).Foreground(colorError)
+}
+
+// styleComponents applies the active theme to the list, input and editor.
+func styleComponents(l *list.Model, in *textinput.Model, ta *textarea.Model) {
+	delegate := list.NewDefaultDelegate()
+	listStyles := list.DefaultStyles()
+	if plainMode {
+		marker := lipgloss.Border{Left: ">"}
+		delegate.Styles.SelectedTitle = lipgloss.NewStyle().Border(marker, false, false, false, true).Padding(0, 0, 0, 1)
+		delegate.Styles.SelectedDesc = delegate.Styles.SelectedTitle
+		delegate.Styles.NormalTitle = lipgloss.NewStyle().Padding(0, 0, 0, 2)
+		delegate.Styles.NormalDesc = delegate.Styles.NormalTitle
+		delegate.Styles.DimmedTitle = delegate.Styles.NormalTitle
+		delegate.Styles.DimmedDesc = delegate.Styles.NormalTitle
+		listStyles.Title = lipgloss.NewStyle()
+		listStyles.TitleBar = lipgloss.NewStyle()
+		listStyles.PaginationStyle = lipgloss.NewStyle()
+		listStyles.StatusBar = lipgloss.NewStyle()
+		listStyles.StatusEmpty = lipgloss.NewStyle()
+		l.Paginator.Type = paginator.Arabic
+	} else {
+		delegate.Styles.NormalTitle = delegate.Styles.NormalTitle.Foreground(colorPrimary)
+		delegate.Styles.NormalDesc = delegate.Styles.NormalDesc.Foreground(colorMuted)
+		delegate.Styles.SelectedTitle = delegate.Styles.SelectedTitle.Bold(true).Foreground(colorSuccess).BorderForeground(colorSuccess)
+		delegate.Styles.SelectedDesc = delegate.Styles.SelectedDesc.Foreground(colorSuccess).BorderForeground(colorSuccess)
+		delegate.Styles.DimmedTitle = delegate.Styles.DimmedTitle.Foreground(colorMuted)
+		delegate.Styles.DimmedDesc = delegate.Styles.DimmedDesc.Foreground(colorMuted)
+		listStyles.Title = listStyles.Title.Bold(true).Foreground(colorPrimary)
+		listStyles.TitleBar = listStyles.TitleBar.BorderStyle(lipgloss.NormalBorder()).BorderBottom(true).BorderForeground(colorBorder)
+		listStyles.PaginationStyle = listStyles.PaginationStyle.Foreground(colorMuted)
+		listStyles.HelpStyle = listStyles.HelpStyle.Foreground(colorMuted)
+		listStyles.StatusBar = listStyles.StatusBar.Foreground(colorMuted)
+		listStyles.StatusEmpty = listStyles.StatusEmpty.Foreground(colorMuted)
+		listStyles.FilterPrompt = listStyles.FilterPrompt.Foreground(colorPrimary).Bold(true)
+		listStyles.FilterCursor = listStyles.FilterCursor.Foreground(colorPrimary)
+		l.Paginator.Type = paginator.Dots
+		if termenv.EnvNoColor() {
+			l.Paginator.Type = paginator.Arabic
+		}
+	}
+	delegate.SetSpacing(0)
+	delegate.ShowDescription = !compactMode
+	l.SetDelegate(entryDelegate{DefaultDelegate: delegate})
+	l.Styles = listStyles
+
+	if plainMode {
+		in.PromptStyle = lipgloss.NewStyle()
+		in.TextStyle = lipgloss.NewStyle()
+		in.PlaceholderStyle = lipgloss.NewStyle()
+		in.Cursor.Style = lipgloss.NewStyle()
+		ta.Prompt = ""
+		ta.FocusedStyle = textarea.Style{}
+		ta.BlurredStyle = ta.FocusedStyle
+		return
+	}
+	in.PromptStyle = lipgloss.NewStyle().Bold(true).Foreground(colorPrimary)
+	in.TextStyle = lipgloss.NewStyle().Foreground(colorPrimary)
+	in.PlaceholderStyle = lipgloss.NewStyle().Foreground(colorMuted)
+	in.Cursor.Style = lipgloss.NewStyle().Foreground(colorPrimary)
+
+	ta.Prompt = "> "
+	ta.FocusedStyle, _ = textarea.DefaultStyles()
+	ta.FocusedStyle.Prompt = lipgloss.NewStyle().Bold(true).Foreground(colorPrimary)
+	ta.FocusedStyle.LineNumber = lipgloss.NewStyle().Foreground(colorMuted)
+	ta.FocusedStyle.CursorLineNumber = lipgloss.NewStyle().Bold(true).Foreground(colorPrimary)
+	ta.FocusedStyle.CursorLine = lipgloss.NewStyle().Foreground(colorPrimary)
+	ta.FocusedStyle.Placeholder = lipgloss.NewStyle().Foreground(colorMuted)
+	ta.BlurredStyle = ta.FocusedStyle
+}
+
+func nextTheme(current string) string {
+	choices := append(append([]string{}, themeChoices...), customThemes()...)
+	for i, name := range choices {
+		if name == current {
+			return choices[(i+1)%len(choices)]
+		}
+	}
+	return choices[0]
+}
+
+func themeLabel(name string) string {
+	switch name {
+	case themeHighContrast:
+		return tr("High contrast")
+	case themePlain:
+		return tr("Plain (no colors or borders)")
+	case themeDefault:
+		return tr("Default")
+	default:
+		return tr("%s (theme file)", name)
+	}
+}
@@ -0,0 +1,143 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// Besides the built-in themes, a theme can be a JSON file in the themes
+// folder of the config directory, shared by all profiles like the
+// translation catalogs. The file name is the theme's name, and each color is
+// a hex color or ANSI number, or one for light and one for dark terminals:
+//
+//	{"primary": "#268BD2", "muted": {"light": "#657B83", "dark": "#93A1A1"}}
+//
+// Colors left out are those of the default theme.
+
+var themeNameRe = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_-]{0,39}$`)
+
+var themeColorRe = regexp.MustCompile(`^(#[0-9A-Fa-f]{6}|#[0-9A-Fa-f]{3}|[0-9]{1,3})$`)
+
+type themeColor lipgloss.AdaptiveColor
+
+func (c *themeColor) UnmarshalJSON(data []byte) error {
+	var both string
+	if err := json.Unmarshal(data, &both); err == nil {
+		c.Light, c.Dark = both, both
+	} else {
+		var pair struct {
+			Light string `json:"light"`
+			Dark  string `json:"dark"`
+		}
+		if err := json.Unmarshal(data, &pair); err != nil {
+			return fmt.Errorf("a color is a string or {\"light\": ..., \"dark\": ...}")
+		}
+		c.Light, c.Dark = pair.Light, pair.Dark
+	}
+	for _, value := range []string{c.Light, c.Dark} {
+		if !themeColorRe.MatchString(value) {
+			return fmt.Errorf("invalid color %q: use #RRGGBB or an ANSI color number", value)
+		}
+	}
+	return nil
+}
+
+type themeFile struct {
+	Primary *themeColor `json:"primary"`
+	Muted   *themeColor `json:"muted"`
+	Border  *themeColor `json:"border"`
+	Success *themeColor `json:"success"`
+	Warning *themeColor `json:"warning"`
+	Error   *themeColor `json:"error"`
+}
+
+func themesDir() string {
+	return filepath.Join(baseConfigDir(), "themes")
+}
+
+// parseThemeFile reads a theme and returns the number of colors it sets.
+func parseThemeFile(data []byte) (themeFile, int, error) {
+	var t themeFile
+	dec := json.NewDecoder(strings.NewReader(string(data)))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&t); err != nil {
+		return t, 0, err
+	}
+	n := 0
+	for _, c := range []*themeColor{t.Primary, t.Muted, t.Border, t.Success, t.Warning, t.Error} {
+		if c != nil {
+			n++
+		}
+	}
+	return t, n, nil
+}
+
+// customThemes returns the names of the theme files, sorted.
+func customThemes() []string {
+	entries, err := os.ReadDir(themesDir())
+	if err != nil {
+		return nil
+	}
+	var names []string
+	for _, e := range entries {
+		name := strings.TrimSuffix(e.Name(), ".json")
+		if e.IsDir() || !strings.HasSuffix(e.Name(), ".json") || !themeNameRe.MatchString(name) || isBuiltinTheme(name) {
+			continue
+		}
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func isBuiltinTheme(name string) bool {
+	for _, builtin := range themeChoices {
+		if name == builtin {
+			return true
+		}
+	}
+	return false
+}
+
+func isCustomTheme(name string) bool {
+	if !themeNameRe.MatchString(name) || isBuiltinTheme(name) {
+		return false
+	}
+	info, err := os.Stat(filepath.Join(themesDir(), name+".json"))
+	return err == nil && !info.IsDir()
+}
+
+// applyThemeFile sets the colors of the theme file name over the current
+// ones. A missing or broken file leaves them as they are.
+func applyThemeFile(name string) {
+	data, err := os.ReadFile(filepath.Join(themesDir(), name+".json"))
+	if err != nil {
+		return
+	}
+	t, _, err := parseThemeFile(data)
+	if err != nil {
+		return
+	}
+	for _, set := range []struct {
+		color *themeColor
+		into  *lipgloss.AdaptiveColor
+	}{
+		{t.Primary, &colorPrimary},
+		{t.Muted, &colorMuted},
+		{t.Border, &colorBorder},
+		{t.Success, &colorSuccess},
+		{t.Warning, &colorWarning},
+		{t.Error, &colorError},
+	} {
+		if set.color != nil {
+			*set.into = lipgloss.AdaptiveColor(*set.color)
+		}
+	}
+}
@@ -0,0 +1,274 @@
+package main
+
+import (
+	"crypto/sha256"
+	"errors"
+	"flag"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"io/fs"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// tidy reports notes that nothing links to and that link nowhere (orphans),
+// and notes with the same or nearly the same content. Near-duplicates are
+// found by comparing the sets of three-word shingles of two notes; pairs
+// whose Jaccard similarity reaches the threshold are reported.
+
+var wikiLinkRe = regexp.MustCompile(`\[\[([^\]|#]+)(?:#[^\]|]*)?(?:\|[^\]]*)?\]\]`)
+
+type tidyNote struct {
+	rel      string
+	words    int
+	digest   [32]byte
+	shingles map[uint64]struct{}
+	outbound int
+	inbound  int
+}
+
+type similarPair struct {
+	a, b       *tidyNote
+	similarity float64
+}
+
+func tidyCommand(args []string, stdout io.Writer) error {
+	flags := flag.NewFlagSet("tidy", flag.ContinueOnError)
+	flags.SetOutput(io.Discard)
+	threshold := flags.Float64("similarity", 0.8, "")
+	minWords := flags.Int("min-words", 20, "")
+	usage := errors.New("usage: gono tidy [-similarity 0.8] [-min-words N] VAULT")
+	if err := flags.Parse(args); err != nil || flags.NArg() != 1 {
+		return usage
+	}
+	if *threshold <= 0 || *threshold > 1 {
+		return errors.New("-similarity must be between 0 and 1")
+	}
+	vault, err := filepath.Abs(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+	if info, err := os.Stat(vault); err != nil || !info.IsDir() {
+		return fmt.Errorf("vault not found: %s", vault)
+	}
+
+	notes, err := scanTidyNotes(vault)
+	if err != nil {
+		return err
+	}
+	var orphans []*tidyNote
+	for _, n := range notes {
+		if n.inbound == 0 && n.outbound == 0 {
+			orphans = append(orphans, n)
+		}
+	}
+	identical := identicalNotes(notes)
+	similar := similarNotes(notes, *threshold, *minWords)
+
+	fmt.Fprintf(stdout, "Checked %s\n", pluralize(len(notes), "note", "notes"))
+	fmt.Fprintf(stdout, "\nOrphans (%d): no links in or out\n", len(orphans))
+	for _, n := range orphans {
+		fmt.Fprintf(stdout, "  %s\n", n.rel)
+	}
+	if len(orphans) > 0 {
+		fmt.Fprintln(stdout, "  Link them from a related or index note, or delete the ones you no longer need.")
+	}
+	fmt.Fprintf(stdout, "\nIdentical content (%s)\n", pluralize(len(identical), "group", "groups"))
+	for _, group := range identical {
+		rels := make([]string, 0, len(group))
+		for _, n := range group {
+			rels = append(rels, n.rel)
+		}
+		fmt.Fprintf(stdout, "  %s\n", strings.Join(rels, ", "))
+		fmt.Fprintf(stdout, "    keep %s, delete %s\n", rels[0], strings.Join(rels[1:], ", "))
+	}
+	fmt.Fprintf(stdout, "\nSimilar content (%s, at least %.0f%% alike)\n", pluralize(len(similar), "pair", "pairs"), *threshold*100)
+	for _, p := range similar {
+		fmt.Fprintf(stdout, "  %3.0f%%  %s\n        %s\n", p.similarity*100, p.a.rel, p.b.rel)
+		fmt.Fprintf(stdout, "    merge %s into %s\n", p.b.rel, p.a.rel)
+	}
+	return nil
+}
+
+// scanTidyNotes reads the notes of vault and counts the links between them.
+func scanTidyNotes(vault string) ([]*tidyNote, error) {
+	var notes []*tidyNote
+	byKey := make(map[string]*tidyNote)
+	texts := make(map[*tidyNote]string)
+	err := walkVault(vault, func(p string, d fs.DirEntry) error {
+		if !isIndexedNote(p) {
+			return nil
+		}
+		content, err := os.ReadFile(p)
+		if err != nil {
+			return nil
+		}
+		rel := relOrBase(vault, p)
+		_, body := splitNoteTitle(p, string(content))
+		words := normalizedWords(body)
+		n := &tidyNote{
+			rel:      rel,
+			words:    len(words),
+			digest:   sha256.Sum256([]byte(strings.Join(words, " "))),
+			shingles: shingles(words),
+		}
+		notes = append(notes, n)
+		texts[n] = string(content)
+		key := strings.ToLower(strings.TrimSuffix(rel, filepath.Ext(rel)))
+		byKey[key] = n
+		if base := filepath.Base(key); byKey[base] == nil {
+			byKey[base] = n
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(notes, func(i, j int) bool { return notes[i].rel < notes[j].rel })
+
+	for _, n := range notes {
+		targets := make(map[*tidyNote]bool)
+		for _, key := range linkKeys(vault, filepath.Join(vault, filepath.FromSlash(n.rel)), texts[n]) {
+			if t := byKey[key]; t != nil {
+				targets[t] = true
+			}
+		}
+		for t := range targets {
+			if t == n {
+				continue
+			}
+			n.outbound++
+			t.inbound++
+		}
+	}
+	return notes, nil
+}
+
+// linkKeys returns the notes text links to, as lower-case keys: the vault
+// path without extension for relative Markdown links, the link target for
+// [[wiki-links]], which may also be a bare file name. Links to URLs, to
+// anchors in the same note and out of the vault are left out.
+func linkKeys(vault string, path string, text string) []string {
+	var keys []string
+	for _, m := range wikiLinkRe.FindAllStringSubmatch(text, -1) {
+		keys = append(keys, strings.ToLower(strings.TrimSuffix(strings.TrimSpace(m[1]), ".md")))
+	}
+	dir := filepath.Dir(path)
+	for _, m := range markdownLinkRe.FindAllStringSubmatch(text, -1) {
+		target := m[3]
+		if strings.Contains(target, "://") || strings.HasPrefix(target, "mailto:") || strings.HasPrefix(target, "#") {
+			continue
+		}
+		target, _, _ = strings.Cut(target, "#")
+		if unescaped, err := url.PathUnescape(target); err == nil {
+			target = unescaped
+		}
+		abs := filepath.Join(dir, filepath.FromSlash(target))
+		if !pathWithin(vault, abs) {
+			continue
+		}
+		rel := relOrBase(vault, abs)
+		keys = append(keys, strings.ToLower(strings.TrimSuffix(rel, filepath.Ext(rel))))
+	}
+	return keys
+}
+
+// normalizedWords lower-cases text and splits it into words, ignoring
+// punctuation and Markdown markup.
+func normalizedWords(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+func shingles(words []string) map[uint64]struct{} {
+	set := make(map[uint64]struct{})
+	for i := 0; i+3 <= len(words); i++ {
+		h := fnv.New64a()
+		h.Write([]byte(strings.Join(words[i:i+3], " ")))
+		set[h.Sum64()] = struct{}{}
+	}
+	return set
+}
+
+// identicalNotes groups non-empty notes with the same words. The note with
+// the most inbound links comes first in each group: it is the one to keep.
+func identicalNotes(notes []*tidyNote) [][]*tidyNote {
+	groups := make(map[[32]byte][]*tidyNote)
+	var order [][32]byte
+	for _, n := range notes {
+		if n.words == 0 {
+			continue
+		}
+		if groups[n.digest] == nil {
+			order = append(order, n.digest)
+		}
+		groups[n.digest] = append(groups[n.digest], n)
+	}
+	var out [][]*tidyNote
+	for _, digest := range order {
+		group := groups[digest]
+		if len(group) < 2 {
+			continue
+		}
+		sort.SliceStable(group, func(i, j int) bool { return group[i].inbound > group[j].inbound })
+		out = append(out, group)
+	}
+	return out
+}
+
+// similarNotes returns the pairs of different notes whose shingle sets have
+// a Jaccard similarity of at least threshold, most similar first. In each
+// pair the longer note comes first, as the one to merge into.
+func similarNotes(notes []*tidyNote, threshold float64, minWords int) []similarPair {
+	var candidates []*tidyNote
+	for _, n := range notes {
+		if n.words >= minWords && len(n.shingles) > 0 {
+			candidates = append(candidates, n)
+		}
+	}
+	var pairs []similarPair
+	for i, a := range candidates {
+		for _, b := range candidates[i+1:] {
+			if a.digest == b.digest {
+				continue
+			}
+			small, large := len(a.shingles), len(b.shingles)
+			if small > large {
+				small, large = large, small
+			}
+			// The similarity cannot exceed the ratio of the set sizes.
+			if float64(small)/float64(large) < threshold {
+				continue
+			}
+			if s := jaccard(a.shingles, b.shingles); s >= threshold {
+				if b.words > a.words {
+					pairs = append(pairs, similarPair{a: b, b: a, similarity: s})
+				} else {
+					pairs = append(pairs, similarPair{a: a, b: b, similarity: s})
+				}
+			}
+		}
+	}
+	sort.SliceStable(pairs, func(i, j int) bool { return pairs[i].similarity > pairs[j].similarity })
+	return pairs
+}
+
+func jaccard(a map[uint64]struct{}, b map[uint64]struct{}) float64 {
+	if len(a) > len(b) {
+		a, b = b, a
+	}
+	shared := 0
+	for h := range a {
+		if _, ok := b[h]; ok {
+			shared++
+		}
+	}
+	return float64(shared) / float64(len(a)+len(b)-shared)
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// The titles and hint lines of the vault and file lists can be replaced in
+// the "titles" section of the configuration. Templates may use {{vault}}
+// (the vault's folder name), {{path}} (the current folder, relative to the
+// vault) and {{count}} (vaults in the registry, or entries in the folder);
+// a "\n" starts a new hint line.
+
+// vaultTitleWidth is the narrowest panel the long built-in vault list title
+// fits on without wrapping.
+const vaultTitleWidth = 96
+
+func (m Model) expandTitle(tmpl string, count int) string {
+	vault := ""
+	if m.vault != "" {
+		vault = filepath.Base(m.vault)
+	}
+	path := ""
+	if m.current != "" {
+		path = relOrDot(m.vault, m.current)
+	}
+	return strings.NewReplacer(
+		"{{vault}}", vault,
+		"{{path}}", path,
+		"{{count}}", strconv.Itoa(count),
+	).Replace(tmpl)
+}
+
+func (m Model) vaultListTitle() string {
+	if m.cfg.Titles.VaultList != "" {
+		return m.expandTitle(m.cfg.Titles.VaultList, len(m.list.Items()))
+	}
+	if contentW, _ := m.contentDims(); contentW < vaultTitleWidth {
+		return tr("Select vault (Enter)")
+	}
+	return tr("Select vault (Enter), create (Ctrl+N), open by path (Ctrl+O), open in explorer (Ctrl+P)")
+}
+
+func (m Model) vaultSelectHints(width int) string {
+	if m.cfg.Titles.VaultHints != "" {
+		return m.expandTitle(m.cfg.Titles.VaultHints, len(m.list.Items()))
+	}
+	return builtinVaultHints(width)
+}
+
+func (m Model) fileListTitle() string {
+	if m.cfg.Titles.FileList != "" {
+		return m.expandTitle(m.cfg.Titles.FileList, m.entries)
+	}
+	return tr("Vault explorer")
+}
@@ -0,0 +1,172 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// Alt+C in the editor puts a table of contents at the top of the note: a
+// list of links to its headings between <!-- toc --> and <!-- /toc --> lines,
+// after the frontmatter and the title. Pressing it again rebuilds the list
+// where it is. The links use the anchors the HTML export gives headings,
+// which are GitHub's (lower case, punctuation dropped, spaces as dashes and
+// -1, -2 on repeats), so they also work on git hosts.
+
+const (
+	tocStart = "<!-- toc -->"
+	tocEnd   = "<!-- /toc -->"
+)
+
+var tocWikiRe = regexp.MustCompile(`\[\[([^\]|]+)(?:\|([^\]]*))?\]\]`)
+
+type tocHeading struct {
+	level  int
+	text   string
+	anchor string
+}
+
+// headingText is a heading as it reads, without links and inline markup.
+func headingText(s string) string {
+	s = markdownLinkRe.ReplaceAllString(s, "$2")
+	s = tocWikiRe.ReplaceAllStringFunc(s, func(m string) string {
+		parts := tocWikiRe.FindStringSubmatch(m)
+		if parts[2] != "" {
+			return parts[2]
+		}
+		return parts[1]
+	})
+	s = strings.NewReplacer("`", "", "**", "", "*", "").Replace(s)
+	return strings.TrimSpace(s)
+}
+
+// headingAnchor returns the anchor of a heading, counting repeats in seen.
+func headingAnchor(heading string, seen map[string]int) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(headingText(heading)) {
+		switch {
+		case unicode.IsLetter(r) || unicode.IsDigit(r) || r == '-' || r == '_':
+			b.WriteRune(r)
+		case r == ' ':
+			b.WriteByte('-')
+		}
+	}
+	anchor := b.String()
+	n := seen[anchor]
+	seen[anchor] = n + 1
+	if n > 0 {
+		anchor = fmt.Sprintf("%s-%d", anchor, n)
+	}
+	return anchor
+}
+
+// frontmatterLines returns the number of lines the frontmatter of lines
+// takes, 0 without one.
+func frontmatterLines(lines []string) int {
+	if len(lines) == 0 || strings.TrimSpace(strings.TrimPrefix(lines[0], "\ufeff")) != "---" {
+		return 0
+	}
+	for i := 1; i < len(lines); i++ {
+		if t := strings.TrimSpace(lines[i]); t == "---" || t == "..." {
+			return i + 1
+		}
+	}
+	return 0
+}
+
+// withTOC returns text with its table of contents inserted or rebuilt, and
+// the line the table starts on. It fails when the note has no headings
+// besides its title.
+func withTOC(text string) (string, int, bool) {
+	lines := strings.Split(text, "\n")
+	body := frontmatterLines(lines)
+	title, start, end := -1, -1, -1
+	for i := body; i < len(lines); i++ {
+		if strings.TrimSpace(lines[i]) != "" {
+			if strings.HasPrefix(lines[i], "# ") {
+				title = i
+			}
+			break
+		}
+	}
+	var headings []tocHeading
+	seen := make(map[string]int)
+	inFence := false
+	for i := body; i < len(lines); i++ {
+		trimmed := strings.TrimSpace(lines[i])
+		switch {
+		case strings.HasPrefix(trimmed, "```"):
+			inFence = !inFence
+		case inFence:
+		case trimmed == tocStart && start < 0:
+			start = i
+		case trimmed == tocEnd && start >= 0 && end < 0:
+			end = i
+		case mdHeadingRe.MatchString(lines[i]):
+			parts := mdHeadingRe.FindStringSubmatch(lines[i])
+			anchor := headingAnchor(parts[2], seen)
+			if i != title {
+				headings = append(headings, tocHeading{level: len(parts[1]), text: headingText(parts[2]), anchor: anchor})
+			}
+		}
+	}
+	if len(headings) == 0 {
+		return text, 0, false
+	}
+	top := 6
+	for _, h := range headings {
+		top = minInt(top, h.level)
+	}
+	block := []string{tocStart}
+	for _, h := range headings {
+		block = append(block, fmt.Sprintf("%s- [%s](#%s)", strings.Repeat("  ", h.level-top), h.text, h.anchor))
+	}
+	block = append(block, tocEnd)
+
+	if start >= 0 && end > start {
+		out := append(append(append([]string{}, lines[:start]...), block...), lines[end+1:]...)
+		return strings.Join(out, "\n"), start, true
+	}
+	at := body
+	if title >= 0 {
+		at = title + 1
+	}
+	rest := lines[at:]
+	for len(rest) > 0 && strings.TrimSpace(rest[0]) == "" {
+		rest = rest[1:]
+	}
+	out := append([]string{}, lines[:at]...)
+	if at > 0 {
+		out = append(out, "")
+	}
+	start = len(out)
+	out = append(out, block...)
+	out = append(out, "")
+	out = append(out, rest...)
+	return strings.Join(out, "\n"), start, true
+}
+
+// insertTOC inserts or rebuilds the table of contents of the open note.
+func (m Model) insertTOC() (tea.Model, tea.Cmd) {
+	value := m.textarea.Value()
+	text, at, ok := withTOC(value)
+	if !ok {
+		m.status = infoStatus("The note has no headings besides its title")
+		return m, nil
+	}
+	if text == value {
+		m.status = infoStatus("The table of contents is up to date")
+		return m, nil
+	}
+	pos := editorCursor(m.textarea)
+	if pos.row >= at {
+		pos.row += strings.Count(text, "\n") - strings.Count(value, "\n")
+	}
+	m.textarea.SetValue(text)
+	setEditorCursor(&m.textarea, pos)
+	m.status = okStatus("Table of contents updated")
+	return m, nil
+}
@@ -0,0 +1,203 @@
+package main
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// Deleting a note or folder in a vault moves it to the vault's .trash
+// folder instead of removing it, unless trash.enabled is off. Each deletion
+// is a batch folder named after the time (.trash/20250603-141502/notes/a.md),
+// keeping the path the entry had in the vault, so it can be put back by hand.
+// When GoNo starts, batches older than trash.retention_days are removed from
+// every registered vault, and then the oldest ones until the trash is within
+// trash.max_size_mb. Alt+X in the file list empties the trash of the vault
+// after a confirmation. Entries in .trash that are not batches, such as those
+// of other editors, are left alone by the purge.
+
+const (
+	trashDirName     = ".trash"
+	trashBatchLayout = "20060102-150405"
+
+	defaultTrashRetention = 30
+)
+
+// trashBatchRe matches the batch names moveToTrash creates.
+var trashBatchRe = regexp.MustCompile(`^\d{8}-\d{6}(?:-\d+)?$`)
+
+var (
+	trashRetentionChoices = []int{0, 7, 30, 90, 365}
+	trashSizeChoices      = []int{0, 100, 500, 1000, 5000}
+)
+
+// trashConfig sets whether deletions go to the trash and how long it keeps
+// them: batches older than RetentionDays (0 keeps them) and, oldest first,
+// those beyond MaxSizeMB (0 sets no cap) are purged at startup.
+type trashConfig struct {
+	Enabled       bool `json:"enabled"`
+	RetentionDays int  `json:"retention_days"`
+	MaxSizeMB     int  `json:"max_size_mb"`
+}
+
+type trashBatch struct {
+	path    string
+	deleted time.Time
+	size    int64
+}
+
+type trashPurgedMsg struct {
+	batches int
+	freed   int64
+}
+
+func trashDir(vault string) string {
+	return filepath.Join(vault, trashDirName)
+}
+
+// usesTrash reports whether deleting target moves it to the trash.
+func (m Model) usesTrash(target deleteTarget) bool {
+	return m.cfg.Trash.Enabled && !target.isVault && !target.purge && m.vault != "" && pathWithin(m.vault, target.path)
+}
+
+// moveToTrash moves path into a new batch of the vault's trash and returns
+// where it went.
+func moveToTrash(vault string, path string, now time.Time) (string, error) {
+	rel, err := filepath.Rel(vault, path)
+	if err != nil {
+		return "", err
+	}
+	batch := filepath.Join(trashDir(vault), now.Format(trashBatchLayout))
+	for i := 2; ; i++ {
+		if _, err := os.Lstat(batch); os.IsNotExist(err) {
+			break
+		}
+		batch = filepath.Join(trashDir(vault), fmt.Sprintf("%s-%d", now.Format(trashBatchLayout), i))
+	}
+	dest := filepath.Join(batch, rel)
+	if err := os.MkdirAll(filepath.Dir(dest), 0755); err != nil {
+		return "", err
+	}
+	if err := os.Rename(path, dest); err != nil {
+		_ = os.RemoveAll(batch)
+		return "", fmt.Errorf("cannot move %s to the trash: %w", filepath.Base(path), err)
+	}
+	return dest, nil
+}
+
+// trashBatches returns the batches in the trash of vault, oldest first.
+func trashBatches(vault string) []trashBatch {
+	entries, err := os.ReadDir(trashDir(vault))
+	if err != nil {
+		return nil
+	}
+	var batches []trashBatch
+	for _, e := range entries {
+		if !e.IsDir() || !trashBatchRe.MatchString(e.Name()) {
+			continue
+		}
+		deleted, err := time.ParseInLocation(trashBatchLayout, e.Name()[:len(trashBatchLayout)], time.Local)
+		if err != nil {
+			continue
+		}
+		b := trashBatch{path: filepath.Join(trashDir(vault), e.Name()), deleted: deleted}
+		_ = filepath.WalkDir(b.path, func(p string, d fs.DirEntry, err error) error {
+			if err == nil && !d.IsDir() {
+				if info, err := d.Info(); err == nil {
+					b.size += info.Size()
+				}
+			}
+			return nil
+		})
+		batches = append(batches, b)
+	}
+	sort.Slice(batches, func(i, j int) bool { return batches[i].path < batches[j].path })
+	return batches
+}
+
+// purgeTrash removes the batches of vault that are older than the
+// retention, then the oldest ones until the rest fits the size cap. It
+// returns how many were removed and the bytes freed.
+func purgeTrash(vault string, cfg trashConfig, now time.Time) (int, int64) {
+	batches := trashBatches(vault)
+	var total int64
+	for _, b := range batches {
+		total += b.size
+	}
+	limit := int64(cfg.MaxSizeMB) << 20
+	removed, freed := 0, int64(0)
+	for _, b := range batches {
+		expired := cfg.RetentionDays > 0 && now.Sub(b.deleted) > time.Duration(cfg.RetentionDays)*24*time.Hour
+		oversize := limit > 0 && total > limit
+		if !expired && !oversize {
+			continue
+		}
+		if err := os.RemoveAll(b.path); err != nil {
+			continue
+		}
+		removed++
+		freed += b.size
+		total -= b.size
+	}
+	return removed, freed
+}
+
+// purgeTrashCmd purges the trash of every registered vault in the
+// background.
+func purgeTrashCmd(cfg trashConfig) tea.Cmd {
+	if cfg.RetentionDays <= 0 && cfg.MaxSizeMB <= 0 {
+		return nil
+	}
+	return func() tea.Msg {
+		vaults, _ := loadVaultRegistry()
+		var msg trashPurgedMsg
+		for _, vault := range vaults {
+			n, freed := purgeTrash(vault, cfg, time.Now())
+			msg.batches += n
+			msg.freed += freed
+		}
+		return msg
+	}
+}
+
+func (m Model) applyTrashPurged(msg trashPurgedMsg) Model {
+	if msg.batches > 0 && m.status.text == "" {
+		m.status = infoStatus("Trash: purged %s of old deletions (%s)", pluralize(msg.batches, "batch", "batches"), formatSize(msg.freed))
+	}
+	return m
+}
+
+// beginEmptyTrash asks to remove the trash of the vault for good.
+func (m Model) beginEmptyTrash() (tea.Model, tea.Cmd) {
+	dir := trashDir(m.vault)
+	if entries, err := os.ReadDir(dir); err != nil || len(entries) == 0 {
+		m.status = infoStatus("The trash is empty")
+		return m, nil
+	}
+	return m.beginDelete(deleteTarget{
+		path:  dir,
+		label: tr("Trash of %s", filepath.Base(m.vault)),
+		isDir: true,
+		purge: true,
+	})
+}
+
+func trashRetentionLabel(days int) string {
+	if days <= 0 {
+		return tr("Keep until emptied")
+	}
+	return trn("%d day", "%d days", days)
+}
+
+func trashSizeLabel(mb int) string {
+	if mb <= 0 {
+		return tr("No limit")
+	}
+	return formatSize(int64(mb) << 20)
+}
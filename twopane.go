@@ -0,0 +1,400 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"syscall"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/mattn/go-runewidth"
+)
+
+// The two-pane browser (F3 in the file list) shows two directories side by
+// side, in the same vault or in two vaults, and copies (F5) or moves (F6) the
+// selected entry from the active pane to the other one. Entries never
+// overwrite: a clashing name gets a numbered suffix.
+
+var errPathOutsideVault = errors.New("path is outside the vault")
+
+type pane struct {
+	vault   string
+	dir     string
+	entries []paneEntry
+	cursor  int
+	top     int
+	hidden  bool
+}
+
+type paneEntry struct {
+	name  string
+	isDir bool
+	up    bool
+}
+
+type twoPane struct {
+	panes  [2]pane
+	active int
+}
+
+func (p *pane) load() error {
+	files, err := os.ReadDir(p.dir)
+	if err != nil {
+		return err
+	}
+	p.entries = p.entries[:0]
+	if !samePath(p.dir, p.vault) {
+		p.entries = append(p.entries, paneEntry{name: "..", isDir: true, up: true})
+	}
+	var dirs, notes []paneEntry
+	for _, f := range files {
+		if isHiddenName(f.Name()) && !p.hidden {
+			continue
+		}
+		isDir := f.IsDir()
+		if f.Type()&fs.ModeSymlink != 0 {
+			if info, err := os.Stat(filepath.Join(p.dir, f.Name())); err == nil {
+				isDir = info.IsDir()
+			}
+		}
+		if isDir {
+			dirs = append(dirs, paneEntry{name: f.Name(), isDir: true})
+		} else {
+			notes = append(notes, paneEntry{name: f.Name()})
+		}
+	}
+	for _, group := range [][]paneEntry{dirs, notes} {
+		sort.Slice(group, func(i, j int) bool {
+			return strings.ToLower(group[i].name) < strings.ToLower(group[j].name)
+		})
+		p.entries = append(p.entries, group...)
+	}
+	p.cursor = minInt(p.cursor, maxInt(0, len(p.entries)-1))
+	return nil
+}
+
+func (p *pane) selected() (paneEntry, bool) {
+	if p.cursor < 0 || p.cursor >= len(p.entries) {
+		return paneEntry{}, false
+	}
+	return p.entries[p.cursor], true
+}
+
+func (p *pane) enter() error {
+	e, ok := p.selected()
+	if !ok || !e.isDir {
+		return nil
+	}
+	target := filepath.Join(p.dir, e.name)
+	if e.up {
+		target = filepath.Dir(p.dir)
+	}
+	if !insideVault(p.vault, target) {
+		return errPathOutsideVault
+	}
+	from := filepath.Base(p.dir)
+	p.dir = target
+	p.cursor, p.top = 0, 0
+	if err := p.load(); err != nil {
+		return err
+	}
+	if e.up {
+		for i, entry := range p.entries {
+			if entry.name == from {
+				p.cursor = i
+			}
+		}
+	}
+	return nil
+}
+
+func (m Model) openTwoPane() (tea.Model, tea.Cmd) {
+	tp := &twoPane{}
+	for i := range tp.panes {
+		tp.panes[i] = pane{vault: m.vault, dir: m.current, hidden: m.cfg.List.ShowHidden}
+		if err := tp.panes[i].load(); err != nil {
+			m.status = errorStatus(err)
+			return m, nil
+		}
+	}
+	m.panes = tp
+	m.state = stateTwoPane
+	m.status = statusLine{}
+	return m, nil
+}
+
+func (m Model) handleTwoPaneKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	tp := m.panes
+	p := &tp.panes[tp.active]
+	m.status = statusLine{}
+	switch msg.String() {
+	case "ctrl+c":
+		return m, tea.Quit
+	case "esc", "q":
+		left := tp.panes[0]
+		m.panes = nil
+		m.state = stateFileList
+		if samePath(left.vault, m.vault) {
+			m.current = left.dir
+		}
+		m = m.refreshFileList()
+		return m, nil
+	case "tab", "left", "right":
+		tp.active = 1 - tp.active
+	case "up", "k":
+		p.cursor = maxInt(0, p.cursor-1)
+	case "down", "j":
+		p.cursor = minInt(len(p.entries)-1, p.cursor+1)
+	case "pgup":
+		p.cursor = maxInt(0, p.cursor-10)
+	case "pgdown":
+		p.cursor = minInt(len(p.entries)-1, p.cursor+10)
+	case "home", "g":
+		p.cursor = 0
+	case "end", "G":
+		p.cursor = len(p.entries) - 1
+	case "enter":
+		if err := p.enter(); err != nil {
+			m.status = errorStatus(err)
+		}
+	case "backspace":
+		if !samePath(p.dir, p.vault) {
+			p.cursor = 0
+			if len(p.entries) > 0 && p.entries[0].up {
+				if err := p.enter(); err != nil {
+					m.status = errorStatus(err)
+				}
+			}
+		}
+	case "=":
+		other := tp.panes[1-tp.active]
+		p.vault, p.dir, p.cursor, p.top = other.vault, other.dir, 0, 0
+		if err := p.load(); err != nil {
+			m.status = errorStatus(err)
+		}
+	case "v":
+		return m.cyclePaneVault()
+	case "f5":
+		return m.transferEntry(false)
+	case "f6":
+		return m.transferEntry(true)
+	}
+	return m, nil
+}
+
+// cyclePaneVault switches the active pane to the next registered vault.
+func (m Model) cyclePaneVault() (tea.Model, tea.Cmd) {
+	p := &m.panes.panes[m.panes.active]
+	vaults, err := loadVaultRegistry()
+	if err != nil {
+		m.status = errorStatus(err)
+		return m, nil
+	}
+	vaults = existingVaults(vaults)
+	if len(vaults) == 0 {
+		return m, nil
+	}
+	next := vaults[0]
+	for i, v := range vaults {
+		if samePath(v, p.vault) {
+			next = vaults[(i+1)%len(vaults)]
+		}
+	}
+	p.vault, p.dir, p.cursor, p.top = next, next, 0, 0
+	if err := p.load(); err != nil {
+		m.status = errorStatus(err)
+		return m, nil
+	}
+	m.status = infoStatus("Pane shows vault %s", filepath.Base(next))
+	return m, nil
+}
+
+// transferEntry copies or moves the active pane's entry into the other
+// pane's directory.
+func (m Model) transferEntry(move bool) (tea.Model, tea.Cmd) {
+	tp := m.panes
+	src, dst := &tp.panes[tp.active], &tp.panes[1-tp.active]
+	e, ok := src.selected()
+	if !ok || e.up {
+		return m, nil
+	}
+	if samePath(src.dir, dst.dir) {
+		m.status = infoStatus("Both panes show the same directory")
+		return m, nil
+	}
+	from := filepath.Join(src.dir, e.name)
+	to := uniquePath(filepath.Join(dst.dir, e.name))
+	if !insideVault(src.vault, from) || !insideVault(dst.vault, to) {
+		m.status = errorStatus(errPathOutsideVault)
+		return m, nil
+	}
+	if dir, ok := protectedDir(src.vault, from); ok && move {
+		m.status = warnStatus("%s is an internal folder and cannot be moved", dir)
+		return m, nil
+	}
+	if dir, ok := protectedDir(dst.vault, to); ok {
+		m.status = warnStatus("%s is an internal folder; nothing is copied into it", dir)
+		return m, nil
+	}
+	if e.isDir && pathWithin(from, dst.dir) {
+		m.status = failStatus("cannot put %s inside itself", e.name)
+		return m, nil
+	}
+	editingMoved := move && m.editing != "" && pathWithin(from, m.editing)
+	if editingMoved && m.dirty() {
+		m.status = failStatus("%s has unsaved changes", relOrBase(m.vault, m.editing))
+		return m, nil
+	}
+
+	var err error
+	if move {
+		err = movePath(from, to)
+	} else if e.isDir {
+		err = copyDir(from, to)
+	} else {
+		err = copyFile(from, to)
+	}
+	if err != nil {
+		m.status = errorStatus(err)
+		return m, nil
+	}
+	if editingMoved {
+		m.editing = ""
+		m.prevNote = ""
+	}
+
+	for _, p := range []*pane{src, dst} {
+		if err := p.load(); err != nil {
+			m.status = errorStatus(err)
+			return m, nil
+		}
+	}
+	for i, entry := range dst.entries {
+		if entry.name == filepath.Base(to) {
+			dst.cursor = i
+		}
+	}
+	if m.dirStats != nil {
+		m = m.invalidateDirStats(from)
+		m = m.invalidateDirStats(to)
+	}
+
+	op := journalCopy
+	if move {
+		op = journalMove
+		m.status = okStatus("Moved %s to %s", e.name, paneLabel(*dst, filepath.Dir(to)))
+	} else {
+		m.status = okStatus("Copied %s to %s", e.name, paneLabel(*dst, filepath.Dir(to)))
+	}
+	if err := journalTransfer(src.vault, dst.vault, op, from, to, e.isDir); err != nil {
+		m.status = warnStatus("Journal not updated: %v", err)
+	}
+	if samePath(src.vault, m.vault) || samePath(dst.vault, m.vault) {
+		return m.startIndexing()
+	}
+	return m, nil
+}
+
+// movePath renames from to to, copying and removing when they are on
+// different file systems.
+func movePath(from string, to string) error {
+	err := os.Rename(from, to)
+	if err == nil || !errors.Is(err, syscall.EXDEV) {
+		return err
+	}
+	info, err := os.Lstat(from)
+	if err != nil {
+		return err
+	}
+	if info.IsDir() {
+		err = copyDir(from, to)
+	} else {
+		err = copyFile(from, to)
+	}
+	if err != nil {
+		return err
+	}
+	return os.RemoveAll(from)
+}
+
+func copyDir(from string, to string) error {
+	if _, err := os.Lstat(to); err == nil {
+		return fmt.Errorf("%w: %s", fs.ErrExist, to)
+	}
+	return filepath.WalkDir(from, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(from, p)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(to, rel)
+		switch {
+		case d.IsDir():
+			return os.MkdirAll(target, 0755)
+		case d.Type()&fs.ModeSymlink != 0:
+			link, err := os.Readlink(p)
+			if err != nil {
+				return err
+			}
+			return os.Symlink(link, target)
+		case d.Type().IsRegular():
+			return copyFile(p, target)
+		}
+		return nil
+	})
+}
+
+func paneLabel(p pane, dir string) string {
+	return filepath.Base(p.vault) + ":" + relOrDot(p.vault, dir)
+}
+
+func (m Model) twoPaneView(contentW int, height int) string {
+	tp := m.panes
+	width := maxInt(10, (contentW-1)/2)
+	columns := make([]string, 2)
+	for i := range tp.panes {
+		p := &tp.panes[i]
+		rows := maxInt(1, height-1)
+		if p.cursor < p.top {
+			p.top = p.cursor
+		}
+		if p.cursor >= p.top+rows {
+			p.top = p.cursor - rows + 1
+		}
+		header := runewidth.Truncate(paneLabel(*p, p.dir), width, "…")
+		lines := []string{subtitleStyle.Render(runewidth.FillRight(header, width))}
+		for r := p.top; r < len(p.entries) && r < p.top+rows; r++ {
+			e := p.entries[r]
+			name := e.name
+			if e.isDir && !e.up {
+				name += string(os.PathSeparator)
+			}
+			name = runewidth.FillRight(runewidth.Truncate(" "+name, width, "…"), width)
+			style := lipgloss.NewStyle()
+			if r == p.cursor {
+				style = style.Reverse(i == tp.active).Underline(i != tp.active)
+			}
+			lines = append(lines, style.Render(name))
+		}
+		for len(lines) < rows+1 {
+			lines = append(lines, strings.Repeat(" ", width))
+		}
+		columns[i] = strings.Join(lines, "\n")
+	}
+	divider := hintStyle.Render(strings.TrimSuffix(strings.Repeat("│\n", height), "\n"))
+	return lipgloss.JoinHorizontal(lipgloss.Top, columns[0], divider, columns[1])
+}
+
+func twoPaneHints(width int) string {
+	if width < 72 {
+		return tr("Tab switch | Enter open | F5 copy | F6 move\nV vault | = same dir | Esc back")
+	}
+	return tr("Tab: switch pane | Enter: open dir | F5: copy | F6: move to other pane\nV: show next vault | =: same dir as other pane | Esc: back")
+}
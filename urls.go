@@ -0,0 +1,152 @@
+package main
+
+import (
+	"os/exec"
+	"regexp"
+	"runtime"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/list"
+	"github.com/charmbracelet/bubbles/textarea"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// Bare URLs in a note are detected as links: Alt+O opens the one under the
+// cursor in the system browser, Alt+U lists every URL of the note.
+
+var urlRe = regexp.MustCompile(`(?i)\b(?:https?://|mailto:|www\.)[^\s<>"'` + "`" + `]+`)
+
+type noteURL struct {
+	url  string
+	line int
+}
+
+// trimURL drops trailing punctuation that ends the sentence rather than the
+// URL, and closing brackets without an opening one inside the URL, as in
+// "(see https://example.com)" or [text](https://example.com).
+func trimURL(u string) string {
+	for u != "" {
+		last := u[len(u)-1]
+		switch {
+		case strings.IndexByte(".,;:!?*_~", last) >= 0:
+			u = u[:len(u)-1]
+		case last == ')' && strings.Count(u, "(") < strings.Count(u, ")"):
+			u = u[:len(u)-1]
+		case last == ']' && strings.Count(u, "[") < strings.Count(u, "]"):
+			u = u[:len(u)-1]
+		default:
+			return u
+		}
+	}
+	return u
+}
+
+// findURLs returns the URLs in line with their rune ranges.
+func findURLs(line string) [][2]int {
+	var found [][2]int
+	for _, loc := range urlRe.FindAllStringIndex(line, -1) {
+		u := trimURL(line[loc[0]:loc[1]])
+		if u == "" {
+			continue
+		}
+		start := len([]rune(line[:loc[0]]))
+		found = append(found, [2]int{start, start + len([]rune(u))})
+	}
+	return found
+}
+
+// noteURLs lists the distinct URLs of text in order of appearance.
+func noteURLs(text string) []noteURL {
+	var urls []noteURL
+	seen := make(map[string]bool)
+	for i, line := range strings.Split(text, "\n") {
+		for _, f := range findURLs(line) {
+			u := string([]rune(line)[f[0]:f[1]])
+			if seen[u] {
+				continue
+			}
+			seen[u] = true
+			urls = append(urls, noteURL{url: u, line: i + 1})
+		}
+	}
+	return urls
+}
+
+// urlAt returns the URL covering column col of line, if any.
+func urlAt(line string, col int) string {
+	for _, f := range findURLs(line) {
+		if col >= f[0] && col <= f[1] {
+			return string([]rune(line)[f[0]:f[1]])
+		}
+	}
+	return ""
+}
+
+// openInBrowser hands u to the desktop's default handler.
+func openInBrowser(u string) error {
+	if strings.HasPrefix(strings.ToLower(u), "www.") {
+		u = "https://" + u
+	}
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		cmd = exec.Command("open", u)
+	case "windows":
+		cmd = exec.Command("rundll32", "url.dll,FileProtocolHandler", u)
+	default:
+		cmd = exec.Command("xdg-open", u)
+	}
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	go cmd.Wait()
+	return nil
+}
+
+func (m Model) openURL(u string) Model {
+	if err := openInBrowser(u); err != nil {
+		m.status = errorStatus(err)
+		return m
+	}
+	m.status = okStatus("Opened %s", u)
+	return m
+}
+
+func (m Model) openURLUnderCursor() (tea.Model, tea.Cmd) {
+	pos := editorCursor(m.textarea)
+	lines := splitRuneLines(m.textarea.Value())
+	u := urlAt(string(lines[pos.row]), pos.col)
+	if u == "" {
+		m.status = infoStatus("No URL under the cursor")
+		return m, nil
+	}
+	return m.openURL(u), nil
+}
+
+func (m Model) showNoteURLs() (tea.Model, tea.Cmd) {
+	urls := noteURLs(m.textarea.Value())
+	if len(urls) == 0 {
+		m.status = infoStatus("No URLs in this note")
+		return m, nil
+	}
+	items := make([]list.Item, 0, len(urls))
+	for _, u := range urls {
+		items = append(items, item{title: u.url, desc: tr("Line %d", u.line), mode: "url"})
+	}
+	m.textarea.Blur()
+	m.state = stateNoteURLs
+	m.status = statusLine{}
+	m.list.SetItems(items)
+	m.list.Title = tr("%s in %s", trn("%d link", "%d links", len(urls)), relOrBase(m.vault, m.editing))
+	m.list.Select(0)
+	return m, nil
+}
+
+func (m Model) openSelectedURL() (tea.Model, tea.Cmd) {
+	m.state = stateEditor
+	m.textarea.Focus()
+	if selected := m.list.SelectedItem(); selected != nil {
+		m = m.openURL(selected.(item).title)
+	}
+	return m, textarea.Blink
+}
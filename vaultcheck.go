@@ -0,0 +1,102 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// The vault list is drawn from the registry without touching the vaults,
+// since a vault on a network mount can take seconds to answer, or hang.
+// Once the first frame is up, every registered path is checked in the
+// background, each for at most vaultCheckTimeout, and vaults that are gone
+// or do not answer are marked in the list. They stay registered: Enter
+// checks a marked vault again and opens it once it is back, and Ctrl+X
+// removes it from the list without touching its files.
+
+const vaultCheckTimeout = 3 * time.Second
+
+type vaultCheckMsg struct {
+	offline map[string]bool
+}
+
+// vaultReachable reports whether path is a directory that answers within
+// timeout.
+func vaultReachable(path string, timeout time.Duration) bool {
+	done := make(chan bool, 1)
+	go func() {
+		info, err := os.Stat(path)
+		done <- err == nil && info.IsDir()
+	}()
+	select {
+	case ok := <-done:
+		return ok
+	case <-time.After(timeout):
+		return false
+	}
+}
+
+// checkVaultsCmd checks all registered vaults at once.
+func checkVaultsCmd() tea.Cmd {
+	return func() tea.Msg {
+		vaults, _ := loadVaultRegistry()
+		results := make(chan string, len(vaults))
+		for _, v := range vaults {
+			go func(v string) {
+				if vaultReachable(v, vaultCheckTimeout) {
+					results <- ""
+				} else {
+					results <- v
+				}
+			}(v)
+		}
+		offline := make(map[string]bool)
+		for range vaults {
+			if v := <-results; v != "" {
+				offline[v] = true
+			}
+		}
+		return vaultCheckMsg{offline: offline}
+	}
+}
+
+func (m Model) applyVaultCheck(msg vaultCheckMsg) Model {
+	m.offline = msg.offline
+	if m.state == stateVaultSelect {
+		index := m.list.Index()
+		m = m.refreshVaultList()
+		m.list.Select(index)
+	}
+	if n := len(msg.offline); n > 0 && m.state == stateVaultSelect {
+		m.status = warnStatus("%s not reachable", trn("%d vault", "%d vaults", n))
+	}
+	return m
+}
+
+// openOfflineVault checks a vault marked unreachable again and opens it
+// when it is back.
+func (m Model) openOfflineVault(path string) (tea.Model, tea.Cmd) {
+	if !vaultReachable(path, vaultCheckTimeout) {
+		m.status = warnStatus("Vault not reachable: %s (Ctrl+X removes it from the list)", path)
+		return m, nil
+	}
+	delete(m.offline, path)
+	m = m.refreshVaultList()
+	m.status = okStatus("Vault selected: %s", filepath.Base(path))
+	return m.enterVault(path)
+}
+
+// forgetVault removes an unreachable vault from the registry, leaving its
+// files alone.
+func (m Model) forgetVault(path string) (tea.Model, tea.Cmd) {
+	if err := unregisterVault(path); err != nil {
+		m.status = errorStatus(err)
+		return m, nil
+	}
+	delete(m.offline, path)
+	m = m.refreshVaultList()
+	m.status = infoStatus("Removed from the list: %s", path)
+	return m, nil
+}
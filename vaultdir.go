@@ -0,0 +1,192 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// New vaults are created in vault_dir, the GoNo folder of the home
+// directory by default, rather than in the home directory itself. Vaults
+// created there by earlier versions stay where they are until "gono
+// move-vaults" moves them into vault_dir. Since a folder in the home
+// directory may just as well be one opened as a vault, the command only
+// lists what it would move unless it is given -yes, and can be limited to
+// the vaults named. A move updates the registry, the startup and capture
+// vaults of the config and the vault's search index along with it; the
+// vault's own state in .gono moves with it, except the note locks, which
+// are keyed by the old paths and dropped. Vaults with a note open are not
+// moved.
+
+const defaultVaultDir = "GoNo"
+
+// vaultStorageRoot is the folder new vaults are created in.
+func vaultStorageRoot(dir string) string {
+	dir = expandHome(dir)
+	if filepath.IsAbs(dir) {
+		return filepath.Clean(dir)
+	}
+	return filepath.Join(homeDir(), dir)
+}
+
+// legacyVaults returns the registered vaults that sit directly in the home
+// directory, where earlier versions created them.
+func legacyVaults() ([]string, error) {
+	vaults, err := loadVaultRegistry()
+	if err != nil {
+		return nil, err
+	}
+	var out []string
+	for _, v := range vaults {
+		if samePath(filepath.Dir(v), homeDir()) {
+			out = append(out, v)
+		}
+	}
+	return out, nil
+}
+
+// openNoteLock returns a lock held on a note of the vault by a running
+// instance, if any.
+func openNoteLock(vault string) (noteLock, bool) {
+	dir := filepath.Join(appDir(vault), noteLocksDir)
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return noteLock{}, false
+	}
+	for _, e := range entries {
+		data, err := os.ReadFile(filepath.Join(dir, e.Name()))
+		if err != nil {
+			continue
+		}
+		var lock noteLock
+		if json.Unmarshal(data, &lock) == nil && !lock.stale() {
+			return lock, true
+		}
+	}
+	return noteLock{}, false
+}
+
+// moveVault moves the vault at from to to and points everything that
+// refers to it by path at the new place.
+func moveVault(cfg *appConfig, from string, to string) error {
+	if _, err := os.Lstat(to); err == nil {
+		return fmt.Errorf("already exists: %s", to)
+	}
+	if lock, ok := openNoteLock(from); ok {
+		return fmt.Errorf("%s is open in GoNo on %s, close it first", relOrBase(from, lock.Path), lock.Host)
+	}
+	if err := os.Rename(from, to); err != nil {
+		return err
+	}
+	_ = os.Rename(indexPath(from), indexPath(to))
+	_ = os.RemoveAll(filepath.Join(appDir(to), noteLocksDir))
+	err := withFileLock(vaultRegistryPath(), func() error {
+		data, err := os.ReadFile(vaultRegistryPath())
+		if err != nil {
+			return err
+		}
+		var reg vaultRegistry
+		if err := json.Unmarshal(data, &reg); err != nil {
+			return err
+		}
+		for i, v := range reg.Vaults {
+			if samePath(v, from) {
+				reg.Vaults[i] = to
+			}
+		}
+		if samePath(reg.Last, from) {
+			reg.Last = to
+		}
+		return writeVaultRegistry(reg)
+	})
+	if err != nil {
+		return fmt.Errorf("moved to %s, but registry update failed: %w", to, err)
+	}
+	for _, field := range []*string{&cfg.Startup.Vault, &cfg.Capture.Vault} {
+		if *field != "" && samePath(expandHome(*field), from) {
+			*field = homeRelative(to)
+		}
+	}
+	return nil
+}
+
+func moveVaultsCommand(args []string, stdout io.Writer) error {
+	flags := flag.NewFlagSet("move-vaults", flag.ContinueOnError)
+	flags.SetOutput(io.Discard)
+	yes := flags.Bool("yes", false, "")
+	usage := errors.New("usage: gono move-vaults [-yes] [VAULT...]")
+	if err := flags.Parse(args); err != nil {
+		return usage
+	}
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+	root := vaultStorageRoot(cfg.VaultDir)
+	if samePath(root, homeDir()) {
+		return errors.New("vault_dir is the home directory: nothing to move")
+	}
+	vaults, err := legacyVaults()
+	if err != nil {
+		return err
+	}
+	if flags.NArg() > 0 {
+		var named []string
+		for _, arg := range flags.Args() {
+			abs, err := filepath.Abs(expandHome(arg))
+			if err != nil {
+				return err
+			}
+			found := false
+			for _, v := range vaults {
+				if samePath(v, abs) {
+					named = append(named, v)
+					found = true
+					break
+				}
+			}
+			if !found {
+				return fmt.Errorf("not a registered vault in the home directory: %s", arg)
+			}
+		}
+		vaults = named
+	}
+	if len(vaults) == 0 {
+		fmt.Fprintln(stdout, "No vaults in the home directory")
+		return nil
+	}
+	if !*yes {
+		for _, v := range vaults {
+			fmt.Fprintf(stdout, "%s -> %s\n", v, filepath.Join(root, filepath.Base(v)))
+		}
+		fmt.Fprintln(stdout, "Nothing moved. Check that these are vaults GoNo created, then run gono move-vaults -yes, or name the vaults to move.")
+		return nil
+	}
+	if err := os.MkdirAll(root, 0755); err != nil {
+		return err
+	}
+	startup, capture := cfg.Startup.Vault, cfg.Capture.Vault
+	failed := 0
+	for _, v := range vaults {
+		to := filepath.Join(root, filepath.Base(v))
+		if err := moveVault(&cfg, v, to); err != nil {
+			fmt.Fprintf(stdout, "%s: %v\n", v, err)
+			failed++
+			continue
+		}
+		fmt.Fprintf(stdout, "%s -> %s\n", v, to)
+	}
+	if cfg.Startup.Vault != startup || cfg.Capture.Vault != capture {
+		if err := saveConfig(cfg); err != nil {
+			return fmt.Errorf("config not updated: %w", err)
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("%s not moved", pluralize(failed, "vault", "vaults"))
+	}
+	return nil
+}
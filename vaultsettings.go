@@ -0,0 +1,35 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+)
+
+const vaultSettingsFileName = "settings.json"
+
+// vaultSettings is the optional per-vault .gono/settings.json: date formats
+// that override the app config, smart folders and protected folders.
+type vaultSettings struct {
+	Dates         dateConfig    `json:"dates"`
+	SmartFolders  []smartFolder `json:"smart_folders"`
+	ProtectedDirs []string      `json:"protected_dirs"`
+}
+
+func vaultSettingsPath(vault string) string {
+	return filepath.Join(appDir(vault), vaultSettingsFileName)
+}
+
+// loadVaultSettings reads the settings of vault. A missing or unreadable
+// file yields empty settings.
+func loadVaultSettings(vault string) vaultSettings {
+	var vs vaultSettings
+	data, err := os.ReadFile(vaultSettingsPath(vault))
+	if err != nil {
+		return vs
+	}
+	if json.Unmarshal(data, &vs) != nil {
+		return vaultSettings{}
+	}
+	return vs
+}
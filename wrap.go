@@ -0,0 +1,202 @@
+package main
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/mattn/go-runewidth"
+)
+
+// The textarea keeps one row per logical line (it is sized to its maximum
+// width) so Up/Down, Home/End and friends move by logical lines, and the
+// editor is drawn by wrappedView instead. With soft wrap on, wrapped rows of
+// list items, quotes and indented lines are aligned under the text of the
+// first row; with it off, long lines are clipped.
+
+var hangingIndentRe = regexp.MustCompile(`^(\s*(?:[-*+] \[[ xX]\] |[-*+] |\d+[.)] |> )?)`)
+
+// wrapSegment is one visual row of a logical line: runes [start, end).
+type wrapSegment struct {
+	start int
+	end   int
+}
+
+// wrapLine splits line into rows of at most width cells, breaking after
+// spaces where possible. Rows after the first lose indent cells of width to
+// the hanging indent.
+func wrapLine(line []rune, width int, indent int) []wrapSegment {
+	if width < 1 {
+		width = 1
+	}
+	var segments []wrapSegment
+	start := 0
+	avail := width
+	for {
+		cells := 0
+		end := start
+		lastSpace := -1
+		for end < len(line) {
+			w := runewidth.RuneWidth(line[end])
+			if cells+w > avail && end > start {
+				break
+			}
+			cells += w
+			if line[end] == ' ' {
+				lastSpace = end
+			}
+			end++
+		}
+		if end < len(line) && lastSpace >= start && lastSpace+1 < end {
+			end = lastSpace + 1
+		}
+		segments = append(segments, wrapSegment{start: start, end: end})
+		if end >= len(line) {
+			return segments
+		}
+		start = end
+		avail = maxInt(1, width-indent)
+	}
+}
+
+// segments returns the visual rows of one logical line.
+func (m Model) segments(line string, width int) ([]wrapSegment, int) {
+	runes := []rune(line)
+	if !m.cfg.Editor.SoftWrap {
+		return []wrapSegment{{start: 0, end: len(runes)}}, 0
+	}
+	indent := hangingIndent(line, width)
+	return wrapLine(runes, width, indent), indent
+}
+
+// hangingIndent returns the width continuation rows are indented by.
+func hangingIndent(line string, width int) int {
+	prefix := hangingIndentRe.FindString(line)
+	indent := runewidth.StringWidth(prefix)
+	if indent > width/2 {
+		return 0
+	}
+	return indent
+}
+
+func (m Model) wrapGutter() int {
+	gutter := lipgloss.Width(m.textarea.Prompt)
+	if m.textarea.ShowLineNumbers {
+		gutter += len(strconv.Itoa(maxInt(m.textarea.LineCount(), 99))) + 2
+	}
+	if m.blame != nil {
+		gutter += blameGutterWidth
+	}
+	return gutter
+}
+
+// wrapWidth is the text width of a wrapped row; one cell is kept free for
+// the cursor at the end of a full row.
+func (m Model) wrapWidth(contentW int) int {
+	return maxInt(8, m.editorWidth(contentW)-m.wrapGutter()-1)
+}
+
+// visualRow locates the cursor among the rows of its logical line.
+func visualRow(segments []wrapSegment, col int) int {
+	for i, seg := range segments {
+		if col < seg.end || i == len(segments)-1 {
+			return i
+		}
+	}
+	return 0
+}
+
+// scrollWrapped keeps the cursor row inside the wrapped view by moving the
+// first shown logical line.
+func (m Model) scrollWrapped(contentW int) Model {
+	lines := strings.Split(m.textarea.Value(), "\n")
+	row := minInt(m.textarea.Line(), len(lines)-1)
+	if m.wrapTop > row {
+		m.wrapTop = row
+	}
+	width := m.wrapWidth(contentW)
+	height := maxInt(1, m.textarea.Height())
+	rowsOf := func(i int) int {
+		segments, _ := m.segments(lines[i], width)
+		return len(segments)
+	}
+	cursorSegments, _ := m.segments(lines[row], width)
+	used := visualRow(cursorSegments, editorColumn(m.textarea)) + 1
+	top := row
+	for top > 0 && used+rowsOf(top-1) <= height {
+		top--
+		used += rowsOf(top)
+	}
+	if m.wrapTop < top {
+		m.wrapTop = top
+	}
+	return m
+}
+
+// wrappedView draws the buffer from wrapTop with soft-wrapped rows.
+func (m Model) wrappedView(contentW int) string {
+	ta := m.textarea
+	style := ta.FocusedStyle
+	lines := strings.Split(ta.Value(), "\n")
+	width := m.wrapWidth(contentW)
+	height := maxInt(1, ta.Height())
+	cursorLine := ta.Line()
+	cursorCol := editorColumn(ta)
+	numberWidth := len(strconv.Itoa(maxInt(ta.LineCount(), 99)))
+
+	var out []string
+	for i := m.wrapTop; i < len(lines) && len(out) < height; i++ {
+		line := []rune(lines[i])
+		segments, indent := m.segments(lines[i], width)
+		if i != cursorLine {
+			line = maskSecrets(line)
+		}
+		cursorRow := -1
+		if i == cursorLine {
+			cursorRow = visualRow(segments, cursorCol)
+		}
+		for r, seg := range segments {
+			if len(out) >= height {
+				break
+			}
+			var b strings.Builder
+			b.WriteString(style.Prompt.Render(ta.Prompt))
+			if m.blame != nil {
+				label := strings.Repeat(" ", blameGutterWidth)
+				if r == 0 {
+					label = m.blameLabel(i)
+				}
+				b.WriteString(style.LineNumber.Render(label))
+			}
+			if ta.ShowLineNumbers {
+				number := strings.Repeat(" ", numberWidth)
+				if r == 0 {
+					number = strings.Repeat(" ", numberWidth-len(strconv.Itoa(i+1))) + strconv.Itoa(i+1)
+				}
+				numberStyle := style.LineNumber
+				if i == cursorLine {
+					numberStyle = style.CursorLineNumber
+				}
+				b.WriteString(numberStyle.Render(" " + number + " "))
+			}
+			if r > 0 {
+				b.WriteString(strings.Repeat(" ", indent))
+			}
+			textStyle := style.Text
+			if i == cursorLine {
+				textStyle = style.CursorLine
+			}
+			at := -1
+			if r == cursorRow {
+				at = cursorCol - seg.start
+			}
+			b.WriteString(m.findRow(line[seg.start:seg.end], seg.start, i, at, textStyle))
+			out = append(out, b.String())
+		}
+	}
+	for len(out) < height {
+		out = append(out, style.Prompt.Render(ta.Prompt))
+	}
+	return strings.Join(out, "\n")
+}
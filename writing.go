@@ -0,0 +1,173 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// GoNo counts the words written in each vault per day, in
+// .gono/words.json: every save adds the words the note gained since it was
+// opened or last saved, so deleting text never counts against the day. With
+// editor.daily_goal set, the editor's subtitle shows today's words, unsaved
+// ones included, against the goal, and the status line cheers when the goal
+// is reached. The writing activity screen (Ctrl+A) sums up the last days.
+
+const wordsFileName = "words.json"
+
+var dailyGoalChoices = []int{0, 250, 500, 750, 1000, 1500, 2000}
+
+// wordTally is today's count of the open vault. below is whether the count
+// was under the goal at the last update, so the goal is cheered once, when
+// it is crossed.
+type wordTally struct {
+	vault string
+	day   string
+	saved int
+	below bool
+}
+
+func wordsPath(vault string) string {
+	return filepath.Join(appDir(vault), wordsFileName)
+}
+
+// loadWordCounts returns the words written per day ("2006-01-02").
+func loadWordCounts(vault string) map[string]int {
+	counts := make(map[string]int)
+	data, err := os.ReadFile(wordsPath(vault))
+	if err != nil {
+		return counts
+	}
+	_ = json.Unmarshal(data, &counts)
+	return counts
+}
+
+// addWordsWritten adds n words to day and returns the day's new count.
+func addWordsWritten(vault string, day string, n int) (int, error) {
+	if err := os.MkdirAll(appDir(vault), 0755); err != nil {
+		return 0, err
+	}
+	total := 0
+	path := wordsPath(vault)
+	err := withFileLock(path, func() error {
+		counts := loadWordCounts(vault)
+		counts[day] += n
+		total = counts[day]
+		data, err := json.Marshal(counts)
+		if err != nil {
+			return err
+		}
+		return writeFileAtomic(path, data, 0644)
+	})
+	return total, err
+}
+
+// wordsGained is how many words after has more than before.
+func wordsGained(before string, after string) int {
+	return maxInt(0, len(strings.Fields(after))-len(strings.Fields(before)))
+}
+
+// countsWords reports whether saving the open note counts toward the day:
+// notes of the vault do, the scratch buffer does not.
+func (m Model) countsWords() bool {
+	return m.vault != "" && m.editing != "" && !m.readOnly && insideVault(m.vault, m.editing) && !m.isScratch(m.editing)
+}
+
+// recordWords adds the words the buffer gained over the saved text, before
+// it is saved.
+func (m Model) recordWords() Model {
+	if !m.countsWords() {
+		return m
+	}
+	gained := wordsGained(m.saved, m.textarea.Value())
+	if gained == 0 {
+		return m
+	}
+	day := time.Now().Format(dayLayout)
+	total, err := addWordsWritten(m.vault, day, gained)
+	if err == nil && m.words != nil && m.words.vault == m.vault && m.words.day == day {
+		m.words.saved = total
+	}
+	return m
+}
+
+// wordsToday is today's count with the words not saved yet.
+func (m Model) wordsToday() int {
+	if m.words == nil {
+		return 0
+	}
+	n := m.words.saved
+	if m.countsWords() && m.dirty() {
+		n += wordsGained(m.saved, m.textarea.Value())
+	}
+	return n
+}
+
+// trackWords keeps today's count for the open vault, reading it again on a
+// new day or in another vault, and cheers when the goal is reached.
+func (m Model) trackWords() Model {
+	if m.vault == "" {
+		m.words = nil
+		return m
+	}
+	day := time.Now().Format(dayLayout)
+	if m.words == nil || m.words.vault != m.vault || m.words.day != day {
+		saved := loadWordCounts(m.vault)[day]
+		m.words = &wordTally{vault: m.vault, day: day, saved: saved, below: saved < m.cfg.Editor.DailyGoal}
+	}
+	goal := m.cfg.Editor.DailyGoal
+	if goal <= 0 {
+		return m
+	}
+	reached := m.wordsToday() >= goal
+	if reached && m.words.below && m.state == stateEditor {
+		m.status = okStatus("Daily goal reached: %d words today. Well done!", m.wordsToday())
+	}
+	m.words.below = !reached
+	return m
+}
+
+// goalInfo is today's progress for the editor's subtitle.
+func (m Model) goalInfo() string {
+	goal := m.cfg.Editor.DailyGoal
+	if goal <= 0 || m.words == nil || m.readOnly {
+		return ""
+	}
+	n := m.wordsToday()
+	if n >= goal {
+		return tr("%d/%d words today ✓", n, goal)
+	}
+	return tr("%d/%d words today", n, goal)
+}
+
+func dailyGoalLabel(goal int) string {
+	if goal <= 0 {
+		return tr("Off")
+	}
+	return trn("%d word", "%d words", goal)
+}
+
+// writingSummary sums up the words written over the last days of counts.
+func writingSummary(counts map[string]int, goal int, today time.Time) []string {
+	week, month, met := 0, 0, 0
+	for i := 0; i < 30; i++ {
+		n := counts[today.AddDate(0, 0, -i).Format(dayLayout)]
+		if i < 7 {
+			week += n
+		}
+		month += n
+		if goal > 0 && n >= goal {
+			met++
+		}
+	}
+	if month == 0 && goal <= 0 {
+		return nil
+	}
+	lines := []string{tr("Words written: %d today, %d in the last 7 days, %d in the last 30 days", counts[today.Format(dayLayout)], week, month)}
+	if goal > 0 {
+		lines = append(lines, tr("Daily goal of %d words met on %s of the last 30", goal, trn("%d day", "%d days", met)))
+	}
+	return lines
+}
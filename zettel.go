@@ -0,0 +1,119 @@
+package main
+
+import (
+	"io/fs"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+const zettelIDLayout = "200601021504"
+
+// noteName returns the file name for a new note called base, prefixed with
+// a timestamp ID when zettelkasten IDs are enabled.
+func (m Model) noteName(base string) string {
+	if m.cfg.ZettelIDs {
+		return time.Now().Format(zettelIDLayout) + "-" + base + ".md"
+	}
+	return base + ".md"
+}
+
+// noteID returns the leading run of digits of a note's file name, or "".
+func noteID(path string) string {
+	base := filepath.Base(path)
+	end := strings.IndexFunc(base, func(r rune) bool { return !unicode.IsDigit(r) })
+	if end < 0 {
+		end = len(base)
+	}
+	if end < 8 {
+		return ""
+	}
+	return base[:end]
+}
+
+// findNotes returns the notes whose ID starts with query, or, when none do,
+// whose file name contains it. Matches are sorted by path.
+func findNotes(vault string, query string) []string {
+	query = strings.ToLower(strings.TrimSpace(query))
+	var byID, byName []string
+	_ = walkVault(vault, func(p string, d fs.DirEntry) error {
+		if !isIndexedNote(p) {
+			return nil
+		}
+		if id := noteID(p); id != "" && strings.HasPrefix(id, query) {
+			byID = append(byID, p)
+			return nil
+		}
+		if strings.Contains(strings.ToLower(d.Name()), query) {
+			byName = append(byName, p)
+		}
+		return nil
+	})
+	matches := byID
+	if len(matches) == 0 {
+		matches = byName
+	}
+	sort.Strings(matches)
+	return matches
+}
+
+// resolveNote finds the single note meant by query, setting a status when
+// there is none or more than one.
+func (m Model) resolveNote(query string) (Model, string, bool) {
+	if strings.TrimSpace(query) == "" {
+		m.status = infoStatus("Type a note ID or part of its name")
+		return m, "", false
+	}
+	matches := findNotes(m.vault, query)
+	switch len(matches) {
+	case 0:
+		m.status = infoStatus("No notes match: %s", query)
+		return m, "", false
+	case 1:
+		return m, matches[0], true
+	default:
+		m.status = statusLine{kind: statusInfo, text: trn("%d note matches, type more of the ID", "%d notes match, type more of the ID", len(matches))}
+		return m, "", false
+	}
+}
+
+// noteLink returns the [[link]] for a note: its vault path without extension.
+func noteLink(vault string, path string) string {
+	rel, err := filepath.Rel(vault, path)
+	if err != nil {
+		rel = filepath.Base(path)
+	}
+	return "[[" + filepath.ToSlash(strings.TrimSuffix(rel, filepath.Ext(rel))) + "]]"
+}
+
+func (m Model) beginLinkNote() (tea.Model, tea.Cmd) {
+	m = m.enterPrompt(stateLinkNote, tr("Note ID or name to link"))
+	return m, textinput.Blink
+}
+
+func (m Model) insertNoteLink(query string) (tea.Model, tea.Cmd) {
+	m, path, ok := m.resolveNote(query)
+	if !ok {
+		return m, nil
+	}
+	m.input.Blur()
+	m.state = stateEditor
+	m.lastList = stateFileList
+	m.textarea.InsertString(noteLink(m.vault, path))
+	m.status = infoStatus("Linked %s", relOrBase(m.vault, path))
+	return m, nil
+}
+
+func (m Model) gotoNote(query string) (tea.Model, tea.Cmd) {
+	m, path, ok := m.resolveNote(query)
+	if !ok {
+		return m, nil
+	}
+	m.input.Blur()
+	return m.openFile(path)
+}